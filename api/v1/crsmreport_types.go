@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+
+// CRSMReportList contains a list of CRSMReport.
+type CRSMReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CRSMReport `json:"items"`
+}
+
+//nolint:lll
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=ksm
+// +kubebuilder:printcolumn:name="Instances",type=integer,JSONPath=".status.totalInstances",description="Total number of instances"
+// +kubebuilder:printcolumn:name="Unhealthy",type=integer,JSONPath=".status.unhealthyInstances",description="Number of instances which are not ready"
+// +kubebuilder:printcolumn:name="Targets",type=integer,JSONPath=".status.totalTargets",description="Number of managed ConfigMap keys"
+
+// CRSMReport is the Schema for the crsmreports API. It summarizes all
+// CustomResourceStateMetrics instances on the cluster.
+type CRSMReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Status of the CRSMReport resource.
+	Status CRSMReportStatus `json:"status,omitempty"`
+}
+
+// CRSMReportStatus defines the observed state of CRSMReport.
+type CRSMReportStatus struct {
+	// Total number of CustomResourceStateMetrics instances.
+	TotalInstances int32 `json:"totalInstances"`
+
+	// Number of CustomResourceStateMetrics instances which are not ready.
+	UnhealthyInstances int32 `json:"unhealthyInstances"`
+
+	// Number of managed ConfigMap keys.
+	TotalTargets int32 `json:"totalTargets"`
+
+	// Number of instances per Namespace.
+	// +optional
+	Namespaces []CRSMReportNamespace `json:"namespaces,omitempty"`
+
+	// List of instances which are not ready.
+	// +optional
+	Unhealthy []CRSMReportInstance `json:"unhealthy,omitempty"`
+
+	// List of managed ConfigMap keys.
+	// +optional
+	Targets []CRSMReportTarget `json:"targets,omitempty"`
+}
+
+// CRSMReportNamespace holds the number of instances in a Namespace.
+type CRSMReportNamespace struct {
+	// Name of the Namespace.
+	Name string `json:"name"`
+
+	// Number of instances in the Namespace.
+	Instances int32 `json:"instances"`
+}
+
+// CRSMReportInstance identifies an instance which is not ready.
+type CRSMReportInstance struct {
+	// Name of the instance.
+	Name string `json:"name"`
+
+	// Namespace of the instance.
+	Namespace string `json:"namespace"`
+
+	// Reason of the Ready condition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message of the Ready condition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CRSMReportTarget holds the number of instances writing into a ConfigMap key.
+type CRSMReportTarget struct {
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap.
+	Namespace string `json:"namespace"`
+
+	// Key of the ConfigMap.
+	Key string `json:"key"`
+
+	// Number of instances writing into the ConfigMap key.
+	Instances int32 `json:"instances"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CRSMReport{}, &CRSMReportList{})
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package v1
 
 import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	// ksm "k8s.io/kube-state-metrics/v2/pkg/customresourcestate"
+
+	"github.com/jtyr/crsm-operator/pkg/ksm"
 )
 
 // +kubebuilder:object:root=true
@@ -36,6 +39,11 @@ type CustomResourceStateMetricsList struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:categories=ksm,shortName=crsm
 // +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type=='Ready')].status",description="Ready condition"
+// +kubebuilder:printcolumn:name="Target Namespace",type=string,JSONPath=".status.configMap.namespace",description="Namespace of the ConfigMap the resources are written into"
+// +kubebuilder:printcolumn:name="ConfigMap",type=string,JSONPath=".status.configMap.name",description="ConfigMap the resources are written into"
+// +kubebuilder:printcolumn:name="Key",type=string,JSONPath=".status.configMap.key",description="Key of the ConfigMap"
+// +kubebuilder:printcolumn:name="Resources",type=integer,JSONPath=".status.resourceCount",description="Number of the resources"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 
 // CustomResourceStateMetrics is the Schema for the customresourcestatemetrics API.
 type CustomResourceStateMetrics struct {
@@ -49,29 +57,281 @@ type CustomResourceStateMetrics struct {
 	Status CustomResourceStateMetricsStatus `json:"status,omitempty"`
 }
 
+//nolint:lll
+// +kubebuilder:validation:XValidation:rule="(has(self.resources) && size(self.resources) > 0) || (has(self.resourcesRaw) && size(self.resourcesRaw) > 0) || (has(self.resourcesFrom) && size(self.resourcesFrom) > 0) || has(self.source)",message="at least one resource must be specified in resources, resourcesRaw, resourcesFrom or source"
+// +kubebuilder:validation:XValidation:rule="!has(self.resourcesRaw) || size(self.resourcesRaw) == 0 || ((!has(self.resources) || size(self.resources) == 0) && (!has(self.resourcesFrom) || size(self.resourcesFrom) == 0) && !has(self.source))",message="resourcesRaw can't be combined with resources, resourcesFrom or source"
+
 // CustomResourceStateMetricsSpec defines the desired state of CustomResourceStateMetrics.
 type CustomResourceStateMetricsSpec struct {
 	// Details of the ConfigMap where the resources will be written into.
-	ConfigMap CustomResourceStateMetricsConfigMap `json:"configMap"`
+	// If not specified, the operator default ConfigMap is used or, if
+	// there is none, the ConfigMap is discovered from the
+	// kube-state-metrics Deployment.
+	// +optional
+	ConfigMap CustomResourceStateMetricsConfigMap `json:"configMap,omitempty"`
 
-	// List of custom resources to be monitored. The content list items can
-	// be arbitrary object that should follow the structure described in the
-	// kube-state-metrics exporter
+	// Target where the resources will be written into instead of the
+	// ConfigMap.
+	// +optional
+	Target *CustomResourceStateMetricsSpecTarget `json:"target,omitempty"`
+
+	// List of custom resources to be monitored. The items follow the
+	// structure described in the kube-state-metrics exporter
 	// (https://github.com/kubernetes/kube-state-metrics/blob/main/docs/metrics/extend/customresourcestate-metrics.md).
-	// This operator doesn't analyze nor modifies its content. It just
-	// writes its content into a ConfigMap as is. This is mainly because the kube-state-metrics package
-	// lacks the "omitempty" JSON tag flag as well as the "DeepCopy*"
-	// methods for the individual types.
-	Resources []runtime.RawExtension `json:"resources,omitempty"`
+	Resources []ksm.Resource `json:"resources,omitempty"`
+
+	// List of custom resources to be monitored as a YAML string (e.g. pasted
+	// from an existing kube-state-metrics configuration). The string is
+	// validated and inserted into the ConfigMap as it is. The lists of
+	// multiple YAML documents are merged into a single list. It can't be
+	// combined with the other ways of specifying the resources.
+	// +optional
+	ResourcesRaw string `json:"resourcesRaw,omitempty"`
+
+	// List of the ConfigMap and Secret keys in the Namespace of the
+	// CustomResourceStateMetrics holding additional resources. Each key holds
+	// a YAML list of resources following the same structure as the list of
+	// the custom resources above (multiple YAML documents are merged). The
+	// resources are rendered again whenever the referenced objects change.
+	// +optional
+	ResourcesFrom []CustomResourceStateMetricsResourcesSource `json:"resourcesFrom,omitempty"`
+
+	// Remote YAML document holding additional resources. The document holds
+	// a YAML list of resources following the same structure as the list of
+	// the custom resources above (multiple YAML documents are merged) and
+	// it's fetched again in the poll interval.
+	// +optional
+	Source *CustomResourceStateMetricsSource `json:"source,omitempty"`
+
+	// Whether the variables in the resources are replaced by the values of
+	// the CustomResourceStateMetrics before the resources are written so the
+	// same resources can be reused across Namespaces. The supported variables
+	// are ${metadata.name}, ${metadata.namespace} and
+	// ${metadata.labels['<key>']}. Use $${...} to write ${...} literally.
+	// Default: false.
+	// +optional
+	Interpolate bool `json:"interpolate,omitempty"`
+
+	// Defaults injected into all resources (including the sourced ones)
+	// before they are written. The values specified by the resources take
+	// precedence. The defaults are not applied to the raw resources.
+	// +optional
+	Defaults *CustomResourceStateMetricsDefaults `json:"defaults,omitempty"`
+
+	// Whether the rendered resources should also be written into a
+	// ConfigMap called "<name>-rendered" in the Namespace of the
+	// CustomResourceStateMetrics so they can be inspected without reading
+	// the shared ConfigMap. Default: false.
+	// +optional
+	Inspect bool `json:"inspect,omitempty"`
+
+	// Policy controlling whether the resources are rewritten into the
+	// ConfigMap during the periodic resync and drift repair. "Always"
+	// rewrites the resources periodically, "OnChange" rewrites them when
+	// the instance or the ConfigMap changes and "Never" writes them only if
+	// they don't exist in the ConfigMap yet so they can be hand-tuned there.
+	// Default: OnChange.
+	// +kubebuilder:validation:Enum=Always;OnChange;Never
+	// +kubebuilder:default=OnChange
+	// +optional
+	ResyncPolicy ResyncPolicy `json:"resyncPolicy,omitempty"`
+
+	// Change window outside of which the changes of the resources are
+	// deferred. Removal of the resources is never deferred.
+	// +optional
+	Schedule *CustomResourceStateMetricsSchedule `json:"schedule,omitempty"`
+
+	// Strategy of restarting kube-state-metrics after the ConfigMap
+	// changes if the restart integration is enabled in the operator.
+	// "Immediate" restarts it right after the change, "Batched" coalesces
+	// the changes of multiple instances within the operator rollout window
+	// into a single restart. Default: Immediate.
+	// +kubebuilder:validation:Enum=Immediate;Batched
+	// +kubebuilder:default=Immediate
+	// +optional
+	RolloutStrategy RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// Reload settings of kube-state-metrics applied after each successful
+	// change of the ConfigMap.
+	// +optional
+	Reload *CustomResourceStateMetricsReload `json:"reload,omitempty"`
+
+	// Whether the writes of the resources are suspended. The resources
+	// already written into the ConfigMap are preserved. Removal of the
+	// resources is never suspended. Default: false.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Policy controlling what happens with the resources in the ConfigMap
+	// when the instance is deleted. "Delete" removes them and "Retain" keeps
+	// them in place (e.g. when migrating the ownership or uninstalling the
+	// operator). Default: Delete.
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Number of the last written revisions of the resources kept in the
+	// status so the resources can be rolled back by the
+	// "ksm.jtyr.io/rollback-to" annotation. The oldest revisions are dropped
+	// earlier if the total size of their resources exceeds 256 KiB.
+	// Default: 3.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +kubebuilder:default=3
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+}
+
+// DeletionPolicy controls whether the resources are removed from the ConfigMap when the instance is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete removes the resources from the ConfigMap.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+
+	// DeletionPolicyRetain keeps the resources in the ConfigMap.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// RolloutStrategy controls how kube-state-metrics is restarted after the ConfigMap changes.
+type RolloutStrategy string
+
+const (
+	// RolloutStrategyImmediate restarts kube-state-metrics right after the change.
+	RolloutStrategyImmediate RolloutStrategy = "Immediate"
+
+	// RolloutStrategyBatched coalesces multiple changes into a single restart.
+	RolloutStrategyBatched RolloutStrategy = "Batched"
+)
+
+// CustomResourceStateMetricsSchedule defines the change window.
+type CustomResourceStateMetricsSchedule struct {
+	// Cron expression (minute hour day-of-month month day-of-week) defining
+	// the start of the window (e.g. "0 9 * * 1-5").
+	Cron string `json:"cron"`
+
+	// Duration of the window (e.g. "8h").
+	Duration metav1.Duration `json:"duration"`
+
+	// Time zone of the cron expression (e.g. "Europe/London").
+	// Default: UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// CustomResourceStateMetricsReload defines how kube-state-metrics is reloaded.
+type CustomResourceStateMetricsReload struct {
+	// Reference to the kube-state-metrics Deployment restarted (by setting
+	// the checksum of the config on its Pod template) after each successful
+	// change of the ConfigMap. The Deployment is restarted even if the
+	// restart integration is disabled in the operator and instead of the
+	// discovered Deployments mounting the ConfigMap.
+	DeploymentRef CustomResourceStateMetricsDeploymentRef `json:"deploymentRef"`
+
+	// Whether the operator should ensure the referenced Deployment has the
+	// volume, the volume mount and the custom resource state config file
+	// argument pointing at the key of the ConfigMap. The Deployment must be
+	// in the Namespace of the ConfigMap. Not supported with the Secret, the
+	// Namespace selector and the compression. Default: false.
+	// +optional
+	ManageConfigFile bool `json:"manageConfigFile,omitempty"`
+}
+
+// CustomResourceStateMetricsDeploymentRef references a Deployment.
+type CustomResourceStateMetricsDeploymentRef struct {
+	// Name of the Deployment.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
+
+	// Namespace of the Deployment. If not specified, the Namespace of the
+	// ConfigMap will be used instead. It must be the Namespace of the
+	// ConfigMap or of the instance.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// CustomResourceStateMetricsResourcesSource references the key holding the
+// resources. Exactly one of the references must be specified.
+type CustomResourceStateMetricsResourcesSource struct {
+	// Key of the ConfigMap holding the resources.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// Key of the Secret holding the resources.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// CustomResourceStateMetricsSource defines the remote document holding the
+// resources. Exactly one of the URL and the OCI reference must be specified.
+type CustomResourceStateMetricsSource struct {
+	// URL of the document served via HTTP or HTTPS.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	URL string `json:"url,omitempty"`
 
-	// Resources []ksm.Resource `json:"resources,omitempty"`
+	// Reference of the OCI artifact (e.g.
+	// "registry.example.com/metrics/foo:1.0.0") whose first layer holds the
+	// document. Only the anonymous access to the registry is supported.
+	// +optional
+	OCI string `json:"oci,omitempty"`
+
+	// SHA-256 digest (sha256:<hex>) of the document. If specified, the
+	// document with a different digest is refused.
+	// +kubebuilder:validation:Pattern=`^sha256:[a-f0-9]{64}$`
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// Interval in which the document is fetched again. Minimum: 1m.
+	// Default: 10m.
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1m')",message="must be at least 1m"
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+}
+
+// MinPollInterval is the shortest interval in which the remote document is
+// fetched again.
+const MinPollInterval = time.Minute
+
+// CustomResourceStateMetricsDefaults defines the defaults of the resources.
+type CustomResourceStateMetricsDefaults struct {
+	// Prefix of the names of the metrics of the resources which don't
+	// specify any.
+	// +optional
+	MetricNamePrefix *string `json:"metricNamePrefix,omitempty"`
+
+	// Labels added to all metrics of the resources.
+	// +optional
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
 }
 
+// ResyncPolicy controls whether the resources are rewritten into the ConfigMap.
+type ResyncPolicy string
+
+const (
+	// ResyncPolicyAlways rewrites the resources periodically.
+	ResyncPolicyAlways ResyncPolicy = "Always"
+
+	// ResyncPolicyOnChange rewrites the resources when the instance or the
+	// ConfigMap changes.
+	ResyncPolicyOnChange ResyncPolicy = "OnChange"
+
+	// ResyncPolicyNever never overwrites resources that exist in the ConfigMap.
+	ResyncPolicyNever ResyncPolicy = "Never"
+)
+
+// +kubebuilder:validation:XValidation:rule="!has(self.name) || size(self.name) > 0",message="name must not be empty"
 type CustomResourceStateMetricsConfigMap struct {
 	// Name of the ConfigMap where the resources will be written into.
+	// Required unless the ConfigMap is discovered.
 	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`
 	// +kubebuilder:validation:MaxLength=63
-	Name string `json:"name"`
+	// +optional
+	Name string `json:"name,omitempty"`
 
 	// Namespace of the ConfigMap where the resources will be written into.
 	// If not specified, the Namespace of the CustomResourceStateMetrics
@@ -81,18 +341,278 @@ type CustomResourceStateMetricsConfigMap struct {
 	Namespace string `json:"namespace,omitempty"`
 
 	// ConfigMap key under which the CustomResourceStateMetrics resources
-	// are stored. Default: config.yaml.
+	// are stored. The key may consist of alphanumeric characters, "-", "_"
+	// or ".". Default: config.yaml.
+	// +kubebuilder:default=config.yaml
+	// +kubebuilder:validation:XValidation:rule="self.matches('^[-._a-zA-Z0-9]+$')",message="must be a valid key"
+	// +kubebuilder:validation:XValidation:rule="size(self) <= 253",message="must be no more than 253 characters"
+	Key string `json:"key,omitempty"`
+
+	// Whether the ConfigMap name and key should be discovered from the
+	// kube-state-metrics Deployment (identified by the labels used by the
+	// common Helm charts) instead of being specified explicitly. If the
+	// Namespace is specified, only that Namespace is searched. The
+	// discovered key takes precedence over the specified one.
+	// Default: false.
+	// +optional
+	Discover bool `json:"discover,omitempty"`
+
+	// Whether the changes should be staged in the "<key>-next" key first
+	// and only promoted into the key once the instance is annotated with
+	// the "ksm.jtyr.io/approve" annotation set to the hash of the staged
+	// content. Default: false.
+	// +optional
+	Staged bool `json:"staged,omitempty"`
+
+	// Dot-separated path to the CustomResourceStateMetrics document nested
+	// in the YAML document stored under the key (e.g.
+	// "customResourceState.config"). If specified, the resources are merged
+	// into the resources list of the nested document instead of the whole
+	// document stored under the key.
+	// +kubebuilder:validation:Pattern=`^[^.]+(\.[^.]+)*$`
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Selector of the Namespaces where the resources are written into
+	// identically-named ConfigMaps (e.g. for per-Namespace kube-state-metrics
+	// shards). The ConfigMaps are kept in sync as the Namespaces start or
+	// stop matching the selector. If specified, the Namespace of the
+	// ConfigMap is ignored. Not supported with the discovery or the Secret.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Labels applied to the ConfigMap when it's created or updated (e.g. to
+	// let downstream automation select it). Labels of all instances writing
+	// into the same ConfigMap are merged.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations applied to the ConfigMap when it's created or updated (e.g.
+	// "reloader.stakater.com/match"). Annotations of all instances writing
+	// into the same ConfigMap are merged.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Whether an immutable copy of the ConfigMap called "<name>-<hash>"
+	// should be created on each change of its content. The name of the
+	// current copy is recorded in the "ksm.jtyr.io/immutable-configmap"
+	// annotation of the ConfigMap and the older copies, except the previous
+	// one, are deleted. Not supported with the Secret. Default: false.
+	// +optional
+	Immutable bool `json:"immutable,omitempty"`
+
+	// Whether the previous content of the key should be copied into the
+	// ConfigMap called "<name>-backup" before each change so an accidental
+	// destructive change can be recovered. Not supported with the Secret.
+	// Default: false.
+	// +optional
+	Backup bool `json:"backup,omitempty"`
+
+	// Whether the instance should own the ConfigMap created for it so the
+	// ConfigMap gets garbage collected once the instance is deleted. The
+	// owner reference is set only if the ConfigMap is created in the
+	// Namespace of the instance and it's removed once other instances write
+	// into the ConfigMap too. Not supported with the Secret and with the
+	// Retain deletion policy. Default: false.
+	// +optional
+	Owned bool `json:"owned,omitempty"`
+
+	// Whether the content of the key should be gzip-compressed and stored
+	// in the binary data under the key with the compressed key suffix
+	// instead of the data (e.g. for very large configurations). The
+	// consumers must decompress the content on mount. Not supported with
+	// the Secret. Default: false.
+	// +optional
+	Compress bool `json:"compress,omitempty"`
+
+	// Suffix of the binary data key holding the compressed content.
+	// Default: .gz.
+	// +kubebuilder:default=.gz
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	// +optional
+	CompressedKeySuffix string `json:"compressedKeySuffix,omitempty"`
+
+	// Format of the content stored under the key (yaml or json). The
+	// comments (e.g. the resource markers) of the JSON content are kept in
+	// the "ksm.jtyr.io/json-keys" annotation of the ConfigMap. Default: json
+	// if the key has the .json extension, yaml otherwise.
+	// +kubebuilder:validation:Enum=yaml;json
+	// +optional
+	Format ConfigFormat `json:"format,omitempty"`
+}
+
+// ConfigFormat is the format of the content stored under the key.
+type ConfigFormat string
+
+const (
+	// ConfigFormatYAML stores the content as YAML.
+	ConfigFormatYAML ConfigFormat = "yaml"
+
+	// ConfigFormatJSON stores the content as JSON.
+	ConfigFormatJSON ConfigFormat = "json"
+)
+
+// CustomResourceStateMetricsSpecTarget defines the target of the resources
+// other than the ConfigMap.
+type CustomResourceStateMetricsSpecTarget struct {
+	// Details of the Secret where the resources will be written into
+	// (e.g. if kube-state-metrics mounts its configuration from a Secret).
+	// The path, the staging settings, the labels and the annotations of the
+	// ConfigMap apply to the Secret as well. The resources are written into
+	// the data field of the Secret as the keys of the write-only stringData
+	// field are not removed once the operator stops writing them.
+	// +optional
+	Secret *CustomResourceStateMetricsSecret `json:"secret,omitempty"`
+}
+
+// CustomResourceStateMetricsSecret defines the Secret where the resources
+// will be written into.
+type CustomResourceStateMetricsSecret struct {
+	// Name of the Secret where the resources will be written into.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Namespace of the Secret where the resources will be written into.
+	// If not specified, the Namespace of the CustomResourceStateMetrics
+	// will be used instead. The Secrets in the other Namespaces must be
+	// allowed explicitly by the operator policy.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Secret key under which the CustomResourceStateMetrics resources are
+	// stored. The key may consist of alphanumeric characters, "-", "_" or
+	// ".". Default: config.yaml.
 	// +kubebuilder:default=config.yaml
+	// +kubebuilder:validation:XValidation:rule="self.matches('^[-._a-zA-Z0-9]+$')",message="must be a valid key"
+	// +kubebuilder:validation:XValidation:rule="size(self) <= 253",message="must be no more than 253 characters"
+	// +optional
 	Key string `json:"key,omitempty"`
 }
 
 // CustomResourceStateMetricsStatus defines the observed state of CustomResourceStateMetrics.
 type CustomResourceStateMetricsStatus struct {
+	// Generation of the instance the status was last updated for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// State conditions that will indicate whether the resource is ready to
 	// be used in the destination ConfigMap.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ConfigMap (or Secret) the resources were written into.
+	// +optional
+	ConfigMap *CustomResourceStateMetricsTarget `json:"configMap,omitempty"`
+
+	// Hash of the rendered resources last written into the ConfigMap.
+	// +optional
+	BlockHash string `json:"blockHash,omitempty"`
+
+	// Time the resources were last written into the ConfigMap.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Names of the metrics generated from the resources (including the
+	// prefix).
+	// +optional
+	MetricNames []string `json:"metricNames,omitempty"`
+
+	// Number of the rendered resources.
+	// +optional
+	ResourceCount int `json:"resourceCount,omitempty"`
+
+	// Kinds of the custom resources the rendered resources generate the
+	// metrics for.
+	// +optional
+	Kinds []CustomResourceStateMetricsKind `json:"kinds,omitempty"`
+
+	// Value of the "ksm.jtyr.io/force-sync" annotation the resources were
+	// last forcibly written for.
+	// +optional
+	ForceSync string `json:"forceSync,omitempty"`
+
+	// Lines removed from (prefixed with -) and added into (prefixed with +)
+	// the ConfigMap key by the last write of the resources (truncated).
+	// +optional
+	LastChange string `json:"lastChange,omitempty"`
+
+	// Last written revisions of the resources (the oldest first) up to the
+	// total size of 256 KiB.
+	// +optional
+	Revisions []CustomResourceStateMetricsRevision `json:"revisions,omitempty"`
+}
+
+// CustomResourceStateMetricsKind identifies the kind of the custom resources.
+type CustomResourceStateMetricsKind struct {
+	// API group of the custom resources.
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Version of the custom resources.
+	Version string `json:"version"`
+
+	// Kind of the custom resources.
+	Kind string `json:"kind"`
+
+	// Plural of the custom resources if specified by the resource.
+	// +optional
+	ResourcePlural string `json:"resourcePlural,omitempty"`
+}
+
+// CustomResourceStateMetricsRevision is a written revision of the resources.
+type CustomResourceStateMetricsRevision struct {
+	// Number of the revision increasing with each written change.
+	Revision int64 `json:"revision"`
+
+	// Hash of the rendered resources.
+	Hash string `json:"hash"`
+
+	// Time the revision was first written into the ConfigMap.
+	Time metav1.Time `json:"time"`
+
+	// Rendered resources.
+	Resources string `json:"resources"`
 }
 
+// CustomResourceStateMetricsTarget identifies the resolved ConfigMap key.
+type CustomResourceStateMetricsTarget struct {
+	// Kind of the object the resources were written into. Empty means
+	// ConfigMap.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	// +optional
+	Kind TargetKind `json:"kind,omitempty"`
+
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap.
+	Namespace string `json:"namespace"`
+
+	// Key of the ConfigMap.
+	Key string `json:"key"`
+
+	// Path of the nested document in the key.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Namespaces of the ConfigMaps the resources were replicated into.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// TargetKind is the kind of the object the resources are written into.
+type TargetKind string
+
+const (
+	// TargetKindConfigMap writes the resources into a ConfigMap.
+	TargetKindConfigMap TargetKind = "ConfigMap"
+
+	// TargetKindSecret writes the resources into a Secret.
+	TargetKindSecret TargetKind = "Secret"
+)
+
 func init() {
 	SchemeBuilder.Register(&CustomResourceStateMetrics{}, &CustomResourceStateMetricsList{})
 }
@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OperatorConfigSpec defines the runtime configuration of the operator. The
+// unset fields fall back to the values of the command line flags.
+type OperatorConfigSpec struct {
+	// Label selector (e.g. team=foo,tier!=dev) filtering the instances managed
+	// by the operator. Overrides the --cr-selector flag.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	// Label selector filtering the Namespaces of the instances managed by the
+	// operator. Overrides the --namespace-selector flag.
+	// +optional
+	NamespaceSelector string `json:"namespaceSelector,omitempty"`
+
+	// ConfigMap key used by the instances that don't specify any. The key
+	// defaulted by the admission webhook is not affected.
+	// +optional
+	DefaultKey string `json:"defaultKey,omitempty"`
+
+	// Interval in which the resources of the instances with the Always resync
+	// policy are rewritten into the ConfigMap. Overrides the --resync-period
+	// flag.
+	// +optional
+	ResyncPeriod *metav1.Duration `json:"resyncPeriod,omitempty"`
+
+	// Whether the kube-state-metrics Deployments mounting the changed
+	// ConfigMap are restarted. Overrides the --restart-ksm flag.
+	// +optional
+	RestartKSM *bool `json:"restartKSM,omitempty"`
+
+	// Header of the documents created in the ConfigMaps which don't exist
+	// yet. It must be a YAML map. Overrides the --document-header flag.
+	// +optional
+	DocumentHeader string `json:"documentHeader,omitempty"`
+
+	// Format of the comment identifying the resources of an instance with a
+	// single %s placeholder of the instance (e.g. "# Managed by %s"). The
+	// markers written in the previous formats are converted with the next
+	// write. Overrides the --marker-format flag.
+	// +optional
+	MarkerFormat string `json:"markerFormat,omitempty"`
+
+	// Prefixes the names of the metrics of the instances must start with
+	// keyed by the Namespace of the instances (e.g. team-a: team_a_). The
+	// instances violating the prefix of their Namespace are rejected by the
+	// admission webhook and (including the sourced resources) by the
+	// controller once rendered.
+	// +optional
+	MetricNamePrefixes map[string]MetricNamePrefix `json:"metricNamePrefixes,omitempty"`
+}
+
+// MetricNamePrefix is the prefix of the metric names.
+// +kubebuilder:validation:Pattern=`^[a-zA-Z_:][a-zA-Z0-9_:]*$`
+type MetricNamePrefix string
+
+// OperatorConfigStatus defines the observed state of OperatorConfig.
+type OperatorConfigStatus struct {
+	// Generation of the spec the status refers to.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the OperatorConfig resource.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//nolint:lll
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=ksm
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the configuration is applied"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// OperatorConfig is the Schema for the operatorconfigs API. It holds the
+// configuration of the operator applied at runtime. Only the OperatorConfig
+// named cluster is used.
+type OperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec of the OperatorConfig resource.
+	Spec OperatorConfigSpec `json:"spec,omitempty"`
+
+	// Status of the OperatorConfig resource.
+	Status OperatorConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OperatorConfigList contains a list of OperatorConfig.
+type OperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OperatorConfig{}, &OperatorConfigList{})
+}
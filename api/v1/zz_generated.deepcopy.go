@@ -21,10 +21,145 @@ limitations under the License.
 package v1
 
 import (
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CRSMReport) DeepCopyInto(out *CRSMReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CRSMReport.
+func (in *CRSMReport) DeepCopy() *CRSMReport {
+	if in == nil {
+		return nil
+	}
+	out := new(CRSMReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CRSMReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CRSMReportInstance) DeepCopyInto(out *CRSMReportInstance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CRSMReportInstance.
+func (in *CRSMReportInstance) DeepCopy() *CRSMReportInstance {
+	if in == nil {
+		return nil
+	}
+	out := new(CRSMReportInstance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CRSMReportList) DeepCopyInto(out *CRSMReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CRSMReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CRSMReportList.
+func (in *CRSMReportList) DeepCopy() *CRSMReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(CRSMReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CRSMReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CRSMReportNamespace) DeepCopyInto(out *CRSMReportNamespace) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CRSMReportNamespace.
+func (in *CRSMReportNamespace) DeepCopy() *CRSMReportNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(CRSMReportNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CRSMReportStatus) DeepCopyInto(out *CRSMReportStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]CRSMReportNamespace, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unhealthy != nil {
+		in, out := &in.Unhealthy, &out.Unhealthy
+		*out = make([]CRSMReportInstance, len(*in))
+		copy(*out, *in)
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]CRSMReportTarget, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CRSMReportStatus.
+func (in *CRSMReportStatus) DeepCopy() *CRSMReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CRSMReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CRSMReportTarget) DeepCopyInto(out *CRSMReportTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CRSMReportTarget.
+func (in *CRSMReportTarget) DeepCopy() *CRSMReportTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(CRSMReportTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomResourceStateMetrics) DeepCopyInto(out *CustomResourceStateMetrics) {
 	*out = *in
@@ -55,6 +190,25 @@ func (in *CustomResourceStateMetrics) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomResourceStateMetricsConfigMap) DeepCopyInto(out *CustomResourceStateMetricsConfigMap) {
 	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsConfigMap.
@@ -67,6 +221,63 @@ func (in *CustomResourceStateMetricsConfigMap) DeepCopy() *CustomResourceStateMe
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsDefaults) DeepCopyInto(out *CustomResourceStateMetricsDefaults) {
+	*out = *in
+	if in.MetricNamePrefix != nil {
+		in, out := &in.MetricNamePrefix, &out.MetricNamePrefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.CommonLabels != nil {
+		in, out := &in.CommonLabels, &out.CommonLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsDefaults.
+func (in *CustomResourceStateMetricsDefaults) DeepCopy() *CustomResourceStateMetricsDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsDeploymentRef) DeepCopyInto(out *CustomResourceStateMetricsDeploymentRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsDeploymentRef.
+func (in *CustomResourceStateMetricsDeploymentRef) DeepCopy() *CustomResourceStateMetricsDeploymentRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsDeploymentRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsKind) DeepCopyInto(out *CustomResourceStateMetricsKind) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsKind.
+func (in *CustomResourceStateMetricsKind) DeepCopy() *CustomResourceStateMetricsKind {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsKind)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomResourceStateMetricsList) DeepCopyInto(out *CustomResourceStateMetricsList) {
 	*out = *in
@@ -99,17 +310,162 @@ func (in *CustomResourceStateMetricsList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsReload) DeepCopyInto(out *CustomResourceStateMetricsReload) {
+	*out = *in
+	out.DeploymentRef = in.DeploymentRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsReload.
+func (in *CustomResourceStateMetricsReload) DeepCopy() *CustomResourceStateMetricsReload {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsReload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsResourcesSource) DeepCopyInto(out *CustomResourceStateMetricsResourcesSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsResourcesSource.
+func (in *CustomResourceStateMetricsResourcesSource) DeepCopy() *CustomResourceStateMetricsResourcesSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsResourcesSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsRevision) DeepCopyInto(out *CustomResourceStateMetricsRevision) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsRevision.
+func (in *CustomResourceStateMetricsRevision) DeepCopy() *CustomResourceStateMetricsRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsSchedule) DeepCopyInto(out *CustomResourceStateMetricsSchedule) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsSchedule.
+func (in *CustomResourceStateMetricsSchedule) DeepCopy() *CustomResourceStateMetricsSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsSecret) DeepCopyInto(out *CustomResourceStateMetricsSecret) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsSecret.
+func (in *CustomResourceStateMetricsSecret) DeepCopy() *CustomResourceStateMetricsSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsSource) DeepCopyInto(out *CustomResourceStateMetricsSource) {
+	*out = *in
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsSource.
+func (in *CustomResourceStateMetricsSource) DeepCopy() *CustomResourceStateMetricsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomResourceStateMetricsSpec) DeepCopyInto(out *CustomResourceStateMetricsSpec) {
 	*out = *in
-	out.ConfigMap = in.ConfigMap
+	in.ConfigMap.DeepCopyInto(&out.ConfigMap)
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(CustomResourceStateMetricsSpecTarget)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
-		*out = make([]runtime.RawExtension, len(*in))
+		*out = make([]ksm.Resource, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ResourcesFrom != nil {
+		in, out := &in.ResourcesFrom, &out.ResourcesFrom
+		*out = make([]CustomResourceStateMetricsResourcesSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(CustomResourceStateMetricsSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(CustomResourceStateMetricsDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(CustomResourceStateMetricsSchedule)
+		**out = **in
+	}
+	if in.Reload != nil {
+		in, out := &in.Reload, &out.Reload
+		*out = new(CustomResourceStateMetricsReload)
+		**out = **in
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsSpec.
@@ -122,6 +478,26 @@ func (in *CustomResourceStateMetricsSpec) DeepCopy() *CustomResourceStateMetrics
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsSpecTarget) DeepCopyInto(out *CustomResourceStateMetricsSpecTarget) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(CustomResourceStateMetricsSecret)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsSpecTarget.
+func (in *CustomResourceStateMetricsSpecTarget) DeepCopy() *CustomResourceStateMetricsSpecTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsSpecTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomResourceStateMetricsStatus) DeepCopyInto(out *CustomResourceStateMetricsStatus) {
 	*out = *in
@@ -132,6 +508,32 @@ func (in *CustomResourceStateMetricsStatus) DeepCopyInto(out *CustomResourceStat
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(CustomResourceStateMetricsTarget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.MetricNames != nil {
+		in, out := &in.MetricNames, &out.MetricNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]CustomResourceStateMetricsKind, len(*in))
+		copy(*out, *in)
+	}
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make([]CustomResourceStateMetricsRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsStatus.
@@ -143,3 +545,136 @@ func (in *CustomResourceStateMetricsStatus) DeepCopy() *CustomResourceStateMetri
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStateMetricsTarget) DeepCopyInto(out *CustomResourceStateMetricsTarget) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStateMetricsTarget.
+func (in *CustomResourceStateMetricsTarget) DeepCopy() *CustomResourceStateMetricsTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStateMetricsTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfig) DeepCopyInto(out *OperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfig.
+func (in *OperatorConfig) DeepCopy() *OperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigList) DeepCopyInto(out *OperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigList.
+func (in *OperatorConfigList) DeepCopy() *OperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigSpec) DeepCopyInto(out *OperatorConfigSpec) {
+	*out = *in
+	if in.ResyncPeriod != nil {
+		in, out := &in.ResyncPeriod, &out.ResyncPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RestartKSM != nil {
+		in, out := &in.RestartKSM, &out.RestartKSM
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MetricNamePrefixes != nil {
+		in, out := &in.MetricNamePrefixes, &out.MetricNamePrefixes
+		*out = make(map[string]MetricNamePrefix, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigSpec.
+func (in *OperatorConfigSpec) DeepCopy() *OperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigStatus) DeepCopyInto(out *OperatorConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigStatus.
+func (in *OperatorConfigStatus) DeepCopy() *OperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
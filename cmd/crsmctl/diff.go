@@ -0,0 +1,134 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/controller"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Exit codes of the diff command. They follow the kubectl diff command.
+const (
+	diffExitNoChanges = 0
+	diffExitChanges   = 1
+	diffExitError     = 2
+)
+
+// runDiff runs the diff command printing into the writer and returns the exit
+// code.
+func runDiff(ctx context.Context, args []string, w io.Writer) int {
+	var files filesFlag
+	var selector string
+	var defaultConfigMap string
+	var allowedRemoteSources string
+
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+
+	fs.Var(&files, "f",
+		"File with the manifests of the CRSMs (- for stdin). Can be repeated. The sources and the targets "+
+			"of the CRSMs are resolved against the cluster.")
+	fs.StringVar(&selector, "selector", "", "Label selector (e.g. team=foo,tier!=dev) filtering the compared CRSMs.")
+	fs.StringVar(&defaultConfigMap, "default-configmap", "",
+		"ConfigMap (namespace/name) the CRSMs which don't specify any are written into. "+
+			"Should match the --default-configmap flag of the operator.")
+	fs.StringVar(&allowedRemoteSources, "allowed-remote-sources", "",
+		"Comma-separated list of the prefixes of the URLs and of the OCI references the CRSMs may fetch the "+
+			"resources from. Should match the --allowed-remote-sources flag of the operator.")
+
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(fs)
+
+	// Errors are handled by the ExitOnError flag
+	_ = fs.Parse(args)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	if len(files) == 0 {
+		setupLog.Error(nil, "The files (-f) must be specified")
+
+		return diffExitError
+	}
+
+	labelSelector, err := labels.Parse(selector)
+	if err != nil {
+		setupLog.Error(err, "failed to parse the label selector")
+
+		return diffExitError
+	}
+
+	instances, _, err := readManifests(files)
+	if err != nil {
+		setupLog.Error(err, "failed to read the manifests")
+
+		return diffExitError
+	}
+
+	instances = slices.DeleteFunc(instances, func(instance ksmv1.CustomResourceStateMetrics) bool {
+		return !labelSelector.Matches(labels.Set(instance.Labels))
+	})
+
+	c, err := newClient()
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+
+		return diffExitError
+	}
+
+	r := newRenderer(c, defaultConfigMap, allowedRemoteSources)
+
+	contents, err := r.RenderLive(ctx, instances)
+	if err != nil {
+		setupLog.Error(err, "failed to render the CRSMs")
+
+		return diffExitError
+	}
+
+	exitCode := diffExitNoChanges
+
+	for _, key := range renderedKeys(contents) {
+		live, _, err := r.LiveContent(ctx, key)
+		if err != nil {
+			setupLog.Error(err, "failed to get the current content",
+				"target", utils.NamespacedName(key.Name, key.Namespace), "key", key.Key)
+
+			return diffExitError
+		}
+
+		name := fmt.Sprintf("%s/%s/%s/%s", key.Kind, key.Namespace, key.Name, key.Key)
+
+		if diff := controller.UnifiedDiff("live/"+name, "rendered/"+name, live, contents[key]); diff != "" {
+			fmt.Fprint(w, diff)
+
+			exitCode = diffExitChanges
+		}
+	}
+
+	return exitCode
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunDiff(t *testing.T) {
+	g := NewWithT(t)
+
+	// The cluster holds only the resources of the foo CRSM
+	selected, code := runCommand(runRender, "-f", "testdata/crsms.yaml", "--selector", "team=foo")
+	g.Expect(code).To(Equal(0))
+
+	// Drop the header of the rendered key
+	_, live, _ := strings.Cut(selected, "\n")
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ksm", Namespace: "monitoring"},
+		Data:       map[string]string{"config.yaml": live},
+	}
+
+	defaultClient := newClient
+	t.Cleanup(func() { newClient = defaultClient })
+
+	newClient = func() (client.Client, error) {
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(cm).Build(), nil
+	}
+
+	tests := map[string]struct {
+		args     []string
+		expected string
+		code     int
+	}{
+		"changes": {
+			args:     []string{"-f", "testdata/crsms.yaml"},
+			expected: golden(g, "diff.golden"),
+			code:     diffExitChanges,
+		},
+		"no_changes": {
+			args: []string{"-f", "testdata/crsms.yaml", "--selector", "team=foo"},
+			code: diffExitNoChanges,
+		},
+		"no_files": {
+			code: diffExitError,
+		},
+		"missing_file": {
+			args: []string{"-f", "testdata/missing.yaml"},
+			code: diffExitError,
+		},
+	}
+
+	for name, test := range tests {
+		out, code := runCommand(runDiff, test.args...)
+
+		g.Expect(code).To(Equal(test.code), "Test [%s]:", name)
+		g.Expect(out).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/controller"
+)
+
+// runImport runs the import command printing into the writer and returns the
+// exit code.
+func runImport(ctx context.Context, args []string, w io.Writer) int {
+	var file string
+	var configMap string
+	var key string
+	var path string
+	var perResource bool
+	var name string
+	var namespace string
+	var targetConfigMap string
+
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+
+	fs.StringVar(&file, "f", "", "File with the kube-state-metrics config to import (- for stdin).")
+	fs.StringVar(&configMap, "configmap", "",
+		"ConfigMap (namespace/name) in the cluster holding the kube-state-metrics config to import.")
+	fs.StringVar(&key, "key", controller.DefaultKey, "Key of the ConfigMap holding the config.")
+	fs.StringVar(&path, "path", "",
+		"Dot-separated path of the CustomResourceStateMetrics document inside of the config. "+
+			"The whole config is the document if not set.")
+	fs.BoolVar(&perResource, "per-resource", false,
+		"If set, a CRSM is created for every resource instead of for every block of the resources "+
+			"written by the operator.")
+	fs.StringVar(&name, "name", "imported", "Name of the CRSM holding the resources not written by the operator.")
+	fs.StringVar(&namespace, "namespace", corev1.NamespaceDefault,
+		"Namespace of the CRSMs of the resources not written by the operator.")
+	fs.StringVar(&targetConfigMap, "target-configmap", "",
+		"ConfigMap (namespace/name) the CRSMs write into. The imported ConfigMap (--configmap) is used if not set.")
+
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(fs)
+
+	// Errors are handled by the ExitOnError flag
+	_ = fs.Parse(args)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	if (file == "") == (configMap == "") {
+		setupLog.Error(nil, "Either the file (-f) or the ConfigMap (--configmap) must be specified")
+
+		return 1
+	}
+
+	var content string
+	var err error
+
+	if file != "" {
+		content, err = readConfigFile(file)
+	} else {
+		content, err = readConfigMap(ctx, parseNamespacedName(configMap), key)
+	}
+
+	if err != nil {
+		setupLog.Error(err, "failed to read the config")
+
+		return 1
+	}
+
+	blocks, err := controller.SplitConfig(content, path, perResource)
+	if err != nil {
+		setupLog.Error(err, "failed to split the config")
+
+		return 1
+	}
+
+	if targetConfigMap == "" {
+		targetConfigMap = configMap
+	}
+
+	target := parseNamespacedName(targetConfigMap)
+	targetKey := ""
+
+	// The default key doesn't have to be repeated in every manifest
+	if configMap != "" && key != controller.DefaultKey {
+		targetKey = key
+	}
+
+	for i, block := range blocks {
+		if block.Name == "" {
+			block.Name = name
+		}
+
+		if block.Namespace == "" {
+			block.Namespace = namespace
+		}
+
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+		}
+
+		if err := printManifest(w, block, target, targetKey, path); err != nil {
+			setupLog.Error(err, "failed to print the manifest")
+
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// readConfigFile reads the config from the file (- for stdin).
+func readConfigFile(file string) (string, error) {
+	var data []byte
+	var err error
+
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	return string(data), nil
+}
+
+// readConfigMap reads the config from the key of the ConfigMap in the cluster.
+func readConfigMap(ctx context.Context, name types.NamespacedName, key string) (string, error) {
+	c, err := newClient()
+	if err != nil {
+		return "", fmt.Errorf("unable to create client: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, name, cm); err != nil {
+		return "", err
+	}
+
+	return controller.ConfigMapContent(cm, key)
+}
+
+// parseNamespacedName parses the namespace/name string. The Namespace is empty
+// if the string has no slash.
+func parseNamespacedName(value string) types.NamespacedName {
+	if ns, name, found := strings.Cut(value, "/"); found {
+		return types.NamespacedName{Name: name, Namespace: ns}
+	}
+
+	return types.NamespacedName{Name: value}
+}
+
+// printManifest prints the manifest of the CRSM holding the resources of the
+// block.
+func printManifest(w io.Writer, block controller.ImportedBlock, target types.NamespacedName, key, path string) error {
+	metadata := map[string]interface{}{"name": block.Name, "namespace": block.Namespace}
+	spec := map[string]interface{}{"resourcesRaw": block.Resources}
+
+	configMap := map[string]interface{}{}
+
+	for field, value := range map[string]string{
+		"name":      target.Name,
+		"namespace": target.Namespace,
+		"key":       key,
+		"path":      path,
+	} {
+		if value != "" {
+			configMap[field] = value
+		}
+	}
+
+	if len(configMap) > 0 {
+		spec["configMap"] = configMap
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2) //nolint:mnd
+
+	// The keys of the maps are encoded sorted
+	if err := encoder.Encode(map[string]interface{}{
+		"apiVersion": ksmv1.GroupVersion.String(),
+		"kind":       "CustomResourceStateMetrics",
+		"metadata":   metadata,
+		"spec":       spec,
+	}); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
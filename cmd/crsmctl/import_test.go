@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunImport(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		args     []string
+		expected string
+		code     int
+	}{
+		"blocks": {
+			args:     []string{"-f", "testdata/config.yaml", "--target-configmap", "monitoring/ksm"},
+			expected: golden(g, "import.golden"),
+		},
+		"per_resource": {
+			args:     []string{"-f", "testdata/config.yaml", "--per-resource", "--namespace", "other"},
+			expected: golden(g, "import-per-resource.golden"),
+		},
+		"no_source": {
+			code: 1,
+		},
+		"both_sources": {
+			args: []string{"-f", "testdata/config.yaml", "--configmap", "monitoring/ksm"},
+			code: 1,
+		},
+		"invalid_path": {
+			args: []string{"-f", "testdata/config.yaml", "--path", "missing"},
+			code: 1,
+		},
+	}
+
+	for name, test := range tests {
+		out, code := runCommand(runImport, test.args...)
+
+		g.Expect(code).To(Equal(test.code), "Test [%s]:", name)
+		g.Expect(out).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// crsmctl is the command line tool working with the CustomResourceStateMetrics
+// the same way the operator does.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/version"
+)
+
+// Usage of the command.
+const usage = `Usage: crsmctl <command> [flags]
+
+Commands:
+  diff      Print the changes the CRSMs would make in their targets.
+  import    Split an existing kube-state-metrics config into the CRSMs.
+  render    Print the kube-state-metrics config assembled from the CRSMs.
+  validate  Validate the manifests of the CRSMs without a cluster.
+  version   Print out the version.
+
+Run 'crsmctl <command> -h' for the flags of the command.
+`
+
+var (
+	scheme   = runtime.NewScheme()
+	setupLog = ctrl.Log.WithName("[crsmctl]")
+)
+
+// newClient creates the client of the cluster.
+var newClient = func() (client.Client, error) {
+	return client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+}
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+
+	utilruntime.Must(ksmv1.AddToScheme(scheme))
+}
+
+func main() {
+	if len(os.Args) < 2 { //nolint:mnd
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "diff":
+		os.Exit(runDiff(ctrl.SetupSignalHandler(), os.Args[2:], os.Stdout))
+	case "import":
+		os.Exit(runImport(ctrl.SetupSignalHandler(), os.Args[2:], os.Stdout))
+	case "render":
+		os.Exit(runRender(ctrl.SetupSignalHandler(), os.Args[2:], os.Stdout))
+	case "validate":
+		os.Exit(runValidate(ctrl.SetupSignalHandler(), os.Args[2:], os.Stdout))
+	case "version":
+		fmt.Println(version.String())
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q.\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
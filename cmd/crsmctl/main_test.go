@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// command is the function running the command.
+type command func(ctx context.Context, args []string, w io.Writer) int
+
+// runCommand runs the command and returns its output and exit code.
+func runCommand(run command, args ...string) (string, int) {
+	var out bytes.Buffer
+
+	code := run(context.Background(), args, &out)
+
+	return out.String(), code
+}
+
+// golden returns the content of the golden file in the testdata directory.
+func golden(g *WithT, name string) string {
+	content, err := os.ReadFile(filepath.Join("testdata", name))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	return string(content)
+}
+
+func TestParseNamespacedName(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(parseNamespacedName("monitoring/ksm").String()).To(Equal("monitoring/ksm"), "Test [namespaced]:")
+	g.Expect(parseNamespacedName("ksm").Namespace).To(BeEmpty(), "Test [name]:")
+	g.Expect(parseNamespacedName("").Name).To(BeEmpty(), "Test [empty]:")
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Size of the buffer used to detect whether the manifests are YAML or JSON.
+const decoderBufferSize = 4096
+
+// filesFlag collects the values of the repeated flag.
+type filesFlag []string
+
+// String returns the comma-separated values.
+func (f *filesFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set adds the comma-separated values.
+func (f *filesFlag) Set(value string) error {
+	*f = append(*f, strings.Split(value, ",")...)
+
+	return nil
+}
+
+// document is a single manifest (in JSON) read from the file.
+type document struct {
+	file string
+	raw  []byte
+}
+
+// readDocuments reads the manifests from the files (- for stdin). The items of
+// the lists (e.g. the output of kubectl get -o yaml) are read as individual
+// manifests.
+func readDocuments(files []string) ([]document, error) {
+	documents := []document{}
+
+	for _, file := range files {
+		var content []byte
+		var err error
+
+		if file == "-" {
+			content, err = io.ReadAll(os.Stdin)
+		} else {
+			content, err = os.ReadFile(file)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), decoderBufferSize)
+
+		for {
+			raw := runtime.RawExtension{}
+
+			if err := decoder.Decode(&raw); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+			}
+
+			// Skip the empty documents
+			if len(raw.Raw) == 0 || string(raw.Raw) == "null" {
+				continue
+			}
+
+			list := struct {
+				Kind  string                 `json:"kind"`
+				Items []runtime.RawExtension `json:"items"`
+			}{}
+
+			if err := json.Unmarshal(raw.Raw, &list); err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+			}
+
+			if !strings.HasSuffix(list.Kind, "List") || list.Items == nil {
+				documents = append(documents, document{file: file, raw: raw.Raw})
+
+				continue
+			}
+
+			for _, item := range list.Items {
+				documents = append(documents, document{file: file, raw: item.Raw})
+			}
+		}
+	}
+
+	return documents, nil
+}
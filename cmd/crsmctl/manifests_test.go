@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestFilesFlag(t *testing.T) {
+	g := NewWithT(t)
+
+	var files filesFlag
+
+	g.Expect(files.Set("a.yaml,b.yaml")).To(Succeed())
+	g.Expect(files.Set("c.yaml")).To(Succeed())
+
+	g.Expect(files).To(Equal(filesFlag{"a.yaml", "b.yaml", "c.yaml"}))
+	g.Expect(files.String()).To(Equal("a.yaml,b.yaml,c.yaml"))
+}
+
+func TestReadDocuments(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		content  string
+		expected []string
+		fails    bool
+	}{
+		"yaml": {
+			content:  "kind: Foo\n",
+			expected: []string{`{"kind":"Foo"}`},
+		},
+		"multiple_documents": {
+			content:  "kind: Foo\n---\n---\nkind: Bar\n",
+			expected: []string{`{"kind":"Foo"}`, `{"kind":"Bar"}`},
+		},
+		"json": {
+			content:  `{"kind": "Foo"}`,
+			expected: []string{`{"kind": "Foo"}`},
+		},
+		"list": {
+			content:  "kind: List\nitems:\n  - kind: Foo\n  - kind: Bar\n",
+			expected: []string{`{"kind":"Foo"}`, `{"kind":"Bar"}`},
+		},
+		"typed_list": {
+			content:  "kind: CustomResourceStateMetricsList\nitems:\n  - kind: Foo\n",
+			expected: []string{`{"kind":"Foo"}`},
+		},
+		"list_without_items": {
+			content:  "kind: FooList\n",
+			expected: []string{`{"kind":"FooList"}`},
+		},
+		"empty": {
+			content:  "",
+			expected: []string{},
+		},
+		"invalid": {
+			content: "kind: [Foo\n",
+			fails:   true,
+		},
+	}
+
+	for name, test := range tests {
+		file := filepath.Join(t.TempDir(), "manifests.yaml")
+		g.Expect(os.WriteFile(file, []byte(test.content), 0o600)).To(Succeed())
+
+		documents, err := readDocuments([]string{file})
+		if test.fails {
+			g.Expect(err).To(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+		raw := []string{}
+
+		for _, document := range documents {
+			g.Expect(document.file).To(Equal(file), "Test [%s]:", name)
+
+			raw = append(raw, string(document.raw))
+		}
+
+		g.Expect(raw).To(Equal(test.expected), "Test [%s]:", name)
+	}
+
+	_, err := readDocuments([]string{filepath.Join(t.TempDir(), "missing.yaml")})
+	g.Expect(err).To(HaveOccurred(), "Test [missing]:")
+}
+
+func TestReadManifests(t *testing.T) {
+	g := NewWithT(t)
+
+	instances, objects, err := readManifests([]string{"testdata/crsms.yaml"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The unknown kinds are skipped
+	g.Expect(instances).To(HaveLen(2))
+	g.Expect(instances[0].Name).To(Equal("foo"))
+	g.Expect(instances[1].Name).To(Equal("bar"))
+	g.Expect(objects).To(HaveLen(1))
+	g.Expect(objects[0].GetName()).To(Equal("ksm"))
+
+	// The CRSMs without Namespace are placed into the default Namespace
+	file := filepath.Join(t.TempDir(), "crsm.yaml")
+	g.Expect(os.WriteFile(file, []byte("apiVersion: ksm.jtyr.io/v1\nkind: CustomResourceStateMetrics\n"+
+		"metadata:\n  name: foo\n"), 0o600)).To(Succeed())
+
+	instances, _, err = readManifests([]string{file})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(instances).To(HaveLen(1))
+	g.Expect(instances[0].Namespace).To(Equal("default"))
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/controller"
+	"github.com/jtyr/crsm-operator/internal/remote"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// runRender runs the render command printing into the writer and returns the
+// exit code.
+func runRender(ctx context.Context, args []string, w io.Writer) int {
+	var files filesFlag
+	var fromCluster bool
+	var namespace string
+	var selector string
+	var defaultConfigMap string
+	var allowedRemoteSources string
+
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+
+	fs.Var(&files, "f",
+		"File with the manifests of the CRSMs (- for stdin). Can be repeated. The ConfigMaps, Secrets, "+
+			"Namespaces and Deployments in the files are used to resolve the sources and the targets of the CRSMs.")
+	fs.BoolVar(&fromCluster, "from-cluster", false,
+		"If set, the CRSMs are read from the cluster as well and their sources and targets are resolved "+
+			"against the cluster. The CRSMs from the files replace the ones with the same name.")
+	fs.StringVar(&namespace, "namespace", "",
+		"Namespace of the rendered CRSMs. All Namespaces are rendered if not set.")
+	fs.StringVar(&selector, "selector", "", "Label selector (e.g. team=foo,tier!=dev) filtering the rendered CRSMs.")
+	fs.StringVar(&defaultConfigMap, "default-configmap", "",
+		"ConfigMap (namespace/name) the CRSMs which don't specify any are written into. "+
+			"Should match the --default-configmap flag of the operator.")
+	fs.StringVar(&allowedRemoteSources, "allowed-remote-sources", "",
+		"Comma-separated list of the prefixes of the URLs and of the OCI references the CRSMs may fetch the "+
+			"resources from. Should match the --allowed-remote-sources flag of the operator.")
+
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(fs)
+
+	// Errors are handled by the ExitOnError flag
+	_ = fs.Parse(args)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	if len(files) == 0 && !fromCluster {
+		setupLog.Error(nil, "Either the files (-f) or the cluster (--from-cluster) must be specified")
+
+		return 1
+	}
+
+	labelSelector, err := labels.Parse(selector)
+	if err != nil {
+		setupLog.Error(err, "failed to parse the label selector")
+
+		return 1
+	}
+
+	instances, objects, err := readManifests(files)
+	if err != nil {
+		setupLog.Error(err, "failed to read the manifests")
+
+		return 1
+	}
+
+	var c client.Client
+
+	if fromCluster {
+		if c, err = newClient(); err != nil {
+			setupLog.Error(err, "unable to create client")
+
+			return 1
+		}
+
+		if instances, err = clusterInstances(ctx, c, namespace, instances); err != nil {
+			setupLog.Error(err, "failed to read the CRSMs from the cluster")
+
+			return 1
+		}
+	} else {
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	}
+
+	// Only the CRSMs the operator would write are rendered
+	instances = slices.DeleteFunc(instances, func(instance ksmv1.CustomResourceStateMetrics) bool {
+		return !instance.DeletionTimestamp.IsZero() ||
+			(namespace != "" && instance.Namespace != namespace) ||
+			!labelSelector.Matches(labels.Set(instance.Labels))
+	})
+
+	contents, err := newRenderer(c, defaultConfigMap, allowedRemoteSources).Render(ctx, instances)
+	if err != nil {
+		setupLog.Error(err, "failed to render the CRSMs")
+
+		return 1
+	}
+
+	printRendered(w, contents)
+
+	return 0
+}
+
+// readManifests reads the CRSMs and the other objects from the files. The
+// CRSMs without Namespace are placed into the default Namespace.
+func readManifests(files []string) ([]ksmv1.CustomResourceStateMetrics, []client.Object, error) {
+	documents, err := readDocuments(files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	instances := []ksmv1.CustomResourceStateMetrics{}
+	objects := []client.Object{}
+
+	for _, document := range documents {
+		obj, _, err := decoder.Decode(document.raw, nil, nil)
+		if runtime.IsNotRegisteredError(err) || runtime.IsMissingKind(err) {
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode %s: %w", document.file, err)
+		}
+
+		switch o := obj.(type) {
+		case *ksmv1.CustomResourceStateMetrics:
+			if o.Namespace == "" {
+				o.Namespace = corev1.NamespaceDefault
+			}
+
+			instances = append(instances, *o)
+		case client.Object:
+			objects = append(objects, o)
+		}
+	}
+
+	return instances, objects, nil
+}
+
+// clusterInstances returns the CRSMs in the cluster replaced by the CRSMs read
+// from the files.
+func clusterInstances(
+	ctx context.Context, c client.Client, namespace string,
+	fileInstances []ksmv1.CustomResourceStateMetrics) ([]ksmv1.CustomResourceStateMetrics, error) {
+	list := &ksmv1.CustomResourceStateMetricsList{}
+
+	if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	instances := fileInstances
+
+	for _, instance := range list.Items {
+		if !slices.ContainsFunc(fileInstances, func(fileInstance ksmv1.CustomResourceStateMetrics) bool {
+			return utils.NamespacedName(fileInstance.Name, fileInstance.Namespace) ==
+				utils.NamespacedName(instance.Name, instance.Namespace)
+		}) {
+			instances = append(instances, instance)
+		}
+	}
+
+	return instances, nil
+}
+
+// newRenderer returns the reconciler rendering the CRSMs the same way the
+// operator with the default ConfigMap (namespace/name) and with the allowed
+// remote sources does. The remote sources are disabled if none are allowed.
+func newRenderer(
+	c client.Client, defaultConfigMap, allowedRemoteSources string) *controller.CustomResourceStateMetricsReconciler {
+	r := &controller.CustomResourceStateMetricsReconciler{
+		Client:           c,
+		Scheme:           scheme,
+		DefaultConfigMap: parseNamespacedName(defaultConfigMap),
+	}
+
+	var prefixes []string
+
+	for _, prefix := range strings.Split(allowedRemoteSources, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	if len(prefixes) > 0 {
+		r.Fetcher = remote.NewFetcher(remote.DefaultTimeout, prefixes)
+	}
+
+	return r
+}
+
+// renderedKeys returns the keys of the rendered content sorted by their
+// Namespace, name and key.
+func renderedKeys(contents map[controller.RenderedKey]string) []controller.RenderedKey {
+	keys := make([]controller.RenderedKey, 0, len(contents))
+	for key := range contents {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b controller.RenderedKey) int {
+		return strings.Compare(
+			strings.Join([]string{a.Namespace, a.Name, string(a.Kind), a.Key}, "/"),
+			strings.Join([]string{b.Namespace, b.Name, string(b.Kind), b.Key}, "/"))
+	})
+
+	return keys
+}
+
+// printRendered prints the rendered content of the targets sorted by their
+// Namespace, name and key.
+func printRendered(w io.Writer, contents map[controller.RenderedKey]string) {
+	for i, key := range renderedKeys(contents) {
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+		}
+
+		fmt.Fprintf(w, "# %s %s, key %s\n", key.Kind, utils.NamespacedName(key.Name, key.Namespace), key.Key)
+		fmt.Fprint(w, contents[key])
+	}
+}
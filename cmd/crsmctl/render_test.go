@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunRender(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		args     []string
+		expected string
+		code     int
+	}{
+		"files": {
+			args:     []string{"-f", "testdata/crsms.yaml"},
+			expected: golden(g, "render.golden"),
+		},
+		"selector": {
+			args:     []string{"-f", "testdata/crsms.yaml", "--selector", "team=foo"},
+			expected: golden(g, "render-selector.golden"),
+		},
+		"namespace": {
+			args: []string{"-f", "testdata/crsms.yaml", "--namespace", "other"},
+		},
+		"no_files": {
+			code: 1,
+		},
+		"invalid_selector": {
+			args: []string{"-f", "testdata/crsms.yaml", "--selector", "team in ("},
+			code: 1,
+		},
+		"missing_file": {
+			args: []string{"-f", "testdata/missing.yaml"},
+			code: 1,
+		},
+	}
+
+	for name, test := range tests {
+		out, code := runCommand(runRender, test.args...)
+
+		g.Expect(code).To(Equal(test.code), "Test [%s]:", name)
+		g.Expect(out).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/config/crd"
+	"github.com/jtyr/crsm-operator/internal/schema"
+	webhookv1 "github.com/jtyr/crsm-operator/internal/webhook/v1"
+)
+
+// runValidate runs the validate command printing into the writer and returns
+// the exit code.
+func runValidate(ctx context.Context, args []string, w io.Writer) int {
+	var files filesFlag
+
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+
+	fs.Var(&files, "f",
+		"File with the manifests to validate (- for stdin). Can be repeated. Only the manifests of the "+
+			"operator resources are validated.")
+
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(fs)
+
+	// Errors are handled by the ExitOnError flag
+	_ = fs.Parse(args)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	if len(files) == 0 {
+		setupLog.Error(nil, "The files (-f) must be specified")
+
+		return 1
+	}
+
+	validator, err := schema.New(crd.Bases)
+	if err != nil {
+		setupLog.Error(err, "failed to load the CRD schemas")
+
+		return 1
+	}
+
+	documents, err := readDocuments(files)
+	if err != nil {
+		setupLog.Error(err, "failed to read the manifests")
+
+		return 1
+	}
+
+	validated, invalid := 0, 0
+
+	for _, document := range documents {
+		errs, known := validateDocument(ctx, validator, document)
+		if !known {
+			continue
+		}
+
+		validated++
+
+		if len(errs) > 0 {
+			invalid++
+
+			printErrors(w, document, errs)
+		}
+	}
+
+	fmt.Fprintf(w, "Validated %d manifests, %d invalid.\n", validated, invalid)
+
+	if invalid > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// validateDocument validates the manifest against the schema of its CRD and
+// the CRSMs the same way the admission webhook does (e.g. whether
+// kube-state-metrics can load the resources). It returns false if the
+// manifest is not an operator resource.
+func validateDocument(ctx context.Context, validator *schema.Validator, document document) ([]error, bool) {
+	obj := map[string]interface{}{}
+
+	if err := json.Unmarshal(document.raw, &obj); err != nil {
+		return []error{err}, true
+	}
+
+	errs, known := validator.Validate(obj)
+	if !known || len(errs) > 0 {
+		return errs, known
+	}
+
+	decoded, _, err := serializer.NewCodecFactory(scheme).UniversalDeserializer().Decode(document.raw, nil, nil)
+	if err != nil {
+		return []error{err}, true
+	}
+
+	if instance, ok := decoded.(*ksmv1.CustomResourceStateMetrics); ok {
+		if _, err := (&webhookv1.CustomResourceStateMetricsCustomValidator{}).ValidateCreate(ctx, instance); err != nil {
+			return []error{err}, true
+		}
+	}
+
+	return nil, true
+}
+
+// printErrors prints the errors of the manifest.
+func printErrors(w io.Writer, document document, errs []error) {
+	meta := struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}{}
+
+	// The manifest was decoded before
+	_ = json.Unmarshal(document.raw, &meta)
+
+	name := meta.Metadata.Name
+	if meta.Metadata.Namespace != "" {
+		name = meta.Metadata.Namespace + "/" + name
+	}
+
+	for _, err := range errs {
+		fmt.Fprintf(w, "%s: %s %s: %v\n", document.file, meta.Kind, name, err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRunValidate(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		args     []string
+		expected string
+		code     int
+	}{
+		"valid": {
+			args:     []string{"-f", "testdata/crsms.yaml"},
+			expected: "Validated 2 manifests, 0 invalid.\n",
+		},
+		"invalid": {
+			args:     []string{"-f", "testdata/crsms.yaml", "-f", "testdata/invalid.yaml"},
+			expected: golden(g, "validate.golden"),
+			code:     1,
+		},
+		"no_files": {
+			code: 1,
+		},
+		"missing_file": {
+			args: []string{"-f", "testdata/missing.yaml"},
+			code: 1,
+		},
+	}
+
+	for name, test := range tests {
+		out, code := runCommand(runValidate, test.args...)
+
+		g.Expect(code).To(Equal(test.code), "Test [%s]:", name)
+		g.Expect(out).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
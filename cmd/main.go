@@ -22,31 +22,46 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
-	"go.uber.org/zap/zapcore"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientfeatures "k8s.io/client-go/features"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
-	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/jtyr/crsm-operator/internal/version"
 
 	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
 	"github.com/jtyr/crsm-operator/internal/controller"
+	"github.com/jtyr/crsm-operator/internal/events"
+	"github.com/jtyr/crsm-operator/internal/logger"
 	"github.com/jtyr/crsm-operator/internal/metrics"
+	"github.com/jtyr/crsm-operator/internal/notifier"
+	"github.com/jtyr/crsm-operator/internal/remote"
+	"github.com/jtyr/crsm-operator/internal/rollout"
+	"github.com/jtyr/crsm-operator/internal/utils"
+	"github.com/jtyr/crsm-operator/internal/verifier"
+	webhookv1 "github.com/jtyr/crsm-operator/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -64,6 +79,16 @@ func init() {
 
 //nolint:gocyclo
 func main() {
+	// Run the selftest subcommand
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		os.Exit(runSelfTest(os.Args[2:]))
+	}
+
+	// Run the preflight subcommand
+	if len(os.Args) > 1 && os.Args[1] == "preflight" {
+		os.Exit(runPreflight(os.Args[2:]))
+	}
+
 	var metricsAddr string
 	var metricsCertPath, metricsCertName, metricsCertKey string
 	var webhookCertPath, webhookCertName, webhookCertKey string
@@ -73,9 +98,44 @@ func main() {
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
 	var verbosity uint
+	var logFormat string
+	var logLevel string
+	var logLevels string
+	var logSamplingFirst int
+	var logSamplingThereafter int
 	var showVersion bool
 	var crsmLabelSelector string
 	var namespaceLabelSelector string
+	var defaultConfigMap string
+	var ksmMetricsURL string
+	var ksmVerifyInterval time.Duration
+	var notificationURL string
+	var notificationTimeout time.Duration
+	var watchList bool
+	var writeBufferMaxAge time.Duration
+	var writeBatchWindow time.Duration
+	var resyncPeriod time.Duration
+	var normalEventsSampleRate uint
+	var eventDedupWindow time.Duration
+	var eventRateLimit int
+	var restartKSM bool
+	var rolloutBatchWindow time.Duration
+	var ksmServiceAccount string
+	var ksmAllowedGroups string
+	var generateNamespace string
+	var janitorInterval time.Duration
+	var rebuildOnStart bool
+	var healthWindow int
+	var failureThreshold float64
+	var allowedTargets string
+	var allowedRemoteSources string
+	var maxConcurrentReconciles int
+	var dryRun bool
+	var documentHeader string
+	var markerFormat string
+	var warnMetricCollisions bool
+	var deduplicateResources bool
+	var takeOverManualResources bool
 
 	// Configure command line flags
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metrics endpoint binds to. "+
@@ -96,11 +156,108 @@ func main() {
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 	flag.UintVar(&verbosity, "verbosity", 0, "Logging verbosity.")
+	flag.StringVar(&logFormat, "log-format", logger.FormatConsole, "Format of the log messages (console or json).")
+	flag.StringVar(&logLevel, "log-level", "",
+		"Level of the log messages (error, info, debug, trace or the verbosity number). "+
+			"Overrides the --verbosity flag if set.")
+	flag.StringVar(&logLevels, "log-levels", "",
+		"Comma-separated levels of the individual loggers (e.g. [crsm]=2,janitor=debug) overriding "+
+			"the level of the other loggers.")
+	flag.IntVar(&logSamplingFirst, "log-sampling-first", 0,
+		"Number of the messages below the warning level with the same text logged per second before "+
+			"they get sampled. The sampling is disabled if set to 0.")
+	flag.IntVar(&logSamplingThereafter, "log-sampling-thereafter", 100, //nolint:mnd
+		"Only every Nth of the sampled messages is logged. All sampled messages are dropped if set to 0.")
 	flag.BoolVar(&showVersion, "version", false, "Print out the operator version.")
 	flag.StringVar(&crsmLabelSelector, "cr-selector", "",
-		"Comma-separated list of labels used for label selector to filter CRSMs.")
+		"Comma-separated list of labels used for label selector to filter CRSMs. "+
+			"Can be changed at runtime by the OperatorConfig.")
 	flag.StringVar(&namespaceLabelSelector, "namespace-selector", "",
-		"Comma-separated list of labels used for label selector to filter Namespaces of the CRSMs.")
+		"Comma-separated list of labels used for label selector to filter Namespaces of the CRSMs. "+
+			"Can be changed at runtime by the OperatorConfig.")
+	flag.StringVar(&defaultConfigMap, "default-configmap", "",
+		"ConfigMap (name or namespace/name) used by CRSMs that don't specify any. "+
+			"If not set, the ConfigMap is discovered from the kube-state-metrics Deployment.")
+	flag.StringVar(&ksmMetricsURL, "ksm-metrics-url", "",
+		"URL of the kube-state-metrics metrics endpoint used to verify that the metrics are produced. "+
+			"Verification is disabled if not set.")
+	flag.DurationVar(&ksmVerifyInterval, "ksm-verify-interval", 5*time.Minute, //nolint:mnd
+		"Interval in which the kube-state-metrics metrics endpoint is verified.")
+	flag.StringVar(&notificationURL, "notification-url", "",
+		"URL where the sync events are sent as JSON via HTTP POST. Notifications are disabled if not set.")
+	flag.DurationVar(&notificationTimeout, "notification-timeout", 10*time.Second, //nolint:mnd
+		"Timeout of the notification requests.")
+	flag.DurationVar(&writeBufferMaxAge, "write-buffer-max-age", 5*time.Minute, //nolint:mnd
+		"Maximum time the ConfigMap writes are buffered while the API server is unreachable. "+
+			"Set it to 0 to disable the buffering.")
+	flag.DurationVar(&writeBatchWindow, "write-batch-window", 0,
+		"Window in which the changes of the CRSMs targeting the same ConfigMap are batched into a single write. "+
+			"Set it to 0 to disable the batching.")
+	flag.DurationVar(&resyncPeriod, "resync-period", 10*time.Minute, //nolint:mnd
+		"Interval in which the resources of the CRSMs with the Always resync policy are rewritten into the ConfigMap.")
+	flag.UintVar(&normalEventsSampleRate, "normal-events-sample-rate", 1,
+		"Record only every Nth Normal event. Set it to 0 to disable the Normal events. Warning events are always recorded.")
+	flag.DurationVar(&eventDedupWindow, "event-dedup-window", 0,
+		"Window in which the events identical to an already recorded event of the same object are dropped. "+
+			"Set it to 0 to disable the deduplication.")
+	flag.IntVar(&eventRateLimit, "event-rate-limit", 0,
+		"Maximum number of events recorded per object per minute. Set it to 0 to disable the rate limit.")
+	flag.BoolVar(&restartKSM, "restart-ksm", false,
+		"If set, the kube-state-metrics Deployments mounting the changed ConfigMap are restarted.")
+	flag.DurationVar(&rolloutBatchWindow, "rollout-batch-window", time.Minute,
+		"Window in which the restarts of the CRSMs with the Batched rollout strategy are coalesced.")
+	flag.StringVar(&ksmServiceAccount, "ksm-service-account", "",
+		"ServiceAccount (namespace/name) of kube-state-metrics granted the access to the custom resources of the CRSMs. "+
+			"The RBAC of kube-state-metrics is not managed if not set.")
+	flag.StringVar(&ksmAllowedGroups, "ksm-allowed-groups", "",
+		"Comma-separated list of the API groups (shell patterns allowed) of the custom resources kube-state-metrics "+
+			"may be granted the access to. The core group is never granted and no group is granted if not set. "+
+			"The operator must itself be allowed to list and watch the resources of these groups.")
+	flag.StringVar(&generateNamespace, "generate-namespace", "",
+		"Namespace where the CRSMs are generated for the CRDs annotated with ksm.jtyr.io/generate=true. "+
+			"The generation is disabled if not set.")
+	flag.DurationVar(&janitorInterval, "janitor-interval", controller.DefaultJanitorInterval,
+		"Interval in which the resources of the deleted CRSMs are removed from the managed ConfigMaps. "+
+			"Set it to 0 to disable the collection.")
+	flag.BoolVar(&rebuildOnStart, "rebuild-on-start", false,
+		"If set, the managed ConfigMap keys are rebuilt from the current CRSMs once the operator starts.")
+	flag.IntVar(&healthWindow, "reconcile-health-window", controller.DefaultHealthWindow,
+		"Number of the recently reconciled CRSMs the failure ratio reported by the health probes is computed from.")
+	flag.Float64Var(&failureThreshold, "reconcile-failure-threshold", 0,
+		"Ratio (0-1) of the recently reconciled CRSMs failing due to an unexpected error at which the operator is reported unhealthy and restarted. "+
+			"Set it to 0 to disable the check.")
+	flag.StringVar(&allowedTargets, "allowed-targets", "",
+		"Comma-separated list of the ConfigMaps (namespace/name, shell patterns allowed) the CRSMs may write into. "+
+			"All ConfigMaps are allowed if not set. The Secrets outside of the Namespace of the CRSM are allowed "+
+			"only if listed.")
+	flag.StringVar(&allowedRemoteSources, "allowed-remote-sources", "",
+		"Comma-separated list of the prefixes of the URLs and of the OCI references (e.g. https://example.com/metrics/ "+
+			"or ghcr.io/myteam/) the CRSMs may fetch the resources from. The remote sources are disabled if not set.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"Number of the CRSMs reconciled in parallel. The writes into the same ConfigMap are serialized.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"If set, the CRSMs are rendered and the changes of the ConfigMaps are reported in their status, events and "+
+			"logs but no ConfigMap is written.")
+	flag.StringVar(&documentHeader, "document-header", "",
+		"Header of the documents created in the ConfigMaps which don't exist yet. It must be a YAML map. "+
+			"Defaults to the kind and the empty resources list of the kube-state-metrics config.")
+	flag.StringVar(&markerFormat, "marker-format", controller.DefaultMarkerFormat,
+		"Format of the comment identifying the resources of a CRSM with a single %s placeholder of the CRSM. "+
+			"The markers written in the previous formats are converted with the next write.")
+	flag.BoolVar(&warnMetricCollisions, "warn-metric-collisions", false,
+		"If set, the webhook and the controller only warn about the metric names colliding with the metrics of "+
+			"the other CRSMs writing into the same ConfigMap instead of rejecting the CRSM.")
+	flag.BoolVar(&deduplicateResources, "deduplicate-resources", false,
+		"If set, the resources identical to the resources of the other CRSMs writing into the same ConfigMap are "+
+			"dropped from the CRSM written later and reported in its status.")
+	flag.BoolVar(&takeOverManualResources, "take-over-manual-resources", false,
+		"If set, the resources found in the ConfigMaps outside of the blocks of the CRSMs are preserved as the "+
+			"\"manual\" block which is kept by the rebuild and by the janitor.")
+	flag.BoolVar(&watchList, "watch-list", true,
+		"If set, the initial sync of the cache is streamed from the API server instead of listed at once. "+
+			"Falls back to the regular list (paginated if served from etcd) if the API server doesn't support it. "+
+			"Enabled by default by client-go too so it's mostly useful to disable the streaming. "+
+			"The KUBE_FEATURE_WatchListClient environment variable takes precedence if set.")
 
 	flag.Parse()
 
@@ -110,14 +267,39 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Enable streaming of the initial sync unless configured explicitly via the environment variable
+	if err := setWatchList(watchList); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to configure watch list: %s\n", err)
+
+		os.Exit(1)
+	}
+
 	// Configure logger
-	opts := zap.Options{
-		Development: true,
-		Level:       zapcore.Level(int(verbosity) * -1), //nolint:gosec
+	if logLevel == "" {
+		logLevel = strconv.FormatUint(uint64(verbosity), 10)
+	}
+
+	opts, err := logger.Options(logFormat, logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logger options: %s\n", err)
+
+		os.Exit(1)
 	}
-	opts.BindFlags(flag.CommandLine)
+
+	// The messages filtered out by the logger levels must not count into the sampling
+	logger.SetSampling(&opts, logSamplingFirst, logSamplingThereafter)
+
+	if err := logger.SetNamedLevels(&opts, logLevels); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logger options: %s\n", err)
+
+		os.Exit(1)
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	setupLog.Info("Streaming of the initial sync",
+		"enabled", clientfeatures.FeatureGates().Enabled(clientfeatures.WatchListClient))
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -158,9 +340,7 @@ func main() {
 		})
 	}
 
-	webhookServer := webhook.NewServer(webhook.Options{
-		TLSOpts: webhookTLSOpts,
-	})
+	webhookServer := newWebhookServer(webhookTLSOpts)
 
 	// Metrics endpoint is enabled in 'config/default/kustomization.yaml'. The Metrics options configure the server.
 	// More info:
@@ -226,6 +406,21 @@ func main() {
 		// if you are doing or is intended to do any operation such as perform cleanups
 		// after the manager stops then its usage might be unsafe.
 		LeaderElectionReleaseOnCancel: true,
+		// Drop the fields the operator never reads to reduce memory usage on big clusters
+		Cache: cache.Options{
+			DefaultTransform: utils.TransformStripManagedFields(),
+			// Objects that are never updated (only patched) by the operator can drop the last-applied annotation too
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Namespace{}:  {Transform: utils.TransformStripMetadata()},
+				&appsv1.Deployment{}: {Transform: utils.TransformStripMetadata()},
+			},
+		},
+		// Read the Secrets directly so they don't have to be listed, watched and kept in memory
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				DisableFor: []client.Object{&corev1.Secret{}},
+			},
+		},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -248,20 +443,201 @@ func main() {
 		setupLog.Error(err, "failed to parse Namespace label selector")
 	}
 
-	if err = (&controller.CustomResourceStateMetricsReconciler{
+	// Parse the default ConfigMap
+	var defaultConfigMapName types.NamespacedName
+
+	if namespace, name, found := strings.Cut(defaultConfigMap, "/"); found {
+		defaultConfigMapName = types.NamespacedName{Name: name, Namespace: namespace}
+	} else {
+		defaultConfigMapName = types.NamespacedName{Name: defaultConfigMap}
+	}
+
+	// Parse the allowed targets
+	targetPolicy, err := controller.NewTargetPolicy(allowedTargets)
+	if err != nil {
+		setupLog.Error(err, "failed to parse the allowed targets")
+		os.Exit(1)
+	}
+
+	// Enable the remote sources only for the allowed locations
+	var fetcher *remote.Fetcher
+	var remotePrefixes []string
+
+	for _, prefix := range strings.Split(allowedRemoteSources, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			remotePrefixes = append(remotePrefixes, prefix)
+		}
+	}
+
+	if len(remotePrefixes) > 0 {
+		fetcher = remote.NewFetcher(remote.DefaultTimeout, remotePrefixes)
+	}
+
+	var ksmServiceAccountName types.NamespacedName
+
+	if ksmServiceAccount != "" {
+		namespace, name, found := strings.Cut(ksmServiceAccount, "/")
+		if !found || namespace == "" || name == "" {
+			setupLog.Error(nil, "invalid kube-state-metrics ServiceAccount, expected namespace/name",
+				"ksm-service-account", ksmServiceAccount)
+			os.Exit(1)
+		}
+
+		ksmServiceAccountName = types.NamespacedName{Name: name, Namespace: namespace}
+	}
+
+	// Parse the API groups allowed for kube-state-metrics
+	ksmGroupPolicy, err := controller.NewKSMGroupPolicy(ksmAllowedGroups)
+	if err != nil {
+		setupLog.Error(err, "failed to parse the API groups allowed for kube-state-metrics")
+		os.Exit(1)
+	}
+
+	// Create the notifier
+	var syncNotifier notifier.Notifier
+
+	if notificationURL != "" {
+		syncNotifier = notifier.NewHTTPNotifier(notificationURL, notificationTimeout)
+	}
+
+	// Create the write buffer
+	var writeBuffer *controller.WriteBuffer
+
+	if writeBufferMaxAge > 0 || writeBatchWindow > 0 {
+		// Flush the batches soon after their window elapses
+		interval := controller.DefaultFlushInterval
+		if writeBatchWindow > 0 {
+			interval = min(interval, writeBatchWindow)
+		}
+
+		writeBuffer = controller.NewWriteBuffer(mgr.GetClient(), writeBufferMaxAge, interval)
+		writeBuffer.BatchWindow = writeBatchWindow
+
+		if err := mgr.Add(writeBuffer); err != nil {
+			setupLog.Error(err, "unable to add write buffer to manager")
+			os.Exit(1)
+		}
+	}
+
+	// Create the janitor
+	if janitorInterval > 0 {
+		janitor := controller.NewJanitor(mgr.GetClient(), mgr.GetEventRecorderFor("crsm-operator"), janitorInterval)
+		janitor.DryRun = dryRun
+
+		if err := mgr.Add(janitor); err != nil {
+			setupLog.Error(err, "unable to add janitor to manager")
+			os.Exit(1)
+		}
+	}
+
+	// Create the restarter
+	restarter := rollout.NewRestarter(mgr.GetClient(), rolloutBatchWindow)
+
+	if err := mgr.Add(restarter); err != nil {
+		setupLog.Error(err, "unable to add restarter to manager")
+		os.Exit(1)
+	}
+
+	// Deduplicate and rate limit the events before they are sampled
+	recorder := events.NewSamplingRecorder(
+		events.NewThrottlingRecorder(mgr.GetEventRecorderFor("crsm-operator"), eventDedupWindow, eventRateLimit),
+		normalEventsSampleRate)
+
+	// Track the recent reconciles for the health probes
+	reconcileHealth := controller.NewReconcileHealth(healthWindow, failureThreshold)
+
+	crsmReconciler := &controller.CustomResourceStateMetricsReconciler{
 		Client:            mgr.GetClient(),
 		Scheme:            mgr.GetScheme(),
-		Recorder:          mgr.GetEventRecorderFor("crsm-operator"),
+		Recorder:          recorder,
 		MetricsRecorder:   metricsRecorder,
 		Selector:          crsmSelector,
 		NamespaceSelector: nsSelector,
-	}).SetupWithManager(mgr); err != nil {
+		DefaultConfigMap:  defaultConfigMapName,
+		Notifier:          syncNotifier,
+		WriteBuffer:       writeBuffer,
+		ResyncPeriod:      resyncPeriod,
+		Restarter:         restarter,
+		RestartMounting:   restartKSM,
+		KSMServiceAccount: ksmServiceAccountName,
+		KSMGroupPolicy:    ksmGroupPolicy,
+		Fetcher:           fetcher,
+		Health:            reconcileHealth,
+		TargetPolicy:      targetPolicy,
+		DryRun:            dryRun,
+
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		DocumentHeader:          documentHeader,
+		MarkerFormat:            markerFormat,
+		DeduplicateResources:    deduplicateResources,
+		TakeOverManualResources: takeOverManualResources,
+		WarnMetricCollisions:    warnMetricCollisions,
+	}
+	if err = crsmReconciler.SetupWithManager(mgr); err != nil {
 
 		setupLog.Error(err, "unable to create controller", "controller", "CustomResourceStateMetrics")
 		os.Exit(1)
 	}
+	if err := mgr.Add(&controller.InstanceCounter{Reconciler: crsmReconciler}); err != nil {
+		setupLog.Error(err, "unable to add instance counter to manager")
+		os.Exit(1)
+	}
+	if rebuildOnStart {
+		if err := mgr.Add(&controller.Rebuilder{Reconciler: crsmReconciler}); err != nil {
+			setupLog.Error(err, "unable to add rebuilder to manager")
+			os.Exit(1)
+		}
+	}
+	if err = (&controller.CRSMReportReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CRSMReport")
+		os.Exit(1)
+	}
+	if err = (&controller.OperatorConfigReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OperatorConfig")
+		os.Exit(1)
+	}
+	if generateNamespace != "" {
+		if err = (&controller.CRDGeneratorReconciler{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			APIReader: mgr.GetAPIReader(),
+			Namespace: generateNamespace,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CRDGenerator")
+			os.Exit(1)
+		}
+	}
+	// The admission webhooks are served only if the certificate is provided
+	if len(webhookCertPath) > 0 {
+		if err = webhookv1.SetupCustomResourceStateMetricsWebhookWithManager(
+			mgr, defaultConfigMapName, controller.DefaultKey, controller.OperatorConfigName,
+			warnMetricCollisions); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "CustomResourceStateMetrics")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if ksmMetricsURL != "" {
+		setupLog.Info("Adding metrics verifier to manager")
+
+		if err := mgr.Add(&verifier.Verifier{
+			Client:          mgr.GetClient(),
+			MetricsRecorder: metricsRecorder,
+			URL:             ksmMetricsURL,
+			Interval:        ksmVerifyInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to add metrics verifier to manager")
+			os.Exit(1)
+		}
+	}
+
 	if metricsCertWatcher != nil {
 		setupLog.Info("Adding metrics certificate watcher to manager")
 
@@ -290,6 +666,28 @@ func main() {
 
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("cache", controller.CacheSyncedChecker(mgr.GetCache())); err != nil {
+		setupLog.Error(err, "unable to set up cache ready check")
+
+		os.Exit(1)
+	}
+
+	// Restart the operator if the reconciles keep failing (not used for the
+	// readiness so the webhook keeps serving while the writes fail)
+	if err := mgr.AddHealthzCheck("reconcile", reconcileHealth.Check); err != nil {
+		setupLog.Error(err, "unable to set up reconcile health check")
+
+		os.Exit(1)
+	}
+
+	// Report the replica as ready only once it can serve admission requests
+	if len(webhookCertPath) > 0 {
+		if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+			setupLog.Error(err, "unable to set up webhook ready check")
+
+			os.Exit(1)
+		}
+	}
 
 	setupLog.Info("Starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
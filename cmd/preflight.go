@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/jtyr/crsm-operator/internal/preflight"
+)
+
+// runPreflight runs the preflight subcommand and returns the exit code.
+func runPreflight(args []string) int {
+	var serviceAccount string
+	var webhookCertPath, webhookCertName, webhookCertKey string
+	var ksmNamespace string
+
+	fs := flag.NewFlagSet("preflight", flag.ExitOnError)
+
+	fs.StringVar(&serviceAccount, "service-account", "",
+		"ServiceAccount (namespace/name) of the operator whose permissions are checked. "+
+			"If not set, the permissions of the caller are checked.")
+	fs.StringVar(&webhookCertPath, "webhook-cert-path", "",
+		"The directory that contains the webhook certificate. The check is skipped if not set.")
+	fs.StringVar(&webhookCertName, "webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
+	fs.StringVar(&webhookCertKey, "webhook-cert-key", "tls.key", "The name of the webhook key file.")
+	fs.StringVar(&ksmNamespace, "ksm-namespace", "",
+		"Namespace where kube-state-metrics is discovered. If not set, all Namespaces are searched.")
+
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(fs)
+
+	// Errors are handled by the ExitOnError flag
+	_ = fs.Parse(args)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	var serviceAccountName types.NamespacedName
+
+	if serviceAccount != "" {
+		namespace, name, found := strings.Cut(serviceAccount, "/")
+		if !found {
+			setupLog.Error(nil, "ServiceAccount must be in the namespace/name format", "serviceAccount", serviceAccount)
+
+			return 1
+		}
+
+		serviceAccountName = types.NamespacedName{Name: name, Namespace: namespace}
+	}
+
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+
+		return 1
+	}
+
+	results := preflight.Run(ctrl.SetupSignalHandler(), c, preflight.Options{
+		ServiceAccount:  serviceAccountName,
+		WebhookCertPath: webhookCertPath,
+		WebhookCertName: webhookCertName,
+		WebhookCertKey:  webhookCertKey,
+		KSMNamespace:    ksmNamespace,
+	})
+
+	preflight.Print(os.Stdout, results)
+
+	if preflight.Failed(results) {
+		return 1
+	}
+
+	return 0
+}
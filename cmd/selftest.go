@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/selftest"
+)
+
+// runSelfTest runs the selftest subcommand and returns the exit code.
+func runSelfTest(args []string) int {
+	var namespace string
+	var configMapName string
+	var configMapNamespace string
+	var configMapKey string
+	var ksmMetricsURL string
+	var timeout time.Duration
+
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+
+	fs.StringVar(&namespace, "namespace", "default",
+		"Namespace where the synthetic resources are created.")
+	fs.StringVar(&configMapName, "configmap-name", "",
+		"Name of the ConfigMap the synthetic CRSM writes into. If not set, the operator resolves it.")
+	fs.StringVar(&configMapNamespace, "configmap-namespace", "",
+		"Namespace of the ConfigMap the synthetic CRSM writes into.")
+	fs.StringVar(&configMapKey, "configmap-key", "", "Key of the ConfigMap the synthetic CRSM writes into.")
+	fs.StringVar(&ksmMetricsURL, "ksm-metrics-url", "http://localhost:8080/metrics",
+		"URL of the kube-state-metrics metrics endpoint.")
+	fs.DurationVar(&timeout, "timeout", 5*time.Minute, //nolint:mnd
+		"How long to wait for each step of the self-test.")
+
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(fs)
+
+	// Errors are handled by the ExitOnError flag
+	_ = fs.Parse(args)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+
+		return 1
+	}
+
+	if err := selftest.Run(ctrl.SetupSignalHandler(), c, selftest.Options{
+		Namespace: namespace,
+		ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{
+			Name:      configMapName,
+			Namespace: configMapNamespace,
+			Key:       configMapKey,
+		},
+		URL:     ksmMetricsURL,
+		Timeout: timeout,
+	}); err != nil {
+		setupLog.Error(err, "Self-test failed")
+
+		return 1
+	}
+
+	return 0
+}
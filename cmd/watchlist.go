@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	clientfeatures "k8s.io/client-go/features"
+)
+
+// Environment variable enabling the client-go WatchListClient feature.
+const watchListEnv = "KUBE_FEATURE_WatchListClient"
+
+// watchListGates overrides the WatchListClient feature of the client-go
+// feature gates.
+type watchListGates struct {
+	clientfeatures.Gates
+
+	enabled bool
+}
+
+// Enabled returns whether the feature is enabled.
+func (g *watchListGates) Enabled(feature clientfeatures.Feature) bool {
+	if feature == clientfeatures.WatchListClient {
+		return g.enabled
+	}
+
+	return g.Gates.Enabled(feature)
+}
+
+// setWatchList enables (or disables) streaming of the initial sync of the
+// informers via the client-go feature gates unless it's configured explicitly
+// via the environment variable. The gates are replaced before any client is
+// created and checked afterwards as client-go ignores the environment
+// variable once the gates were read.
+//
+// The streaming is enabled by default since client-go v0.35 so the flag is
+// mostly useful to disable it. The paginated list isn't configured separately
+// as the informers already list in pages of 500 objects if the list is served
+// from etcd, while the list served from the watch cache of the API server
+// ignores the page size anyway and only the streaming keeps it bounded there.
+func setWatchList(enabled bool) error {
+	if _, ok := os.LookupEnv(watchListEnv); ok {
+		return nil
+	}
+
+	clientfeatures.ReplaceFeatureGates(&watchListGates{Gates: clientfeatures.FeatureGates(), enabled: enabled})
+
+	if clientfeatures.FeatureGates().Enabled(clientfeatures.WatchListClient) != enabled {
+		return fmt.Errorf("failed to set the %s feature gate to %t", clientfeatures.WatchListClient, enabled)
+	}
+
+	return nil
+}
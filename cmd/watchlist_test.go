@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clientfeatures "k8s.io/client-go/features"
+)
+
+func TestSetWatchList(t *testing.T) {
+	g := NewWithT(t)
+
+	gates := clientfeatures.FeatureGates()
+	t.Cleanup(func() { clientfeatures.ReplaceFeatureGates(gates) })
+
+	// The gates are overridden if the environment variable isn't set
+	t.Setenv(watchListEnv, "")
+	g.Expect(os.Unsetenv(watchListEnv)).To(Succeed())
+
+	for _, enabled := range []bool{false, true} {
+		g.Expect(setWatchList(enabled)).To(Succeed(), "Test [override_%t]:", enabled)
+		g.Expect(clientfeatures.FeatureGates().Enabled(clientfeatures.WatchListClient)).To(Equal(enabled),
+			"Test [override_%t]:", enabled)
+		g.Expect(clientfeatures.FeatureGates().Enabled(clientfeatures.InformerResourceVersion)).To(
+			Equal(gates.Enabled(clientfeatures.InformerResourceVersion)), "Test [override_%t]:", enabled)
+	}
+
+	// The environment variable takes precedence over the flag
+	clientfeatures.ReplaceFeatureGates(gates)
+	t.Setenv(watchListEnv, "false")
+
+	g.Expect(setWatchList(true)).To(Succeed(), "Test [env]:")
+	g.Expect(clientfeatures.FeatureGates()).To(BeIdenticalTo(gates), "Test [env]:")
+}
@@ -0,0 +1,32 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/tls"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// newWebhookServer creates the webhook server. It doesn't need leader
+// election so it's served by every replica while the reconcilers run only on
+// the leader.
+func newWebhookServer(tlsOpts []func(*tls.Config)) webhook.Server {
+	return webhook.NewServer(webhook.Options{
+		TLSOpts: tlsOpts,
+	})
+}
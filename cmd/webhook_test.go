@@ -0,0 +1,20 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewWebhookServer(t *testing.T) {
+	g := NewWithT(t)
+
+	server := newWebhookServer(nil)
+
+	// Served by every replica
+	g.Expect(server.NeedLeaderElection()).To(BeFalse())
+
+	// Not ready before it can serve the admission requests
+	g.Expect(server.StartedChecker()(&http.Request{})).To(HaveOccurred())
+}
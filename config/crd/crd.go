@@ -0,0 +1,10 @@
+// Package crd embeds the generated CustomResourceDefinitions so the tools can
+// validate the manifests without a cluster.
+package crd
+
+import "embed"
+
+// Bases holds the generated CustomResourceDefinitions.
+//
+//go:embed bases/*.yaml
+var Bases embed.FS
@@ -0,0 +1,90 @@
+package crd
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+func TestResourcesSchema(t *testing.T) {
+	g := NewWithT(t)
+
+	content, err := Bases.ReadFile("bases/ksm.jtyr.io_customresourcestatemetrics.yaml")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	crd := map[string]any{}
+	g.Expect(yaml.Unmarshal(content, &crd)).To(Succeed())
+
+	versions := nested(crd, "spec", "versions").([]any)
+	g.Expect(versions).NotTo(BeEmpty())
+
+	for _, version := range versions {
+		schema := nested(version, "schema", "openAPIV3Schema", "properties", "spec", "properties", "resources", "items")
+
+		// The schema must describe all the fields so kubectl explain and the
+		// validation see the same structure as the operator
+		expectSchema(g, schema, reflect.TypeFor[ksm.Resource](), "spec.resources")
+	}
+}
+
+// expectSchema expects the properties of the schema to match the JSON fields
+// of the struct type, recursively.
+func expectSchema(g *WithT, schema any, typ reflect.Type, path string) {
+	properties, ok := nested(schema, "properties").(map[string]any)
+	g.Expect(ok).To(BeTrue(), "Test [%s]: no properties", path)
+
+	fields := []string{}
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		fields = append(fields, name)
+
+		fieldSchema := properties[name]
+		fieldType := field.Type
+
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Slice {
+			fieldSchema = nested(fieldSchema, "items")
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct {
+			expectSchema(g, fieldSchema, fieldType, path+"."+name)
+		}
+	}
+
+	names := slices.Collect(func(yield func(string) bool) {
+		for name := range properties {
+			if !yield(name) {
+				return
+			}
+		}
+	})
+
+	g.Expect(names).To(ConsistOf(fields), "Test [%s]:", path)
+}
+
+// nested returns the value of the nested field of the map or nil if it
+// doesn't exist.
+func nested(obj any, fields ...string) any {
+	for _, field := range fields {
+		m, ok := obj.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		obj = m[field]
+	}
+
+	return obj
+}
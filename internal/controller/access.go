@@ -0,0 +1,160 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
+
+// Time the result of the access review is reused for the writes of the same
+// target.
+const accessReviewTTL = time.Minute
+
+// Caches the results of the access reviews per target and verb.
+var accessReviews = newAccessCache()
+
+// accessCache caches the results of the access reviews so they are not sent
+// on every write.
+type accessCache struct {
+	mu      sync.Mutex
+	entries map[accessKey]accessEntry
+}
+
+// accessKey identifies the reviewed access.
+type accessKey struct {
+	resource  string
+	namespace string
+	name      string
+	verb      string
+}
+
+// accessEntry holds the result of the access review.
+type accessEntry struct {
+	allowed bool
+	time    time.Time
+}
+
+// newAccessCache creates a new empty accessCache.
+func newAccessCache() *accessCache {
+	return &accessCache{
+		entries: make(map[accessKey]accessEntry),
+	}
+}
+
+// get returns the result of the access review unless it's older than the TTL.
+func (c *accessCache) get(key accessKey) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+
+	if time.Since(entry.time) > accessReviewTTL {
+		delete(c.entries, key)
+
+		return false, false
+	}
+
+	return entry.allowed, true
+}
+
+// set caches the result of the access review.
+func (c *accessCache) set(key accessKey, allowed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = accessEntry{
+		allowed: allowed,
+		time:    time.Now(),
+	}
+}
+
+// checkAccess reviews whether the operator is allowed to write the target
+// object so the missing permissions (e.g. in the Namespace of a cross-namespace
+// target) are reported clearly instead of as a generic Forbidden error. The
+// results are cached for a short time. The write is attempted anyway if the
+// access can't be reviewed.
+func (r *CustomResourceStateMetricsReconciler) checkAccess(
+	ctx context.Context, kind ksmv1.TargetKind, cm *corev1.ConfigMap) error {
+	resource := "configmaps"
+	if kind == ksmv1.TargetKindSecret {
+		resource = "secrets"
+	}
+
+	// The target is written by the server-side apply which creates it if missing
+	verbs := []string{"patch"}
+	if cm.ResourceVersion == "" {
+		verbs = append(verbs, "create")
+	}
+
+	denied := []string{}
+
+	for _, verb := range verbs {
+		key := accessKey{resource: resource, namespace: cm.Namespace, name: cm.Name, verb: verb}
+
+		allowed, ok := accessReviews.get(key)
+		if !ok {
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: cm.Namespace,
+						Resource:  resource,
+						Name:      cm.Name,
+						Verb:      verb,
+					},
+				},
+			}
+
+			if err := r.Create(ctx, review); err != nil {
+				log.Error(
+					err,
+					"Unable to review the access, the write is attempted anyway",
+					"target", utils.NamespacedName(cm.Name, cm.Namespace),
+					"verb", verb)
+
+				return nil
+			}
+
+			allowed = review.Status.Allowed
+			accessReviews.set(key, allowed)
+		}
+
+		if !allowed {
+			denied = append(denied, verb)
+		}
+	}
+
+	if len(denied) > 0 {
+		return withReason(resultInsufficientPermissions, fmt.Errorf(
+			"the operator is not allowed to %v the %s in the Namespace %s", denied, resource, cm.Namespace))
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestCheckAccess(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(authorizationv1.AddToScheme(scheme)).To(Succeed())
+
+	reviews := 0
+	denied := map[string]bool{"create": true}
+	var reviewErr error
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+			review := obj.(*authorizationv1.SelfSubjectAccessReview)
+			reviews++
+
+			if reviewErr != nil {
+				return reviewErr
+			}
+
+			review.Status.Allowed = !denied[review.Spec.ResourceAttributes.Verb]
+
+			return nil
+		},
+	}).Build()
+	r := &CustomResourceStateMetricsReconciler{Client: c}
+	ctx := context.Background()
+
+	newTarget := func(name, resourceVersion string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "access", ResourceVersion: resourceVersion},
+		}
+	}
+
+	// The create is reviewed only if the target doesn't exist yet
+	err := r.checkAccess(ctx, ksmv1.TargetKindConfigMap, newTarget("foo", ""))
+	g.Expect(resultReason(err)).To(Equal(resultInsufficientPermissions), "Test [denied]:")
+	g.Expect(reviews).To(Equal(2), "Test [denied]:")
+
+	// The results are reused for the same target
+	err = r.checkAccess(ctx, ksmv1.TargetKindConfigMap, newTarget("foo", ""))
+	g.Expect(resultReason(err)).To(Equal(resultInsufficientPermissions), "Test [cached]:")
+	g.Expect(reviews).To(Equal(2), "Test [cached]:")
+
+	g.Expect(r.checkAccess(ctx, ksmv1.TargetKindConfigMap, newTarget("foo", "1"))).To(Succeed(), "Test [patch]:")
+	g.Expect(reviews).To(Equal(2), "Test [patch]:")
+
+	// The other targets and kinds are reviewed separately
+	g.Expect(r.checkAccess(ctx, ksmv1.TargetKindSecret, newTarget("foo", "1"))).To(Succeed(), "Test [secret]:")
+	g.Expect(reviews).To(Equal(3), "Test [secret]:")
+
+	// The write is attempted if the access can't be reviewed and the failure isn't cached
+	reviewErr = errors.New("failed")
+
+	for range 2 {
+		g.Expect(r.checkAccess(ctx, ksmv1.TargetKindConfigMap, newTarget("bar", "1"))).To(Succeed(), "Test [failed]:")
+	}
+
+	g.Expect(reviews).To(Equal(5), "Test [failed]:")
+}
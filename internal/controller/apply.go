@@ -0,0 +1,177 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Name of the field manager owning the managed fields of the ConfigMaps.
+const FieldManager = "crsm-operator"
+
+// managedKeys returns the sorted ConfigMap keys written by the operator which
+// are the keys with the recorded content hash and their staging keys.
+func managedKeys(cm *corev1.ConfigMap) []string {
+	keys := []string{}
+
+	for key := range getBlockHashes(cm) {
+		// Skip the hashes of the instance blocks
+		if strings.Contains(key, "/") {
+			continue
+		}
+
+		if _, ok := cm.Data[key]; ok {
+			keys = append(keys, key)
+		}
+
+		if _, ok := cm.Data[key+nextKeySuffix]; ok {
+			keys = append(keys, key+nextKeySuffix)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// configMapApplyConfiguration returns the apply configuration holding only the
+// fields of the ConfigMap managed by the operator. All managed keys must be
+// always applied together as the keys omitted from the apply get removed.
+func configMapApplyConfiguration(cm *corev1.ConfigMap) *corev1ac.ConfigMapApplyConfiguration {
+	content := make(map[string]string)
+
+	for _, key := range managedKeys(cm) {
+		content[key] = cm.Data[key]
+	}
+
+	data, binaryData := splitData(cm, content)
+	labels, annotations := appliedMetadata(cm)
+
+	ac := corev1ac.ConfigMap(cm.Name, cm.Namespace).
+		WithLabels(labels).
+		WithAnnotations(annotations).
+		WithData(data).
+		WithBinaryData(binaryData)
+
+	// The owner references omitted from the apply get removed
+	for _, ref := range instanceReferences(cm) {
+		ac.WithOwnerReferences(metav1ac.OwnerReference().
+			WithAPIVersion(ref.APIVersion).
+			WithKind(ref.Kind).
+			WithName(ref.Name).
+			WithUID(ref.UID))
+	}
+
+	// Fail if the ConfigMap was modified since it was read
+	if cm.ResourceVersion != "" {
+		ac.WithResourceVersion(cm.ResourceVersion)
+	}
+
+	return ac
+}
+
+// appliedMetadata returns the labels and annotations of the ConfigMap managed
+// by the operator. The labels and annotations omitted from the apply get
+// removed.
+func appliedMetadata(cm *corev1.ConfigMap) (map[string]string, map[string]string) {
+	labels, annotations := managedMetadata(cm)
+
+	annotations[BlockHashesAnnotation] = cm.Annotations[BlockHashesAnnotation]
+
+	for _, name := range []string{
+		MetadataAnnotation, ImmutableAnnotation, CompressedKeysAnnotation, JSONKeysAnnotation,
+		MarkerFormatsAnnotation,
+	} {
+		if value, ok := cm.Annotations[name]; ok {
+			annotations[name] = value
+		}
+	}
+
+	return labels, annotations
+}
+
+// applyConfigMap applies the managed fields of the ConfigMap with Server-Side
+// Apply. Fields owned by other managers are not overwritten (unless the
+// ownership is forced by the options) and the conflict is returned instead.
+// The same applies if the ConfigMap was modified since it was read.
+func applyConfigMap(ctx context.Context, c client.Client, cm *corev1.ConfigMap, opts ...client.ApplyOption) error {
+	opts = append([]client.ApplyOption{client.FieldOwner(FieldManager)}, opts...)
+
+	return c.Apply(ctx, configMapApplyConfiguration(cm), opts...)
+}
+
+// isFieldConflict returns true if the apply failed because the fields are
+// owned by other managers.
+func isFieldConflict(err error) bool {
+	var statusErr apierrors.APIStatus
+
+	if !apierrors.IsConflict(err) || !errors.As(err, &statusErr) {
+		return false
+	}
+
+	if details := statusErr.Status().Details; details != nil {
+		for _, cause := range details.Causes {
+			if cause.Type == metav1.CauseTypeFieldManagerConflict {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isConfigMapConflict returns true if the write failed because the ConfigMap
+// (or the Secret) was modified since it was read.
+func isConfigMapConflict(err error) bool {
+	var statusErr apierrors.APIStatus
+
+	if !apierrors.IsConflict(err) || isFieldConflict(err) || !errors.As(err, &statusErr) {
+		return false
+	}
+
+	details := statusErr.Status().Details
+
+	return details != nil && (details.Kind == "configmaps" || details.Kind == "secrets")
+}
+
+// retryOnConflict runs the read-modify-write of the ConfigMap again if the
+// ConfigMap was modified concurrently. The backoff gives the cache time to
+// observe the concurrent write before the ConfigMap is read again.
+func retryOnConflict(write func() (bool, error)) (bool, error) {
+	var changed bool
+
+	err := retry.OnError(retry.DefaultBackoff, isConfigMapConflict, func() error {
+		var err error
+
+		changed, err = write()
+
+		return err
+	})
+
+	return changed, err
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestConfigMapApplyConfiguration(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "bar",
+			Annotations: map[string]string{
+				"helm.sh/resource-policy": "keep",
+			},
+		},
+		Data: map[string]string{
+			"config.yaml":                 "block\n",
+			"config.yaml" + nextKeySuffix: "staged\n",
+			"other.yaml":                  "other\n",
+		},
+	}
+
+	g.Expect(managedKeys(cm)).To(BeEmpty(), "Test [no-hashes]:")
+
+	setBlockHashes(cm, "config.yaml", "foo@bar", "block\n")
+
+	g.Expect(managedKeys(cm)).To(Equal([]string{"config.yaml", "config.yaml" + nextKeySuffix}), "Test [keys]:")
+
+	ac := configMapApplyConfiguration(cm)
+
+	g.Expect(*ac.Name).To(Equal("foo"), "Test [name]:")
+	g.Expect(*ac.Namespace).To(Equal("bar"), "Test [namespace]:")
+	g.Expect(ac.Data).To(Equal(map[string]string{
+		"config.yaml":                 "block\n",
+		"config.yaml" + nextKeySuffix: "staged\n",
+	}), "Test [data]:")
+	g.Expect(ac.Annotations).To(HaveKey(BlockHashesAnnotation), "Test [annotations]:")
+	g.Expect(ac.Annotations).NotTo(HaveKey("helm.sh/resource-policy"), "Test [annotations]:")
+	g.Expect(ac.ResourceVersion).To(BeNil(), "Test [new]:")
+
+	cm.ResourceVersion = "1"
+
+	g.Expect(*configMapApplyConfiguration(cm).ResourceVersion).To(Equal("1"), "Test [existing]:")
+}
+
+func TestConflicts(t *testing.T) {
+	g := NewWithT(t)
+
+	fieldConflict := apierrors.NewApplyConflict([]metav1.StatusCause{{
+		Type:    metav1.CauseTypeFieldManagerConflict,
+		Message: `conflict with "helm"`,
+		Field:   ".data.config.yaml",
+	}}, `Apply failed with 1 conflict: conflict with "helm": .data.config.yaml`)
+
+	tests := []struct {
+		name          string
+		err           error
+		fieldConflict bool
+		retried       bool
+	}{
+		{
+			name: "nil",
+			err:  nil,
+		},
+		{
+			name:    "configmap",
+			err:     apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "foo", errors.New("foo")),
+			retried: true,
+		},
+		{
+			name:    "secret",
+			err:     apierrors.NewConflict(schema.GroupResource{Resource: "secrets"}, "foo", errors.New("foo")),
+			retried: true,
+		},
+		{
+			name: "wrapped",
+			err: fmt.Errorf("failed to update ConfigMap: %w", withReason(resultWriteError,
+				apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "foo", errors.New("foo")))),
+			retried: true,
+		},
+		{
+			name: "instance",
+			err: apierrors.NewConflict(
+				schema.GroupResource{Group: "ksm.jtyr.io", Resource: "customresourcestatemetrics"},
+				"foo", errors.New("foo")),
+		},
+		{
+			name:          "field-manager",
+			err:           fieldConflict,
+			fieldConflict: true,
+		},
+	}
+
+	for _, test := range tests {
+		g.Expect(isFieldConflict(test.err)).To(Equal(test.fieldConflict), "Test [%s]:", test.name)
+		g.Expect(isConfigMapConflict(test.err)).To(Equal(test.retried), "Test [%s]:", test.name)
+	}
+
+	attempts := 0
+	changed, err := retryOnConflict(func() (bool, error) {
+		attempts++
+
+		if attempts < 3 {
+			return false, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "foo", errors.New("foo"))
+		}
+
+		return true, nil
+	})
+
+	g.Expect(err).NotTo(HaveOccurred(), "Test [retry]:")
+	g.Expect(changed).To(BeTrue(), "Test [retry]:")
+	g.Expect(attempts).To(Equal(3), "Test [retry]:")
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Suffix of the name of the ConfigMap holding the previous content of the
+// keys of the ConfigMap.
+const backupSuffix = "-backup"
+
+// Name of the label identifying the ConfigMap the backup belongs to.
+const BackupOfLabel = "ksm.jtyr.io/backup-of"
+
+// backupConfigMap copies the previous content of the key into the backup
+// ConfigMap before the changed content gets written. The content is kept
+// uncompressed and in YAML so it can be restored by hand.
+func (r *CustomResourceStateMetricsReconciler) backupConfigMap(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap, cmKey,
+	previous string) error {
+	if !instance.Spec.ConfigMap.Backup || targetKind(instance) == ksmv1.TargetKindSecret || r.DryRun ||
+		previous == "" || previous == cm.Data[cmKey] {
+		return nil
+	}
+
+	backup := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cm.Name + backupSuffix,
+			Namespace: cm.Namespace,
+		},
+	}
+
+	log.V(1).Info(
+		"Backing up the previous content of the ConfigMap",
+		"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+		"configMap", utils.NamespacedName(cm.Name, cm.Namespace),
+		"key", cmKey,
+		"backup", backup.Name)
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, backup, func() error {
+		if backup.Labels == nil {
+			backup.Labels = make(map[string]string)
+		}
+
+		backup.Labels[BackupOfLabel] = cm.Name
+
+		if backup.Data == nil {
+			backup.Data = make(map[string]string)
+		}
+
+		backup.Data[cmKey] = previous
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to back up the previous content of the ConfigMap: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Name of the ConfigMap annotation holding the hashes of the managed blocks.
+const BlockHashesAnnotation = "ksm.jtyr.io/block-hashes"
+
+// getBlockHashes returns the block hashes recorded on the ConfigMap. The map
+// is keyed by the ConfigMap key (hash of the whole content) and by the
+// ConfigMap key and the instance (hash of the instance block).
+func getBlockHashes(cm *corev1.ConfigMap) map[string]string {
+	hashes := make(map[string]string)
+
+	if value, ok := cm.Annotations[BlockHashesAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &hashes); err != nil {
+			// Ignore corrupted annotation, it gets rewritten on the next update
+			return make(map[string]string)
+		}
+	}
+
+	return hashes
+}
+
+// blockHashKey returns the key under which the hash of the instance block is
+// recorded.
+func blockHashKey(cmKey, instanceNamespacedName string) string {
+	return cmKey + "/" + instanceNamespacedName
+}
+
+// blockUnchanged returns true if the recorded hashes confirm that the ConfigMap
+// key already contains the block and that the content wasn't modified since.
+func blockUnchanged(cm *corev1.ConfigMap, cmKey, instanceNamespacedName, block string) bool {
+	hashes := getBlockHashes(cm)
+
+	blockHash, ok := hashes[blockHashKey(cmKey, instanceNamespacedName)]
+	if !ok || blockHash != utils.Hash(block) {
+		return false
+	}
+
+	contentHash, ok := hashes[cmKey]
+
+	return ok && contentHash == utils.Hash(cm.Data[cmKey])
+}
+
+// setBlockHashes records the hash of the instance block (removes it if the
+// block is empty) and the hash of the current content of the ConfigMap key.
+func setBlockHashes(cm *corev1.ConfigMap, cmKey, instanceNamespacedName, block string) {
+	hashes := getBlockHashes(cm)
+
+	if block == "" {
+		delete(hashes, blockHashKey(cmKey, instanceNamespacedName))
+	} else {
+		hashes[blockHashKey(cmKey, instanceNamespacedName)] = utils.Hash(block)
+	}
+
+	hashes[cmKey] = utils.Hash(cm.Data[cmKey])
+
+	// The markers of the whole content are written in the current format
+	setMarkerFormat(cm, cmKey)
+
+	// Marshaling of a map of strings can't fail
+	value, _ := json.Marshal(hashes)
+
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+
+	cm.Annotations[BlockHashesAnnotation] = string(value)
+}
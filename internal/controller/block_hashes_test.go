@@ -0,0 +1,54 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestBlockHashes(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{
+		Data: map[string]string{
+			"config.yaml": "header\nblock\n",
+		},
+	}
+
+	g.Expect(blockUnchanged(cm, "config.yaml", "foo@bar", "block\n")).To(BeFalse(), "Test [no-annotation]:")
+
+	setBlockHashes(cm, "config.yaml", "foo@bar", "block\n")
+
+	g.Expect(blockUnchanged(cm, "config.yaml", "foo@bar", "block\n")).To(BeTrue(), "Test [same-block]:")
+	g.Expect(blockUnchanged(cm, "config.yaml", "foo@bar", "other\n")).To(BeFalse(), "Test [other-block]:")
+	g.Expect(blockUnchanged(cm, "config.yaml", "baz@bar", "block\n")).To(BeFalse(), "Test [other-instance]:")
+
+	cm.Data["config.yaml"] += "manual\n"
+
+	g.Expect(blockUnchanged(cm, "config.yaml", "foo@bar", "block\n")).To(BeFalse(), "Test [modified-content]:")
+
+	setBlockHashes(cm, "config.yaml", "foo@bar", "")
+
+	g.Expect(getBlockHashes(cm)).To(HaveLen(1), "Test [removed-block]:")
+
+	cm.Annotations[BlockHashesAnnotation] = "{"
+
+	g.Expect(getBlockHashes(cm)).To(BeEmpty(), "Test [corrupted-annotation]:")
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+// Name of the ConfigMap annotation holding the suffixes of the binary data
+// keys with the compressed content of the keys.
+const CompressedKeysAnnotation = "ksm.jtyr.io/compressed-keys"
+
+// Suffix of the binary data key holding the compressed content used if no
+// suffix was specified.
+const DefaultCompressedKeySuffix = ".gz"
+
+// compressedKeySuffix returns the suffix of the binary data key the content
+// of the instance key should be compressed into or an empty string if the
+// content should not be compressed.
+func compressedKeySuffix(instance *ksmv1.CustomResourceStateMetrics) string {
+	if !instance.Spec.ConfigMap.Compress || specTargetKind(instance) == ksmv1.TargetKindSecret {
+		return ""
+	}
+
+	if instance.Spec.ConfigMap.CompressedKeySuffix == "" {
+		return DefaultCompressedKeySuffix
+	}
+
+	return instance.Spec.ConfigMap.CompressedKeySuffix
+}
+
+// getCompressedKeys returns the suffixes of the binary data keys recorded on
+// the ConfigMap keyed by the compressed key.
+func getCompressedKeys(cm *corev1.ConfigMap) map[string]string {
+	keys := make(map[string]string)
+
+	if value, ok := cm.Annotations[CompressedKeysAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &keys); err != nil {
+			// Ignore corrupted annotation, it gets rewritten on the next update
+			return make(map[string]string)
+		}
+	}
+
+	return keys
+}
+
+// setCompressedKey records the suffix of the binary data key the content of
+// the key is compressed into (removes it if the suffix is empty). It returns
+// whether the record changed.
+func setCompressedKey(cm *corev1.ConfigMap, cmKey, suffix string) bool {
+	keys := getCompressedKeys(cm)
+
+	if keys[cmKey] == suffix {
+		return false
+	}
+
+	if suffix == "" {
+		delete(keys, cmKey)
+	} else {
+		keys[cmKey] = suffix
+	}
+
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+
+	if len(keys) == 0 {
+		delete(cm.Annotations, CompressedKeysAnnotation)
+
+		return true
+	}
+
+	// Marshaling of a map of strings can't fail
+	value, _ := json.Marshal(keys)
+
+	cm.Annotations[CompressedKeysAnnotation] = string(value)
+
+	return true
+}
+
+// binaryDataKey returns the binary data key holding the compressed content of
+// the key (or of its staging key) if the key is compressed.
+func binaryDataKey(compressedKeys map[string]string, key string) (string, bool) {
+	suffix, ok := compressedKeys[key]
+	if !ok {
+		suffix, ok = compressedKeys[strings.TrimSuffix(key, nextKeySuffix)]
+	}
+
+	return key + suffix, ok
+}
+
+// splitData splits the content of the ConfigMap keys into the data and the
+// compressed binary data. The content of the JSON keys is converted into JSON
+// first.
+func splitData(cm *corev1.ConfigMap, content map[string]string) (map[string]string, map[string][]byte) {
+	compressedKeys := getCompressedKeys(cm)
+	data := make(map[string]string)
+	binaryData := make(map[string][]byte)
+
+	for key, value := range encodeJSONData(cm, content) {
+		if binaryKey, ok := binaryDataKey(compressedKeys, key); ok {
+			binaryData[binaryKey] = compress(value)
+		} else {
+			data[key] = value
+		}
+	}
+
+	return data, binaryData
+}
+
+// loadData converts the stored content of the ConfigMap keys (compressed or
+// stored as JSON) into the YAML data. The formats of the markers recorded on
+// the ConfigMap are recognized from now on.
+func loadData(cm *corev1.ConfigMap) error {
+	recognizeMarkerFormats(cm)
+
+	if err := decompressData(cm); err != nil {
+		return err
+	}
+
+	return decodeJSONData(cm)
+}
+
+// decompressData decompresses the compressed binary data of the ConfigMap
+// into its data so the content can be processed the same way as the content
+// which is not compressed.
+func decompressData(cm *corev1.ConfigMap) error {
+	for key, suffix := range getCompressedKeys(cm) {
+		for _, k := range []string{key, key + nextKeySuffix} {
+			value, ok := cm.BinaryData[k+suffix]
+			if !ok {
+				continue
+			}
+
+			content, err := decompress(value)
+			if err != nil {
+				return fmt.Errorf("failed to decompress the binary data key %s: %w", k+suffix, err)
+			}
+
+			if cm.Data == nil {
+				cm.Data = make(map[string]string)
+			}
+
+			cm.Data[k] = content
+		}
+	}
+
+	return nil
+}
+
+// compress returns the gzip-compressed content. The output is deterministic
+// so the unchanged content doesn't change the ConfigMap.
+func compress(content string) []byte {
+	var buf bytes.Buffer
+
+	// Writing into the buffer can't fail
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write([]byte(content))
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+// decompress returns the content of the gzip-compressed data.
+func decompress(data []byte) (string, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
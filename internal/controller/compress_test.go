@@ -0,0 +1,67 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompression(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Data: map[string]string{
+			"config.yaml":                 "block\n",
+			"config.yaml" + nextKeySuffix: "staged\n",
+		},
+	}
+
+	setBlockHashes(cm, "config.yaml", "foo@bar", "block\n")
+
+	g.Expect(setCompressedKey(cm, "config.yaml", "")).To(BeFalse(), "Test [not-compressed]:")
+	g.Expect(setCompressedKey(cm, "config.yaml", ".gz")).To(BeTrue(), "Test [compressed]:")
+	g.Expect(setCompressedKey(cm, "config.yaml", ".gz")).To(BeFalse(), "Test [unchanged]:")
+
+	ac := configMapApplyConfiguration(cm)
+
+	g.Expect(ac.Data).To(BeEmpty(), "Test [data]:")
+	g.Expect(ac.BinaryData).To(HaveLen(2), "Test [binary-data]:")
+	g.Expect(ac.BinaryData["config.yaml.gz"]).To(Equal(compress("block\n")), "Test [deterministic]:")
+	g.Expect(ac.Annotations).To(HaveKey(CompressedKeysAnnotation), "Test [annotations]:")
+
+	// The content read from the API server gets decompressed
+	read := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Annotations: ac.Annotations},
+		BinaryData: ac.BinaryData,
+	}
+
+	g.Expect(decompressData(read)).To(Succeed(), "Test [decompress]:")
+	g.Expect(read.Data).To(Equal(cm.Data), "Test [decompress]:")
+
+	read.BinaryData["config.yaml.gz"] = []byte("corrupted")
+
+	g.Expect(decompressData(read)).NotTo(Succeed(), "Test [corrupted]:")
+
+	g.Expect(setCompressedKey(cm, "config.yaml", "")).To(BeTrue(), "Test [decompressed]:")
+	g.Expect(cm.Annotations).NotTo(HaveKey(CompressedKeysAnnotation), "Test [decompressed]:")
+	g.Expect(configMapApplyConfiguration(cm).BinaryData).To(BeEmpty(), "Test [decompressed]:")
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Number of consecutive failed writes after which the instance is degraded.
+const writeFailureThreshold = 3
+
+// Reasons of the results which can't be fixed without a change of the spec.
+var stalledResults = []string{
+	resultInvalidResources, resultInvalidSchedule, resultTooLarge, resultTargetNotAllowed, resultMetricNameNotAllowed,
+}
+
+// Counts the consecutive failed writes per instance.
+var writeFailures = newFailureCounter()
+
+// failureCounter counts the consecutive failures of the instances.
+type failureCounter struct {
+	mu     sync.Mutex
+	counts map[types.UID]int
+}
+
+// newFailureCounter creates a new empty failureCounter.
+func newFailureCounter() *failureCounter {
+	return &failureCounter{
+		counts: make(map[types.UID]int),
+	}
+}
+
+// inc increments the number of the consecutive failures and returns it.
+func (c *failureCounter) inc(uid types.UID) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[uid]++
+
+	return c.counts[uid]
+}
+
+// reset forgets the failures of the instance.
+func (c *failureCounter) reset(uid types.UID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.counts, uid)
+}
+
+// setSynced records that the resources in the ConfigMap match the spec
+// (persisted with the next status update). The failed writes are forgotten.
+func setSynced(instance *ksmv1.CustomResourceStateMetrics, message string) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeSynced,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonSynced,
+		Message:            message,
+	})
+
+	writeFailures.reset(instance.UID)
+
+	// Clear the condition set by the failed write
+	if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeStalled) {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeStalled,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonSynced,
+			Message:            "The resources were written successfully.",
+		})
+	}
+
+	// Clear the condition set by the denied write
+	if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeInsufficientPermissions) {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeInsufficientPermissions,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonAccessGranted,
+			Message:            "The operator is allowed to write the target.",
+		})
+	}
+
+	// Clear the condition set by the failed writes
+	if condition := meta.FindStatusCondition(instance.Status.Conditions, conditionTypeDegraded); condition != nil &&
+		condition.Status == metav1.ConditionTrue && condition.Reason == reasonWriteFailing {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeDegraded,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonWriteRecovered,
+			Message:            "The resources were written successfully.",
+		})
+	}
+}
+
+// setNotSynced records that the resources in the ConfigMap don't match the
+// spec yet (persisted with the next status update).
+func setNotSynced(instance *ksmv1.CustomResourceStateMetrics, reason, message string) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeSynced,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setWriteFailed records the failed write of the resources (persisted with
+// the next status update). The instance becomes degraded once the writes
+// keep failing.
+func setWriteFailed(instance *ksmv1.CustomResourceStateMetrics, err error) {
+	setNotSynced(instance, reasonSyncFailed, fmt.Sprintf("Failed to write the resources: %v", err))
+
+	// The retries don't help until the spec is changed
+	if reason := resultReason(err); slices.Contains(stalledResults, reason) {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeStalled,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: instance.Generation,
+			Reason:             reason,
+			Message:            fmt.Sprintf("Failed to write the resources: %v", err),
+		})
+	}
+
+	// The permissions must be granted to the operator
+	if resultReason(err) == resultInsufficientPermissions {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeInsufficientPermissions,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonAccessDenied,
+			Message:            fmt.Sprintf("Failed to write the resources: %v", err),
+		})
+	}
+
+	failures := writeFailures.inc(instance.UID)
+	if failures < writeFailureThreshold {
+		return
+	}
+
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeDegraded,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonWriteFailing,
+		Message:            fmt.Sprintf("The last %d writes of the resources failed.", failures),
+	})
+}
+
+// blockModified returns true if the block of the instance written into the
+// ConfigMap key was changed by another writer. That's the case if the recorded
+// hash confirms that the same block was written before but it's not found in
+// the content anymore.
+func blockModified(cm *corev1.ConfigMap, cmKey, instanceNamespacedName, block, merged string) bool {
+	blockHash, ok := getBlockHashes(cm)[blockHashKey(cmKey, instanceNamespacedName)]
+
+	return ok && blockHash == utils.Hash(block) && merged != cm.Data[cmKey]
+}
+
+// setConflict records that the block of the instance was changed by another
+// writer (persisted with the next status update).
+func (r *CustomResourceStateMetricsReconciler) setConflict(
+	instance *ksmv1.CustomResourceStateMetrics, cmNamespacedName, cmKey string) {
+	message := fmt.Sprintf(
+		"The resources in the key %s of the ConfigMap %s were changed by another writer and are restored.",
+		cmKey, cmNamespacedName)
+
+	// Record the event
+	r.Recorder.Event(instance, corev1.EventTypeWarning, reasonBlockModified, message)
+
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeConflict,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonBlockModified,
+		Message:            message,
+	})
+}
+
+// setDuplicated records that the resources of the instance identical to the
+// resources of the other instances were dropped from the key of the ConfigMap
+// (persisted with the next status update). The condition is cleared once there
+// are no duplicates.
+func (r *CustomResourceStateMetricsReconciler) setDuplicated(
+	instance *ksmv1.CustomResourceStateMetrics, cmNamespacedName, cmKey string, owners []string) {
+	if len(owners) == 0 {
+		if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeDuplicated) {
+			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+				Type:               conditionTypeDuplicated,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: instance.Generation,
+				Reason:             reasonNoDuplicates,
+				Message:            "No resources are identical to the resources of other instances.",
+			})
+		}
+
+		return
+	}
+
+	message := fmt.Sprintf(
+		"The resources identical to the resources of %s in the key %s of the ConfigMap %s were dropped.",
+		strings.Join(owners, ", "), cmKey, cmNamespacedName)
+
+	// Record the event
+	r.Recorder.Event(instance, corev1.EventTypeWarning, reasonResourcesDeduplicated, message)
+
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeDuplicated,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonResourcesDeduplicated,
+		Message:            message,
+	})
+}
+
+// reportMarkers records the corrupted markers in the key of the ConfigMap the
+// write of the instance repairs. The resources of the instance are deduplicated
+// by the write and the markers of all resources are normalized.
+func (r *CustomResourceStateMetricsReconciler) reportMarkers(
+	instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName, cmNamespacedName, cmKey, content,
+	path string) {
+	problems := checkMarkers(content, path)
+	if len(problems) == 0 {
+		return
+	}
+
+	log.Info(
+		"Repairing the corrupted markers",
+		"instance", instanceNamespacedName,
+		"configMap", cmNamespacedName,
+		"key", cmKey,
+		"problems", problems)
+
+	// Record the event
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonMarkersRepaired,
+		"Repairing the corrupted markers in the key %s of the ConfigMap %s: %s.",
+		cmKey, cmNamespacedName, strings.Join(problems, "; "))
+}
+
+// clearConflict clears the Conflict status condition once the spec of the
+// instance changed since the conflict was recorded (persisted with the next
+// status update).
+func clearConflict(instance *ksmv1.CustomResourceStateMetrics) {
+	condition := meta.FindStatusCondition(instance.Status.Conditions, conditionTypeConflict)
+	if condition == nil || condition.Status != metav1.ConditionTrue ||
+		condition.ObservedGeneration == instance.Generation {
+		return
+	}
+
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeConflict,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonConflictResolved,
+		Message:            "No other writer changed the resources since the spec was updated.",
+	})
+}
+
+// setReconciling derives the Reconciling status condition from the other
+// conditions so kstatus (used by Argo CD and Flux) can compute the health of
+// the instance. The instance is reconciling until it's ready unless it's
+// stalled or intentionally idle (paused, suspended or waiting for the change
+// window).
+func setReconciling(instance *ksmv1.CustomResourceStateMetrics) {
+	conditions := instance.Status.Conditions
+	condition := metav1.Condition{
+		Type:               conditionTypeReconciling,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonReconciled,
+		Message:            "The reconciliation finished.",
+	}
+
+	ready := meta.FindStatusCondition(conditions, conditionTypeReady)
+	idle := false
+
+	for _, conditionType := range []string{conditionTypePaused, conditionTypeSuspended, conditionTypePendingWindow} {
+		idle = idle || meta.IsStatusConditionTrue(conditions, conditionType)
+	}
+
+	if ready != nil && ready.Status != metav1.ConditionTrue && !idle &&
+		!meta.IsStatusConditionTrue(conditions, conditionTypeStalled) {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ready.Reason
+		condition.Message = ready.Message
+	}
+
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+}
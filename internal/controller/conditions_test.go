@@ -0,0 +1,240 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestWriteFailures(t *testing.T) {
+	g := NewWithT(t)
+
+	instance := &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{UID: "write-failures"}}
+
+	for i := 1; i < writeFailureThreshold; i++ {
+		setWriteFailed(instance, errors.New("conflict"))
+
+		g.Expect(meta.IsStatusConditionFalse(instance.Status.Conditions, conditionTypeSynced)).To(BeTrue())
+		g.Expect(meta.FindStatusCondition(instance.Status.Conditions, conditionTypeDegraded)).To(BeNil())
+	}
+
+	setWriteFailed(instance, errors.New("conflict"))
+
+	degraded := meta.FindStatusCondition(instance.Status.Conditions, conditionTypeDegraded)
+	g.Expect(degraded).NotTo(BeNil())
+	g.Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(degraded.Reason).To(Equal(reasonWriteFailing))
+
+	setSynced(instance, "Written.")
+
+	g.Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeSynced)).To(BeTrue())
+	g.Expect(meta.IsStatusConditionFalse(instance.Status.Conditions, conditionTypeDegraded)).To(BeTrue())
+
+	// The counter starts from scratch after the successful write
+	setWriteFailed(instance, errors.New("conflict"))
+
+	g.Expect(meta.IsStatusConditionFalse(instance.Status.Conditions, conditionTypeDegraded)).To(BeTrue())
+}
+
+func TestSetSyncedKeepsTooLarge(t *testing.T) {
+	g := NewWithT(t)
+
+	instance := &ksmv1.CustomResourceStateMetrics{}
+	instance.Status.Conditions = []metav1.Condition{
+		{Type: conditionTypeDegraded, Status: metav1.ConditionTrue, Reason: reasonTooLarge},
+	}
+
+	setSynced(instance, "Written.")
+
+	g.Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeDegraded)).To(BeTrue())
+}
+
+func TestBlockModified(t *testing.T) {
+	g := NewWithT(t)
+
+	fooYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Foo\n    version: v1\n"
+	barYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Bar\n    version: v1\n"
+
+	content, _, err := mergeResources("", "", "foo@ns", fooYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	tampered, _, err := mergeResources("", "", "foo@ns", barYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	tests := map[string]struct {
+		recorded string
+		block    string
+		expected bool
+	}{
+		"modified":     {recorded: fooYaml, block: fooYaml, expected: true},
+		"spec_changed": {recorded: barYaml, block: fooYaml},
+		"not_recorded": {block: fooYaml},
+	}
+
+	for name, test := range tests {
+		cm := &corev1.ConfigMap{Data: map[string]string{"config.yaml": tampered}}
+
+		if test.recorded != "" {
+			setBlockHashes(cm, "config.yaml", "foo@ns", test.recorded)
+		}
+
+		g.Expect(blockModified(cm, "config.yaml", "foo@ns", test.block, content)).To(
+			Equal(test.expected), "Test [%s]:", name)
+	}
+
+	// The unchanged content is not a conflict
+	cm := &corev1.ConfigMap{Data: map[string]string{"config.yaml": content}}
+	setBlockHashes(cm, "config.yaml", "foo@ns", fooYaml)
+
+	g.Expect(blockModified(cm, "config.yaml", "foo@ns", fooYaml, content)).To(BeFalse())
+}
+
+func TestClearConflict(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		generation int64
+		expected   metav1.ConditionStatus
+	}{
+		"same_generation":    {generation: 1, expected: metav1.ConditionTrue},
+		"changed_generation": {generation: 2, expected: metav1.ConditionFalse},
+	}
+
+	for name, test := range tests {
+		instance := &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{Generation: test.generation}}
+		instance.Status.Conditions = []metav1.Condition{{
+			Type:               conditionTypeConflict,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: 1,
+			Reason:             reasonBlockModified,
+		}}
+
+		clearConflict(instance)
+
+		g.Expect(meta.FindStatusCondition(instance.Status.Conditions, conditionTypeConflict).Status).To(
+			Equal(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestSetWriteFailedStalled(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"invalid_resources": {err: withReason(resultInvalidResources, errors.New("invalid")), expected: true},
+		"too_large":         {err: withReason(resultTooLarge, errConfigMapTooLarge), expected: true},
+		"write_error":       {err: withReason(resultWriteError, errors.New("timeout"))},
+		"unknown":           {err: errors.New("unknown")},
+	}
+
+	for name, test := range tests {
+		instance := &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{UID: types.UID(name)}}
+
+		setWriteFailed(instance, test.err)
+
+		g.Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeStalled)).To(
+			Equal(test.expected), "Test [%s]:", name)
+
+		setSynced(instance, "Written.")
+
+		g.Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeStalled)).To(
+			BeFalse(), "Test [%s]:", name)
+	}
+}
+
+func TestSetReconciling(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		conditions []metav1.Condition
+		expected   metav1.ConditionStatus
+	}{
+		"no_conditions": {
+			expected: metav1.ConditionFalse,
+		},
+		"ready": {
+			conditions: []metav1.Condition{
+				{Type: conditionTypeReady, Status: metav1.ConditionTrue, Reason: reasonSynced},
+			},
+			expected: metav1.ConditionFalse,
+		},
+		"pending_approval": {
+			conditions: []metav1.Condition{
+				{Type: conditionTypeReady, Status: metav1.ConditionFalse, Reason: reasonPendingApproval},
+			},
+			expected: metav1.ConditionTrue,
+		},
+		"stalled": {
+			conditions: []metav1.Condition{
+				{Type: conditionTypeReady, Status: metav1.ConditionFalse, Reason: reasonSyncFailed},
+				{Type: conditionTypeStalled, Status: metav1.ConditionTrue, Reason: resultInvalidResources},
+			},
+			expected: metav1.ConditionFalse,
+		},
+		"paused": {
+			conditions: []metav1.Condition{
+				{Type: conditionTypeReady, Status: metav1.ConditionFalse, Reason: reasonSyncFailed},
+				{Type: conditionTypePaused, Status: metav1.ConditionTrue, Reason: reasonPaused},
+			},
+			expected: metav1.ConditionFalse,
+		},
+	}
+
+	for name, test := range tests {
+		instance := &ksmv1.CustomResourceStateMetrics{}
+		instance.Status.Conditions = test.conditions
+
+		setReconciling(instance)
+
+		g.Expect(meta.FindStatusCondition(instance.Status.Conditions, conditionTypeReconciling).Status).To(
+			Equal(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestSetWriteFailedInsufficientPermissions(t *testing.T) {
+	g := NewWithT(t)
+
+	instance := &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{UID: "insufficient-permissions"}}
+
+	setWriteFailed(instance, withReason(resultInsufficientPermissions, errors.New("denied")))
+
+	condition := meta.FindStatusCondition(instance.Status.Conditions, conditionTypeInsufficientPermissions)
+	g.Expect(condition).NotTo(BeNil())
+	g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(condition.Reason).To(Equal(reasonAccessDenied))
+
+	// Other failures don't report the permissions
+	other := &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{UID: "other-failure"}}
+	setWriteFailed(other, withReason(resultWriteError, errors.New("conflict")))
+
+	g.Expect(meta.FindStatusCondition(other.Status.Conditions, conditionTypeInsufficientPermissions)).To(BeNil())
+
+	setSynced(instance, "Written.")
+
+	g.Expect(meta.IsStatusConditionFalse(instance.Status.Conditions, conditionTypeInsufficientPermissions)).To(BeTrue())
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+// Name of the CustomResourceDefinition annotation requesting the generation
+// of the baseline CustomResourceStateMetrics instance.
+const GenerateAnnotation = "ksm.jtyr.io/generate"
+
+// Name of the label marking the generated CustomResourceStateMetrics
+// instances.
+const GeneratedLabel = "ksm.jtyr.io/generated"
+
+// GroupVersionKind of the CustomResourceDefinition.
+var crdGVK = schema.GroupVersionKind{
+	Group:   "apiextensions.k8s.io",
+	Version: "v1",
+	Kind:    "CustomResourceDefinition",
+}
+
+// Logger definition with a prefix.
+var generatorLog = ctrl.Log.WithName("[generator]")
+
+// CRDGeneratorReconciler creates the baseline CustomResourceStateMetrics
+// instances for the annotated CustomResourceDefinitions.
+type CRDGeneratorReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Reader used to read the whole CustomResourceDefinitions as only their
+	// metadata are cached.
+	APIReader client.Reader
+
+	// Namespace the instances are generated in.
+	Namespace string
+}
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+
+// Reconcile creates the baseline instance for the annotated
+// CustomResourceDefinition or deletes it once the annotation is removed. The
+// existing instance is never updated so it can be customized.
+func (r *CRDGeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+
+	if err := r.APIReader.Get(ctx, req.NamespacedName, crd); err != nil {
+		// The generated instance is garbage collected with the definition
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	instanceName := types.NamespacedName{Name: crd.GetName(), Namespace: r.Namespace}
+	instance := &ksmv1.CustomResourceStateMetrics{}
+	exists := true
+
+	if err := r.Get(ctx, instanceName, instance); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to get the generated CustomResourceStateMetrics: %w", err)
+		}
+
+		exists = false
+	}
+
+	if crd.GetAnnotations()[GenerateAnnotation] != "true" || !crd.GetDeletionTimestamp().IsZero() {
+		// Delete only the instance generated for the definition
+		if !exists || !metav1.IsControlledBy(instance, crd) {
+			return ctrl.Result{}, nil
+		}
+
+		generatorLog.Info(
+			"Deleting generated instance",
+			"crd", crd.GetName(),
+			"instance", utils.NamespacedName(instance.Name, instance.Namespace))
+
+		if err := r.Delete(ctx, instance); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to delete the generated CustomResourceStateMetrics: %w", err)
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if exists {
+		return ctrl.Result{}, nil
+	}
+
+	generated, err := generatedInstance(crd, r.Namespace)
+	if err != nil {
+		generatorLog.Error(err, "Unable to generate instance", "crd", crd.GetName())
+
+		// Nothing to retry until the definition changes
+		return ctrl.Result{}, nil
+	}
+
+	if err := controllerutil.SetControllerReference(crd, generated, r.Scheme); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to set the owner of the generated CustomResourceStateMetrics: %w", err)
+	}
+
+	generatorLog.Info(
+		"Creating generated instance",
+		"crd", crd.GetName(),
+		"instance", utils.NamespacedName(generated.Name, generated.Namespace))
+
+	if err := r.Create(ctx, generated); err != nil && !apierrors.IsAlreadyExists(err) {
+		return ctrl.Result{}, fmt.Errorf("failed to create the generated CustomResourceStateMetrics: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// generatedInstance returns the baseline instance generating the info metric
+// of the custom resources defined by the CustomResourceDefinition.
+func generatedInstance(crd *unstructured.Unstructured, namespace string) (*ksmv1.CustomResourceStateMetrics, error) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+
+	if kind == "" {
+		return nil, errors.New("the CustomResourceDefinition has no kind")
+	}
+
+	version := storageVersion(crd)
+	if version == "" {
+		return nil, errors.New("the CustomResourceDefinition has no served version")
+	}
+
+	// Identify the custom resource by its name (and Namespace)
+	labelsFromPath := map[string][]string{
+		"name": {"metadata", "name"},
+	}
+
+	if scope != "Cluster" {
+		labelsFromPath["namespace"] = []string{"metadata", "namespace"}
+	}
+
+	return &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      crd.GetName(),
+			Namespace: namespace,
+			Labels: map[string]string{
+				GeneratedLabel: "true",
+			},
+		},
+		Spec: ksmv1.CustomResourceStateMetricsSpec{
+			Resources: []ksm.Resource{
+				{
+					GroupVersionKind: ksm.GroupVersionKind{
+						Group:   group,
+						Version: version,
+						Kind:    kind,
+					},
+					ResourcePlural: plural,
+					LabelsFromPath: labelsFromPath,
+					Metrics: []ksm.Metric{
+						{
+							Name: strings.ToLower(kind) + "_info",
+							Help: "Information about the " + kind + ".",
+							Each: ksm.Each{
+								Type: ksm.MetricTypeInfo,
+								Info: &ksm.Info{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// storageVersion returns the storage version of the CustomResourceDefinition
+// if it's served or the first served version otherwise.
+func storageVersion(crd *unstructured.Unstructured) string {
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	served := ""
+
+	for _, v := range versions {
+		version, ok := v.(map[string]any)
+		if !ok || version["served"] != true {
+			continue
+		}
+
+		name, _ := version["name"].(string)
+
+		if version["storage"] == true {
+			return name
+		}
+
+		if served == "" {
+			served = name
+		}
+	}
+
+	return served
+}
+
+// SetupWithManager sets up the controller with the Manager. Only the
+// metadata of the CustomResourceDefinitions are watched as their schemas can
+// be large.
+func (r *CRDGeneratorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	crd := &metav1.PartialObjectMetadata{}
+	crd.SetGroupVersionKind(crdGVK)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(crd, builder.WithPredicates(utils.AnnotationsChangedPredicate(GenerateAnnotation))).
+		// Recreate the generated instance if it gets deleted
+		Owns(&ksmv1.CustomResourceStateMetrics{}).
+		Named("crdgenerator").
+		Complete(r)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+func newCRD(scope string, versions ...map[string]any) *unstructured.Unstructured {
+	items := []any{}
+	for _, version := range versions {
+		items = append(items, version)
+	}
+
+	crd := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"group": "myteam.io",
+			"names": map[string]any{
+				"kind":   "Foo",
+				"plural": "foos",
+			},
+			"scope":    scope,
+			"versions": items,
+		},
+	}}
+	crd.SetGroupVersionKind(crdGVK)
+	crd.SetName("foos.myteam.io")
+
+	return crd
+}
+
+func TestGeneratedInstance(t *testing.T) {
+	g := NewWithT(t)
+
+	v1alpha1 := map[string]any{"name": "v1alpha1", "served": true, "storage": false}
+	v1 := map[string]any{"name": "v1", "served": true, "storage": true}
+	v2 := map[string]any{"name": "v2", "served": false, "storage": false}
+
+	tests := map[string]struct {
+		crd            *unstructured.Unstructured
+		version        string
+		labelsFromPath map[string][]string
+	}{
+		"namespaced": {
+			crd:     newCRD("Namespaced", v1alpha1, v1, v2),
+			version: "v1",
+			labelsFromPath: map[string][]string{
+				"name":      {"metadata", "name"},
+				"namespace": {"metadata", "namespace"},
+			},
+		},
+		"cluster": {
+			crd:     newCRD("Cluster", v2, v1alpha1),
+			version: "v1alpha1",
+			labelsFromPath: map[string][]string{
+				"name": {"metadata", "name"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		instance, err := generatedInstance(test.crd, "ksm")
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+		g.Expect(instance.Name).To(Equal("foos.myteam.io"), "Test [%s]:", name)
+		g.Expect(instance.Namespace).To(Equal("ksm"), "Test [%s]:", name)
+		g.Expect(instance.Labels).To(HaveKeyWithValue(GeneratedLabel, "true"), "Test [%s]:", name)
+		g.Expect(instance.Spec.Resources).To(HaveLen(1), "Test [%s]:", name)
+
+		resource := instance.Spec.Resources[0]
+		g.Expect(resource.GroupVersionKind).To(Equal(ksm.GroupVersionKind{
+			Group: "myteam.io", Version: test.version, Kind: "Foo"}), "Test [%s]:", name)
+		g.Expect(resource.ResourcePlural).To(Equal("foos"), "Test [%s]:", name)
+		g.Expect(resource.LabelsFromPath).To(Equal(test.labelsFromPath), "Test [%s]:", name)
+		g.Expect(ksm.MetricNames(instance.Spec.Resources)).To(Equal([]string{"kube_customresource_foo_info"}),
+			"Test [%s]:", name)
+		g.Expect(ksm.ValidateResource(resource)).To(Succeed(), "Test [%s]:", name)
+	}
+
+	// No served version
+	_, err := generatedInstance(newCRD("Namespaced", v2), "ksm")
+	g.Expect(err).To(HaveOccurred(), "Test [not-served]:")
+}
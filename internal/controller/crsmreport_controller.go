@@ -0,0 +1,215 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+// Name of the CRSMReport maintained by the operator.
+const ReportName = "cluster"
+
+// Logger definition with a prefix.
+var reportLog = ctrl.Log.WithName("[report]")
+
+// CRSMReportReconciler maintains the CRSMReport summarizing all
+// CustomResourceStateMetrics instances.
+type CRSMReportReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=ksm.jtyr.io,resources=crsmreports,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=ksm.jtyr.io,resources=crsmreports/status,verbs=get;update;patch
+
+// Reconcile recomputes the CRSMReport from the current set of instances.
+func (r *CRSMReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Maintain only the single report
+	if req.Name != ReportName {
+		return ctrl.Result{}, nil
+	}
+
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+
+	if err := r.List(ctx, instances); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list CustomResourceStateMetrics: %w", err)
+	}
+
+	status := buildReport(instances.Items)
+
+	report := &ksmv1.CRSMReport{}
+
+	if err := r.Get(ctx, types.NamespacedName{Name: ReportName}, report); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to get CRSMReport: %w", err)
+		}
+
+		reportLog.V(1).Info("Creating report", "report", ReportName)
+
+		report = &ksmv1.CRSMReport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ReportName,
+			},
+		}
+
+		if err := r.Create(ctx, report); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create CRSMReport: %w", err)
+		}
+	}
+
+	if equality.Semantic.DeepEqual(report.Status, status) {
+		return ctrl.Result{}, nil
+	}
+
+	reportLog.V(1).Info(
+		"Updating report",
+		"report", ReportName,
+		"instances", status.TotalInstances,
+		"unhealthy", status.UnhealthyInstances)
+
+	report.Status = status
+
+	if err := r.Status().Update(ctx, report); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update CRSMReport status: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reportTarget identifies the ConfigMap key the resources are written into.
+type reportTarget struct {
+	name      string
+	namespace string
+	key       string
+}
+
+// buildReport summarizes the instances.
+func buildReport(instances []ksmv1.CustomResourceStateMetrics) ksmv1.CRSMReportStatus {
+	status := ksmv1.CRSMReportStatus{}
+	namespaces := make(map[string]int32)
+	targets := make(map[reportTarget]int32)
+
+	for i := range instances {
+		instance := &instances[i]
+
+		status.TotalInstances++
+		namespaces[instance.Namespace]++
+
+		if target := instance.Status.ConfigMap; target != nil {
+			// Count every replicated ConfigMap as a separate target
+			namespaces := target.Namespaces
+			if len(namespaces) == 0 {
+				namespaces = []string{target.Namespace}
+			}
+
+			for _, namespace := range namespaces {
+				targets[reportTarget{name: target.Name, namespace: namespace, key: target.Key}]++
+			}
+		}
+
+		if ready := meta.FindStatusCondition(instance.Status.Conditions, conditionTypeReady); ready == nil ||
+			ready.Status != metav1.ConditionTrue {
+			unhealthy := ksmv1.CRSMReportInstance{
+				Name:      instance.Name,
+				Namespace: instance.Namespace,
+			}
+
+			if ready != nil {
+				unhealthy.Reason = ready.Reason
+				unhealthy.Message = ready.Message
+			}
+
+			status.Unhealthy = append(status.Unhealthy, unhealthy)
+		}
+	}
+
+	for name, count := range namespaces {
+		status.Namespaces = append(status.Namespaces, ksmv1.CRSMReportNamespace{
+			Name:      name,
+			Instances: count,
+		})
+	}
+
+	for target, count := range targets {
+		status.Targets = append(status.Targets, ksmv1.CRSMReportTarget{
+			Name:      target.name,
+			Namespace: target.namespace,
+			Key:       target.key,
+			Instances: count,
+		})
+	}
+
+	status.UnhealthyInstances = int32(len(status.Unhealthy)) //nolint:gosec
+	status.TotalTargets = int32(len(status.Targets))         //nolint:gosec
+
+	// Make the output deterministic
+	sort.Slice(status.Namespaces, func(i, j int) bool {
+		return status.Namespaces[i].Name < status.Namespaces[j].Name
+	})
+	sort.Slice(status.Unhealthy, func(i, j int) bool {
+		a, b := status.Unhealthy[i], status.Unhealthy[j]
+
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+
+		return a.Name < b.Name
+	})
+	sort.Slice(status.Targets, func(i, j int) bool {
+		a, b := status.Targets[i], status.Targets[j]
+
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+
+		return a.Key < b.Key
+	})
+
+	return status
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CRSMReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ksmv1.CRSMReport{}).
+		Watches(
+			&ksmv1.CustomResourceStateMetrics{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, _ client.Object) []reconcile.Request {
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: ReportName}}}
+			}),
+		).
+		Named("crsmreport").
+		Complete(r)
+}
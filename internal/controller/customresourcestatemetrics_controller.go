@@ -19,10 +19,16 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,37 +37,149 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/discovery"
 	"github.com/jtyr/crsm-operator/internal/metrics"
+	"github.com/jtyr/crsm-operator/internal/notifier"
+	"github.com/jtyr/crsm-operator/internal/remote"
+	"github.com/jtyr/crsm-operator/internal/rollout"
+	"github.com/jtyr/crsm-operator/internal/schedule"
 	"github.com/jtyr/crsm-operator/internal/utils"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
 )
 
 // Name of the finalizer that gets attached to the instance.
 const FinalizerName = "ksm.jtyr.io/finalizer"
 
-// Format for the begin marker.
-const beginMarkerFormat = "# BEGIN CustomResourceStateMetrics %s"
+// Name of the annotation used to approve staged changes.
+const ApproveAnnotation = "ksm.jtyr.io/approve"
 
-// Format for the end marker.
-const endMarkerFormat = "# END CustomResourceStateMetrics %s"
+// Name of the annotation forcing the resources to be written again (e.g. after
+// a manual repair of the ConfigMap). Each new value forces a single write.
+const ForceSyncAnnotation = "ksm.jtyr.io/force-sync"
+
+// Name of the annotation pausing the writes of the resources without a change
+// of the spec (e.g. by GitOps tooling).
+const PausedAnnotation = "ksm.jtyr.io/paused"
+
+// Name of the field index of the instances by the ConfigMaps (namespace/name)
+// their resources were written into.
+const configMapTargetIndex = ".status.configMap"
+
+// Name of the field index of the instances by the Secrets (namespace/name)
+// their resources were written into.
+const secretTargetIndex = ".status.secret"
+
+// Suffix of the ConfigMap key where staged changes are written into.
+const nextKeySuffix = "-next"
+
+// Suffix of the name of the ConfigMap holding the rendered resources for inspection.
+const inspectionSuffix = "-rendered"
+
+// Default ConfigMap key used if none is specified.
+const DefaultKey = "config.yaml"
+
+// Default header of the document stored in the ConfigMap.
+const dataHeader = "kind: CustomResourceStateMetrics\nspec:\n  resources:\n"
 
 // Rype for the Ready status condition.
 const conditionTypeReady = "Ready"
 
-// Reasons for status conditions and events.
-const reasonAdding = "Adding"
+// Type for the PendingWindow status condition.
+const conditionTypePendingWindow = "PendingWindow"
+
+// Type for the Suspended status condition.
+const conditionTypeSuspended = "Suspended"
+
+// Type for the Paused status condition.
+const conditionTypePaused = "Paused"
+
+// Type for the Degraded status condition.
+const conditionTypeDegraded = "Degraded"
+
+// Type for the Synced status condition.
+const conditionTypeSynced = "Synced"
+
+// Type for the Conflict status condition.
+const conditionTypeConflict = "Conflict"
+
+// Type for the Duplicated status condition.
+const conditionTypeDuplicated = "Duplicated"
+
+// Type for the InsufficientPermissions status condition.
+const conditionTypeInsufficientPermissions = "InsufficientPermissions"
+
+// Types for the Reconciling and Stalled status conditions following the
+// kstatus conventions.
+const conditionTypeReconciling = "Reconciling"
+const conditionTypeStalled = "Stalled"
+
+// Time after which the schedule is checked again if no window starts soon.
+const windowRecheckInterval = time.Hour
+
+// Reason for events recorded on the kube-state-metrics Deployment.
+const reasonConfigChanged = "ConfigChanged"
+
+// Reasons of the lifecycle of the resources used by both the status conditions
+// and the events so the creates can be distinguished from the updates.
+const reasonCreating = "Creating"
+const reasonUpdating = "Updating"
 const reasonRemoving = "Removing"
+const reasonSynced = "Synced"
+const reasonSyncFailed = "SyncFailed"
+
+// Other reasons for status conditions and events.
+const reasonAdopting = "Adopting"
+const reasonTakingOver = "TakingOver"
+const reasonRetaining = "Retaining"
+const reasonPendingApproval = "PendingApproval"
+const reasonWriteBuffered = "WriteBuffered"
+const reasonWriteBatched = "WriteBatched"
+const reasonPendingWindow = "PendingWindow"
+const reasonWindowOpen = "WindowOpen"
+const reasonSuspended = "Suspended"
+const reasonResumed = "Resumed"
+const reasonPaused = "Paused"
+const reasonUnpaused = "Unpaused"
+const reasonTooLarge = "TooLarge"
+const reasonWithinLimit = "WithinLimit"
+const reasonWriteFailing = "WriteFailing"
+const reasonWriteRecovered = "WriteRecovered"
+const reasonBlockModified = "BlockModified"
+const reasonAccessDenied = "AccessDenied"
+const reasonAccessGranted = "AccessGranted"
+const reasonConflictResolved = "ConflictResolved"
+const reasonMarkersRepaired = "MarkersRepaired"
+const reasonResourcesDeduplicated = "ResourcesDeduplicated"
+const reasonNoDuplicates = "NoDuplicates"
+const reasonReconciled = "Reconciled"
 
 // Logger definition with a prefix.
 var log = ctrl.Log.WithName("[crsm]")
 
 // Records resources created on the cluster.
 var resources = make(map[string]int)
+var resourcesMu sync.Mutex
+
+// Caches the rendered resources per instance generation.
+var renderedResources = newRenderCache()
+
+// Serializes the writes into the same target ConfigMap.
+var targetLocks = newKeyedMutex()
+
+// Records group/kind pairs the instances define metrics for.
+var gvkUsage = make(map[string]map[ksm.GroupVersionKind]struct{})
+var gvkUsageMu sync.Mutex
 
 // CustomResourceStateMetricsReconciler reconciles a CustomResourceStateMetrics object
 type CustomResourceStateMetricsReconciler struct {
@@ -71,6 +189,48 @@ type CustomResourceStateMetricsReconciler struct {
 	MetricsRecorder   metrics.MetricsRecorder
 	Selector          labels.Selector
 	NamespaceSelector labels.Selector
+	DefaultConfigMap  types.NamespacedName
+	Notifier          notifier.Notifier
+	WriteBuffer       *WriteBuffer
+	ResyncPeriod      time.Duration
+	Restarter         *rollout.Restarter
+	RestartMounting   bool
+	KSMServiceAccount types.NamespacedName
+	KSMGroupPolicy    *KSMGroupPolicy
+	Fetcher           *remote.Fetcher
+	Health            *ReconcileHealth
+	TargetPolicy      *TargetPolicy
+	DryRun            bool
+
+	// Drop the resources identical to the resources of the other instances
+	// written into the same target.
+	DeduplicateResources bool
+
+	// Preserve the unmarked resources found in the ConfigMap as the block of
+	// the synthetic manual owner.
+	TakeOverManualResources bool
+
+	// Only warn about the metric names generated by the other instances
+	// writing into the same target instead of refusing the instance.
+	WarnMetricCollisions bool
+
+	// Number of the instances reconciled in parallel (1 if not set). The writes
+	// into the same ConfigMap are serialized.
+	MaxConcurrentReconciles int
+
+	// Header of the newly created documents and format of the markers of the
+	// resources (the defaults if not set).
+	DocumentHeader string
+	MarkerFormat   string
+
+	// Configuration overridden by the OperatorConfig and whether the
+	// OperatorConfig was loaded already
+	config       atomic.Pointer[RuntimeConfig]
+	configLoaded atomic.Bool
+
+	// Hash of the last applied RBAC of kube-state-metrics
+	ksmRBACMu   sync.Mutex
+	ksmRBACHash string
 }
 
 // Data is a structure used to read the raw resources from the CustomResourceStateMetrics instance.
@@ -84,14 +244,19 @@ type Data struct {
 // +kubebuilder:rbac:groups=ksm.jtyr.io,resources=customresourcestatemetrics/finalizers,verbs=update
 
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
-// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;create;update
-// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;create;patch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.20.4/pkg/reconcile
-func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *CustomResourceStateMetricsReconciler) Reconcile(
+	ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	_ = logf.FromContext(ctx)
 
 	// Content of the instance
@@ -107,6 +272,11 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 					req.Namespace))
 		}
 
+		// The deleted instance must not count into the health of the operator
+		if client.IgnoreNotFound(err) == nil && r.Health != nil {
+			r.Health.forget(utils.NamespacedName(req.Name, req.Namespace))
+		}
+
 		// We'll ignore not-found errors, since they can't be fixed by
 		// an immediate requeue (we'll need to wait for a new
 		// notification), and we can get them on deleted requests.
@@ -116,6 +286,56 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 	// Namespaced name of the instance
 	instanceNamespacedName := utils.NamespacedName(instance.Name, instance.Namespace)
 
+	// Record the result once the reconciliation finishes
+	defer func() {
+		r.recordResult(instance, err)
+
+		if r.Health != nil {
+			// The deleted instance must not count into the health of the operator
+			if err == nil && !controllerutil.ContainsFinalizer(instance, FinalizerName) &&
+				!instance.DeletionTimestamp.IsZero() {
+				r.Health.forget(instanceNamespacedName)
+			} else {
+				r.Health.record(instanceNamespacedName, err)
+			}
+		}
+
+		result, err = retryResult(instance, result, err)
+	}()
+
+	// Nothing is written with the layout of the flags until the OperatorConfig is loaded
+	if !r.configLoaded.Load() {
+		if _, err := r.loadOperatorConfig(ctx); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Skip the changes while the instance is paused
+	if instance.DeletionTimestamp.IsZero() {
+		if paused, err := r.checkPaused(ctx, instance, instanceNamespacedName); err != nil || paused {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Skip the changes while the instance is suspended
+	if instance.DeletionTimestamp.IsZero() {
+		if suspended, err := r.checkSuspended(ctx, instance, instanceNamespacedName); err != nil || suspended {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Defer the changes until the change window opens
+	if instance.DeletionTimestamp.IsZero() && instance.Spec.Schedule != nil {
+		requeueAfter, open, err := r.checkWindow(ctx, instance, instanceNamespacedName)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if !open {
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
+	}
+
 	if !instance.DeletionTimestamp.IsZero() { //nolint:gocritic
 		log.Info("Deleting resources", "instance", instanceNamespacedName)
 
@@ -123,21 +343,35 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonRemoving, "Deleting resource.")
 
 		// Remove instance from ConfigMap
-		if err := r.deleteCustomResourceStateMetric(ctx, instance, instanceNamespacedName); err != nil {
+		changed, err := retryOnConflict(func() (bool, error) {
+			return r.deleteCustomResourceStateMetric(ctx, instance, instanceNamespacedName)
+		})
+
+		// Nothing is removed in the dry-run mode so the deletion isn't blocked
+		if errors.Is(err, errDryRun) {
+			changed, err = false, nil
+		}
+
+		if isBuffered(err) {
+			return r.bufferedResult(instance, instanceNamespacedName, err), nil
+		} else if err != nil {
 			// Record the event
-			r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonRemoving,
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonSyncFailed,
 				"Failed to delete resources from the ConfigMap: %v", err)
+			r.notify(ctx, instance, notifier.EventFailed,
+				fmt.Sprintf("Failed to delete resources from the ConfigMap: %v", err))
 
 			// Update the status condition
 			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-				Type:    conditionTypeReady,
-				Status:  metav1.ConditionFalse,
-				Reason:  reasonRemoving,
-				Message: "Failed to delete resources from the ConfigMap.",
+				Type:               conditionTypeReady,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: instance.Generation,
+				Reason:             reasonSyncFailed,
+				Message:            "Failed to delete resources from the ConfigMap.",
 			})
-			if err := r.Status().Update(ctx, instance); err != nil {
+			if err := r.updateStatus(ctx, instance); err != nil {
 				// Record the event
-				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonRemoving,
+				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonSyncFailed,
 					"Failed to update status: %v", err)
 
 				return ctrl.Result{}, fmt.Errorf("failed to update status for %s: %w",
@@ -150,11 +384,32 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 		}
 
 		// Deregister the resource
-		delete(resources, instanceNamespacedName)
+		r.deregisterInstance(instance)
+		renderedResources.delete(instance.UID)
+		writeFailures.reset(instance.UID)
+		r.forgetRemoteSource(instance)
+		r.recordGVKUsage(instanceNamespacedName, nil)
+		r.syncKSMRBAC(ctx, instance)
+
+		if r.WriteBuffer != nil {
+			r.WriteBuffer.forget(instance.UID)
+		}
+
+		// Send the notification
+		if instance.Spec.DeletionPolicy == ksmv1.DeletionPolicyRetain {
+			r.notify(ctx, instance, notifier.EventRemoved, "Resources were retained in the ConfigMap.")
+		} else {
+			r.notify(ctx, instance, notifier.EventRemoved, "Resources were removed from the ConfigMap.")
+		}
+
+		if changed {
+			r.recordConfigChange(ctx, instance, "removed")
+		}
 
-		// Decrement the metric counter
+		// Remove the instance metrics
 		if r.MetricsRecorder != nil {
-			r.MetricsRecorder.DecCRSMTotal()
+			r.MetricsRecorder.DeleteMetricsMissing(instance.Name, instance.Namespace)
+			r.MetricsRecorder.DeleteInstanceReady(instance.Name, instance.Namespace)
 		}
 
 		// Remove finalizer if it exists
@@ -167,19 +422,20 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 
 			if err := r.Update(ctx, instance); err != nil {
 				// Record the event
-				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonRemoving,
+				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonSyncFailed,
 					"Failed to delete finalizer: %v", err)
 
 				// Update the status condition
 				meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-					Type:    conditionTypeReady,
-					Status:  metav1.ConditionFalse,
-					Reason:  reasonRemoving,
-					Message: "Failed to delete finalizer.",
+					Type:               conditionTypeReady,
+					Status:             metav1.ConditionFalse,
+					ObservedGeneration: instance.Generation,
+					Reason:             reasonSyncFailed,
+					Message:            "Failed to delete finalizer.",
 				})
-				if err := r.Status().Update(ctx, instance); err != nil {
+				if err := r.updateStatus(ctx, instance); err != nil {
 					// Record the event
-					r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonRemoving,
+					r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonSyncFailed,
 						"Failed to update status: %v", err)
 
 					return ctrl.Result{}, fmt.Errorf("failed to update status for %s: %w",
@@ -191,41 +447,53 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 					instanceNamespacedName, err)
 			}
 		}
-	} else if instance.Generation == 1 && !controllerutil.ContainsFinalizer(instance, FinalizerName) {
+	} else if !controllerutil.ContainsFinalizer(instance, FinalizerName) {
 		log.Info("Creating resources", "instance", instanceNamespacedName)
 
 		// Record the event
-		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonAdding, "Adding resources into the ConfigMap.")
+		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonCreating, "Adding resources into the ConfigMap.")
 
 		// Update the status condition
 		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-			Type:    conditionTypeReady,
-			Status:  metav1.ConditionFalse,
-			Reason:  reasonAdding,
-			Message: "Adding resources into the ConfigMap.",
+			Type:               conditionTypeReady,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonCreating,
+			Message:            "Adding resources into the ConfigMap.",
 		})
-		if err := r.Status().Update(ctx, instance); err != nil {
+		if err := r.updateStatus(ctx, instance); err != nil {
 			return ctrl.Result{}, fmt.Errorf(
 				"failed to update status for the CustomResourceStateMetrics instance %s: %w",
 				instanceNamespacedName, err)
 		}
 
 		// Add resources
-		if err := r.addCustomResourceStateMetric(ctx, instance, instanceNamespacedName); err != nil {
+		changed, err := retryOnConflict(func() (bool, error) {
+			return r.addCustomResourceStateMetric(ctx, instance, instanceNamespacedName)
+		})
+		if isBuffered(err) {
+			return r.bufferedResult(instance, instanceNamespacedName, err), nil
+		} else if errors.Is(err, errDryRun) {
+			return r.dryRunResult(ctx, instance, instanceNamespacedName, err)
+		} else if err != nil {
 			// Record the event
-			r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonAdding,
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonSyncFailed,
 				"Failed to add resources into the ConfigMap: %v", err)
+			r.notify(ctx, instance, notifier.EventFailed,
+				fmt.Sprintf("Failed to add resources into the ConfigMap: %v", err))
 
-			// Update the status condition
+			// Update the status conditions
+			setWriteFailed(instance, err)
 			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-				Type:    conditionTypeReady,
-				Status:  metav1.ConditionFalse,
-				Reason:  reasonAdding,
-				Message: "Failed to add resources into the ConfigMap.",
+				Type:               conditionTypeReady,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: instance.Generation,
+				Reason:             reasonSyncFailed,
+				Message:            "Failed to add resources into the ConfigMap.",
 			})
-			if err := r.Status().Update(ctx, instance); err != nil {
+			if err := r.updateStatus(ctx, instance); err != nil {
 				// Record the event
-				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonAdding,
+				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonSyncFailed,
 					"Failed to update status: %v", err)
 
 				return ctrl.Result{}, fmt.Errorf("failed to update status for %s: %w",
@@ -238,11 +506,14 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 		}
 
 		// Register the resource
-		resources[instanceNamespacedName] = 1
-
-		// Increment the metric counter
-		if r.MetricsRecorder != nil {
-			r.MetricsRecorder.IncCRSMTotal()
+		r.registerInstance(instance)
+		r.recordGVKUsage(instanceNamespacedName, instance.Status.Kinds)
+		r.syncKSMRBAC(ctx, instance)
+
+		// Send the notification
+		if changed {
+			r.notify(ctx, instance, notifier.EventAdded, "Resources were added into the ConfigMap.")
+			r.recordConfigChange(ctx, instance, "added")
 		}
 
 		// Add finalizer if it doesn't exist yet
@@ -254,7 +525,7 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 			// This triggers a new reconciliation
 			if err := r.Update(ctx, instance); err != nil {
 				// Record the event
-				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonAdding,
+				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonSyncFailed,
 					"Failed to add finalizer: %v", err)
 
 				return ctrl.Result{}, fmt.Errorf(
@@ -266,24 +537,34 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 		log.Info("Updating resources", "instance", instanceNamespacedName)
 
 		// Record the event
-		r.Recorder.Event(instance, "Normal", reasonAdding, "Updating resources in the ConfigMap.")
+		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonUpdating, "Updating resources in the ConfigMap.")
 
 		// Update resources
-		if err := r.addCustomResourceStateMetric(ctx, instance, instanceNamespacedName); err != nil {
+		changed, err := retryOnConflict(func() (bool, error) {
+			return r.addCustomResourceStateMetric(ctx, instance, instanceNamespacedName)
+		})
+		if isBuffered(err) {
+			return r.bufferedResult(instance, instanceNamespacedName, err), nil
+		} else if errors.Is(err, errDryRun) {
+			return r.dryRunResult(ctx, instance, instanceNamespacedName, err)
+		} else if err != nil {
 			// Record the event
-			r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonAdding,
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonSyncFailed,
 				"Failed to update the ConfigMap: %v", err)
+			r.notify(ctx, instance, notifier.EventFailed, fmt.Sprintf("Failed to update the ConfigMap: %v", err))
 
-			// Update the status condition
+			// Update the status conditions
+			setWriteFailed(instance, err)
 			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-				Type:    conditionTypeReady,
-				Status:  metav1.ConditionFalse,
-				Reason:  reasonAdding,
-				Message: "Failed to update the ConfigMap.",
+				Type:               conditionTypeReady,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: instance.Generation,
+				Reason:             reasonSyncFailed,
+				Message:            "Failed to update the ConfigMap.",
 			})
-			if err := r.Status().Update(ctx, instance); err != nil {
+			if err := r.updateStatus(ctx, instance); err != nil {
 				// Record the event
-				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonAdding,
+				r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonSyncFailed,
 					"Failed to update status: %v", err)
 
 				return ctrl.Result{}, fmt.Errorf(
@@ -296,333 +577,1799 @@ func (r *CustomResourceStateMetricsReconciler) Reconcile(ctx context.Context, re
 				instanceNamespacedName, err)
 		}
 
-		// Register the resource if it wasn't registered yet
-		if _, ok := resources[instanceNamespacedName]; !ok {
-			resources[instanceNamespacedName] = 1
+		// Record the group/kind pairs as they might have changed
+		r.recordGVKUsage(instanceNamespacedName, instance.Status.Kinds)
+		r.syncKSMRBAC(ctx, instance)
 
-			// Increment the metric counter
-			if r.MetricsRecorder != nil {
-				r.MetricsRecorder.IncCRSMTotal()
-			}
+		// Send the notification
+		if changed {
+			r.notify(ctx, instance, notifier.EventUpdated, "Resources were updated in the ConfigMap.")
+			r.recordConfigChange(ctx, instance, "updated")
 		}
+
+		// Register the resource if it wasn't registered yet
+		r.registerInstance(instance)
 	}
 
-	return ctrl.Result{}, nil
-}
+	if !instance.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
 
-// deleteCustomResourceStateMetric removes resources from a ConfigMap.
-func (r *CustomResourceStateMetricsReconciler) deleteCustomResourceStateMetric(
-	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string) error {
-	log.V(1).Info("Processing deletion of resources", "instance", instanceNamespacedName)
+	// Schedule the periodic resync
+	requeueAfter := time.Duration(0)
+	resyncPeriod := r.runtimeConfig().ResyncPeriod
 
-	// Define ConfigMap properties
-	cmName := instance.Spec.ConfigMap.Name
-	cmNamespace := instance.Spec.ConfigMap.Namespace
-	cmKey := instance.Spec.ConfigMap.Key
+	if instance.Spec.ResyncPolicy == ksmv1.ResyncPolicyAlways && resyncPeriod > 0 {
+		requeueAfter = resyncPeriod
+	}
 
-	// If no Namespace was specified, use the namespace from the instance
-	if cmNamespace == "" {
-		cmNamespace = instance.Namespace
+	// Schedule the next poll of the remote source
+	if instance.Spec.Source != nil {
+		if interval := pollInterval(instance.Spec.Source); requeueAfter == 0 || interval < requeueAfter {
+			requeueAfter = interval
+		}
 	}
 
-	// Namespaced name of the ConfigMap
-	cmNamespacedName := utils.NamespacedName(cmName, cmNamespace)
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
 
-	// Check if the ConfigMap exists
-	cm := &corev1.ConfigMap{}
-	err := r.Get(ctx, types.NamespacedName{
-		Name:      cmName,
-		Namespace: cmNamespace,
-	}, cm)
-	if err != nil {
-		log.V(1).Info(
-			"ConfigMap doesn't exist",
-			"instance", instanceNamespacedName,
-			"configMap", cmNamespacedName)
+// recordResult records the reason of the reconcile result of the instance.
+func (r *CustomResourceStateMetricsReconciler) recordResult(instance *ksmv1.CustomResourceStateMetrics, err error) {
+	if r.MetricsRecorder == nil {
+		return
+	}
 
-		// Record the event
-		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonRemoving,
-			"The ConfigMap with the resources doesn't exist.")
+	// Remove the records of the deleted instance
+	if err == nil && !instance.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(instance, FinalizerName) {
+		r.MetricsRecorder.DeleteLastReconcileResult(instance.Name, instance.Namespace)
 
-		// Update the status condition
-		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-			Type:    conditionTypeReady,
-			Status:  metav1.ConditionFalse,
-			Reason:  reasonRemoving,
-			Message: "The ConfigMap with the resources doesn't exist.",
-		})
-		if err := r.Status().Update(ctx, instance); err != nil {
-			return fmt.Errorf(
-				"failed to update status for the CustomResourceStateMetrics instance %s: %w",
-				instanceNamespacedName, err)
-		}
+		return
+	}
 
-		return nil
+	reason := resultSynced
+
+	if err != nil {
+		reason = resultReason(err)
+	} else if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypePaused) {
+		reason = reasonPaused
+	} else if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeSuspended) {
+		reason = reasonSuspended
+	} else if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypePendingWindow) {
+		reason = reasonPendingWindow
+	} else if condition := meta.FindStatusCondition(instance.Status.Conditions, conditionTypeReady); condition != nil &&
+		(condition.Reason == reasonPendingApproval || condition.Reason == reasonWriteBuffered ||
+			condition.Reason == reasonWriteBatched || condition.Reason == reasonDryRun) {
+		reason = condition.Reason
 	}
 
-	// Try to find the block in the ConfigMap
-	lines := strings.Split(cm.Data[cmKey], "\n")
-	found, beginIndex, endIndex := r.findBlock(instanceNamespacedName, lines)
+	r.MetricsRecorder.SetLastReconcileResult(instance.Name, instance.Namespace, reason)
+}
 
-	if !found {
-		log.V(1).Info(
-			"No block found",
-			"instance", instanceNamespacedName,
-			"configMap", cmNamespacedName)
+// updateStatus updates the status of the instance and records the generation
+// the status refers to.
+func (r *CustomResourceStateMetricsReconciler) updateStatus(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics,
+) error {
+	instance.Status.ObservedGeneration = instance.Generation
 
-		// Record the event
-		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonRemoving,
-			"Resources don't exist in the ConfigMap.")
+	setReconciling(instance)
 
-		// Update the status condition
-		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-			Type:    conditionTypeReady,
-			Status:  metav1.ConditionFalse,
-			Reason:  reasonRemoving,
-			Message: "Resources don't exist in the ConfigMap.",
-		})
-		if err := r.Status().Update(ctx, instance); err != nil {
-			return fmt.Errorf(
-				"failed to update status for the CustomResourceStateMetrics instance %s: %w",
-				instanceNamespacedName, err)
-		}
+	if err := r.Status().Update(ctx, instance); err != nil {
+		return err
+	}
 
-		return nil
+	// Expose the readiness for alerting
+	if r.MetricsRecorder != nil {
+		r.MetricsRecorder.SetInstanceReady(instance.Name, instance.Namespace,
+			meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeReady))
 	}
 
-	log.V(1).Info(
-		"Removing block",
-		"instance", instanceNamespacedName,
-		"configMap", cmNamespacedName,
-		"position", fmt.Sprintf("%d;%d", beginIndex, endIndex))
+	return nil
+}
 
-	// Reset the current data and fill it with individual fragments
-	// without the found block
-	cm.Data[cmKey] = ""
+// checkPaused returns whether the instance is paused by the annotation and
+// records it in the Paused status condition.
+func (r *CustomResourceStateMetricsReconciler) checkPaused(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string,
+) (bool, error) {
+	if !isPaused(instance) {
+		// Clear the condition (persisted with the next status update)
+		if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypePaused) {
+			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+				Type:               conditionTypePaused,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: instance.Generation,
+				Reason:             reasonUnpaused,
+				Message:            "The reconciliation is unpaused.",
+			})
+		}
 
-	if beginIndex > 0 {
-		cm.Data[cmKey] += r.joinLines(lines, 0, beginIndex-1)
+		return false, nil
 	}
 
-	if endIndex < len(lines)-1 {
-		cm.Data[cmKey] += r.joinLines(lines, endIndex+1, -1)
-	}
+	log.V(1).Info("Instance is paused", "instance", instanceNamespacedName)
 
-	// Update the ConfigMap
-	if err := r.Update(ctx, cm); err != nil {
-		return fmt.Errorf("failed to update the ConfigMap: %w", err)
+	if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypePaused) {
+		return true, nil
 	}
 
 	// Record the event
-	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonRemoving,
-		"Finished removal of resources from the ConfigMap.")
+	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonPaused,
+		"The reconciliation is paused by the annotation.")
 
 	// Update the status condition
 	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-		Type:    conditionTypeReady,
-		Status:  metav1.ConditionFalse,
-		Reason:  reasonRemoving,
-		Message: "Finished the removal of resources from the ConfigMap.",
+		Type:               conditionTypePaused,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonPaused,
+		Message:            "The reconciliation is paused by the annotation.",
 	})
-	if err := r.Status().Update(ctx, instance); err != nil {
-		return fmt.Errorf(
+	if err := r.updateStatus(ctx, instance); err != nil {
+		return true, fmt.Errorf(
 			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
 			instanceNamespacedName, err)
 	}
 
-	return nil
+	return true, nil
 }
 
-// addCustomResourceStateMetric adds resources into a ConfigMap.
-func (r *CustomResourceStateMetricsReconciler) addCustomResourceStateMetric(
-	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string) error {
-	log.V(1).Info("Processing addition of reources", "instance", instanceNamespacedName)
+// checkSuspended returns whether the instance is suspended and records it in
+// the Suspended status condition.
+func (r *CustomResourceStateMetricsReconciler) checkSuspended(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string,
+) (bool, error) {
+	if !instance.Spec.Suspend {
+		// Clear the condition (persisted with the next status update)
+		if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeSuspended) {
+			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+				Type:               conditionTypeSuspended,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: instance.Generation,
+				Reason:             reasonResumed,
+				Message:            "The writes of the resources are resumed.",
+			})
+		}
 
-	// Markers for the data separation in the final ConfigMap
-	dataMarkerBegin := fmt.Sprintf("# BEGIN CustomResourceStateMetrics %s", instanceNamespacedName)
-	dataMarkerEnd := fmt.Sprintf("# END CustomResourceStateMetrics %s", instanceNamespacedName)
-	dataYaml, err := r.decodeData(instance.Spec.Resources)
-	if err != nil {
-		return fmt.Errorf("failed to decode resource data: %w", err)
+		return false, nil
 	}
 
-	// Define ConfigMap properties
-	cmName := instance.Spec.ConfigMap.Name
-	cmNamespace := instance.Spec.ConfigMap.Namespace
-	cmKey := instance.Spec.ConfigMap.Key
-	cmDataHeader := "kind: CustomResourceStateMetrics\nspec:\n  resources:\n"
-	cmData := fmt.Sprintf(
-		"%s\n%s%s\n",
-		dataMarkerBegin,
-		dataYaml,
-		dataMarkerEnd,
-	)
+	log.V(1).Info("Instance is suspended", "instance", instanceNamespacedName)
 
-	// If no Namespace was specified, use the namespace from the instance
-	if cmNamespace == "" {
-		cmNamespace = instance.Namespace
+	if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeSuspended) {
+		return true, nil
 	}
 
-	// Namespaced name of the ConfigMap
-	cmNamespacedName := utils.NamespacedName(cmName, cmNamespace)
+	// Record the event
+	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonSuspended,
+		"The writes of the resources are suspended.")
 
-	// Check if the ConfigMap exists
-	cm := &corev1.ConfigMap{}
-	err = r.Get(ctx, types.NamespacedName{
-		Name:      cmName,
-		Namespace: cmNamespace,
-	}, cm)
+	// Update the status condition
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeSuspended,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonSuspended,
+		Message:            "The writes of the resources are suspended.",
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		return true, fmt.Errorf(
+			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+			instanceNamespacedName, err)
+	}
+
+	return true, nil
+}
+
+// checkWindow checks whether the change window of the instance is open. If
+// it's not, it returns the time after which it should be checked again.
+func (r *CustomResourceStateMetricsReconciler) checkWindow(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string,
+) (time.Duration, bool, error) {
+	cron, err := schedule.Parse(instance.Spec.Schedule.Cron)
 	if err != nil {
-		if client.IgnoreNotFound(err) != nil {
-			return fmt.Errorf("failed to get ConfigMap: %w", err)
-		}
+		return 0, false, withReason(resultInvalidSchedule, err)
+	}
 
-		// Create a new ConfigMap because it doesn't exist yet
-		log.V(1).Info(
-			"Creating a new ConfigMap",
-			"instance", instanceNamespacedName,
-			"configMap", cmNamespacedName)
+	location, err := time.LoadLocation(instance.Spec.Schedule.TimeZone)
+	if err != nil {
+		return 0, false, withReason(resultInvalidSchedule, fmt.Errorf("failed to load the time zone: %w", err))
+	}
 
-		cm := &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      cmName,
-				Namespace: cmNamespace,
-			},
-			Data: make(map[string]string),
-		}
+	window := &schedule.Window{
+		Schedule: cron,
+		Duration: instance.Spec.Schedule.Duration.Duration,
+		Location: location,
+	}
 
-		cm.Data[cmKey] = cmDataHeader
-		cm.Data[cmKey] += cmData
+	now := time.Now()
 
-		if err := r.Create(ctx, cm); err != nil {
-			return fmt.Errorf("failed to create a new ConfigMap: %w", err)
+	if window.Open(now) {
+		// Clear the condition (persisted with the next status update)
+		if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypePendingWindow) {
+			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+				Type:               conditionTypePendingWindow,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: instance.Generation,
+				Reason:             reasonWindowOpen,
+				Message:            "The change window is open.",
+			})
 		}
 
-		// Record the event
-		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonAdding,
-			"Finished the addition of resources into a newly created ConfigMap.")
+		return 0, true, nil
+	}
 
-		// Update the status condition
-		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-			Type:    conditionTypeReady,
-			Status:  metav1.ConditionTrue,
-			Reason:  reasonAdding,
-			Message: "Finished the addition of resources into a newly created ConfigMap.",
-		})
-		if err := r.Status().Update(ctx, instance); err != nil {
-			return fmt.Errorf(
-				"failed to update status for the CustomResourceStateMetrics instance %s: %w",
-				instanceNamespacedName, err)
-		}
+	requeueAfter := windowRecheckInterval
+	message := "Changes are deferred until the change window opens."
 
-		return nil
+	if next, found := window.Next(now); found {
+		requeueAfter = next.Sub(now)
+		message = fmt.Sprintf("Changes are deferred until the change window opens at %s.", next.Format(time.RFC3339))
 	}
 
-	log.V(1).Info(
-		"Updating the existing ConfigMap",
-		"instance", instanceNamespacedName,
-		"configMap", cmNamespacedName)
+	log.V(1).Info("Change window is closed", "instance", instanceNamespacedName, "requeueAfter", requeueAfter)
+
+	// Update the status condition
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypePendingWindow,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonPendingWindow,
+		Message:            message,
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		return 0, false, fmt.Errorf(
+			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+			instanceNamespacedName, err)
+	}
 
-	// Try to find the block in the ConfigMap
-	lines := strings.Split(cm.Data[cmKey], "\n")
-	found, beginIndex, endIndex := r.findBlock(instanceNamespacedName, lines)
+	return requeueAfter, false, nil
+}
 
-	// Set the header if the ConfigMap is in its default state containing only the empty map
-	if strings.TrimSpace(cm.Data[cmKey]) == "{}" {
-		cm.Data[cmKey] = cmDataHeader
+// rollout restarts the kube-state-metrics Deployment referenced by the
+// instance or, if enabled, the Deployments mounting the ConfigMap so they load
+// the new content.
+func (r *CustomResourceStateMetricsReconciler) rollout(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap, cmKey string) {
+	if r.Restarter == nil {
+		return
 	}
 
-	if found {
-		if strings.TrimSuffix(cmData, "\n") == strings.Join(lines[beginIndex:endIndex+1], "\n") {
-			log.V(1).Info(
-				"The same block already exists",
-				"instance", instanceNamespacedName,
-				"configMap", cmNamespacedName,
-				"position", fmt.Sprintf("%d;%d", beginIndex, endIndex))
+	var deployments []types.NamespacedName
 
-			// Record the event
-			r.Recorder.Event(instance, corev1.EventTypeNormal, reasonAdding,
-				"The same resources already exist in the ConfigMap.")
+	if instance.Spec.Reload != nil {
+		deployment, err := reloadDeployment(instance, cm.Namespace)
+		if err != nil {
+			log.Error(
+				err,
+				"Refusing to restart the referenced Deployment",
+				"instance", utils.NamespacedName(instance.Name, instance.Namespace))
 
-			// Update the status condition
-			meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-				Type:    conditionTypeReady,
-				Status:  metav1.ConditionTrue,
-				Reason:  reasonAdding,
-				Message: "The same resources already exist in the ConfigMap.",
-			})
-			if err := r.Status().Update(ctx, instance); err != nil {
-				return fmt.Errorf(
-					"failed to update status for the CustomResourceStateMetrics instance %s: %w",
-					instanceNamespacedName, err)
-			}
+			// Record the event
+			r.Recorder.Event(instance, corev1.EventTypeWarning, reasonSyncFailed, err.Error()+".")
 
-			return nil
+			return
 		}
 
-		log.V(1).Info(
-			"Replacing existing block in the existing ConfigMap",
-			"instance", instanceNamespacedName,
-			"configMap", cmNamespacedName,
-			"position", fmt.Sprintf("%d;%d", beginIndex, endIndex))
+		deployments = append(deployments, deployment)
+	} else if r.runtimeConfig().RestartMounting {
+		mounting, err := r.deploymentsMountingTarget(ctx, targetKind(instance), cm.Namespace, cm.Name)
+		if err != nil {
+			log.Error(
+				err,
+				"Failed to find Deployments mounting the ConfigMap",
+				"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+				"configMap", utils.NamespacedName(cm.Name, cm.Namespace))
 
-		// Reset the current data and fill it with individual fragments
-		cm.Data[cmKey] = ""
+			return
+		}
 
-		if beginIndex > 0 {
-			cm.Data[cmKey] += r.joinLines(lines, 0, beginIndex-1)
+		for i := range mounting {
+			deployments = append(deployments, client.ObjectKeyFromObject(&mounting[i]))
 		}
+	}
+
+	checksum := utils.Hash(cm.Data[cmKey])
 
-		cm.Data[cmKey] += cmData
+	for _, deployment := range deployments {
+		if instance.Spec.RolloutStrategy == ksmv1.RolloutStrategyBatched {
+			r.Restarter.Schedule(deployment, checksum)
 
-		if endIndex < len(lines)-1 {
-			cm.Data[cmKey] += r.joinLines(lines, endIndex+1, -1)
+			continue
 		}
-	} else {
-		log.V(1).Info(
-			"Appending block at the end of the existing ConfigMap",
-			"instance", instanceNamespacedName,
-			"configMap", cmNamespacedName)
 
-		cm.Data[cmKey] += cmData
+		if err := r.Restarter.Restart(ctx, deployment, checksum); err != nil {
+			log.Error(
+				err,
+				"Failed to restart Deployment",
+				"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+				"deployment", utils.NamespacedName(deployment.Name, deployment.Namespace))
+		}
 	}
+}
 
-	// Update the ConfigMap
-	if err := r.Update(ctx, cm); err != nil {
-		return fmt.Errorf("failed to update ConfigMap: %w", err)
+// notify sends the sync event to the notification sink if it's configured.
+func (r *CustomResourceStateMetricsReconciler) notify(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, eventType, message string) {
+	if r.Notifier == nil {
+		return
 	}
 
-	// Record the event
-	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonAdding,
-		"Finished the addition of resources into an existing ConfigMap.")
+	event := notifier.Event{
+		Type:      eventType,
+		Name:      instance.Name,
+		Namespace: instance.Namespace,
+		Message:   message,
+		Timestamp: time.Now().UTC(),
+	}
 
-	// Update the status condition
-	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
-		Type:    conditionTypeReady,
-		Status:  metav1.ConditionTrue,
-		Reason:  reasonAdding,
-		Message: "Finished the addition of resources into an existing ConfigMap.",
-	})
-	if err := r.Status().Update(ctx, instance); err != nil {
-		return fmt.Errorf(
-			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
-			instanceNamespacedName, err)
+	if target := instance.Status.ConfigMap; target != nil {
+		event.ConfigMapName = target.Name
+		event.ConfigMapNamespace = target.Namespace
+		event.ConfigMapKey = target.Key
 	}
 
-	return nil
+	if err := r.Notifier.Notify(ctx, event); err != nil {
+		log.Error(
+			err,
+			"Failed to send notification",
+			"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+			"type", eventType)
+	}
 }
 
-// decodeData decodes raw resources into YAML string.
-func (r *CustomResourceStateMetricsReconciler) decodeData(resources []runtime.RawExtension) (string, error) {
-	data := Data{}
+// recordConfigChange records an event on the Deployments (usually
+// kube-state-metrics) mounting the changed ConfigMap so their restarts can be
+// correlated with the instance which caused the change.
+func (r *CustomResourceStateMetricsReconciler) recordConfigChange(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, action string) {
+	target := instance.Status.ConfigMap
+	if target == nil {
+		return
+	}
 
-	// Marshal raw portions of the resources into a structure
-	for i := range resources {
-		// Convert the raw structure to a JSON bytes array
-		jsonBytes, err := resources[i].MarshalJSON()
-		if err != nil {
-			return "", fmt.Errorf("failed to encode resources #%d to JSON: %w", i, err)
-		}
+	namespaces := target.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{target.Namespace}
+	}
+
+	for _, namespace := range namespaces {
+		deployments, err := r.deploymentsMountingTarget(ctx, targetKind(instance), namespace, target.Name)
+		if err != nil {
+			log.Error(
+				err,
+				"Failed to find Deployments mounting the ConfigMap",
+				"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+				"configMap", utils.NamespacedName(target.Name, namespace))
+
+			continue
+		}
+
+		for i := range deployments {
+			r.Recorder.Eventf(&deployments[i], corev1.EventTypeNormal, reasonConfigChanged,
+				"Resources of the CustomResourceStateMetrics %s/%s were %s in the ConfigMap %s (key %s).",
+				instance.Namespace, instance.Name, action, target.Name, target.Key)
+		}
+	}
+}
+
+// deploymentsMountingTarget returns the Deployments mounting the ConfigMap or
+// the Secret the resources are written into.
+func (r *CustomResourceStateMetricsReconciler) deploymentsMountingTarget(
+	ctx context.Context, kind ksmv1.TargetKind, namespace, name string) ([]appsv1.Deployment, error) {
+	if kind == ksmv1.TargetKindSecret {
+		return discovery.DeploymentsMountingSecret(ctx, r.Client, namespace, name)
+	}
+
+	return discovery.DeploymentsMountingConfigMap(ctx, r.Client, namespace, name)
+}
+
+// registerInstance registers the instance and increments the metric counter
+// of its Namespace unless the instance is already registered.
+func (r *CustomResourceStateMetricsReconciler) registerInstance(instance *ksmv1.CustomResourceStateMetrics) {
+	instanceNamespacedName := utils.NamespacedName(instance.Name, instance.Namespace)
+
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+
+	if _, ok := resources[instanceNamespacedName]; ok {
+		return
+	}
+
+	resources[instanceNamespacedName] = 1
+
+	if r.MetricsRecorder != nil {
+		r.MetricsRecorder.IncCRSMTotal(instance.Namespace)
+	}
+}
+
+// deregisterInstance deregisters the instance and decrements the metric
+// counter of its Namespace if the instance was registered.
+func (r *CustomResourceStateMetricsReconciler) deregisterInstance(instance *ksmv1.CustomResourceStateMetrics) {
+	instanceNamespacedName := utils.NamespacedName(instance.Name, instance.Namespace)
+
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+
+	if _, ok := resources[instanceNamespacedName]; !ok {
+		return
+	}
+
+	delete(resources, instanceNamespacedName)
+
+	if r.MetricsRecorder != nil {
+		r.MetricsRecorder.DecCRSMTotal(instance.Namespace)
+	}
+}
+
+// InstanceCounter registers the instances whose resources were already added
+// once the operator starts so the metric counters are accurate right after
+// the restart.
+type InstanceCounter struct {
+	Reconciler *CustomResourceStateMetricsReconciler
+}
+
+// NeedLeaderElection makes the counting run only on the leader which
+// reconciles the instances.
+func (c *InstanceCounter) NeedLeaderElection() bool {
+	return true
+}
+
+// Start counts the instances once.
+func (c *InstanceCounter) Start(ctx context.Context) error {
+	r := c.Reconciler
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+
+	if err := r.List(ctx, instances); err != nil {
+		log.Error(err, "Failed to count the CustomResourceStateMetrics")
+
+		return nil
+	}
+
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+
+		if instance.DeletionTimestamp.IsZero() && controllerutil.ContainsFinalizer(instance, FinalizerName) {
+			r.registerInstance(instance)
+		}
+	}
+
+	return nil
+}
+
+// recordGVKUsage records the group/kind pairs the instance defines metrics for
+// and updates the usage metric of the affected pairs.
+func (r *CustomResourceStateMetricsReconciler) recordGVKUsage(
+	instanceNamespacedName string, kinds []ksmv1.CustomResourceStateMetricsKind) {
+	current := make(map[ksm.GroupVersionKind]struct{})
+
+	for _, kind := range kinds {
+		// Count the group/kind pairs regardless of the version
+		current[ksm.GroupVersionKind{Group: kind.Group, Kind: kind.Kind}] = struct{}{}
+	}
+
+	gvkUsageMu.Lock()
+	defer gvkUsageMu.Unlock()
+
+	// Collect the pairs affected by the change
+	affected := make(map[ksm.GroupVersionKind]struct{})
+
+	for gvk := range gvkUsage[instanceNamespacedName] {
+		affected[gvk] = struct{}{}
+	}
+
+	for gvk := range current {
+		affected[gvk] = struct{}{}
+	}
+
+	if len(current) > 0 {
+		gvkUsage[instanceNamespacedName] = current
+	} else {
+		delete(gvkUsage, instanceNamespacedName)
+	}
+
+	if r.MetricsRecorder == nil {
+		return
+	}
+
+	for gvk := range affected {
+		count := 0
+
+		for _, gvks := range gvkUsage {
+			if _, ok := gvks[gvk]; ok {
+				count++
+			}
+		}
+
+		r.MetricsRecorder.SetGVKUsage(gvk.Group, gvk.Kind, count)
+	}
+}
+
+// deleteCustomResourceStateMetric removes resources from a ConfigMap. It
+// returns whether the content of the ConfigMap key changed.
+func (r *CustomResourceStateMetricsReconciler) deleteCustomResourceStateMetric(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string) (bool, error) {
+	log.V(1).Info("Processing deletion of resources", "instance", instanceNamespacedName)
+
+	// Keep the resources in the ConfigMap
+	if instance.Spec.DeletionPolicy == ksmv1.DeletionPolicyRetain {
+		log.V(1).Info("Retaining resources due to the deletion policy", "instance", instanceNamespacedName)
+
+		// Record the event
+		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonRetaining,
+			"Retaining resources in the ConfigMap due to the deletion policy.")
+
+		return false, nil
+	}
+
+	var cmName, cmNamespace, cmKey, cmPath string
+	var cmNamespaces []string
+	var err error
+
+	// Define ConfigMap properties, preferring the ConfigMap the resources were written into
+	if target := instance.Status.ConfigMap; target != nil && target.Name != "" {
+		cmName, cmNamespace, cmKey, cmPath = target.Name, target.Namespace, target.Key, target.Path
+		cmNamespaces = target.Namespaces
+	} else if cmName, cmNamespace, cmKey, err = r.configMapTarget(ctx, instance); err != nil {
+		return false, withReason(resultTargetError, err)
+	} else {
+		cmPath = instance.Spec.ConfigMap.Path
+	}
+
+	if len(cmNamespaces) == 0 {
+		return r.removeFromConfigMap(ctx, instance, instanceNamespacedName, cmName, cmNamespace, cmKey, cmPath)
+	}
+
+	// Remove the resources from all the replicated ConfigMaps
+	changed := false
+
+	for _, namespace := range cmNamespaces {
+		replicaChanged, err := r.removeFromConfigMap(
+			ctx, instance, instanceNamespacedName, cmName, namespace, cmKey, cmPath)
+		changed = changed || replicaChanged
+
+		if err != nil {
+			return changed, err
+		}
+	}
+
+	return changed, nil
+}
+
+// removeFromConfigMap removes resources from the specific ConfigMap. It
+// returns whether the content of the ConfigMap key changed.
+func (r *CustomResourceStateMetricsReconciler) removeFromConfigMap(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName, cmName, cmNamespace,
+	cmKey, cmPath string) (bool, error) {
+	// Namespaced name of the ConfigMap
+	cmNamespacedName := utils.NamespacedName(cmName, cmNamespace)
+
+	// Prevent the concurrent reconciles from overwriting each other's changes
+	defer targetLocks.lock(types.NamespacedName{Name: cmName, Namespace: cmNamespace})()
+
+	// Check if the ConfigMap exists
+	cm := &corev1.ConfigMap{}
+	err := r.getConfigMap(ctx, types.NamespacedName{
+		Name:      cmName,
+		Namespace: cmNamespace,
+	}, targetKind(instance), cm)
+	if err != nil {
+		log.V(1).Info(
+			"ConfigMap doesn't exist",
+			"instance", instanceNamespacedName,
+			"configMap", cmNamespacedName)
+
+		// Record the event
+		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonRemoving,
+			"The ConfigMap with the resources doesn't exist.")
+
+		// Update the status condition
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeReady,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonRemoving,
+			Message:            "The ConfigMap with the resources doesn't exist.",
+		})
+		if err := r.updateStatus(ctx, instance); err != nil {
+			return false, fmt.Errorf(
+				"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+				instanceNamespacedName, err)
+		}
+
+		return false, nil
+	}
+
+	data, found, err := removeResources(cm.Data[cmKey], cmPath, instanceNamespacedName)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove resources from the ConfigMap: %w", err)
+	}
+
+	if !found {
+		log.V(1).Info(
+			"No resources found",
+			"instance", instanceNamespacedName,
+			"configMap", cmNamespacedName,
+			"path", cmPath)
+
+		return false, r.setResourcesMissing(ctx, instance, instanceNamespacedName)
+	}
+
+	r.reportMarkers(instance, instanceNamespacedName, cmNamespacedName, cmKey, cm.Data[cmKey], cmPath)
+
+	log.V(1).Info(
+		"Removing resources",
+		"instance", instanceNamespacedName,
+		"configMap", cmNamespacedName,
+		"path", cmPath)
+
+	originalData := cm.Data[cmKey]
+	cm.Data[cmKey] = data
+
+	return r.writeRemoval(ctx, instance, instanceNamespacedName, cm, cmKey, originalData)
+}
+
+// addCustomResourceStateMetric adds resources into a ConfigMap. It returns
+// whether the content of the ConfigMap key changed.
+func (r *CustomResourceStateMetricsReconciler) addCustomResourceStateMetric(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string) (bool, error) {
+	log.V(1).Info("Processing addition of reources", "instance", instanceNamespacedName)
+
+	// Write the resources even if they seem to be up to date
+	force := forceSyncRequested(instance)
+	if force {
+		log.Info("Forcing the sync of resources", "instance", instanceNamespacedName)
+
+		renderedResources.delete(instance.UID)
+
+		// Persisted with the next status update
+		instance.Status.ForceSync = instance.Annotations[ForceSyncAnnotation]
+	}
+
+	// Restore the recorded revision of the resources if requested
+	dataYaml, rolledBack, err := rollbackResources(instance)
+	if err != nil {
+		return false, withReason(resultInvalidResources, err)
+	}
+
+	if rolledBack {
+		// Report the rollback only once the requested revision differs from the written one
+		if utils.Hash(dataYaml) != instance.Status.BlockHash {
+			log.Info(
+				"Rolling back the resources",
+				"instance", instanceNamespacedName,
+				"revision", instance.Annotations[RollbackToAnnotation])
+
+			// Record the event
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, reasonRolledBack,
+				"The resources are rolled back to the revision %s.", instance.Annotations[RollbackToAnnotation])
+		}
+	} else if dataYaml, err = r.renderInstance(ctx, instance); err != nil {
+		return false, withReason(resultInvalidResources, err)
+	}
+
+	// Expose the number of the resources and the generated metric names
+	// (persisted with the next status update)
+	rendered := parseRendered(dataYaml)
+	instance.Status.ResourceCount = len(rendered)
+	instance.Status.MetricNames = ksm.MetricNames(rendered)
+	instance.Status.Kinds = resourceKinds(rendered)
+
+	// Refuse the metric names not allowed in the Namespace of the instance
+	if err := r.checkMetricNamePrefix(instance, instance.Status.MetricNames); err != nil {
+		return false, err
+	}
+
+	// Forget the conflict recorded for the previous spec
+	clearConflict(instance)
+
+	// Define ConfigMap properties
+	cmName, cmNamespace, cmKey, err := r.configMapTarget(ctx, instance)
+	if err != nil {
+		return false, withReason(resultTargetError, err)
+	}
+
+	// Resolve the Namespaces the ConfigMap is replicated into
+	var cmNamespaces []string
+	if replicated(instance) {
+		if cmNamespaces, err = r.selectedNamespaces(ctx, instance); err != nil {
+			return false, withReason(resultTargetError, err)
+		}
+	}
+
+	// Namespaces of the ConfigMaps the resources are written into
+	namespaces := cmNamespaces
+	if !replicated(instance) {
+		namespaces = []string{cmNamespace}
+	}
+
+	// Refuse the targets not allowed by the operator policy
+	if err := r.TargetPolicy.checkTargets(cmName, namespaces); err != nil {
+		return false, err
+	}
+
+	if specTargetKind(instance) == ksmv1.TargetKindSecret {
+		if err := r.TargetPolicy.checkSecretTargets(instance.Namespace, cmName, namespaces); err != nil {
+			return false, err
+		}
+	}
+
+	changed := false
+
+	// Remove the resources from the ConfigMaps in the Namespaces not selected
+	// anymore (before the status stops pointing to them)
+	if previous := instance.Status.ConfigMap; previous != nil {
+		for _, namespace := range previous.Namespaces {
+			if previous.Name == cmName && slices.Contains(namespaces, namespace) {
+				continue
+			}
+
+			log.V(1).Info(
+				"Removing resources from the ConfigMap in the Namespace not selected anymore",
+				"instance", instanceNamespacedName,
+				"configMap", utils.NamespacedName(previous.Name, namespace))
+
+			replicaChanged, err := r.removeFromConfigMap(
+				ctx, instance, instanceNamespacedName, previous.Name, namespace, previous.Key, previous.Path)
+			changed = changed || replicaChanged
+
+			if err != nil {
+				return changed, err
+			}
+		}
+	}
+
+	// Record the resolved ConfigMap (persisted with the next status update)
+	instance.Status.ConfigMap = &ksmv1.CustomResourceStateMetricsTarget{
+		Kind:       specTargetKind(instance),
+		Name:       cmName,
+		Namespace:  cmNamespace,
+		Key:        cmKey,
+		Path:       instance.Spec.ConfigMap.Path,
+		Namespaces: cmNamespaces,
+	}
+
+	// Refuse the metric names generated by the older instances writing into the
+	// same target
+	if err := r.checkMetricCollisions(ctx, instance); err != nil {
+		return changed, err
+	}
+
+	// Expose the rendered resources for inspection
+	if err := r.reconcileInspection(ctx, instance, instanceNamespacedName, cmKey, dataYaml); err != nil {
+		return changed, err
+	}
+
+	if len(namespaces) == 0 {
+		log.V(1).Info("No Namespace matches the Namespace selector", "instance", instanceNamespacedName)
+
+		// Record the event
+		r.Recorder.Event(instance, corev1.EventTypeWarning, reasonSyncFailed,
+			"No Namespace matches the Namespace selector of the ConfigMap.")
+
+		// Update the status condition
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeReady,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonSyncFailed,
+			Message:            "No Namespace matches the Namespace selector of the ConfigMap.",
+		})
+		if err := r.updateStatus(ctx, instance); err != nil {
+			return changed, fmt.Errorf(
+				"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+				instanceNamespacedName, err)
+		}
+
+		return changed, nil
+	}
+
+	// Write the resources into the ConfigMaps in all the Namespaces
+	for _, namespace := range namespaces {
+		replicaChanged, err := r.addToConfigMap(
+			ctx, instance, instanceNamespacedName, dataYaml, cmName, namespace, cmKey, force)
+		changed = changed || replicaChanged
+
+		if err != nil {
+			return changed, err
+		}
+	}
+
+	// Make kube-state-metrics read the ConfigMap if requested
+	if err := r.wireDeployment(ctx, instance, cmName, cmNamespace, cmKey); err != nil {
+		return changed, withReason(resultTargetError, err)
+	}
+
+	return changed, nil
+}
+
+// addToConfigMap adds resources into the specific ConfigMap. It returns
+// whether the content of the ConfigMap key changed. If forced, the recorded
+// hashes and the resync policy are ignored.
+func (r *CustomResourceStateMetricsReconciler) addToConfigMap(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName, dataYaml, cmName,
+	cmNamespace, cmKey string, force bool) (bool, error) {
+	// Namespaced name of the ConfigMap
+	cmNamespacedName := utils.NamespacedName(cmName, cmNamespace)
+
+	// Prevent the concurrent reconciles from overwriting each other's changes
+	defer targetLocks.lock(types.NamespacedName{Name: cmName, Namespace: cmNamespace})()
+
+	// Check if the ConfigMap exists
+	cm := &corev1.ConfigMap{}
+	err := r.getConfigMap(ctx, types.NamespacedName{
+		Name:      cmName,
+		Namespace: cmNamespace,
+	}, targetKind(instance), cm)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return false, fmt.Errorf("failed to get ConfigMap: %w", err)
+		}
+
+		// Create a new ConfigMap because it doesn't exist yet
+		log.V(1).Info(
+			"Creating a new ConfigMap",
+			"instance", instanceNamespacedName,
+			"configMap", cmNamespacedName)
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cmName,
+				Namespace: cmNamespace,
+			},
+			Data: make(map[string]string),
+		}
+
+		// The nested document is created from scratch
+		original := documentHeader()
+		if instance.Spec.ConfigMap.Path != "" {
+			original = ""
+		}
+
+		cm.Data[cmKey], _, err = mergeResources(original, instance.Spec.ConfigMap.Path, instanceNamespacedName, dataYaml)
+		if err != nil {
+			return false, fmt.Errorf("failed to merge resources into the ConfigMap: %w", err)
+		}
+
+		// Stage the content if the change must be approved first
+		staged, hash := false, ""
+		if instance.Spec.ConfigMap.Staged {
+			staged, hash = r.stageData(instance, cm, cmKey, original)
+		}
+
+		// Record the hashes so the content doesn't have to be parsed on no-op reconciles
+		r.recordBlockHashes(cm, cmKey, instanceNamespacedName, dataYaml, staged)
+
+		// Record the requested labels and annotations
+		setMetadata(cm, instanceNamespacedName, instance.Spec.ConfigMap.Labels, instance.Spec.ConfigMap.Annotations)
+
+		// Record whether the content is compressed
+		setCompressedKey(cm, cmKey, compressedKeySuffix(instance))
+
+		// Record whether the content is stored as JSON
+		setJSONKey(cm, cmKey, jsonFormat(instance, cmKey))
+
+		// Make the instance the owner of the ConfigMap if requested
+		if err := r.setOwnerReference(instance, cm, true); err != nil {
+			return false, err
+		}
+
+		// Create the immutable copy of the content if requested
+		if err := r.rotateImmutable(ctx, instance, cm); err != nil {
+			return false, err
+		}
+
+		if err := r.writeConfigMap(ctx, instance, cm, false); err != nil {
+			// Finish the addition once the buffered write is flushed
+			if isBuffered(err) && !staged {
+				r.WriteBuffer.await(instance.UID, awaitedWrite{key: client.ObjectKeyFromObject(cm), block: dataYaml})
+			}
+
+			return false, fmt.Errorf("failed to create a new ConfigMap: %w", err)
+		}
+
+		if staged {
+			return false, r.setPendingApproval(ctx, instance, instanceNamespacedName, cmKey, hash)
+		}
+
+		// Restart kube-state-metrics to load the new content
+		r.rollout(ctx, instance, cm, cmKey)
+
+		// Record the events
+		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonSynced,
+			"Finished the addition of resources into a newly created ConfigMap.")
+		r.recordTargetEvent(instance, cm, reasonCreating, "Block for %s added.", instanceNamespacedName)
+
+		// Record what was written and when (persisted with the status update)
+		recordSync(instance, dataYaml)
+
+		// Update the status conditions
+		setSynced(instance, "The resources were written into a newly created ConfigMap.")
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeReady,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonSynced,
+			Message:            "Finished the addition of resources into a newly created ConfigMap.",
+		})
+		if err := r.updateStatus(ctx, instance); err != nil {
+			return false, fmt.Errorf(
+				"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+				instanceNamespacedName, err)
+		}
+
+		return true, nil
+	}
+
+	// Skip parsing of the content if the recorded hashes confirm there is nothing to do
+	if !force && instance.Spec.ConfigMap.Path == "" && blockUnchanged(cm, cmKey, instanceNamespacedName, dataYaml) {
+		log.V(1).Info(
+			"The same block already exists according to the recorded hashes",
+			"instance", instanceNamespacedName,
+			"configMap", cmNamespacedName)
+
+		return r.keepAddition(ctx, instance, instanceNamespacedName, cm, cmKey)
+	}
+
+	log.V(1).Info(
+		"Updating the existing ConfigMap",
+		"instance", instanceNamespacedName,
+		"configMap", cmNamespacedName)
+
+	// Keep the original content in case the change gets staged
+	originalData := cm.Data[cmKey]
+
+	cmPath := instance.Spec.ConfigMap.Path
+
+	if r.DeduplicateResources {
+		deduplicated, owners, err := deduplicateResources(cm.Data[cmKey], cmPath, instanceNamespacedName, dataYaml)
+		if err != nil {
+			return false, fmt.Errorf("failed to deduplicate resources: %w", err)
+		}
+
+		r.setDuplicated(instance, cmNamespacedName, cmKey, owners)
+
+		dataYaml = deduplicated
+	}
+
+	data, adopted, err := mergeResources(cm.Data[cmKey], cmPath, instanceNamespacedName, dataYaml)
+	if err != nil {
+		return false, fmt.Errorf("failed to merge resources into the ConfigMap: %w", err)
+	}
+
+	// Preserve the resources written by hand before the operator took over
+	if r.TakeOverManualResources {
+		var tookOver bool
+
+		if data, tookOver, err = takeOverResources(data, cmPath); err != nil {
+			return false, fmt.Errorf("failed to take over the resources in the ConfigMap: %w", err)
+		}
+
+		if tookOver {
+			log.Info(
+				"Taking over the unmanaged resources in the existing ConfigMap",
+				"instance", instanceNamespacedName,
+				"configMap", cmNamespacedName)
+
+			// Record the event
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, reasonTakingOver,
+				"Preserving the unmanaged resources found in the ConfigMap as the %s block.", ManualOwner)
+		}
+	}
+
+	if data == cm.Data[cmKey] {
+		log.V(1).Info(
+			"The same resources already exist",
+			"instance", instanceNamespacedName,
+			"configMap", cmNamespacedName)
+
+		return r.keepAddition(ctx, instance, instanceNamespacedName, cm, cmKey)
+	}
+
+	if instance.Spec.ResyncPolicy == ksmv1.ResyncPolicyNever && !force {
+		if _, found, _ := removeResources(cm.Data[cmKey], cmPath, instanceNamespacedName); found {
+			log.V(1).Info(
+				"Keeping the existing resources due to the resync policy",
+				"instance", instanceNamespacedName,
+				"configMap", cmNamespacedName)
+
+			if err := r.writeMetadata(ctx, instance, instanceNamespacedName, cm, cmKey); err != nil {
+				return false, err
+			}
+
+			return false, r.setResourcesExist(ctx, instance, instanceNamespacedName)
+		}
+	}
+
+	// Restore the resources changed by another writer
+	restore := blockModified(cm, cmKey, instanceNamespacedName, dataYaml, data)
+	if restore {
+		log.Info(
+			"Restoring the resources changed by another writer",
+			"instance", instanceNamespacedName,
+			"configMap", cmNamespacedName)
+
+		r.setConflict(instance, cmNamespacedName, cmKey)
+	}
+
+	r.reportMarkers(instance, instanceNamespacedName, cmNamespacedName, cmKey, originalData, cmPath)
+
+	if adopted {
+		log.V(1).Info(
+			"Adopting identical unmanaged resources in the existing ConfigMap",
+			"instance", instanceNamespacedName,
+			"configMap", cmNamespacedName)
+
+		// Record the event
+		r.Recorder.Event(instance, corev1.EventTypeNormal, reasonAdopting,
+			"Adopting identical unmanaged resources found in the ConfigMap.")
+	}
+
+	log.V(1).Info(
+		"Merging resources into the existing ConfigMap",
+		"instance", instanceNamespacedName,
+		"configMap", cmNamespacedName,
+		"path", cmPath)
+
+	cm.Data[cmKey] = data
+
+	return r.writeAddition(ctx, instance, instanceNamespacedName, cm, cmKey, dataYaml, originalData, restore)
+}
+
+// keepAddition handles the resources already present in the ConfigMap. If
+// they were written by the flushed buffered write, the addition is finished
+// now, otherwise the resources are only recorded as existing.
+func (r *CustomResourceStateMetricsReconciler) keepAddition(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string,
+	cm *corev1.ConfigMap, cmKey string) (bool, error) {
+	write, flushed, err := r.flushedAddition(instance, cm)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.writeMetadata(ctx, instance, instanceNamespacedName, cm, cmKey); err != nil {
+		return false, err
+	}
+
+	if flushed {
+		log.V(1).Info(
+			"Finishing the addition of the flushed write",
+			"instance", instanceNamespacedName,
+			"configMap", utils.NamespacedName(cm.Name, cm.Namespace))
+
+		return r.finishAddition(ctx, instance, instanceNamespacedName, cm, cmKey, write)
+	}
+
+	return false, r.setResourcesExist(ctx, instance, instanceNamespacedName)
+}
+
+// configMapTarget resolves the name, Namespace and key of the ConfigMap (or
+// the Secret) where the resources of the instance are written into.
+func (r *CustomResourceStateMetricsReconciler) configMapTarget(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics) (string, string, string, error) {
+	// The Secret takes precedence over the ConfigMap
+	if secret := targetSecret(instance); secret != nil {
+		namespace, key := secret.Namespace, secret.Key
+
+		if namespace == "" {
+			namespace = instance.Namespace
+		}
+
+		if key == "" {
+			key = r.runtimeConfig().DefaultKey
+		}
+
+		return secret.Name, namespace, key, nil
+	}
+
+	cmName := instance.Spec.ConfigMap.Name
+	cmNamespace := instance.Spec.ConfigMap.Namespace
+	cmKey := instance.Spec.ConfigMap.Key
+
+	if cmKey == "" {
+		cmKey = r.runtimeConfig().DefaultKey
+	}
+
+	// Use the operator default if no ConfigMap was specified
+	if cmName == "" && !instance.Spec.ConfigMap.Discover && r.DefaultConfigMap.Name != "" {
+		cmName = r.DefaultConfigMap.Name
+
+		if cmNamespace == "" {
+			cmNamespace = r.DefaultConfigMap.Namespace
+		}
+	}
+
+	// Discover the ConfigMap if requested or if there is no ConfigMap to be used
+	if instance.Spec.ConfigMap.Discover || cmName == "" {
+		// Resolve the ConfigMap mounted by the kube-state-metrics Deployment
+		target, err := discovery.FindTarget(ctx, r.Client, cmNamespace)
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to discover the kube-state-metrics ConfigMap: %w", err)
+		}
+
+		log.V(1).Info(
+			"Discovered kube-state-metrics ConfigMap",
+			"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+			"deployment", utils.NamespacedName(target.Deployment, target.Namespace),
+			"configMap", utils.NamespacedName(target.Name, target.Namespace),
+			"key", target.Key)
+
+		return target.Name, target.Namespace, target.Key, nil
+	}
+
+	// If no Namespace was specified, use the namespace from the instance
+	if cmNamespace == "" {
+		cmNamespace = instance.Namespace
+	}
+
+	return cmName, cmNamespace, cmKey, nil
+}
+
+// reconcileInspection writes the rendered resources of the instance into a
+// dedicated ConfigMap in the instance Namespace or removes it if the
+// inspection is disabled.
+func (r *CustomResourceStateMetricsReconciler) reconcileInspection(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName, cmKey,
+	dataYaml string) error {
+	// The inspection ConfigMap is not written in the dry-run mode either
+	if r.DryRun {
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name + inspectionSuffix,
+			Namespace: instance.Namespace,
+		},
+	}
+
+	if !instance.Spec.Inspect {
+		// Remove the inspection ConfigMap if it was created before
+		if err := r.Get(ctx, client.ObjectKeyFromObject(cm), cm); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		if !metav1.IsControlledBy(cm, instance) {
+			return nil
+		}
+
+		log.V(1).Info(
+			"Deleting inspection ConfigMap",
+			"instance", instanceNamespacedName,
+			"configMap", utils.NamespacedName(cm.Name, cm.Namespace))
+
+		if err := r.Delete(ctx, cm); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete the inspection ConfigMap: %w", err)
+		}
+
+		return nil
+	}
+
+	log.V(1).Info(
+		"Writing inspection ConfigMap",
+		"instance", instanceNamespacedName,
+		"configMap", utils.NamespacedName(cm.Name, cm.Namespace))
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Data = map[string]string{
+			cmKey: dataHeader + dataYaml,
+		}
+
+		return controllerutil.SetControllerReference(instance, cm, r.Scheme)
+	}); err != nil {
+		return fmt.Errorf("failed to write the inspection ConfigMap: %w", err)
+	}
+
+	return nil
+}
+
+// getConfigMap gets the ConfigMap preferring its buffered desired state. The
+// Secret is converted into the ConfigMap, the compressed content is
+// decompressed and the JSON content is converted into YAML.
+func (r *CustomResourceStateMetricsReconciler) getConfigMap(
+	ctx context.Context, key types.NamespacedName, kind ksmv1.TargetKind, cm *corev1.ConfigMap) error {
+	if kind == ksmv1.TargetKindSecret {
+		secret := &corev1.Secret{}
+
+		if err := r.Get(ctx, key, secret); err != nil {
+			return err
+		}
+
+		configMapFromSecret(secret).DeepCopyInto(cm)
+		recognizeMarkerFormats(cm)
+
+		return decodeJSONData(cm)
+	}
+
+	if r.WriteBuffer != nil {
+		if buffered, ok := r.WriteBuffer.get(key); ok {
+			buffered.DeepCopyInto(cm)
+
+			return nil
+		}
+	}
+
+	if err := r.Get(ctx, key, cm); err != nil {
+		return err
+	}
+
+	return loadData(cm)
+}
+
+// writeConfigMap applies the managed fields of the ConfigMap (or of the
+// Secret). The write is refused if the ConfigMap would exceed the size limit
+// and the write of the ConfigMap is buffered if the API server is
+// unreachable or if the writes are batched. Nothing is written in the dry-run
+// mode. If forced, the fields owned by other managers are taken over.
+func (r *CustomResourceStateMetricsReconciler) writeConfigMap(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap, force bool) error {
+	kind := targetKind(instance)
+
+	if err := r.checkSize(instance, cm); err != nil {
+		return err
+	}
+
+	if err := r.checkAccess(ctx, kind, cm); err != nil {
+		return err
+	}
+
+	// Only record what would be written
+	if r.DryRun {
+		return r.dryRunWrite(ctx, instance, cm)
+	}
+
+	// Merge the write with the writes of the other instances of the batch (the
+	// forced write is applied on its own)
+	if r.WriteBuffer != nil && r.WriteBuffer.batching() && kind != ksmv1.TargetKindSecret && !force {
+		r.WriteBuffer.batch(cm)
+
+		return errWriteBatched
+	}
+
+	opts := []client.ApplyOption{}
+	if force {
+		opts = append(opts, client.ForceOwnership)
+	}
+
+	var err error
+
+	if kind == ksmv1.TargetKindSecret {
+		err = applySecret(ctx, r.Client, cm, opts...)
+	} else {
+		err = applyConfigMap(ctx, r.Client, cm, opts...)
+	}
+
+	if err == nil {
+		r.recordConfigMapMetrics(cm)
+	}
+
+	// Surface the fields owned by other managers (e.g. Helm or kubectl)
+	if isFieldConflict(err) {
+		return withReason(resultFieldConflict, err)
+	}
+
+	// The content of the Secrets is not kept in memory
+	if r.WriteBuffer == nil || r.WriteBuffer.MaxAge <= 0 || kind == ksmv1.TargetKindSecret {
+		return withReason(resultWriteError, err)
+	}
+
+	if isUnavailable(err) {
+		log.Info(
+			"API server is unreachable, buffering the write",
+			"configMap", utils.NamespacedName(cm.Name, cm.Namespace))
+
+		r.WriteBuffer.add(cm)
+
+		return errWriteBuffered
+	}
+
+	if err == nil {
+		// The buffered state was superseded
+		r.WriteBuffer.remove(client.ObjectKeyFromObject(cm))
+	}
+
+	return withReason(resultWriteError, err)
+}
+
+// isBuffered returns true if the write of the ConfigMap was buffered or
+// batched instead of written.
+func isBuffered(err error) bool {
+	return errors.Is(err, errWriteBuffered) || errors.Is(err, errWriteBatched)
+}
+
+// bufferedResult returns the result of a reconciliation whose write was
+// buffered. The instance is reconciled again once the buffered write expires
+// or once the batched write is flushed.
+func (r *CustomResourceStateMetricsReconciler) bufferedResult(
+	instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string, err error) ctrl.Result {
+	log.V(1).Info("Write was buffered", "instance", instanceNamespacedName)
+
+	reason := reasonWriteBuffered
+	message := "The write of the ConfigMap was buffered until the API server is reachable."
+	requeueAfter := r.WriteBuffer.MaxAge
+
+	if errors.Is(err, errWriteBatched) {
+		reason = reasonWriteBatched
+		message = "The write of the ConfigMap was batched with the writes of other instances."
+		requeueAfter = r.WriteBuffer.BatchWindow + r.WriteBuffer.Interval
+	}
+
+	// Update the status conditions (persisted with the next status update)
+	setNotSynced(instance, reason, message)
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+
+	return ctrl.Result{RequeueAfter: requeueAfter}
+}
+
+// writeAddition writes the ConfigMap with the added resources and updates
+// the status of the instance. If restoring the resources changed by another
+// writer, the ownership of the key is taken over from that writer.
+func (r *CustomResourceStateMetricsReconciler) writeAddition(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string,
+	cm *corev1.ConfigMap, cmKey, dataYaml, originalData string, restore bool) (bool, error) {
+	// Stage the content if the change must be approved first
+	staged, hash := false, ""
+	if instance.Spec.ConfigMap.Staged {
+		staged, hash = r.stageData(instance, cm, cmKey, originalData)
+	}
+
+	// Record the hashes so the content doesn't have to be parsed on no-op reconciles
+	r.recordBlockHashes(cm, cmKey, instanceNamespacedName, dataYaml, staged)
+
+	// Record the requested labels and annotations
+	setMetadata(cm, instanceNamespacedName, instance.Spec.ConfigMap.Labels, instance.Spec.ConfigMap.Annotations)
+
+	// Record whether the content is compressed
+	setCompressedKey(cm, cmKey, compressedKeySuffix(instance))
+
+	// Record whether the content is stored as JSON
+	setJSONKey(cm, cmKey, jsonFormat(instance, cmKey))
+
+	// Keep the owner of the ConfigMap only while it's the only writer
+	if err := r.setOwnerReference(instance, cm, false); err != nil {
+		return false, err
+	}
+
+	// Create the immutable copy of the content if requested
+	if err := r.rotateImmutable(ctx, instance, cm); err != nil {
+		return false, err
+	}
+
+	// Keep the previous content if requested
+	if err := r.backupConfigMap(ctx, instance, cm, cmKey, originalData); err != nil {
+		return false, err
+	}
+
+	_, replaced, _ := removeResources(originalData, instance.Spec.ConfigMap.Path, instanceNamespacedName)
+	write := awaitedWrite{
+		key:      client.ObjectKeyFromObject(cm),
+		block:    dataYaml,
+		change:   changeDiff(originalData, cm.Data[cmKey]),
+		replaced: replaced,
+	}
+
+	// Update the ConfigMap
+	if err := r.writeConfigMap(ctx, instance, cm, restore); err != nil {
+		// Finish the addition once the buffered write is flushed
+		if isBuffered(err) && !staged {
+			r.WriteBuffer.await(instance.UID, write)
+		}
+
+		return false, fmt.Errorf("failed to update ConfigMap: %w", err)
+	}
+
+	if staged {
+		return false, r.setPendingApproval(ctx, instance, instanceNamespacedName, cmKey, hash)
+	}
+
+	return r.finishAddition(ctx, instance, instanceNamespacedName, cm, cmKey, write)
+}
+
+// finishAddition restarts kube-state-metrics to load the written resources,
+// records the events and updates the status of the instance.
+func (r *CustomResourceStateMetricsReconciler) finishAddition(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string,
+	cm *corev1.ConfigMap, cmKey string, write awaitedWrite) (bool, error) {
+	// Restart kube-state-metrics to load the new content
+	r.rollout(ctx, instance, cm, cmKey)
+
+	// Record the events
+	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonSynced,
+		"Finished the addition of resources into an existing ConfigMap.")
+
+	// Expose what changed (persisted with the status update)
+	if write.change != "" {
+		instance.Status.LastChange = write.change
+	}
+
+	if write.replaced {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, reasonUpdating,
+			"Replaced the resources in the key %s of the ConfigMap:\n%s", cmKey, instance.Status.LastChange)
+		r.recordTargetEvent(instance, cm, reasonUpdating, "Block for %s updated.", instanceNamespacedName)
+	} else {
+		r.recordTargetEvent(instance, cm, reasonCreating, "Block for %s added.", instanceNamespacedName)
+	}
+
+	// Record what was written and when (persisted with the status update)
+	recordSync(instance, write.block)
+
+	// Update the status conditions
+	setSynced(instance, "The resources were written into an existing ConfigMap.")
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonSynced,
+		Message:            "Finished the addition of resources into an existing ConfigMap.",
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		return false, fmt.Errorf(
+			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+			instanceNamespacedName, err)
+	}
+
+	if r.WriteBuffer != nil {
+		r.WriteBuffer.forget(instance.UID)
+	}
+
+	return true, nil
+}
+
+// flushedAddition returns the addition of the instance whose buffered write
+// of the ConfigMap was flushed in the meantime. If the write is still
+// buffered, the buffered error is returned so the instance is reconciled
+// again once the write is flushed.
+func (r *CustomResourceStateMetricsReconciler) flushedAddition(
+	instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap) (awaitedWrite, bool, error) {
+	if r.WriteBuffer == nil {
+		return awaitedWrite{}, false, nil
+	}
+
+	write, ok := r.WriteBuffer.awaited(instance.UID)
+	if !ok || write.key != client.ObjectKeyFromObject(cm) {
+		return awaitedWrite{}, false, nil
+	}
+
+	if queued, batched := r.WriteBuffer.queued(write.key); queued {
+		if batched {
+			return awaitedWrite{}, false, errWriteBatched
+		}
+
+		return awaitedWrite{}, false, errWriteBuffered
+	}
+
+	return write, true, nil
+}
+
+// recordTargetEvent records the event on the ConfigMap (or on the Secret) the
+// resources of the instance were written into.
+func (r *CustomResourceStateMetricsReconciler) recordTargetEvent(
+	instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap, reason, messageFmt string, args ...any) {
+	var target runtime.Object = cm
+
+	if targetKind(instance) == ksmv1.TargetKindSecret {
+		target = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: cm.Name, Namespace: cm.Namespace, UID: cm.UID}}
+	}
+
+	r.Recorder.Eventf(target, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+// writeRemoval writes the ConfigMap without the removed resources and
+// updates the status of the instance.
+func (r *CustomResourceStateMetricsReconciler) writeRemoval(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string,
+	cm *corev1.ConfigMap, cmKey, originalData string) (bool, error) {
+	// Forget the hash of the removed block
+	setBlockHashes(cm, cmKey, instanceNamespacedName, "")
+
+	// Forget the labels and annotations requested by the instance
+	setMetadata(cm, instanceNamespacedName, nil, nil)
+
+	// Forget the owner of the ConfigMap unless it gets garbage collected with
+	// the deleted instance
+	if instance.DeletionTimestamp.IsZero() {
+		if err := r.setOwnerReference(instance, cm, false); err != nil {
+			return false, err
+		}
+	}
+
+	// Create the immutable copy of the content if requested
+	if err := r.rotateImmutable(ctx, instance, cm); err != nil {
+		return false, err
+	}
+
+	// Keep the previous content if requested
+	if err := r.backupConfigMap(ctx, instance, cm, cmKey, originalData); err != nil {
+		return false, err
+	}
+
+	// Update the ConfigMap
+	if err := r.writeConfigMap(ctx, instance, cm, false); err != nil {
+		return false, fmt.Errorf("failed to update the ConfigMap: %w", err)
+	}
+
+	// Restart kube-state-metrics to load the new content
+	r.rollout(ctx, instance, cm, cmKey)
+
+	// Record the events
+	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonRemoving,
+		"Finished removal of resources from the ConfigMap.")
+	r.recordTargetEvent(instance, cm, reasonRemoving, "Block for %s removed.", instanceNamespacedName)
+
+	// Update the status condition
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonRemoving,
+		Message:            "Finished the removal of resources from the ConfigMap.",
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		return false, fmt.Errorf(
+			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+			instanceNamespacedName, err)
+	}
+
+	return true, nil
+}
+
+// writeMetadata writes the ConfigMap whose content is up to date if the
+// labels, annotations, the compression or the format requested by the
+// instance changed.
+func (r *CustomResourceStateMetricsReconciler) writeMetadata(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string,
+	cm *corev1.ConfigMap, cmKey string) error {
+	metadataChanged := setMetadata(
+		cm, instanceNamespacedName, instance.Spec.ConfigMap.Labels, instance.Spec.ConfigMap.Annotations)
+	compressionChanged := setCompressedKey(cm, cmKey, compressedKeySuffix(instance))
+	formatChanged := setJSONKey(cm, cmKey, jsonFormat(instance, cmKey))
+
+	if !metadataChanged && !compressionChanged && !formatChanged {
+		return nil
+	}
+
+	log.V(1).Info(
+		"Updating metadata of the ConfigMap",
+		"instance", instanceNamespacedName,
+		"configMap", utils.NamespacedName(cm.Name, cm.Namespace))
+
+	if err := r.writeConfigMap(ctx, instance, cm, false); err != nil {
+		return fmt.Errorf("failed to update metadata of the ConfigMap: %w", err)
+	}
+
+	return nil
+}
+
+// setResourcesMissing updates the status of the instance whose resources
+// don't exist in the ConfigMap.
+func (r *CustomResourceStateMetricsReconciler) setResourcesMissing(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string) error {
+	// Record the event
+	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonRemoving,
+		"Resources don't exist in the ConfigMap.")
+
+	// Update the status condition
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonRemoving,
+		Message:            "Resources don't exist in the ConfigMap.",
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		return fmt.Errorf(
+			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+			instanceNamespacedName, err)
+	}
+
+	return nil
+}
+
+// setResourcesExist updates the status of the instance whose resources
+// already exist in the ConfigMap.
+func (r *CustomResourceStateMetricsReconciler) setResourcesExist(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string) error {
+	// Record the event
+	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonSynced,
+		"The same resources already exist in the ConfigMap.")
+
+	// Update the status conditions
+	setSynced(instance, "The same resources already exist in the ConfigMap.")
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonSynced,
+		Message:            "The same resources already exist in the ConfigMap.",
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		return fmt.Errorf(
+			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+			instanceNamespacedName, err)
+	}
+
+	return nil
+}
+
+// recordBlockHashes records the hashes of the block and of the content on the
+// ConfigMap. Staged blocks are not live yet so their hash is not recorded.
+func (r *CustomResourceStateMetricsReconciler) recordBlockHashes(
+	cm *corev1.ConfigMap, cmKey, instanceNamespacedName, block string, staged bool) {
+	if staged {
+		block = ""
+	}
+
+	setBlockHashes(cm, cmKey, instanceNamespacedName, block)
+}
+
+// parseRendered returns the rendered resources or nil if they can't be
+// parsed.
+func parseRendered(dataYaml string) []ksm.Resource {
+	resources, err := ksm.ParseResources(dataYaml)
+	if err != nil {
+		return nil
+	}
+
+	return resources
+}
+
+// recordSync records the hash of the written block, the time of the write and
+// the revision of the block in the status of the instance.
+func recordSync(instance *ksmv1.CustomResourceStateMetrics, block string) {
+	now := metav1.Now()
+
+	instance.Status.BlockHash = utils.Hash(block)
+	instance.Status.LastSyncTime = &now
+
+	recordRevision(instance, block, now)
+}
+
+// stageData moves the new content of the key into the staging key unless the
+// staged content was already approved. It returns whether the content was
+// staged and the hash of the new content.
+func (r *CustomResourceStateMetricsReconciler) stageData(
+	instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap, cmKey, original string) (bool, string) {
+	nextKey := cmKey + nextKeySuffix
+	hash := utils.Hash(cm.Data[cmKey])
+
+	if instance.Annotations[ApproveAnnotation] == hash {
+		log.V(1).Info(
+			"Promoting approved staged content",
+			"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+			"hash", hash)
+
+		// Swap the keys in the same update
+		delete(cm.Data, nextKey)
+
+		return false, hash
+	}
+
+	cm.Data[nextKey] = cm.Data[cmKey]
+	cm.Data[cmKey] = original
+
+	return true, hash
+}
+
+// setPendingApproval records that the staged content waits for an approval.
+func (r *CustomResourceStateMetricsReconciler) setPendingApproval(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName, cmKey,
+	hash string) error {
+	message := fmt.Sprintf(
+		"Resources are staged in the ConfigMap key %s waiting for approval (set the %s annotation to %s).",
+		cmKey+nextKeySuffix, ApproveAnnotation, hash)
+
+	// Record the event
+	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonPendingApproval, message)
+
+	// Update the status conditions
+	setNotSynced(instance, reasonPendingApproval, message)
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonPendingApproval,
+		Message:            message,
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		return fmt.Errorf(
+			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+			instanceNamespacedName, err)
+	}
+
+	return nil
+}
+
+// isPaused returns whether the instance is paused by the annotation.
+func isPaused(instance *ksmv1.CustomResourceStateMetrics) bool {
+	return instance.Annotations[PausedAnnotation] == "true"
+}
+
+// forceSyncRequested returns whether the instance carries a force-sync
+// annotation value which wasn't handled yet.
+func forceSyncRequested(instance *ksmv1.CustomResourceStateMetrics) bool {
+	value := instance.Annotations[ForceSyncAnnotation]
+
+	return value != "" && value != instance.Status.ForceSync
+}
+
+// renderInstance loads the resources of the instance and renders them into
+// YAML string with the variables resolved.
+func (r *CustomResourceStateMetricsReconciler) renderInstance(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics) (string, error) {
+	resources, err := r.loadResources(ctx, instance)
+	if err != nil {
+		return "", err
+	}
+
+	dataYaml, err := r.renderData(instance, resources)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode resource data: %w", err)
+	}
+
+	// Resolve the variables against the instance (not cached as the labels
+	// can change without changing the generation)
+	if instance.Spec.Interpolate {
+		if dataYaml, err = utils.Interpolate(dataYaml, instance); err != nil {
+			return "", err
+		}
+	}
+
+	return dataYaml, nil
+}
+
+// renderData renders the resources of the instance into YAML string reusing
+// the cached result if the instance generation didn't change. The result is
+// not cached if some resources are sourced as they can change independently
+// of the instance. The raw resources are used as they are unless they are
+// split into multiple documents.
+func (r *CustomResourceStateMetricsReconciler) renderData(
+	instance *ksmv1.CustomResourceStateMetrics, resources []ksm.Resource) (string, error) {
+	if instance.Spec.ResourcesRaw != "" {
+		raw, err := ksm.FlattenDocuments(instance.Spec.ResourcesRaw)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(raw, " \t\n") + "\n", nil
+	}
+
+	sourced := len(instance.Spec.ResourcesFrom) > 0 || instance.Spec.Source != nil
+
+	if !sourced {
+		if data, found := renderedResources.get(instance.UID, instance.Generation); found {
+			return data, nil
+		}
+	}
+
+	data, err := r.decodeData(resources)
+	if err != nil {
+		return "", err
+	}
+
+	if !sourced {
+		renderedResources.set(instance.UID, instance.Generation, data)
+	}
+
+	return data, nil
+}
+
+// decodeData decodes the resources into YAML string.
+func (r *CustomResourceStateMetricsReconciler) decodeData(resources []ksm.Resource) (string, error) {
+	data := Data{}
+
+	// Marshal the resources into a generic structure so the keys are sorted
+	for i := range resources {
+		// Convert the resource to a JSON bytes array
+		jsonBytes, err := json.Marshal(&resources[i])
+		if err != nil {
+			return "", fmt.Errorf("failed to encode resources #%d to JSON: %w", i, err)
+		}
 
 		// Convert the JSON bytes array to a structure
 		var jsonObj interface{}
@@ -654,73 +2401,192 @@ func (r *CustomResourceStateMetricsReconciler) decodeData(resources []runtime.Ra
 	return yamlDataSplit[1], nil
 }
 
-// findBlock finds a specific marker in the array of lines.
-func (r *CustomResourceStateMetricsReconciler) findBlock(name string, lines []string) (bool, int, int) {
-	found := false
-	beginIndex := -1
-	endIndex := -1
+// selectorPredicate returns the predicate matching the instances selected by
+// the label and Namespace selectors. The selectors are read on each event so
+// the changes made by the OperatorConfig take effect immediately.
+func (r *CustomResourceStateMetricsReconciler) selectorPredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		// Finish the cleanup of the instances deselected by a narrowed selector
+		if obj.GetDeletionTimestamp() != nil && controllerutil.ContainsFinalizer(obj, FinalizerName) {
+			return true
+		}
 
-	beginMarker := fmt.Sprintf(beginMarkerFormat, name)
-	endMarker := fmt.Sprintf(endMarkerFormat, name)
+		config := r.runtimeConfig()
+		e := event.GenericEvent{Object: obj}
 
-	for i, line := range lines {
-		if line == beginMarker {
-			beginIndex = i
-		}
+		// Label selectors must always match in order to reconcile (the
+		// Namespaces are read from the cache of the manager client)
+		return utils.LabelSelectorPredicate(config.Selector).Generic(e) &&
+			utils.NamespaceLabelSelectorPredicate(r.Client, config.NamespaceSelector).Generic(e)
+	})
+}
 
-		if line == endMarker && beginIndex > -1 {
-			endIndex = i
-			found = true
-		}
-	}
+// configMapTargetKeys returns the keys of the ConfigMaps the resources of the
+// instance were written into (namespace/name) used by the field index.
+func configMapTargetKeys(obj client.Object) []string {
+	return targetKeys(obj, ksmv1.TargetKindConfigMap)
+}
 
-	return found, beginIndex, endIndex
+// secretTargetKeys returns the keys of the Secret the resources of the
+// instance were written into (namespace/name) used by the field index.
+func secretTargetKeys(obj client.Object) []string {
+	return targetKeys(obj, ksmv1.TargetKindSecret)
 }
 
-// joinLines joins slice of lines and makes sure the last line ends with a new
-// line unless at the end of the lines.
-func (r *CustomResourceStateMetricsReconciler) joinLines(lines []string, start, end int) string {
-	strip := false
-	lastIndex := len(lines) - 1
+// targetKeys returns the keys of the objects of the kind the resources of the
+// instance were written into (namespace/name).
+func targetKeys(obj client.Object, kind ksmv1.TargetKind) []string {
+	instance, ok := obj.(*ksmv1.CustomResourceStateMetrics)
+	if !ok {
+		return nil
+	}
+
+	target := instance.Status.ConfigMap
+	if target == nil || target.Name == "" {
+		return nil
+	}
+
+	// The target written before the kind was recorded is a ConfigMap
+	if (target.Kind == ksmv1.TargetKindSecret) != (kind == ksmv1.TargetKindSecret) {
+		return nil
+	}
 
-	if start < 0 {
-		start = 0
+	namespaces := target.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{target.Namespace}
 	}
 
-	if end == lastIndex {
-		strip = true
-	} else if end == -1 || end > lastIndex {
-		end = lastIndex
-		strip = true
+	keys := make([]string, 0, len(namespaces))
+
+	for _, namespace := range namespaces {
+		keys = append(keys, namespace+"/"+target.Name)
 	}
 
-	result := strings.Join(lines[start:end+1], "\n")
+	return keys
+}
+
+// targetToInstances maps the ConfigMap (or the Secret) to the selected
+// instances whose resources were written into it. The instances are looked up
+// by the field index so they don't have to be all listed on each change of a
+// ConfigMap or a Secret.
+func (r *CustomResourceStateMetricsReconciler) targetToInstances(index string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		instances := &ksmv1.CustomResourceStateMetricsList{}
+
+		if err := r.List(ctx, instances, client.MatchingFields{
+			index: obj.GetNamespace() + "/" + obj.GetName(),
+		}); err != nil {
+			log.Error(err, "Failed to list instances", "target", utils.NamespacedName(obj.GetName(), obj.GetNamespace()))
+
+			return nil
+		}
+
+		selected := r.selectorPredicate()
+		requests := []reconcile.Request{}
+
+		for i := range instances.Items {
+			instance := &instances.Items[i]
+
+			if !selected.Generic(event.GenericEvent{Object: instance}) {
+				continue
+			}
+
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)})
+		}
 
-	if strip {
-		result = strings.TrimRight(result, "\n")
-	} else if !strings.HasSuffix(result, "\n") {
-		result += "\n"
+		return requests
 	}
+}
 
-	return result
+// controllerOptions returns the options of the controller.
+func (r *CustomResourceStateMetricsReconciler) controllerOptions() crcontroller.Options {
+	return crcontroller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CustomResourceStateMetricsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := setDocumentLayout(r.DocumentHeader, r.MarkerFormat); err != nil {
+		return err
+	}
+
+	// Index the instances by the ConfigMaps they write into
+	if err := mgr.GetFieldIndexer().IndexField(
+		context.Background(), &ksmv1.CustomResourceStateMetrics{}, configMapTargetIndex, configMapTargetKeys,
+	); err != nil {
+		return fmt.Errorf("failed to index the ConfigMap targets: %w", err)
+	}
+
+	// Index the instances by the Secrets they write into
+	if err := mgr.GetFieldIndexer().IndexField(
+		context.Background(), &ksmv1.CustomResourceStateMetrics{}, secretTargetIndex, secretTargetKeys,
+	); err != nil {
+		return fmt.Errorf("failed to index the Secret targets: %w", err)
+	}
+
 	combinedPredicate := predicate.And(
-		// Reconcile only if generation value changed or labels changed
+		// Reconcile only if generation value, labels or relevant annotations changed
 		predicate.Or(
 			predicate.GenerationChangedPredicate{},
 			utils.LabelsChangedPredicate(),
+			utils.AnnotationsChangedPredicate(
+				ApproveAnnotation, ForceSyncAnnotation, PausedAnnotation, RollbackToAnnotation),
 		),
-		// Label selectors must always match in order to reconcile
-		utils.LabelSelectorPredicate(r.Selector),
-		utils.NamespaceLabelSelectorPredicate(r.Client, r.NamespaceSelector),
+		r.selectorPredicate(),
 	)
 
+	secretMetadata := &metav1.PartialObjectMetadata{}
+	secretMetadata.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&ksmv1.CustomResourceStateMetrics{}).
-		WithEventFilter(combinedPredicate).
+		For(&ksmv1.CustomResourceStateMetrics{}, builder.WithPredicates(combinedPredicate)).
+		// Repair the managed content if the ConfigMap gets modified or deleted externally
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.targetToInstances(configMapTargetIndex)),
+			builder.WithPredicates(predicate.Or(
+				utils.ConfigMapDataChangedPredicate(),
+				utils.DeletedPredicate(),
+			)),
+		).
+		// Render the resources again if the ConfigMaps or Secrets they are read from change
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.sourcesTo(ksmv1.TargetKindConfigMap)),
+			builder.WithPredicates(predicate.Or(
+				utils.ConfigMapDataChangedPredicate(),
+				utils.CreatedPredicate(),
+				utils.DeletedPredicate(),
+			)),
+		).
+		// Only the metadata of the Secrets are watched so they don't have to be kept in memory
+		Watches(
+			secretMetadata,
+			handler.EnqueueRequestsFromMapFunc(r.sourcesTo(ksmv1.TargetKindSecret)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		// Repair the managed content if the Secret gets modified or deleted
+		// externally (the change of the data is seen only in the version)
+		Watches(
+			secretMetadata,
+			handler.EnqueueRequestsFromMapFunc(r.targetToInstances(secretTargetIndex)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		// Reconcile the instances with the changed runtime configuration
+		Watches(
+			&ksmv1.OperatorConfig{},
+			handler.EnqueueRequestsFromMapFunc(r.operatorConfigToInstances),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		// Replicate the ConfigMap as the Namespaces start or stop matching the selector
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.namespaceToInstances),
+			builder.WithPredicates(predicate.Or(
+				utils.LabelsChangedPredicate(),
+				utils.DeletedPredicate(),
+			)),
+		).
+		WithOptions(r.controllerOptions()).
 		Named("customresourcestatemetrics").
 		Complete(r)
 }
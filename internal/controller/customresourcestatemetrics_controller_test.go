@@ -19,20 +19,32 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
 )
 
 var _ = Describe("CustomResourceStateMetrics Controller", func() {
@@ -61,9 +73,9 @@ var _ = Describe("CustomResourceStateMetrics Controller", func() {
 						ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{
 							Name: "kube-state-metrics-customresourcestate-config",
 						},
-						Resources: []runtime.RawExtension{
+						Resources: []ksm.Resource{
 							{
-								Raw: []byte(`{"foo": "bar"}`),
+								GroupVersionKind: ksm.GroupVersionKind{Group: "myteam.io", Version: "v1", Kind: "Foo"},
 							},
 						},
 					},
@@ -102,118 +114,1028 @@ var _ = Describe("CustomResourceStateMetrics Controller", func() {
 				g.Expect(err).NotTo(HaveOccurred())
 				g.Expect(crsm.Status.Conditions[0].Status).To(Equal(metav1.ConditionTrue),
 					"Resource is not yet ready")
+				g.Expect(crsm.Status.ObservedGeneration).To(Equal(crsm.Generation))
+				g.Expect(crsm.Status.Conditions[0].ObservedGeneration).To(Equal(crsm.Generation))
 			}
 			Eventually(verifyResourceIsReady).Should(Succeed())
 		})
 	})
+
+	Context("when validating a resource", func() {
+		ctx := context.Background()
+
+		resource := []ksm.Resource{{GroupVersionKind: ksm.GroupVersionKind{Version: "v1", Kind: "Foo"}}}
+		resourceRaw := "- groupVersionKind:\n    version: v1\n    kind: Foo\n"
+
+		tests := map[string]struct {
+			spec ksmv1.CustomResourceStateMetricsSpec
+			err  string
+		}{
+			"no-resources": {
+				spec: ksmv1.CustomResourceStateMetricsSpec{},
+				err:  "at least one resource must be specified",
+			},
+			"raw-and-resources": {
+				spec: ksmv1.CustomResourceStateMetricsSpec{Resources: resource, ResourcesRaw: resourceRaw},
+				err:  "resourcesRaw can't be combined",
+			},
+			"invalid-key": {
+				spec: ksmv1.CustomResourceStateMetricsSpec{
+					ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{Key: "config/yaml"},
+					Resources: resource,
+				},
+				err: "must be a valid key",
+			},
+		}
+
+		for name, test := range tests {
+			It("should reject the invalid resource "+name, func() {
+				instance := &ksmv1.CustomResourceStateMetrics{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Spec:       test.spec,
+				}
+
+				Expect(k8sClient.Create(ctx, instance)).To(MatchError(ContainSubstring(test.err)))
+			})
+		}
+	})
+
+	Context("when backing up a ConfigMap", func() {
+		ctx := context.Background()
+
+		It("should keep the previous content of the key", func() {
+			r := &CustomResourceStateMetricsReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+			instance := &ksmv1.CustomResourceStateMetrics{
+				Spec: ksmv1.CustomResourceStateMetricsSpec{
+					ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{Backup: true},
+				},
+			}
+
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "backed-up", Namespace: "default"},
+				Data:       map[string]string{"config.yaml": "new\n"},
+			}
+
+			By("Skipping the unchanged content")
+			Expect(r.backupConfigMap(ctx, instance, cm, "config.yaml", "new\n")).To(Succeed())
+
+			backup := &corev1.ConfigMap{}
+			backupKey := types.NamespacedName{Name: "backed-up" + backupSuffix, Namespace: "default"}
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, backupKey, backup))).To(BeTrue())
+
+			By("Copying the previous content")
+			Expect(r.backupConfigMap(ctx, instance, cm, "config.yaml", "old\n")).To(Succeed())
+			Expect(r.backupConfigMap(ctx, instance, cm, "other.yaml", "other\n")).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, backupKey, backup)).To(Succeed())
+			Expect(backup.Labels).To(HaveKeyWithValue(BackupOfLabel, "backed-up"))
+			Expect(backup.Data).To(Equal(map[string]string{"config.yaml": "old\n", "other.yaml": "other\n"}))
+
+			Expect(k8sClient.Delete(ctx, backup)).To(Succeed())
+		})
+	})
+
+	Context("when the block in the ConfigMap drifts", func() {
+		ctx := context.Background()
+
+		It("should restore the managed block and keep the unmanaged resources", func() {
+			r := newTestReconciler()
+			instance := newTestInstance("drift", "drift-config", "Foo")
+			cmNamespacedName := types.NamespacedName{Name: "drift-config", Namespace: "default"}
+
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+			DeferCleanup(deleteTestInstance, ctx, r, instance, cmNamespacedName)
+
+			By("Writing the block")
+			reconcileTestInstance(ctx, r, instance)
+
+			By("Changing the managed block and adding an unmanaged resource")
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+
+			cm.Data[DefaultKey] = "kind: CustomResourceStateMetrics\nspec:\n  resources:\n" +
+				"    - groupVersionKind:\n        group: myteam.io\n        version: v1\n        kind: Manual\n" +
+				"    # CustomResourceStateMetrics drift@default\n" +
+				"    - groupVersionKind:\n        group: myteam.io\n        version: v1\n        kind: Bar\n"
+			Expect(k8sClient.Update(ctx, cm)).To(Succeed())
+
+			By("Reconciling the drifted ConfigMap")
+			reconcileTestInstance(ctx, r, instance)
+
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: Foo"))
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: Manual"))
+			Expect(cm.Data[DefaultKey]).NotTo(ContainSubstring("kind: Bar"))
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(instance), instance)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeConflict)).To(BeTrue())
+		})
+	})
+
+	Context("when the ConfigMap is deleted", func() {
+		ctx := context.Background()
+
+		It("should recreate it with the blocks of all instances", func() {
+			r := newTestReconciler()
+			foo := newTestInstance("recreate-foo", "recreate-config", "Foo")
+			bar := newTestInstance("recreate-bar", "recreate-config", "Bar")
+			cmNamespacedName := types.NamespacedName{Name: "recreate-config", Namespace: "default"}
+
+			for _, instance := range []*ksmv1.CustomResourceStateMetrics{foo, bar} {
+				Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+				DeferCleanup(deleteTestInstance, ctx, r, instance, cmNamespacedName)
+			}
+
+			By("Writing the blocks")
+			reconcileTestInstance(ctx, r, foo)
+			reconcileTestInstance(ctx, r, bar)
+
+			By("Deleting the ConfigMap")
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
+
+			By("Reconciling the instances writing into the deleted ConfigMap")
+			reconcileTestInstance(ctx, r, foo)
+			reconcileTestInstance(ctx, r, bar)
+
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring(formatMarker("recreate-foo@default")))
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: Foo"))
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring(formatMarker("recreate-bar@default")))
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: Bar"))
+		})
+	})
+
+	Context("when the instance is suspended", func() {
+		ctx := context.Background()
+
+		It("should skip the writes until it's resumed", func() {
+			r := newTestReconciler()
+			instance := newTestInstance("suspend", "suspend-config", "Foo")
+			cmNamespacedName := types.NamespacedName{Name: "suspend-config", Namespace: "default"}
+
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+			DeferCleanup(deleteTestInstance, ctx, r, instance, cmNamespacedName)
+
+			By("Writing the block")
+			reconcileTestInstance(ctx, r, instance)
+
+			By("Changing the resources of the suspended instance")
+			instance.Spec.Suspend = true
+			instance.Spec.Resources[0].GroupVersionKind.Kind = "Bar"
+			Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+
+			reconcileTestInstance(ctx, r, instance)
+
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: Foo"))
+			Expect(cm.Data[DefaultKey]).NotTo(ContainSubstring("kind: Bar"))
+
+			condition := meta.FindStatusCondition(instance.Status.Conditions, conditionTypeSuspended)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(reasonSuspended))
+
+			By("Resuming the instance")
+			instance.Spec.Suspend = false
+			Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+
+			reconcileTestInstance(ctx, r, instance)
+
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: Bar"))
+			Expect(cm.Data[DefaultKey]).NotTo(ContainSubstring("kind: Foo"))
+
+			condition = meta.FindStatusCondition(instance.Status.Conditions, conditionTypeSuspended)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(reasonResumed))
+		})
+	})
+
+	Context("when the instance is deleted", func() {
+		ctx := context.Background()
+
+		It("should remove or retain the block according to the deletion policy", func() {
+			r := newTestReconciler()
+			retained := newTestInstance("deletion-retain", "deletion-config", "Foo")
+			retained.Spec.DeletionPolicy = ksmv1.DeletionPolicyRetain
+			deleted := newTestInstance("deletion-delete", "deletion-config", "Bar")
+			deleted.Spec.DeletionPolicy = ksmv1.DeletionPolicyDelete
+			cmNamespacedName := types.NamespacedName{Name: "deletion-config", Namespace: "default"}
+
+			for _, instance := range []*ksmv1.CustomResourceStateMetrics{retained, deleted} {
+				Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+				DeferCleanup(deleteTestInstance, ctx, r, instance, cmNamespacedName)
+
+				reconcileTestInstance(ctx, r, instance)
+			}
+
+			By("Deleting the instances")
+			for _, instance := range []*ksmv1.CustomResourceStateMetrics{retained, deleted} {
+				Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)})
+				Expect(err).NotTo(HaveOccurred())
+
+				// Deleted once the finalizer is removed
+				err = k8sClient.Get(ctx, client.ObjectKeyFromObject(instance), &ksmv1.CustomResourceStateMetrics{})
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring(formatMarker("deletion-retain@default")))
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: Foo"))
+			Expect(cm.Data[DefaultKey]).NotTo(ContainSubstring(formatMarker("deletion-delete@default")))
+			Expect(cm.Data[DefaultKey]).NotTo(ContainSubstring("kind: Bar"))
+		})
+	})
+
+	Context("when no ConfigMap is specified", func() {
+		ctx := context.Background()
+
+		It("should record the discovered ConfigMap in the status", func() {
+			r := newTestReconciler()
+			instance := newTestInstance("discover", "", "Foo")
+			instance.Spec.ConfigMap.Namespace = ""
+			cmNamespacedName := types.NamespacedName{Name: "discover-config", Namespace: "default"}
+
+			deployment := newTestKSMDeployment("discover-ksm", "default", "discover-config")
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, deployment)
+
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+			DeferCleanup(deleteTestInstance, ctx, r, instance, cmNamespacedName)
+
+			reconcileTestInstance(ctx, r, instance)
+
+			Expect(instance.Status.ConfigMap).To(Equal(&ksmv1.CustomResourceStateMetricsTarget{
+				Kind:      ksmv1.TargetKindConfigMap,
+				Name:      "discover-config",
+				Namespace: "default",
+				Key:       "custom.yaml",
+			}))
+
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+			Expect(cm.Data["custom.yaml"]).To(ContainSubstring(formatMarker("discover@default")))
+		})
+	})
+
+	Context("when the resync policy is set", func() {
+		ctx := context.Background()
+
+		It("should keep the existing block with the Never policy unless forced", func() {
+			r := newTestReconciler()
+			instance := newTestInstance("resync-never", "resync-never-config", "Foo")
+			instance.Spec.ResyncPolicy = ksmv1.ResyncPolicyNever
+			cmNamespacedName := types.NamespacedName{Name: "resync-never-config", Namespace: "default"}
+
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+			DeferCleanup(deleteTestInstance, ctx, r, instance, cmNamespacedName)
+
+			By("Writing the block")
+			reconcileTestInstance(ctx, r, instance)
+
+			By("Changing the block by hand")
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+
+			cm.Data[DefaultKey] = strings.Replace(cm.Data[DefaultKey], "kind: Foo", "kind: Bar", 1)
+			Expect(k8sClient.Update(ctx, cm)).To(Succeed())
+
+			reconcileTestInstance(ctx, r, instance)
+
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: Bar"))
+
+			By("Forcing the sync")
+			instance.Annotations = map[string]string{ForceSyncAnnotation: "2025-01-02T03:04:05Z"}
+			Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+
+			reconcileTestInstance(ctx, r, instance)
+
+			Expect(k8sClient.Get(ctx, cmNamespacedName, cm)).To(Succeed())
+			Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: Foo"))
+			Expect(cm.Data[DefaultKey]).NotTo(ContainSubstring("kind: Bar"))
+		})
+
+		It("should resync periodically only with the Always policy", func() {
+			r := newTestReconciler()
+			r.ResyncPeriod = time.Hour
+			cmNamespacedName := types.NamespacedName{Name: "resync-config", Namespace: "default"}
+
+			tests := map[ksmv1.ResyncPolicy]time.Duration{
+				ksmv1.ResyncPolicyAlways:   time.Hour,
+				ksmv1.ResyncPolicyOnChange: 0,
+			}
+
+			for policy, expected := range tests {
+				instance := newTestInstance("resync-"+strings.ToLower(string(policy)), "resync-config", string(policy))
+				instance.Spec.ResyncPolicy = policy
+
+				Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+				DeferCleanup(deleteTestInstance, ctx, r, instance, cmNamespacedName)
+
+				reconcileTestInstance(ctx, r, instance)
+
+				result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(expected), "Test [%s]:", policy)
+			}
+		})
+	})
 })
 
-func TestFindBlock(t *testing.T) {
+// newTestReconciler returns the reconciler using the envtest client.
+func newTestReconciler() *CustomResourceStateMetricsReconciler {
+	return &CustomResourceStateMetricsReconciler{
+		Client:   k8sClient,
+		Scheme:   k8sClient.Scheme(),
+		Recorder: record.NewFakeRecorder(100),
+	}
+}
+
+// newTestInstance returns the instance writing the resource of the kind into
+// the ConfigMap in the default Namespace.
+func newTestInstance(name, cmName, kind string) *ksmv1.CustomResourceStateMetrics {
+	return &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: ksmv1.CustomResourceStateMetricsSpec{
+			ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: cmName, Namespace: "default"},
+			Resources: []ksm.Resource{
+				{GroupVersionKind: ksm.GroupVersionKind{Group: "myteam.io", Version: "v1", Kind: kind}},
+			},
+		},
+	}
+}
+
+// newTestKSMDeployment returns the kube-state-metrics Deployment mounting the
+// key custom.yaml of the ConfigMap as the custom resource state config.
+func newTestKSMDeployment(name, namespace, cmName string) *appsv1.Deployment {
+	labels := map[string]string{"app.kubernetes.io/name": "kube-state-metrics"}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:         "kube-state-metrics",
+						Image:        "registry.k8s.io/kube-state-metrics/kube-state-metrics",
+						Args:         []string{"--custom-resource-state-config-file=/etc/ksm/custom.yaml"},
+						VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/etc/ksm"}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "config",
+						VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: cmName},
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// reconcileTestInstance reconciles the instance until it has the finalizer
+// and reloads it.
+func reconcileTestInstance(
+	ctx context.Context, r *CustomResourceStateMetricsReconciler, instance *ksmv1.CustomResourceStateMetrics) {
+	for range 2 {
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(instance), instance)).To(Succeed())
+}
+
+// deleteTestInstance deletes the instance, finishes its finalization and
+// deletes the ConfigMap.
+func deleteTestInstance(
+	ctx context.Context, r *CustomResourceStateMetricsReconciler, instance *ksmv1.CustomResourceStateMetrics,
+	cmNamespacedName types.NamespacedName) {
+	Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, instance))).To(Succeed())
+
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)})
+	Expect(err).NotTo(HaveOccurred())
+
+	cm := &corev1.ConfigMap{}
+	cm.Name, cm.Namespace = cmNamespacedName.Name, cmNamespacedName.Namespace
+	Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, cm))).To(Succeed())
+}
+
+func TestBuildReport(t *testing.T) {
+	g := NewWithT(t)
+
+	target := &ksmv1.CustomResourceStateMetricsTarget{
+		Name:      "ksm",
+		Namespace: "monitoring",
+		Key:       "config.yaml",
+	}
+
+	instances := []ksmv1.CustomResourceStateMetrics{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-b"},
+			Status: ksmv1.CustomResourceStateMetricsStatus{
+				Conditions: []metav1.Condition{
+					{Type: conditionTypeReady, Status: metav1.ConditionTrue, Reason: reasonSynced},
+				},
+				ConfigMap: target,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "team-a"},
+			Status: ksmv1.CustomResourceStateMetricsStatus{
+				Conditions: []metav1.Condition{
+					{Type: conditionTypeReady, Status: metav1.ConditionFalse, Reason: reasonSyncFailed, Message: "Failed."},
+				},
+				ConfigMap: target,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "baz", Namespace: "team-b"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "qux", Namespace: "team-a"},
+			Status: ksmv1.CustomResourceStateMetricsStatus{
+				Conditions: []metav1.Condition{
+					{Type: conditionTypeReady, Status: metav1.ConditionTrue, Reason: reasonSynced},
+				},
+				ConfigMap: &ksmv1.CustomResourceStateMetricsTarget{
+					Name:       "ksm",
+					Namespace:  "team-a",
+					Key:        "config.yaml",
+					Namespaces: []string{"shard-1", "shard-2"},
+				},
+			},
+		},
+	}
+
+	expected := ksmv1.CRSMReportStatus{
+		TotalInstances:     4,
+		UnhealthyInstances: 2,
+		TotalTargets:       3,
+		Namespaces: []ksmv1.CRSMReportNamespace{
+			{Name: "team-a", Instances: 2},
+			{Name: "team-b", Instances: 2},
+		},
+		Unhealthy: []ksmv1.CRSMReportInstance{
+			{Name: "bar", Namespace: "team-a", Reason: reasonSyncFailed, Message: "Failed."},
+			{Name: "baz", Namespace: "team-b"},
+		},
+		Targets: []ksmv1.CRSMReportTarget{
+			{Name: "ksm", Namespace: "monitoring", Key: "config.yaml", Instances: 2},
+			{Name: "ksm", Namespace: "shard-1", Key: "config.yaml", Instances: 1},
+			{Name: "ksm", Namespace: "shard-2", Key: "config.yaml", Instances: 1},
+		},
+	}
+
+	g.Expect(buildReport(instances)).To(Equal(expected))
+}
+
+func TestForceSyncRequested(t *testing.T) {
 	g := NewWithT(t)
 
 	tests := map[string]struct {
-		name     string
-		expected string
+		annotation string
+		handled    string
+		expected   bool
 	}{
-		"foo-found": {
-			name:     "foo",
-			expected: "true;1;3",
-		},
-		"bar-found": {
-			name:     "bar",
-			expected: "true;4;6",
+		"no_annotation":   {},
+		"new_value":       {annotation: "2025-01-02T03:04:05Z", expected: true},
+		"changed_value":   {annotation: "2025-01-02T03:04:05Z", handled: "2025-01-01T00:00:00Z", expected: true},
+		"handled_value":   {annotation: "2025-01-02T03:04:05Z", handled: "2025-01-02T03:04:05Z"},
+		"removed_handled": {handled: "2025-01-02T03:04:05Z"},
+	}
+
+	for name, test := range tests {
+		instance := &ksmv1.CustomResourceStateMetrics{
+			Status: ksmv1.CustomResourceStateMetricsStatus{ForceSync: test.handled},
+		}
+
+		if test.annotation != "" {
+			instance.Annotations = map[string]string{ForceSyncAnnotation: test.annotation}
+		}
+
+		g.Expect(forceSyncRequested(instance)).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestIsPaused(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		annotations map[string]string
+		expected    bool
+	}{
+		"no_annotation": {},
+		"paused":        {annotations: map[string]string{PausedAnnotation: "true"}, expected: true},
+		"not_paused":    {annotations: map[string]string{PausedAnnotation: "false"}},
+		"other_value":   {annotations: map[string]string{PausedAnnotation: "yes"}},
+	}
+
+	for name, test := range tests {
+		instance := &ksmv1.CustomResourceStateMetrics{}
+		instance.Annotations = test.annotations
+
+		g.Expect(isPaused(instance)).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestRecordSync(t *testing.T) {
+	g := NewWithT(t)
+
+	block := "- groupVersionKind:\n    group: myteam.io\n    kind: Foo\n    version: v1\n"
+	instance := &ksmv1.CustomResourceStateMetrics{}
+
+	recordSync(instance, block)
+
+	g.Expect(instance.Status.BlockHash).To(Equal(utils.Hash(block)))
+	g.Expect(instance.Status.LastSyncTime).NotTo(BeNil())
+}
+
+func TestParseRendered(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		dataYaml string
+		expected []string
+	}{
+		"default_prefix": {
+			dataYaml: "- groupVersionKind:\n    group: myteam.io\n    version: v1\n    kind: Foo\n" +
+				"  metrics:\n    - name: uptime\n      each:\n        type: Gauge\n        gauge:\n" +
+				"          path: [status, uptime]\n",
+			expected: []string{"kube_customresource_uptime"},
 		},
-		"baz-found": {
-			name:     "baz",
-			expected: "true;7;9",
+		"custom_prefix": {
+			dataYaml: "- groupVersionKind:\n    group: myteam.io\n    version: v1\n    kind: Foo\n" +
+				"  metricNamePrefix: myteam_foo\n" +
+				"  metrics:\n    - name: uptime\n      each:\n        type: Gauge\n        gauge:\n" +
+				"          path: [status, uptime]\n",
+			expected: []string{"myteam_foo_uptime"},
 		},
-		"asd-not-found": {
-			name:     "asd",
-			expected: "false;-1;-1",
+		"invalid": {
+			dataYaml: "- groupVersionKind:\n    kind: Foo\n",
 		},
 	}
 
-	r := CustomResourceStateMetricsReconciler{}
+	for name, test := range tests {
+		resources := parseRendered(test.dataYaml)
+
+		g.Expect(resources).To(HaveLen(len(test.expected)), "Test [%s]:", name)
+		g.Expect(ksm.MetricNames(resources)).To(ConsistOf(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestRecordTargetEvent(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := record.NewFakeRecorder(10)
+	r := &CustomResourceStateMetricsReconciler{Recorder: recorder}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "ksm", Namespace: "monitoring"}}
+
+	tests := map[string]*ksmv1.CustomResourceStateMetrics{
+		"configmap": {},
+		"secret": {Status: ksmv1.CustomResourceStateMetricsStatus{
+			ConfigMap: &ksmv1.CustomResourceStateMetricsTarget{Kind: ksmv1.TargetKindSecret, Name: "ksm"},
+		}},
+	}
+
+	for name, instance := range tests {
+		r.recordTargetEvent(instance, cm, reasonUpdating, "Block for %s updated.", "foo@ns")
+
+		g.Expect(<-recorder.Events).To(Equal("Normal Updating Block for foo@ns updated."), "Test [%s]:", name)
+	}
+}
+
+func TestRegisterInstance(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &CustomResourceStateMetricsReconciler{}
+	instance := &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{Name: "register", Namespace: "ns"}}
+
+	// Registered only once
+	r.registerInstance(instance)
+	r.registerInstance(instance)
+	g.Expect(resources).To(HaveKeyWithValue("register@ns", 1))
+
+	// Deregistering of an unknown instance is a no-op
+	r.deregisterInstance(instance)
+	r.deregisterInstance(instance)
+	g.Expect(resources).NotTo(HaveKey("register@ns"))
+}
+
+func TestSelectorPredicate(t *testing.T) {
+	g := NewWithT(t)
+
+	selector, _ := labels.Parse("team=foo")
+	r := &CustomResourceStateMetricsReconciler{Selector: selector, NamespaceSelector: labels.Everything()}
+	now := metav1.Now()
+
+	deleting := &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{
+		Name: "deleting", Namespace: "ns", DeletionTimestamp: &now, Finalizers: []string{FinalizerName}}}
+	other := &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{
+		Name: "other", Namespace: "ns", Labels: map[string]string{"team": "bar"}}}
+
+	g.Expect(r.selectorPredicate().Generic(event.GenericEvent{Object: deleting})).To(BeTrue(), "Test [deleting]:")
+	g.Expect(r.selectorPredicate().Generic(event.GenericEvent{Object: other})).To(BeFalse(), "Test [not_selected]:")
+
+	// The selector narrowed at runtime is used by the existing predicate
+	predicate := r.selectorPredicate()
+	narrowed, _ := labels.Parse("team=baz")
+	r.config.Store(&RuntimeConfig{Selector: narrowed, NamespaceSelector: labels.Everything()})
+
+	other.Labels["team"] = "foo"
+	g.Expect(predicate.Generic(event.GenericEvent{Object: other})).To(BeFalse(), "Test [runtime]:")
+}
+
+func TestReconcileInspection(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	ctx := context.Background()
+	dataYaml := "    - groupVersionKind:\n        kind: Foo\n"
 
-	lines := []string{
-		"aaa: bbb",
-		fmt.Sprintf(beginMarkerFormat, "foo"),
-		"foo: bar",
-		fmt.Sprintf(endMarkerFormat, "foo"),
-		fmt.Sprintf(beginMarkerFormat, "bar"),
-		"bar: baz",
-		fmt.Sprintf(endMarkerFormat, "bar"),
-		fmt.Sprintf(beginMarkerFormat, "baz"),
-		"baz: foo",
-		fmt.Sprintf(endMarkerFormat, "baz"),
+	instance := &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "foo-uid"},
+	}
+	owned := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      "foo" + inspectionSuffix,
+		Namespace: "bar",
+		OwnerReferences: []metav1.OwnerReference{
+			*metav1.NewControllerRef(instance, ksmv1.GroupVersion.WithKind("CustomResourceStateMetrics")),
+		},
+	}}
+	foreign := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo" + inspectionSuffix, Namespace: "bar"}}
+
+	tests := map[string]struct {
+		inspect  bool
+		dryRun   bool
+		existing *corev1.ConfigMap
+		expected bool
+	}{
+		"enabled":          {inspect: true, expected: true},
+		"enabled_existing": {inspect: true, existing: owned, expected: true},
+		"disabled":         {},
+		"disabled_owned":   {existing: owned},
+		"disabled_foreign": {existing: foreign, expected: true},
+		"dry_run":          {inspect: true, dryRun: true},
 	}
 
 	for name, test := range tests {
-		found, begin, end := r.findBlock(test.name, lines)
-		result := fmt.Sprintf("%t;%d;%d", found, begin, end)
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		if test.existing != nil {
+			builder = builder.WithObjects(test.existing.DeepCopy())
+		}
+
+		r := &CustomResourceStateMetricsReconciler{Client: builder.Build(), Scheme: scheme, DryRun: test.dryRun}
+		instance.Spec.Inspect = test.inspect
+
+		g.Expect(r.reconcileInspection(ctx, instance, "foo@bar", DefaultKey, dataYaml)).To(Succeed(), "Test [%s]:", name)
 
-		g.Expect(result).To(Equal(test.expected), "Test [%s]:", name)
+		cm := &corev1.ConfigMap{}
+		err := r.Get(ctx, types.NamespacedName{Name: "foo" + inspectionSuffix, Namespace: "bar"}, cm)
+
+		if !test.expected {
+			g.Expect(errors.IsNotFound(err)).To(BeTrue(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+		if test.inspect {
+			g.Expect(cm.Data).To(Equal(map[string]string{DefaultKey: dataHeader + dataYaml}), "Test [%s]:", name)
+			g.Expect(metav1.IsControlledBy(cm, instance)).To(BeTrue(), "Test [%s]:", name)
+		}
 	}
 }
 
-func TestJoinLines(t *testing.T) {
+func TestConfigMapTarget(t *testing.T) {
 	g := NewWithT(t)
 
+	scheme := runtime.NewScheme()
+	g.Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+
+	ctx := context.Background()
+	deployment := newTestKSMDeployment("kube-state-metrics", "monitoring", "ksm-config")
+	defaultConfigMap := types.NamespacedName{Name: "default-config", Namespace: "operator"}
+
 	tests := map[string]struct {
-		begin    int
-		end      int
-		expected string
+		configMap        ksmv1.CustomResourceStateMetricsConfigMap
+		defaultConfigMap types.NamespacedName
+		deployed         bool
+		expected         []string
+		fails            bool
 	}{
-		"beginning": {
-			begin:    0,
-			end:      3,
-			expected: "0\n1\n2\n3\n",
+		"explicit": {
+			configMap:        ksmv1.CustomResourceStateMetricsConfigMap{Name: "foo", Key: "foo.yaml"},
+			defaultConfigMap: defaultConfigMap,
+			expected:         []string{"foo", "bar", "foo.yaml"},
+		},
+		"default": {
+			defaultConfigMap: defaultConfigMap,
+			deployed:         true,
+			expected:         []string{"default-config", "operator", DefaultKey},
+		},
+		"default_namespace": {
+			configMap:        ksmv1.CustomResourceStateMetricsConfigMap{Namespace: "team"},
+			defaultConfigMap: defaultConfigMap,
+			expected:         []string{"default-config", "team", DefaultKey},
 		},
-		"middle": {
-			begin:    4,
-			end:      7,
-			expected: "4\n5\n6\n7\n",
+		"discovered": {
+			deployed: true,
+			expected: []string{"ksm-config", "monitoring", "custom.yaml"},
 		},
-		"end": {
-			begin:    7,
-			end:      9,
-			expected: "7\n8\n9",
+		"discover_requested": {
+			configMap:        ksmv1.CustomResourceStateMetricsConfigMap{Discover: true},
+			defaultConfigMap: defaultConfigMap,
+			deployed:         true,
+			expected:         []string{"ksm-config", "monitoring", "custom.yaml"},
 		},
-		"unknown-end": {
-			begin:    7,
-			end:      -1,
-			expected: "7\n8\n9",
+		"discovered_in_namespace": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Namespace: "other"},
+			deployed:  true,
+			fails:     true,
+		},
+		"not_discovered": {
+			fails: true,
+		},
+	}
+
+	for name, test := range tests {
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		if test.deployed {
+			builder = builder.WithObjects(deployment.DeepCopy())
+		}
+
+		r := &CustomResourceStateMetricsReconciler{Client: builder.Build(), DefaultConfigMap: test.defaultConfigMap}
+		instance := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec:       ksmv1.CustomResourceStateMetricsSpec{ConfigMap: test.configMap},
+		}
+
+		cmName, cmNamespace, cmKey, err := r.configMapTarget(ctx, instance)
+
+		if test.fails {
+			g.Expect(err).To(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect([]string{cmName, cmNamespace, cmKey}).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestControllerOptions(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &CustomResourceStateMetricsReconciler{MaxConcurrentReconciles: 4}
+
+	g.Expect(r.controllerOptions().MaxConcurrentReconciles).To(Equal(4))
+}
+
+func TestConcurrentReconciles(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&ksmv1.CustomResourceStateMetrics{}).Build()
+	r := &CustomResourceStateMetricsReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(100)}
+
+	kinds := []string{"Foo", "Bar", "Baz", "Qux"}
+
+	for _, kind := range kinds {
+		instance := newTestInstance("concurrent-"+strings.ToLower(kind), "concurrent-config", kind)
+		instance.UID = types.UID(instance.Name)
+
+		g.Expect(c.Create(ctx, instance)).To(Succeed())
+	}
+
+	// The writes into the same ConfigMap must not overwrite each other
+	var wg sync.WaitGroup
+
+	// The failures must be asserted in the test goroutine
+	errs := make(chan error, len(kinds))
+
+	for _, kind := range kinds {
+		wg.Go(func() {
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name: "concurrent-" + strings.ToLower(kind), Namespace: "default"}})
+			if err != nil {
+				errs <- fmt.Errorf("%s: %w", kind, err)
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(c.Get(ctx, types.NamespacedName{Name: "concurrent-config", Namespace: "default"}, cm)).To(Succeed())
+
+	for _, kind := range kinds {
+		g.Expect(cm.Data[DefaultKey]).To(ContainSubstring("kind: "+kind), "Test [%s]:", kind)
+	}
+}
+
+func TestReconcileDeletedFailureCount(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&ksmv1.CustomResourceStateMetrics{}).Build()
+	r := &CustomResourceStateMetricsReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(100)}
+
+	instance := newTestInstance("deleted-failing", "deleted-config", "Foo")
+	instance.UID = types.UID(instance.Name)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)}
+
+	g.Expect(c.Create(ctx, instance)).To(Succeed())
+
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The instance is deleted while its writes are failing
+	writeFailures.inc(instance.UID)
+
+	g.Expect(c.Delete(ctx, instance)).To(Succeed())
+
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	writeFailures.mu.Lock()
+	defer writeFailures.mu.Unlock()
+
+	g.Expect(writeFailures.counts).NotTo(HaveKey(instance.UID))
+}
+
+func TestReconcileRollbackEvent(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&ksmv1.CustomResourceStateMetrics{}).Build()
+	recorder := record.NewFakeRecorder(100)
+	r := &CustomResourceStateMetricsReconciler{Client: c, Scheme: scheme, Recorder: recorder}
+
+	instance := newTestInstance("rolled-back", "rolled-back-config", "Foo")
+	instance.UID = types.UID(instance.Name)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)}
+
+	g.Expect(c.Create(ctx, instance)).To(Succeed())
+
+	reconcileWith := func(change func(*ksmv1.CustomResourceStateMetrics)) {
+		g.Expect(c.Get(ctx, req.NamespacedName, instance)).To(Succeed())
+		change(instance)
+		g.Expect(c.Update(ctx, instance)).To(Succeed())
+
+		_, err := r.Reconcile(ctx, req)
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	rolledBackEvents := func() int {
+		count := 0
+
+		for len(recorder.Events) > 0 {
+			if strings.Contains(<-recorder.Events, reasonRolledBack) {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	reconcileWith(func(*ksmv1.CustomResourceStateMetrics) {})
+	reconcileWith(func(instance *ksmv1.CustomResourceStateMetrics) {
+		instance.Spec.Resources[0].GroupVersionKind.Kind = "Bar"
+		instance.Generation++
+	})
+	g.Expect(rolledBackEvents()).To(BeZero(), "Test [rendered]:")
+
+	// The rollback is reported once
+	reconcileWith(func(instance *ksmv1.CustomResourceStateMetrics) {
+		instance.Annotations = map[string]string{RollbackToAnnotation: "1"}
+	})
+	g.Expect(rolledBackEvents()).To(Equal(1), "Test [rolled-back]:")
+
+	reconcileWith(func(*ksmv1.CustomResourceStateMetrics) {})
+	g.Expect(rolledBackEvents()).To(BeZero(), "Test [applied]:")
+}
+
+func TestConfigMapTargetKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		target   *ksmv1.CustomResourceStateMetricsTarget
+		expected []string
+	}{
+		"not_written": {},
+		"configmap": {
+			target:   &ksmv1.CustomResourceStateMetricsTarget{Name: "ksm", Namespace: "monitoring"},
+			expected: []string{"monitoring/ksm"},
 		},
-		"out-of-bound-begin": {
-			begin:    -100,
-			end:      3,
-			expected: "0\n1\n2\n3\n",
+		"replicated": {
+			target:   &ksmv1.CustomResourceStateMetricsTarget{Name: "ksm", Namespaces: []string{"a", "b"}},
+			expected: []string{"a/ksm", "b/ksm"},
 		},
-		"out-of-bound-end": {
-			begin:    7,
-			end:      1000,
-			expected: "7\n8\n9",
+		"secret": {
+			target: &ksmv1.CustomResourceStateMetricsTarget{
+				Kind: ksmv1.TargetKindSecret, Name: "ksm", Namespace: "monitoring"},
 		},
 	}
 
-	r := CustomResourceStateMetricsReconciler{}
+	for name, test := range tests {
+		instance := &ksmv1.CustomResourceStateMetrics{
+			Status: ksmv1.CustomResourceStateMetricsStatus{ConfigMap: test.target},
+		}
 
-	lines := []string{
-		"0",
-		"1",
-		"2",
-		"3",
-		"4",
-		"5",
-		"6",
-		"7",
-		"8",
-		"9",
+		g.Expect(configMapTargetKeys(instance)).To(ConsistOf(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestSecretTargetKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		target   *ksmv1.CustomResourceStateMetricsTarget
+		expected []string
+	}{
+		"not_written": {},
+		"configmap": {
+			target: &ksmv1.CustomResourceStateMetricsTarget{
+				Kind: ksmv1.TargetKindConfigMap, Name: "ksm", Namespace: "monitoring"},
+		},
+		"secret": {
+			target: &ksmv1.CustomResourceStateMetricsTarget{
+				Kind: ksmv1.TargetKindSecret, Name: "ksm", Namespace: "monitoring"},
+			expected: []string{"monitoring/ksm"},
+		},
 	}
 
 	for name, test := range tests {
-		result := r.joinLines(lines, test.begin, test.end)
+		instance := &ksmv1.CustomResourceStateMetrics{
+			Status: ksmv1.CustomResourceStateMetricsStatus{ConfigMap: test.target},
+		}
 
-		g.Expect(result).To(Equal(test.expected), "Test [%s]:", name)
+		g.Expect(secretTargetKeys(instance)).To(ConsistOf(test.expected), "Test [%s]:", name)
 	}
 }
+
+func TestTargetToInstances(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	newInstance := func(name string, kind ksmv1.TargetKind) *ksmv1.CustomResourceStateMetrics {
+		instance := newTestInstance(name, "ksm", "Foo")
+		instance.Status.ConfigMap = &ksmv1.CustomResourceStateMetricsTarget{
+			Kind: kind, Name: "ksm", Namespace: "monitoring"}
+
+		return instance
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(newInstance("configmap", ksmv1.TargetKindConfigMap), newInstance("secret", ksmv1.TargetKindSecret)).
+		WithIndex(&ksmv1.CustomResourceStateMetrics{}, configMapTargetIndex, configMapTargetKeys).
+		WithIndex(&ksmv1.CustomResourceStateMetrics{}, secretTargetIndex, secretTargetKeys).
+		Build()
+	r := &CustomResourceStateMetricsReconciler{
+		Client: c, Scheme: scheme, Selector: labels.Everything(), NamespaceSelector: labels.Everything()}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ksm", Namespace: "monitoring"}}
+	requests := r.targetToInstances(secretTargetIndex)(context.Background(), secret)
+	g.Expect(requests).To(ConsistOf(reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: "secret", Namespace: "default"}}), "Test [secret]:")
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "ksm", Namespace: "monitoring"}}
+	requests = r.targetToInstances(configMapTargetIndex)(context.Background(), cm)
+	g.Expect(requests).To(ConsistOf(reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: "configmap", Namespace: "default"}}), "Test [configmap]:")
+}
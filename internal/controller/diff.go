@@ -0,0 +1,189 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of the unchanged lines around the changes in the unified diff.
+const diffContextLines = 3
+
+// Maximum length of the diff of the change recorded in the events and in the
+// status (the event messages are truncated by the API server at 1024 bytes).
+const changeDiffMaxLength = 800
+
+// changeDiff returns the lines removed from the old content and the lines added
+// by the new content truncated to the maximum length. The number of the
+// omitted lines is noted at the end.
+func changeDiff(oldContent, newContent string) string {
+	diff := diffLines(oldContent, newContent)
+	lines := make([]string, 0, len(diff))
+	length := 0
+
+	for i, line := range diff {
+		if length += len(line) + 1; length > changeDiffMaxLength {
+			lines = append(lines, fmt.Sprintf("... (%d more lines)", len(diff)-i))
+
+			break
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// diffLines returns the lines removed from the old content (prefixed with -)
+// and the lines added by the new content (prefixed with +) in the order of
+// the content. The unchanged lines are omitted.
+func diffLines(oldContent, newContent string) []string {
+	if oldContent == newContent {
+		return nil
+	}
+
+	diff := []string{}
+
+	for _, line := range diffScript(splitLines(oldContent), splitLines(newContent)) {
+		if !strings.HasPrefix(line, " ") {
+			diff = append(diff, line)
+		}
+	}
+
+	return diff
+}
+
+// UnifiedDiff returns the unified diff of the old and the new content labeled
+// by their names. It returns an empty string if the content doesn't differ.
+func UnifiedDiff(oldName, newName, oldContent, newContent string) string {
+	script := diffScript(splitLines(oldContent), splitLines(newContent))
+
+	// Line numbers of the old and the new content preceding each line of the script
+	oldPos := make([]int, len(script)+1)
+	newPos := make([]int, len(script)+1)
+
+	for i, line := range script {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+
+		if !strings.HasPrefix(line, "+") {
+			oldPos[i+1]++
+		}
+
+		if !strings.HasPrefix(line, "-") {
+			newPos[i+1]++
+		}
+	}
+
+	// Ranges of the script forming the hunks
+	hunks := [][2]int{}
+
+	for i, line := range script {
+		if strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		start, end := max(0, i-diffContextLines), min(len(script), i+1+diffContextLines)
+
+		if n := len(hunks); n > 0 && hunks[n-1][1] >= start {
+			hunks[n-1][1] = end
+		} else {
+			hunks = append(hunks, [2]int{start, end})
+		}
+	}
+
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, hunk := range hunks {
+		start, end := hunk[0], hunk[1]
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(oldPos[start], oldPos[end]-oldPos[start]), hunkRange(newPos[start], newPos[end]-newPos[start]))
+
+		for _, line := range script[start:end] {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// hunkRange returns the range of the lines of the hunk header. The empty range
+// refers to the line preceding it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// diffScript returns the lines of the old and the new content prefixed with -
+// if they were removed, with + if they were added and with a space if they
+// are unchanged. The removals precede the additions.
+func diffScript(oldLines, newLines []string) []string {
+	// Length of the longest common subsequence of the remaining lines
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	script := []string{}
+	i, j := 0, 0
+
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			script = append(script, " "+oldLines[i])
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			script = append(script, "-"+oldLines[i])
+			i++
+		default:
+			script = append(script, "+"+newLines[j])
+			j++
+		}
+	}
+
+	return script
+}
+
+// splitLines splits the content into lines ignoring the trailing newline.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiffLines(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name       string
+		oldContent string
+		newContent string
+		diff       []string
+	}{
+		{
+			name:       "same",
+			oldContent: "a\nb\n",
+			newContent: "a\nb\n",
+			diff:       nil,
+		},
+		{
+			name:       "created",
+			oldContent: "",
+			newContent: "a\nb\n",
+			diff:       []string{"+a", "+b"},
+		},
+		{
+			name:       "removed",
+			oldContent: "a\nb\n",
+			newContent: "",
+			diff:       []string{"-a", "-b"},
+		},
+		{
+			name:       "changed",
+			oldContent: "a\nb\nc\n",
+			newContent: "a\nx\nc\nd\n",
+			diff:       []string{"-b", "+x", "+d"},
+		},
+		{
+			name:       "trailing-newline",
+			oldContent: "a\nb",
+			newContent: "a\nb\n",
+			diff:       []string{},
+		},
+	}
+
+	for _, test := range tests {
+		g.Expect(diffLines(test.oldContent, test.newContent)).To(Equal(test.diff), "Test [%s]:", test.name)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name       string
+		oldContent string
+		newContent string
+		diff       string
+	}{
+		{
+			name:       "same",
+			oldContent: "a\nb\n",
+			newContent: "a\nb\n",
+			diff:       "",
+		},
+		{
+			name:       "created",
+			oldContent: "",
+			newContent: "a\n",
+			diff:       "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name:       "changed",
+			oldContent: "a\nb\nc\n",
+			newContent: "a\nx\nc\nd\n",
+			diff:       "--- old\n+++ new\n@@ -1,3 +1,4 @@\n a\n-b\n+x\n c\n+d\n",
+		},
+		{
+			name:       "hunks",
+			oldContent: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			newContent: "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n",
+			diff: "--- old\n+++ new\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+y\n",
+		},
+	}
+
+	for _, test := range tests {
+		g.Expect(UnifiedDiff("old", "new", test.oldContent, test.newContent)).To(
+			Equal(test.diff), "Test [%s]:", test.name)
+	}
+}
+
+func TestChangeDiff(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(changeDiff("a\nb\n", "a\nb\n")).To(BeEmpty(), "Test [unchanged]:")
+	g.Expect(changeDiff("a\nb\nc\n", "a\nd\nc\n")).To(Equal("-b\n+d"), "Test [changed]:")
+
+	// Long diffs are truncated
+	line := strings.Repeat("x", 99)
+	diff := changeDiff("", strings.Repeat(line+"\n", 20))
+
+	g.Expect(len(diff)).To(BeNumerically("<=", changeDiffMaxLength+32), "Test [truncated]:")
+	g.Expect(diff).To(HaveSuffix("\n... (13 more lines)"), "Test [truncated]:")
+}
@@ -0,0 +1,789 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Prefixes of the comments delimiting the blocks of the instances written by
+// the older versions of the operator.
+const legacyBeginPrefix = "# BEGIN CustomResourceStateMetrics "
+const legacyEndPrefix = "# END CustomResourceStateMetrics "
+
+// Indentation of the written document.
+const documentIndent = 2
+
+// Synthetic owner of the resources which were in the ConfigMap before the
+// operator took it over. The resources are preserved as a block which is
+// never removed by the operator.
+const ManualOwner = "manual"
+
+// resourceList is the parsed document with the resources list of the
+// CustomResourceStateMetrics document and the owner of each resource.
+type resourceList struct {
+	doc    *yaml.Node
+	seq    *yaml.Node
+	owners []string
+}
+
+// mergeResources merges the rendered resources of the instance into the
+// resources list of the CustomResourceStateMetrics document located at the
+// dot-separated path (the whole document if the path is empty). Each resource
+// is marked with a head comment so it can be replaced or removed later and the
+// resources are ordered by the owning instance. If the instance has no
+// resources yet, identical unmarked resources are adopted instead of being
+// duplicated. It returns whether the resources were adopted.
+func mergeResources(content, path, instanceNamespacedName, dataYaml string) (string, bool, error) {
+	list, err := loadResources(content, path, true)
+	if err != nil {
+		return "", false, err
+	}
+
+	items, err := decodeResources(dataYaml)
+	if err != nil {
+		return "", false, err
+	}
+
+	marker := formatMarker(instanceNamespacedName)
+
+	for _, item := range items {
+		item.HeadComment = marker
+	}
+
+	adopted := false
+	if !slices.Contains(list.owners, instanceNamespacedName) {
+		adopted = list.adopt(items, instanceNamespacedName)
+	}
+
+	// Replace the resources of the instance
+	list.remove(instanceNamespacedName)
+
+	for _, item := range items {
+		list.seq.Content = append(list.seq.Content, item)
+		list.owners = append(list.owners, instanceNamespacedName)
+	}
+
+	list.sort()
+
+	data, err := encodeDocument(list.doc)
+
+	return data, adopted, err
+}
+
+// deduplicateResources drops the rendered resources of the instance identical
+// to the resources of the other instances in the resources list of the
+// CustomResourceStateMetrics document located at the dot-separated path so
+// kube-state-metrics doesn't register the same metrics twice. The resources
+// are compared by the hash of their normalized content. It returns the
+// remaining resources and the owners of the dropped duplicates.
+func deduplicateResources(content, path, instanceNamespacedName, dataYaml string) (string, []string, error) {
+	list, err := loadResources(content, path, false)
+	if err != nil || list.seq == nil {
+		return dataYaml, nil, err
+	}
+
+	hashes := make(map[string]string)
+
+	for i, item := range list.seq.Content {
+		if list.owners[i] == "" || list.owners[i] == instanceNamespacedName {
+			continue
+		}
+
+		if hash := resourceHash(item); hash != "" {
+			if _, ok := hashes[hash]; !ok {
+				hashes[hash] = list.owners[i]
+			}
+		}
+	}
+
+	if len(hashes) == 0 {
+		return dataYaml, nil, nil
+	}
+
+	items, err := decodeResources(dataYaml)
+	if err != nil {
+		return "", nil, err
+	}
+
+	kept := make([]*yaml.Node, 0, len(items))
+	owners := []string{}
+
+	for _, item := range items {
+		owner, ok := hashes[resourceHash(item)]
+		if !ok {
+			kept = append(kept, item)
+
+			continue
+		}
+
+		if !slices.Contains(owners, owner) {
+			owners = append(owners, owner)
+		}
+	}
+
+	if len(owners) == 0 {
+		return dataYaml, nil, nil
+	}
+
+	data, err := encodeResources(kept)
+
+	return data, owners, err
+}
+
+// takeOverResources marks the unmarked resources of the resources list of the
+// CustomResourceStateMetrics document located at the dot-separated path (the
+// whole document if the path is empty) as owned by the synthetic manual owner
+// so they are preserved by the rebuild. It returns false if there were no
+// unmarked resources.
+func takeOverResources(content, path string) (string, bool, error) {
+	list, err := loadResources(content, path, false)
+	if err != nil || list.seq == nil || !slices.Contains(list.owners, "") {
+		return content, false, err
+	}
+
+	for i, owner := range list.owners {
+		if owner == "" {
+			list.owners[i] = ManualOwner
+		}
+	}
+
+	markResources(list.seq, list.owners)
+	list.sort()
+
+	data, err := encodeDocument(list.doc)
+
+	return data, true, err
+}
+
+// removeResources removes the resources of the instance from the resources
+// list of the CustomResourceStateMetrics document located at the dot-separated
+// path (the whole document if the path is empty). It returns false if there
+// were no resources of the instance.
+func removeResources(content, path, instanceNamespacedName string) (string, bool, error) {
+	list, err := loadResources(content, path, false)
+	if err != nil || list.seq == nil || !slices.Contains(list.owners, instanceNamespacedName) {
+		return content, false, err
+	}
+
+	list.remove(instanceNamespacedName)
+	list.sort()
+
+	data, err := encodeDocument(list.doc)
+
+	return data, true, err
+}
+
+// loadResources parses the document and identifies the owners of the
+// resources. The markers are normalized so the blocks written by the older
+// versions get converted with the next write.
+func loadResources(content, path string, create bool) (*resourceList, error) {
+	doc, err := parseDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, err := documentResources(doc, path, create)
+	if err != nil || seq == nil {
+		return &resourceList{doc: doc}, err
+	}
+
+	owners := resourceOwners(seq.Content)
+
+	stripComments(doc, isLegacyMarker)
+	markResources(seq, owners)
+
+	return &resourceList{doc: doc, seq: seq, owners: owners}, nil
+}
+
+// markResources replaces the markers of the resources by the markers of their
+// owners.
+func markResources(seq *yaml.Node, owners []string) {
+	for i, item := range seq.Content {
+		if owners[i] == "" {
+			continue
+		}
+
+		stripComments(item, isResourceMarker)
+
+		// Keep the other comments below the marker
+		item.HeadComment = strings.TrimSpace(formatMarker(owners[i]) + "\n" + item.HeadComment)
+	}
+}
+
+// adopt marks the unmarked resources identical to the rendered resources as
+// owned by the instance. It returns false unless all rendered resources were
+// found.
+func (l *resourceList) adopt(items []*yaml.Node, instanceNamespacedName string) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	matched := []int{}
+
+	for _, item := range items {
+		index := -1
+
+		for i, candidate := range l.seq.Content {
+			if l.owners[i] == "" && !slices.Contains(matched, i) && sameResource(candidate, item) {
+				index = i
+
+				break
+			}
+		}
+
+		if index < 0 {
+			return false
+		}
+
+		matched = append(matched, index)
+	}
+
+	for _, i := range matched {
+		l.owners[i] = instanceNamespacedName
+	}
+
+	return true
+}
+
+// remove removes the resources of the instance from the list.
+func (l *resourceList) remove(instanceNamespacedName string) {
+	items := make([]*yaml.Node, 0, len(l.seq.Content))
+	owners := make([]string, 0, len(l.owners))
+
+	for i, item := range l.seq.Content {
+		if l.owners[i] != instanceNamespacedName {
+			items = append(items, item)
+			owners = append(owners, l.owners[i])
+		}
+	}
+
+	l.seq.Content, l.owners = items, owners
+}
+
+// sort orders the resources by the Namespace and name of the owning instance
+// so the content doesn't depend on the order in which the instances were
+// reconciled. Unmarked resources are kept first in their original order and
+// the resources of each instance keep their rendered order.
+func (l *resourceList) sort() {
+	indexes := make([]int, len(l.seq.Content))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	slices.SortStableFunc(indexes, func(a, b int) int {
+		return compareOwners(l.owners[a], l.owners[b])
+	})
+
+	items := make([]*yaml.Node, 0, len(indexes))
+	owners := make([]string, 0, len(indexes))
+
+	for _, i := range indexes {
+		items = append(items, l.seq.Content[i])
+		owners = append(owners, l.owners[i])
+	}
+
+	l.seq.Content, l.owners = items, owners
+}
+
+// compareOwners compares the namespaced names (name@namespace) of the owning
+// instances by the Namespace first. Unmarked resources come first.
+func compareOwners(a, b string) int {
+	aName, aNamespace, _ := strings.Cut(a, "@")
+	bName, bNamespace, _ := strings.Cut(b, "@")
+
+	if c := cmp.Compare(aNamespace, bNamespace); c != 0 {
+		return c
+	}
+
+	return cmp.Compare(aName, bName)
+}
+
+// parseDocument parses the YAML document. Empty content results in an empty
+// map. The documents following the first one (e.g. the headers repeated after
+// a separator) and the duplicated keys are merged so a single canonical
+// document gets written back.
+func parseDocument(content string) (*yaml.Node, error) {
+	var doc *yaml.Node
+
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+
+	for {
+		next := &yaml.Node{}
+
+		if err := decoder.Decode(next); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse the ConfigMap content: %w", err)
+		}
+
+		// Skip the empty documents (e.g. after the trailing separator)
+		if len(next.Content) == 0 || isNullNode(next.Content[0]) {
+			continue
+		}
+
+		if next.Content[0].Kind != yaml.MappingNode {
+			return nil, errors.New("the ConfigMap content is not a map")
+		}
+
+		if doc == nil {
+			doc = next
+		} else {
+			doc.Content[0].Content = append(doc.Content[0].Content, next.Content[0].Content...)
+		}
+	}
+
+	if doc == nil {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{newDocumentNode(yaml.MappingNode)}}
+	}
+
+	mergeDuplicateKeys(doc.Content[0])
+
+	return doc, nil
+}
+
+// mergeDuplicateKeys merges the values of the keys repeated in the map and in
+// its nested maps. The empty values are replaced, the maps are merged and the
+// lists are concatenated. Any other value is replaced by the last one the same
+// way the YAML parsers do. The items of the lists are left untouched.
+func mergeDuplicateKeys(node *yaml.Node) {
+	content := make([]*yaml.Node, 0, len(node.Content))
+	index := make(map[string]int)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		j, ok := index[key.Value]
+		if !ok {
+			index[key.Value] = len(content) + 1
+			content = append(content, key, value)
+
+			continue
+		}
+
+		switch previous := content[j]; {
+		case isNullNode(value):
+		case isNullNode(previous):
+			content[j] = value
+		case previous.Kind == value.Kind && (value.Kind == yaml.MappingNode || value.Kind == yaml.SequenceNode):
+			previous.Content = append(previous.Content, value.Content...)
+		default:
+			content[j] = value
+		}
+	}
+
+	node.Content = content
+
+	for i := 1; i < len(node.Content); i += 2 {
+		if node.Content[i].Kind == yaml.MappingNode {
+			mergeDuplicateKeys(node.Content[i])
+		}
+	}
+}
+
+// isNullNode returns true if the node is an empty value.
+func isNullNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// encodeDocument encodes the YAML document.
+func encodeDocument(doc *yaml.Node) (string, error) {
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(documentIndent)
+
+	if err := encoder.Encode(doc); err != nil {
+		return "", fmt.Errorf("failed to encode the ConfigMap content: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode the ConfigMap content: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// decodeResources decodes the rendered resources into the list items.
+func decodeResources(dataYaml string) ([]*yaml.Node, error) {
+	rendered := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte("resources:\n"+dataYaml), rendered); err != nil {
+		return nil, fmt.Errorf("failed to decode the rendered resources: %w", err)
+	}
+
+	if len(rendered.Content) == 0 || len(rendered.Content[0].Content) < 2 {
+		return []*yaml.Node{}, nil
+	}
+
+	return rendered.Content[0].Content[1].Content, nil
+}
+
+// encodeResources encodes the list items the same way as the rendered
+// resources.
+func encodeResources(items []*yaml.Node) (string, error) {
+	if len(items) == 0 {
+		return "", nil
+	}
+
+	data, err := yaml.Marshal(&yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "resources"},
+			{Kind: yaml.SequenceNode, Content: items},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the resources: %w", err)
+	}
+
+	// Remove the first line
+	_, resources, _ := strings.Cut(string(data), "\n")
+
+	return resources, nil
+}
+
+// documentResources returns the resources list of the CustomResourceStateMetrics
+// document located at the dot-separated path. Missing nodes are created if
+// requested, otherwise nil is returned.
+func documentResources(doc *yaml.Node, path string, create bool) (*yaml.Node, error) {
+	node := doc.Content[0]
+
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			if node = documentValue(node, key, yaml.MappingNode, create); node == nil {
+				return nil, nil
+			}
+
+			if node.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("the path %q doesn't point to a map", path)
+			}
+		}
+	}
+
+	// Set the kind of a newly created document
+	if create && documentValue(node, "kind", yaml.ScalarNode, false) == nil {
+		kind := documentValue(node, "kind", yaml.ScalarNode, true)
+		kind.Value = "CustomResourceStateMetrics"
+	}
+
+	spec := documentValue(node, "spec", yaml.MappingNode, create)
+	if spec == nil {
+		return nil, nil
+	}
+
+	if spec.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the spec at the path %q is not a map", path)
+	}
+
+	seq := documentValue(spec, "resources", yaml.SequenceNode, create)
+	if seq == nil {
+		return nil, nil
+	}
+
+	if seq.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("the resources at the path %q are not a list", path)
+	}
+
+	// Make sure the list is written in the block style
+	seq.Style = 0
+
+	return seq, nil
+}
+
+// documentValue returns the value of the key in the map. Missing and empty
+// values are created with the specified kind if requested.
+func documentValue(node *yaml.Node, key string, kind yaml.Kind, create bool) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			continue
+		}
+
+		value := node.Content[i+1]
+
+		// Replace empty value
+		if create && value.Kind == yaml.ScalarNode && (value.Tag == "!!null" || value.Value == "") {
+			*value = *newDocumentNode(kind)
+		}
+
+		return value
+	}
+
+	if !create {
+		return nil
+	}
+
+	value := newDocumentNode(kind)
+
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	node.Style = 0
+
+	return value
+}
+
+// newDocumentNode creates a new empty node of the kind.
+func newDocumentNode(kind yaml.Kind) *yaml.Node {
+	switch kind {
+	case yaml.MappingNode:
+		return &yaml.Node{Kind: kind, Tag: "!!map"}
+	case yaml.SequenceNode:
+		return &yaml.Node{Kind: kind, Tag: "!!seq"}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+	}
+}
+
+// resourceOwners returns the instance owning each of the resources or an empty
+// string for unmarked resources. The BEGIN/END markers of the blocks written
+// by the older versions are recognized too.
+func resourceOwners(items []*yaml.Node) []string {
+	owners, _, _ := scanMarkers(items)
+
+	return owners
+}
+
+// scanMarkers returns the instance owning each of the resources, the
+// descriptions of the corrupted markers and the BEGIN/END blocks which didn't
+// end within the list. The nested and interleaved blocks are tracked so the
+// resources following the end of an inner block still belong to the outer
+// block. The resources of an unterminated block belong to it until the end of
+// the list.
+func scanMarkers(items []*yaml.Node) ([]string, []string, []string) {
+	owners := make([]string, len(items))
+	problems := []string{}
+	blocks := []string{}
+	closed := []string{}
+	runs := []string{}
+
+	problem := func(format string, args ...any) {
+		if message := fmt.Sprintf(format, args...); !slices.Contains(problems, message) {
+			problems = append(problems, message)
+		}
+	}
+
+	legacyMarker := func(line string) {
+		if name, ok := strings.CutPrefix(line, legacyBeginPrefix); ok {
+			switch {
+			case slices.Contains(blocks, name):
+				problem("the block of %s begins twice", name)
+			case slices.Contains(closed, name):
+				problem("the block of %s is duplicated", name)
+			case len(blocks) > 0:
+				problem("the block of %s is nested in the block of %s", name, blocks[len(blocks)-1])
+			}
+
+			blocks = append(blocks, name)
+
+			return
+		}
+
+		name := strings.TrimPrefix(line, legacyEndPrefix)
+		i := slices.Index(blocks, name)
+
+		switch {
+		case i < 0:
+			problem("the block of %s ends without beginning", name)
+
+			return
+		case i < len(blocks)-1:
+			problem("the blocks of %s and %s are interleaved", name, blocks[i+1])
+		}
+
+		blocks = slices.Delete(blocks, i, i+1)
+		closed = append(closed, name)
+	}
+
+	for i, item := range items {
+		markers := []string{}
+
+		for _, line := range headCommentLines(item) {
+			if isLegacyMarker(line) {
+				legacyMarker(line)
+			} else if owner, ok := markerOwner(line); ok {
+				if !slices.Contains(markers, owner) {
+					markers = append(markers, owner)
+				}
+			}
+		}
+
+		if len(markers) > 1 {
+			problem("the resource %d has the markers of %s", i, strings.Join(markers, " and "))
+		}
+
+		if len(markers) > 0 {
+			owners[i] = markers[len(markers)-1]
+		} else if len(blocks) > 0 {
+			owners[i] = blocks[len(blocks)-1]
+		}
+
+		// The marked resources of an instance are expected in a single run
+		if owner := owners[i]; len(markers) > 0 && (i == 0 || owners[i-1] != owner) {
+			if slices.Contains(runs, owner) {
+				problem("the resources of %s are duplicated", owner)
+			}
+
+			runs = append(runs, owner)
+		}
+
+		// The markers following the resource get attached to its last node
+		for _, line := range footCommentLines(item) {
+			if isLegacyMarker(line) {
+				legacyMarker(line)
+			}
+		}
+	}
+
+	return owners, problems, blocks
+}
+
+// checkMarkers returns the descriptions of the corrupted markers in the
+// resources list of the CustomResourceStateMetrics document located at the
+// dot-separated path (the whole document if the path is empty).
+func checkMarkers(content, path string) []string {
+	doc, err := parseDocument(content)
+	if err != nil {
+		return nil
+	}
+
+	seq, err := documentResources(doc, path, false)
+	if err != nil || seq == nil {
+		return nil
+	}
+
+	_, problems, open := scanMarkers(seq.Content)
+
+	// The END marker of the last block gets attached outside of the list
+	lines := footCommentLines(doc)
+
+	for _, name := range open {
+		if !slices.Contains(lines, legacyEndPrefix+name) {
+			problems = append(problems, fmt.Sprintf("the block of %s is not terminated", name))
+		}
+	}
+
+	return problems
+}
+
+// headCommentLines returns the lines of the head comment of the list item. The
+// head comment of the first key is included as the parser might attach the
+// comment to it.
+func headCommentLines(item *yaml.Node) []string {
+	comment := item.HeadComment
+
+	if item.Kind == yaml.MappingNode && len(item.Content) > 0 {
+		comment += "\n" + item.Content[0].HeadComment
+	}
+
+	lines := []string{}
+
+	for _, line := range strings.Split(comment, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// footCommentLines returns the foot comment lines of all nodes of the tree.
+func footCommentLines(node *yaml.Node) []string {
+	lines := []string{}
+
+	for _, line := range strings.Split(node.FootComment, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	for _, child := range node.Content {
+		lines = append(lines, footCommentLines(child)...)
+	}
+
+	return lines
+}
+
+// isResourceMarker returns true if the comment line is a resource marker.
+func isResourceMarker(line string) bool {
+	_, ok := markerOwner(line)
+
+	return ok
+}
+
+// isLegacyMarker returns true if the comment line is a BEGIN/END marker.
+func isLegacyMarker(line string) bool {
+	line = strings.TrimSpace(line)
+
+	return strings.HasPrefix(line, legacyBeginPrefix) || strings.HasPrefix(line, legacyEndPrefix)
+}
+
+// stripComments removes the comment lines matching the function from all nodes
+// of the tree.
+func stripComments(node *yaml.Node, match func(line string) bool) {
+	strip := func(comment string) string {
+		lines := strings.Split(comment, "\n")
+
+		return strings.Join(slices.DeleteFunc(lines, match), "\n")
+	}
+
+	node.HeadComment = strip(node.HeadComment)
+	node.LineComment = strip(node.LineComment)
+	node.FootComment = strip(node.FootComment)
+
+	for _, child := range node.Content {
+		stripComments(child, match)
+	}
+}
+
+// sameResource returns true if both resources have the same content.
+func sameResource(a, b *yaml.Node) bool {
+	var aValue, bValue interface{}
+
+	if a.Decode(&aValue) != nil || b.Decode(&bValue) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(aValue, bValue)
+}
+
+// resourceHash returns the hash of the normalized content of the resource
+// (the map keys sorted, the comments and the styles ignored). It returns an
+// empty string if the resource can't be normalized.
+func resourceHash(item *yaml.Node) string {
+	var value interface{}
+
+	if item.Decode(&value) != nil {
+		return ""
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	return utils.Hash(string(data))
+}
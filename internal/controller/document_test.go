@@ -0,0 +1,392 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMergeResources(t *testing.T) {
+	g := NewWithT(t)
+
+	dataYaml := "    - groupVersionKind:\n        group: myteam.io\n        kind: Foo\n        version: v1\n"
+
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		err     bool
+	}{
+		{
+			name:    "root",
+			content: dataHeader,
+			path:    "",
+		},
+		{
+			name:    "root-empty-map",
+			content: "{}",
+			path:    "",
+		},
+		{
+			name:    "empty",
+			content: "",
+			path:    "customResourceState.config",
+		},
+		{
+			name:    "empty-map",
+			content: "{}",
+			path:    "customResourceState.config",
+		},
+		{
+			name:    "existing-values",
+			content: "replicas: 1\ncustomResourceState:\n  enabled: true\n  config:\n",
+			path:    "customResourceState.config",
+		},
+		{
+			name:    "not-a-map",
+			content: "customResourceState: foo\n",
+			path:    "customResourceState.config",
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		result, adopted, err := mergeResources(test.content, test.path, "foo@bar", dataYaml)
+
+		if test.err {
+			g.Expect(err).To(HaveOccurred(), "Test [%s]:", test.name)
+
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(adopted).To(BeFalse(), "Test [%s]:", test.name)
+		g.Expect(result).To(ContainSubstring("# CustomResourceStateMetrics foo@bar"), "Test [%s]:", test.name)
+		g.Expect(result).To(ContainSubstring("kind: CustomResourceStateMetrics"), "Test [%s]:", test.name)
+
+		// Merging the same resources again must not duplicate them
+		again, _, err := mergeResources(result, test.path, "foo@bar", dataYaml)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(again).To(Equal(result), "Test [%s]:", test.name)
+
+		removed, found, err := removeResources(result, test.path, "foo@bar")
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(found).To(BeTrue(), "Test [%s]:", test.name)
+		g.Expect(removed).NotTo(ContainSubstring("foo@bar"), "Test [%s]:", test.name)
+
+		_, found, err = removeResources(removed, test.path, "foo@bar")
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(found).To(BeFalse(), "Test [%s]:", test.name)
+	}
+}
+
+func TestMergeResourcesLegacy(t *testing.T) {
+	g := NewWithT(t)
+
+	content := dataHeader +
+		"# BEGIN CustomResourceStateMetrics foo@bar\n" +
+		"    - groupVersionKind:\n        kind: Foo\n" +
+		"# END CustomResourceStateMetrics foo@bar\n" +
+		"    - groupVersionKind:\n        kind: Manual\n" +
+		"# BEGIN CustomResourceStateMetrics bar@bar\n" +
+		"    - groupVersionKind:\n        kind: Bar\n" +
+		"    - groupVersionKind:\n        kind: Baz\n" +
+		"# END CustomResourceStateMetrics bar@bar\n"
+
+	result, _, err := mergeResources(content, "", "foo@bar", "    - groupVersionKind:\n        kind: Qux\n")
+	g.Expect(err).NotTo(HaveOccurred(), "Test [merge]:")
+	g.Expect(result).NotTo(ContainSubstring("BEGIN"), "Test [merge]:")
+	g.Expect(result).NotTo(ContainSubstring("END"), "Test [merge]:")
+	g.Expect(result).NotTo(ContainSubstring("kind: Foo"), "Test [merge]:")
+	g.Expect(result).To(ContainSubstring("kind: Qux"), "Test [merge]:")
+
+	list, err := loadResources(result, "", false)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [owners]:")
+	g.Expect(list.owners).To(Equal([]string{"", "bar@bar", "bar@bar", "foo@bar"}), "Test [owners]:")
+
+	removed, found, err := removeResources(content, "", "bar@bar")
+	g.Expect(err).NotTo(HaveOccurred(), "Test [remove]:")
+	g.Expect(found).To(BeTrue(), "Test [remove]:")
+	g.Expect(removed).To(ContainSubstring("kind: Manual"), "Test [remove]:")
+	g.Expect(removed).NotTo(ContainSubstring("kind: Bar"), "Test [remove]:")
+	g.Expect(removed).NotTo(ContainSubstring("kind: Baz"), "Test [remove]:")
+}
+
+func TestParseDocumentDuplicates(t *testing.T) {
+	g := NewWithT(t)
+
+	fooYaml := "    # CustomResourceStateMetrics foo@ns\n    - groupVersionKind:\n        kind: Foo\n"
+	barYaml := "    - groupVersionKind:\n        kind: Bar\n"
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "duplicated-header",
+			content: dataHeader + dataHeader + fooYaml + barYaml,
+		},
+		{
+			name:    "duplicated-resources",
+			content: dataHeader + fooYaml + dataHeader + barYaml,
+		},
+		{
+			name:    "documents",
+			content: dataHeader + fooYaml + "---\n" + dataHeader + barYaml + "---\n",
+		},
+		{
+			name:    "nested",
+			content: "kind: CustomResourceStateMetrics\nspec: {}\nspec:\n  resources:\n" + fooYaml + barYaml,
+		},
+	}
+
+	for _, test := range tests {
+		result, _, err := mergeResources(test.content, "", "baz@ns", "    - groupVersionKind:\n        kind: Baz\n")
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(strings.Count(result, "kind: CustomResourceStateMetrics")).To(Equal(1), "Test [%s]:", test.name)
+		g.Expect(strings.Count(result, "spec:")).To(Equal(1), "Test [%s]:", test.name)
+		g.Expect(result).NotTo(ContainSubstring("---"), "Test [%s]:", test.name)
+
+		list, err := loadResources(result, "", false)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(list.owners).To(Equal([]string{"", "baz@ns", "foo@ns"}), "Test [%s]:", test.name)
+	}
+}
+
+func TestCheckMarkers(t *testing.T) {
+	g := NewWithT(t)
+
+	begin := func(name string) string { return "# BEGIN CustomResourceStateMetrics " + name + "\n" }
+	end := func(name string) string { return "# END CustomResourceStateMetrics " + name + "\n" }
+	marker := func(name string) string { return "    # CustomResourceStateMetrics " + name + "\n" }
+	resource := func(kind string) string { return "    - groupVersionKind:\n        kind: " + kind + "\n" }
+
+	tests := []struct {
+		name     string
+		content  string
+		problems []string
+		owners   []string
+	}{
+		{
+			name: "valid",
+			content: begin("foo@ns") + resource("Foo") + end("foo@ns") + resource("Manual") +
+				marker("bar@ns") + resource("Bar"),
+			problems: []string{},
+			owners:   []string{"", "bar@ns", "foo@ns"},
+		},
+		{
+			name: "nested",
+			content: begin("foo@ns") + resource("Foo") + begin("bar@ns") + resource("Bar") + end("bar@ns") +
+				resource("Foo2") + end("foo@ns") + resource("Manual"),
+			problems: []string{"the block of bar@ns is nested in the block of foo@ns"},
+			owners:   []string{"", "bar@ns", "foo@ns"},
+		},
+		{
+			name: "duplicated-block",
+			content: begin("foo@ns") + resource("Foo") + end("foo@ns") + resource("Manual") +
+				begin("foo@ns") + resource("Foo") + end("foo@ns"),
+			problems: []string{"the block of foo@ns is duplicated"},
+			owners:   []string{"", "foo@ns"},
+		},
+		{
+			name:     "unterminated",
+			content:  resource("Manual") + begin("foo@ns") + resource("Foo") + resource("Bar"),
+			problems: []string{"the block of foo@ns is not terminated"},
+			owners:   []string{"", "foo@ns"},
+		},
+		{
+			name: "duplicated-resources",
+			content: marker("foo@ns") + resource("Foo") + marker("bar@ns") + resource("Bar") +
+				marker("foo@ns") + resource("Foo"),
+			problems: []string{"the resources of foo@ns are duplicated"},
+			owners:   []string{"bar@ns", "foo@ns"},
+		},
+	}
+
+	for _, test := range tests {
+		content := dataHeader + test.content
+
+		g.Expect(checkMarkers(content, "")).To(Equal(test.problems), "Test [%s]:", test.name)
+
+		// The write removes all copies of the resources of the instance and normalizes the markers
+		result, _, err := mergeResources(content, "", "foo@ns", resource("New"))
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(checkMarkers(result, "")).To(BeEmpty(), "Test [%s]:", test.name)
+		g.Expect(result).NotTo(ContainSubstring("kind: Foo"), "Test [%s]:", test.name)
+		g.Expect(strings.Count(result, "kind: New")).To(Equal(1), "Test [%s]:", test.name)
+
+		list, err := loadResources(result, "", false)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(slices.Compact(list.owners)).To(Equal(test.owners), "Test [%s]:", test.name)
+	}
+}
+
+func TestMergeResourcesAdopt(t *testing.T) {
+	g := NewWithT(t)
+
+	content := dataHeader + "  - groupVersionKind:\n      kind: Foo\n  - groupVersionKind:\n      kind: Bar\n"
+
+	tests := []struct {
+		name     string
+		dataYaml string
+		adopted  bool
+		count    int
+	}{
+		{
+			name:     "identical",
+			dataYaml: "    - groupVersionKind:\n        kind: Bar\n",
+			adopted:  true,
+			count:    1,
+		},
+		{
+			name:     "different",
+			dataYaml: "    - groupVersionKind:\n        kind: Baz\n",
+			adopted:  false,
+			count:    1,
+		},
+		{
+			name:     "partial",
+			dataYaml: "    - groupVersionKind:\n        kind: Bar\n    - groupVersionKind:\n        kind: Baz\n",
+			adopted:  false,
+			count:    2,
+		},
+	}
+
+	for _, test := range tests {
+		result, adopted, err := mergeResources(content, "", "foo@bar", test.dataYaml)
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(adopted).To(Equal(test.adopted), "Test [%s]:", test.name)
+		g.Expect(strings.Count(result, "kind: Bar")).To(Equal(test.count), "Test [%s]:", test.name)
+	}
+}
+
+func TestMergeResourcesOrder(t *testing.T) {
+	g := NewWithT(t)
+
+	instances := []string{"foo@team-b", "bar@team-a", "baz@team-a", "qux@team-b"}
+	expected := []string{"bar@team-a", "baz@team-a", "foo@team-b", "qux@team-b"}
+
+	var results []string
+
+	// The content must not depend on the order of the merges
+	for _, order := range [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {2, 0, 3, 1}} {
+		content := dataHeader
+
+		for _, i := range order {
+			dataYaml := "    - groupVersionKind:\n        kind: " + instances[i] + "\n"
+
+			var err error
+
+			content, _, err = mergeResources(content, "", instances[i], dataYaml)
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%v]:", order)
+		}
+
+		list, err := loadResources(content, "", false)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%v]:", order)
+		g.Expect(list.owners).To(Equal(expected), "Test [%v]:", order)
+
+		results = append(results, content)
+	}
+
+	g.Expect(results[1]).To(Equal(results[0]), "Test [reproducible]:")
+	g.Expect(results[2]).To(Equal(results[0]), "Test [reproducible]:")
+}
+
+func TestDeduplicateResources(t *testing.T) {
+	g := NewWithT(t)
+
+	content, _, err := mergeResources(dataHeader, "", "foo@bar",
+		"    - groupVersionKind:\n        kind: Foo\n        version: v1\n    - groupVersionKind:\n        kind: Bar\n")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	tests := []struct {
+		name     string
+		instance string
+		dataYaml string
+		expected string
+		owners   []string
+	}{
+		{
+			name:     "unique",
+			instance: "baz@bar",
+			dataYaml: "    - groupVersionKind:\n        kind: Baz\n",
+			expected: "    - groupVersionKind:\n        kind: Baz\n",
+			owners:   nil,
+		},
+		{
+			name:     "normalized",
+			instance: "baz@bar",
+			dataYaml: "    - groupVersionKind: {version: v1, kind: Foo}\n    - groupVersionKind:\n        kind: Baz\n",
+			expected: "    - groupVersionKind:\n        kind: Baz\n",
+			owners:   []string{"foo@bar"},
+		},
+		{
+			name:     "all",
+			instance: "baz@bar",
+			dataYaml: "    - groupVersionKind:\n        kind: Bar\n",
+			expected: "",
+			owners:   []string{"foo@bar"},
+		},
+		{
+			name:     "own",
+			instance: "foo@bar",
+			dataYaml: "    - groupVersionKind:\n        kind: Bar\n",
+			expected: "    - groupVersionKind:\n        kind: Bar\n",
+			owners:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		result, owners, err := deduplicateResources(content, "", test.instance, test.dataYaml)
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		g.Expect(result).To(Equal(test.expected), "Test [%s]:", test.name)
+		g.Expect(owners).To(Equal(test.owners), "Test [%s]:", test.name)
+	}
+}
+
+func TestTakeOverResources(t *testing.T) {
+	g := NewWithT(t)
+
+	content, _, err := mergeResources(dataHeader+"  - groupVersionKind:\n      kind: Manual\n", "", "foo@bar",
+		"    - groupVersionKind:\n        kind: Foo\n")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	data, tookOver, err := takeOverResources(content, "")
+	g.Expect(err).NotTo(HaveOccurred(), "Test [unmarked]:")
+	g.Expect(tookOver).To(BeTrue(), "Test [unmarked]:")
+	g.Expect(data).To(ContainSubstring(formatMarker(ManualOwner)+"\n"), "Test [unmarked]:")
+
+	list, err := loadResources(data, "", false)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(list.owners).To(Equal([]string{ManualOwner, "foo@bar"}), "Test [owners]:")
+
+	again, tookOver, err := takeOverResources(data, "")
+	g.Expect(err).NotTo(HaveOccurred(), "Test [marked]:")
+	g.Expect(tookOver).To(BeFalse(), "Test [marked]:")
+	g.Expect(again).To(Equal(data), "Test [marked]:")
+
+	// The taken over resources survive the removal of the other blocks
+	data, _, err = removeResources(data, "", "foo@bar")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data).To(ContainSubstring("kind: Manual"), "Test [removal]:")
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Reason of the status condition and of the event of the write skipped in the
+// dry-run mode.
+const reasonDryRun = "DryRun"
+
+// errDryRun is returned instead of writing the ConfigMap in the dry-run mode.
+var errDryRun = errors.New("the ConfigMap is not written in the dry-run mode")
+
+// dryRunWrite records what would be written into the ConfigMap (or into the
+// Secret) instead of writing it. The full diff of the changed keys is logged.
+func (r *CustomResourceStateMetricsReconciler) dryRunWrite(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap) error {
+	cmNamespacedName := utils.NamespacedName(cm.Name, cm.Namespace)
+	current := &corev1.ConfigMap{}
+
+	if err := r.getConfigMap(
+		ctx, client.ObjectKeyFromObject(cm), targetKind(instance), current); client.IgnoreNotFound(err) != nil {
+		return withReason(resultWriteError, fmt.Errorf("failed to get the ConfigMap: %w", err))
+	}
+
+	keys := managedKeys(cm)
+
+	for _, key := range managedKeys(current) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	changes := []string{}
+
+	for _, key := range keys {
+		diff := diffLines(current.Data[key], cm.Data[key])
+		if len(diff) == 0 {
+			continue
+		}
+
+		added, removed := 0, 0
+
+		for _, line := range diff {
+			if strings.HasPrefix(line, "+") {
+				added++
+			} else {
+				removed++
+			}
+		}
+
+		changes = append(changes, fmt.Sprintf("%s (+%d -%d lines)", key, added, removed))
+
+		log.Info(
+			"Dry run: the ConfigMap key would change",
+			"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+			"configMap", cmNamespacedName,
+			"key", key,
+			"diff", strings.Join(diff, "\n"))
+	}
+
+	message := fmt.Sprintf("The content of the ConfigMap %s would not change.", cmNamespacedName)
+	if len(changes) > 0 {
+		message = fmt.Sprintf(
+			"The keys %s of the ConfigMap %s would change.", strings.Join(changes, ", "), cmNamespacedName)
+	}
+
+	// Record the event
+	r.Recorder.Event(instance, corev1.EventTypeNormal, reasonDryRun, message)
+
+	return fmt.Errorf("%w: %s", errDryRun, message)
+}
+
+// dryRunResult records the skipped write in the status of the instance. The
+// instance is reconciled again once it or the ConfigMap changes.
+func (r *CustomResourceStateMetricsReconciler) dryRunResult(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, instanceNamespacedName string,
+	err error) (ctrl.Result, error) {
+	log.V(1).Info("Write was skipped in the dry-run mode", "instance", instanceNamespacedName)
+
+	message := strings.TrimPrefix(err.Error(), errDryRun.Error()+": ")
+
+	// Update the status conditions
+	setNotSynced(instance, reasonDryRun, message)
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonDryRun,
+		Message:            message,
+	})
+	if err := r.updateStatus(ctx, instance); err != nil {
+		return ctrl.Result{}, fmt.Errorf(
+			"failed to update status for the CustomResourceStateMetrics instance %s: %w",
+			instanceNamespacedName, err)
+	}
+
+	return ctrl.Result{}, nil
+}
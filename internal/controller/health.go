@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// Default number of the recently reconciled instances the failure ratio is
+// computed from.
+const DefaultHealthWindow = 20
+
+// Time the health check waits for the informer caches to sync.
+const cacheSyncCheckTimeout = time.Second
+
+// ReconcileHealth tracks the results of the last reconciles of the recently
+// reconciled instances so the health probes can report the operator unhealthy
+// once too many of them fail. Each instance is counted once regardless of how
+// often it's reconciled so a single instance retrying its failure can't make
+// the operator unhealthy.
+type ReconcileHealth struct {
+	Threshold float64
+
+	mu      sync.Mutex
+	window  int
+	results map[string]bool
+	order   []string
+}
+
+// NewReconcileHealth creates a new ReconcileHealth reporting unhealthy once
+// the ratio of the failing instances out of the last window reconciled
+// instances reaches the threshold. Zero threshold disables the check.
+func NewReconcileHealth(window int, threshold float64) *ReconcileHealth {
+	return &ReconcileHealth{
+		Threshold: threshold,
+		window:    max(window, 1),
+		results:   make(map[string]bool),
+	}
+}
+
+// record records the result of the reconcile of the instance. The failures
+// caused by the spec or by the environment (e.g. a missing target Namespace or
+// an unreachable API server) don't indicate a problem of the operator and a
+// restart doesn't fix them so they are not recorded.
+func (h *ReconcileHealth) record(instanceNamespacedName string, err error) {
+	if isPermanent(err) || isTransient(err) || isUnavailable(err) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Move the instance to the end of the order
+	if i := slices.Index(h.order, instanceNamespacedName); i >= 0 {
+		h.order = slices.Delete(h.order, i, i+1)
+	}
+
+	h.order = append(h.order, instanceNamespacedName)
+	h.results[instanceNamespacedName] = err != nil
+
+	// Forget the least recently reconciled instance
+	if len(h.order) > h.window {
+		delete(h.results, h.order[0])
+		h.order = h.order[1:]
+	}
+}
+
+// forget drops the results of the deleted instance.
+func (h *ReconcileHealth) forget(instanceNamespacedName string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i := slices.Index(h.order, instanceNamespacedName); i >= 0 {
+		h.order = slices.Delete(h.order, i, i+1)
+		delete(h.results, instanceNamespacedName)
+	}
+}
+
+// failureRatio returns the ratio of the failing instances out of the recently
+// reconciled instances. The ratio is zero until the window is full so the
+// failures of a few instances don't make the operator unhealthy.
+func (h *ReconcileHealth) failureRatio() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.order) < h.window {
+		return 0
+	}
+
+	failures := 0
+
+	for _, failed := range h.results {
+		if failed {
+			failures++
+		}
+	}
+
+	return float64(failures) / float64(len(h.results))
+}
+
+// Check implements healthz.Checker and fails once the ratio of the failing
+// instances reaches the threshold.
+func (h *ReconcileHealth) Check(_ *http.Request) error {
+	if h.Threshold <= 0 {
+		return nil
+	}
+
+	if ratio := h.failureRatio(); ratio >= h.Threshold {
+		return fmt.Errorf("%.0f%% of the last %d reconciled instances failed", ratio*100, h.window) //nolint:mnd
+	}
+
+	return nil
+}
+
+// CacheSyncedChecker returns a healthz.Checker failing until the informer
+// caches are synced.
+func CacheSyncedChecker(c cache.Cache) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), cacheSyncCheckTimeout)
+		defer cancel()
+
+		if !c.WaitForCacheSync(ctx) {
+			return errors.New("the informer caches are not synced")
+		}
+
+		return nil
+	}
+}
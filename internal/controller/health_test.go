@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestReconcileHealth(t *testing.T) {
+	g := NewWithT(t)
+
+	failed := errors.New("failed")
+	notFound := apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "foo")
+	invalid := withReason(resultInvalidResources, failed)
+
+	type result struct {
+		instance string
+		err      error
+	}
+
+	tests := map[string]struct {
+		threshold float64
+		results   []result
+		healthy   bool
+	}{
+		"disabled": {
+			results: []result{{"a", failed}, {"b", failed}, {"c", failed}, {"d", failed}},
+			healthy: true,
+		},
+		"window_not_full": {
+			threshold: 0.5,
+			results:   []result{{"a", failed}, {"b", failed}, {"c", failed}},
+			healthy:   true,
+		},
+		"below_threshold": {
+			threshold: 0.5,
+			results:   []result{{"a", failed}, {"b", nil}, {"c", nil}, {"d", nil}},
+			healthy:   true,
+		},
+		"at_threshold": {
+			threshold: 0.5,
+			results:   []result{{"a", failed}, {"b", nil}, {"c", failed}, {"d", nil}},
+		},
+		"recovered": {
+			threshold: 0.5,
+			results:   []result{{"a", failed}, {"b", failed}, {"c", failed}, {"d", nil}, {"a", nil}, {"b", nil}},
+			healthy:   true,
+		},
+		"single_instance_retrying": {
+			threshold: 0.5,
+			results: []result{
+				{"a", nil}, {"b", nil}, {"c", nil}, {"d", failed},
+				{"d", failed}, {"d", failed}, {"d", failed}, {"d", failed},
+			},
+			healthy: true,
+		},
+		"environment_failures": {
+			threshold: 0.5,
+			results:   []result{{"a", notFound}, {"b", notFound}, {"c", invalid}, {"d", invalid}, {"e", nil}},
+			healthy:   true,
+		},
+		"forgotten_instances": {
+			threshold: 0.5,
+			results:   []result{{"a", failed}, {"b", failed}, {"c", nil}, {"d", nil}, {"e", nil}, {"f", nil}},
+			healthy:   true,
+		},
+	}
+
+	for name, test := range tests {
+		h := NewReconcileHealth(4, test.threshold)
+
+		for _, result := range test.results {
+			h.record(result.instance, result.err)
+		}
+
+		if test.healthy {
+			g.Expect(h.Check(nil)).To(Succeed(), "Test [%s]:", name)
+		} else {
+			g.Expect(h.Check(nil)).NotTo(Succeed(), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestReconcileHealthForget(t *testing.T) {
+	g := NewWithT(t)
+
+	failed := errors.New("failed")
+
+	h := NewReconcileHealth(2, 0.5)
+	h.record("a", failed)
+	h.record("b", nil)
+
+	g.Expect(h.Check(nil)).NotTo(Succeed(), "Test [failing]:")
+
+	// The deleted instance doesn't count anymore
+	h.forget("a")
+	h.record("c", nil)
+
+	g.Expect(h.Check(nil)).To(Succeed(), "Test [forgotten]:")
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Name of the ConfigMap annotation pointing to its current immutable copy.
+const ImmutableAnnotation = "ksm.jtyr.io/immutable-configmap"
+
+// Name of the label identifying the ConfigMap the immutable copy belongs to.
+const ImmutableOfLabel = "ksm.jtyr.io/immutable-of"
+
+// immutableData returns the live content of the ConfigMap (without the
+// staged content) which is copied into the immutable ConfigMap.
+func immutableData(cm *corev1.ConfigMap) map[string]string {
+	data := make(map[string]string)
+
+	for _, key := range managedKeys(cm) {
+		if strings.HasSuffix(key, nextKeySuffix) {
+			continue
+		}
+
+		data[key] = cm.Data[key]
+	}
+
+	return data
+}
+
+// immutableName returns the name of the immutable copy of the ConfigMap
+// holding the data.
+func immutableName(name string, data map[string]string) string {
+	// Marshaling of a map of strings can't fail and its keys are sorted
+	value, _ := json.Marshal(data)
+
+	return name + "-" + utils.Hash(string(value))
+}
+
+// rotateImmutable creates the immutable copy of the content of the ConfigMap
+// and points the ConfigMap to it (persisted with the next write of the
+// ConfigMap). The older copies, except the previous one, are deleted.
+func (r *CustomResourceStateMetricsReconciler) rotateImmutable(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap) error {
+	if !instance.Spec.ConfigMap.Immutable || targetKind(instance) == ksmv1.TargetKindSecret || r.DryRun {
+		return nil
+	}
+
+	data := immutableData(cm)
+	name := immutableName(cm.Name, data)
+	previous := cm.Annotations[ImmutableAnnotation]
+
+	if previous == name {
+		return nil
+	}
+
+	log.V(1).Info(
+		"Creating immutable copy of the ConfigMap",
+		"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+		"configMap", utils.NamespacedName(cm.Name, cm.Namespace),
+		"copy", name)
+
+	readOnly := true
+	immutable := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cm.Namespace,
+			Labels: map[string]string{
+				ImmutableOfLabel: cm.Name,
+			},
+		},
+		Immutable: &readOnly,
+	}
+
+	// Keep the compressed content compressed
+	immutable.Data, immutable.BinaryData = splitData(cm, data)
+
+	if err := r.Create(ctx, immutable); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create the immutable copy of the ConfigMap: %w", err)
+	}
+
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+
+	cm.Annotations[ImmutableAnnotation] = name
+
+	// Delete the copies nobody should be using anymore
+	copies := &corev1.ConfigMapList{}
+	if err := r.List(ctx, copies, client.InNamespace(cm.Namespace),
+		client.MatchingLabels{ImmutableOfLabel: cm.Name}); err != nil {
+		return fmt.Errorf("failed to list the immutable copies of the ConfigMap: %w", err)
+	}
+
+	for i := range copies.Items {
+		if copies.Items[i].Name == name || copies.Items[i].Name == previous {
+			continue
+		}
+
+		log.V(1).Info(
+			"Deleting outdated immutable copy of the ConfigMap",
+			"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+			"configMap", utils.NamespacedName(cm.Name, cm.Namespace),
+			"copy", copies.Items[i].Name)
+
+		if err := r.Delete(ctx, &copies.Items[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete the outdated immutable copy of the ConfigMap: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestImmutableData(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{
+		Data: map[string]string{
+			"config.yaml":      "live\n",
+			"config.yaml-next": "staged\n",
+			"other.yaml":       "unmanaged\n",
+		},
+	}
+
+	setBlockHashes(cm, "config.yaml", "foo@bar", "live\n")
+
+	data := immutableData(cm)
+	g.Expect(data).To(Equal(map[string]string{"config.yaml": "live\n"}), "Test [live-only]:")
+
+	name := immutableName("cm", data)
+	g.Expect(name).To(HavePrefix("cm-"), "Test [name]:")
+	g.Expect(immutableName("cm", map[string]string{"config.yaml": "live\n"})).To(Equal(name), "Test [same-content]:")
+	g.Expect(immutableName("cm", map[string]string{"config.yaml": "changed\n"})).NotTo(Equal(name),
+		"Test [changed-content]:")
+}
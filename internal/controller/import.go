@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ImportedBlock is a part of an existing kube-state-metrics config which can
+// be managed by a single instance.
+type ImportedBlock struct {
+	// Name and Namespace of the instance. The name of the owner is used for the
+	// marked resources. Both are empty for the unmarked resources if they are
+	// not split per resource.
+	Name      string
+	Namespace string
+
+	// Resources is the YAML list of the resources of the block.
+	Resources string
+}
+
+// SplitConfig splits the resources of the CustomResourceStateMetrics document
+// located at the dot-separated path (the whole document if the path is empty)
+// into the blocks of their owners identified by the markers. The unmarked
+// resources are grouped into a single block. If perResource is set, every
+// resource gets its own block named after its kind and group instead.
+func SplitConfig(content, path string, perResource bool) ([]ImportedBlock, error) {
+	list, err := loadResources(content, path, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if list.seq == nil || len(list.seq.Content) == 0 {
+		return nil, errors.New("no resources found in the config")
+	}
+
+	// The markers are meaningless in the manifests
+	stripComments(list.seq, isResourceMarker)
+
+	blocks := []ImportedBlock{}
+	items := [][]*yaml.Node{}
+	index := make(map[string]int)
+
+	for i, item := range list.seq.Content {
+		block := ImportedBlock{}
+		key := list.owners[i]
+
+		if perResource {
+			block.Name = resourceName(item)
+			key = strconv.Itoa(i)
+		} else if list.owners[i] != "" {
+			block.Name, block.Namespace, _ = strings.Cut(list.owners[i], "@")
+		}
+
+		if j, ok := index[key]; ok {
+			items[j] = append(items[j], item)
+
+			continue
+		}
+
+		index[key] = len(blocks)
+		blocks = append(blocks, block)
+		items = append(items, []*yaml.Node{item})
+	}
+
+	uniqueNames(blocks)
+
+	for i := range blocks {
+		data, err := encodeDocument(&yaml.Node{Kind: yaml.SequenceNode, Content: items[i]})
+		if err != nil {
+			return nil, err
+		}
+
+		blocks[i].Resources = data
+	}
+
+	return blocks, nil
+}
+
+// ConfigMapContent returns the content of the ConfigMap key. The compressed
+// keys are decompressed and the JSON keys are converted into YAML.
+func ConfigMapContent(cm *corev1.ConfigMap, key string) (string, error) {
+	cm = cm.DeepCopy()
+
+	if err := loadData(cm); err != nil {
+		return "", err
+	}
+
+	content, ok := cm.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found", key)
+	}
+
+	return content, nil
+}
+
+// resourceName returns the name of the resource derived from its kind and
+// group (e.g. foo.example.com).
+func resourceName(item *yaml.Node) string {
+	name := "resource"
+
+	gvk := documentValue(item, "groupVersionKind", yaml.MappingNode, false)
+	if gvk == nil || gvk.Kind != yaml.MappingNode {
+		return name
+	}
+
+	if kind := documentValue(gvk, "kind", yaml.ScalarNode, false); kind != nil && kind.Value != "" {
+		name = kind.Value
+	}
+
+	if group := documentValue(gvk, "group", yaml.ScalarNode, false); group != nil && group.Value != "" {
+		name += "." + group.Value
+	}
+
+	return strings.ToLower(name)
+}
+
+// uniqueNames appends a numeric suffix to the names of the blocks repeating in
+// the same Namespace.
+func uniqueNames(blocks []ImportedBlock) {
+	seen := make(map[string]int)
+
+	for i := range blocks {
+		if blocks[i].Name == "" {
+			continue
+		}
+
+		key := blocks[i].Name + "@" + blocks[i].Namespace
+
+		if seen[key]++; seen[key] > 1 {
+			blocks[i].Name = fmt.Sprintf("%s-%d", blocks[i].Name, seen[key])
+		}
+	}
+}
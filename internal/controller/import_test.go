@@ -0,0 +1,60 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSplitConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	content := dataHeader +
+		"    # CustomResourceStateMetrics foo@ns\n" +
+		"    - groupVersionKind:\n        group: myteam.io\n        kind: Foo\n" +
+		"    - groupVersionKind:\n        kind: Manual\n" +
+		"    # CustomResourceStateMetrics bar@ns\n" +
+		"    - groupVersionKind:\n        group: myteam.io\n        kind: Bar\n" +
+		"    # CustomResourceStateMetrics bar@ns\n" +
+		"    - groupVersionKind:\n        group: myteam.io\n        kind: Foo\n"
+
+	blocks, err := SplitConfig(content, "", false)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [blocks]:")
+	g.Expect(blocks).To(HaveLen(3), "Test [blocks]:")
+	g.Expect(blocks[0].Name).To(Equal("foo"), "Test [blocks]:")
+	g.Expect(blocks[0].Namespace).To(Equal("ns"), "Test [blocks]:")
+	g.Expect(blocks[1].Name).To(BeEmpty(), "Test [blocks]:")
+	g.Expect(blocks[1].Resources).To(Equal("- groupVersionKind:\n    kind: Manual\n"), "Test [blocks]:")
+	g.Expect(blocks[2].Name).To(Equal("bar"), "Test [blocks]:")
+	g.Expect(blocks[2].Resources).To(ContainSubstring("kind: Bar"), "Test [blocks]:")
+	g.Expect(blocks[2].Resources).To(ContainSubstring("kind: Foo"), "Test [blocks]:")
+	g.Expect(blocks[2].Resources).NotTo(ContainSubstring("CustomResourceStateMetrics"), "Test [blocks]:")
+
+	blocks, err = SplitConfig(content, "", true)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [per-resource]:")
+	g.Expect(blocks).To(HaveLen(4), "Test [per-resource]:")
+	g.Expect([]string{blocks[0].Name, blocks[1].Name, blocks[2].Name, blocks[3].Name}).To(
+		Equal([]string{"foo.myteam.io", "manual", "bar.myteam.io", "foo.myteam.io-2"}), "Test [per-resource]:")
+
+	_, err = SplitConfig(dataHeader, "", false)
+	g.Expect(err).To(HaveOccurred(), "Test [empty]:")
+
+	_, err = SplitConfig(content, "missing", false)
+	g.Expect(err).To(HaveOccurred(), "Test [path]:")
+}
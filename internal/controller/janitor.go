@@ -0,0 +1,297 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Default interval in which the orphaned resources are collected.
+const DefaultJanitorInterval = time.Hour
+
+// Reason of the event recorded on the ConfigMap the orphaned resources were
+// removed from.
+const reasonOrphansRemoved = "OrphansRemoved"
+
+// Logger definition with a prefix.
+var janitorLog = ctrl.Log.WithName("[janitor]")
+
+// Janitor periodically removes the resources of the instances which no
+// longer exist from the managed ConfigMaps. Such resources remain in the
+// ConfigMap if the instance was deleted (e.g. its finalizer was removed)
+// while the operator was not running.
+type Janitor struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+	Interval time.Duration
+
+	// Only log the orphaned resources instead of removing them
+	DryRun bool
+}
+
+// NewJanitor creates a new Janitor.
+func NewJanitor(c client.Client, recorder record.EventRecorder, interval time.Duration) *Janitor {
+	return &Janitor{
+		Client:   c,
+		Recorder: recorder,
+		Interval: interval,
+	}
+}
+
+// NeedLeaderElection makes the janitor collect only on the leader.
+func (j *Janitor) NeedLeaderElection() bool {
+	return true
+}
+
+// Start runs the collection loop until the context is canceled.
+func (j *Janitor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := j.collect(ctx); err != nil {
+				janitorLog.Error(err, "Failed to collect orphaned resources")
+			}
+		}
+	}
+}
+
+// collect removes the orphaned resources from all managed ConfigMaps.
+func (j *Janitor) collect(ctx context.Context) error {
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+
+	if err := j.Client.List(ctx, instances); err != nil {
+		return fmt.Errorf("failed to list CustomResourceStateMetrics: %w", err)
+	}
+
+	existing := make(map[string]struct{}, len(instances.Items))
+
+	// The taken over resources have no instance
+	existing[ManualOwner] = struct{}{}
+
+	for i := range instances.Items {
+		existing[utils.NamespacedName(instances.Items[i].Name, instances.Items[i].Namespace)] = struct{}{}
+	}
+
+	cms := &corev1.ConfigMapList{}
+
+	if err := j.Client.List(ctx, cms); err != nil {
+		return fmt.Errorf("failed to list ConfigMaps: %w", err)
+	}
+
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+
+		// Only the ConfigMaps written by the operator are managed
+		if _, ok := cm.Annotations[BlockHashesAnnotation]; !ok {
+			continue
+		}
+
+		j.collectConfigMap(ctx, cm.DeepCopy(), existing)
+	}
+
+	return nil
+}
+
+// collectConfigMap removes the orphaned resources from the ConfigMap.
+func (j *Janitor) collectConfigMap(ctx context.Context, cm *corev1.ConfigMap, existing map[string]struct{}) {
+	cmNamespacedName := utils.NamespacedName(cm.Name, cm.Namespace)
+
+	// Prevent overwriting the changes of the concurrent reconciles
+	defer targetLocks.lock(types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace})()
+
+	orphans, err := removeOrphans(cm, existing)
+	if err != nil {
+		janitorLog.Error(err, "Failed to remove orphaned resources", "configMap", cmNamespacedName)
+
+		return
+	}
+
+	if len(orphans) == 0 {
+		return
+	}
+
+	janitorLog.Info(
+		"Removing orphaned resources", "configMap", cmNamespacedName, "instances", orphans, "dryRun", j.DryRun)
+
+	if j.DryRun {
+		return
+	}
+
+	if err := applyConfigMap(ctx, j.Client, cm); err != nil {
+		janitorLog.Error(err, "Failed to update the ConfigMap", "configMap", cmNamespacedName)
+
+		return
+	}
+
+	j.Recorder.Eventf(cm, corev1.EventTypeNormal, reasonOrphansRemoved,
+		"Removed resources of the deleted instances: %s.", strings.Join(orphans, ", "))
+}
+
+// removeOrphans removes the resources, the block hashes and the requested
+// metadata of the instances which don't exist from the ConfigMap. It returns
+// the sorted namespaced names of the removed instances.
+func removeOrphans(cm *corev1.ConfigMap, existing map[string]struct{}) ([]string, error) {
+	if err := loadData(cm); err != nil {
+		return nil, err
+	}
+
+	orphans := []string{}
+
+	addOrphan := func(owner string) {
+		if _, ok := existing[owner]; !ok && owner != "" && !slices.Contains(orphans, owner) {
+			orphans = append(orphans, owner)
+		}
+	}
+
+	// Owners of the resources
+	documents := make(map[string]*yaml.Node)
+	lists := make(map[string][]*resourceList)
+
+	for _, key := range managedKeys(cm) {
+		doc, err := parseDocument(cm.Data[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the key %s: %w", key, err)
+		}
+
+		documents[key] = doc
+		lists[key] = documentResourceLists(doc)
+
+		for _, list := range lists[key] {
+			for _, owner := range list.owners {
+				addOrphan(owner)
+			}
+		}
+	}
+
+	// Owners of the block hashes and of the requested metadata
+	cmKeys := []string{}
+
+	for key := range getBlockHashes(cm) {
+		if cmKey, owner, found := strings.Cut(key, "/"); found {
+			addOrphan(owner)
+		} else {
+			cmKeys = append(cmKeys, cmKey)
+		}
+	}
+
+	for owner := range getMetadata(cm) {
+		addOrphan(owner)
+	}
+
+	if len(orphans) == 0 {
+		return nil, nil
+	}
+
+	slices.Sort(orphans)
+
+	for key, doc := range documents {
+		changed := false
+
+		for _, list := range lists[key] {
+			count := len(list.owners)
+
+			for _, owner := range orphans {
+				list.remove(owner)
+			}
+
+			changed = changed || len(list.owners) != count
+		}
+
+		if !changed {
+			continue
+		}
+
+		data, err := encodeDocument(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode the key %s: %w", key, err)
+		}
+
+		cm.Data[key] = data
+	}
+
+	for _, owner := range orphans {
+		for _, cmKey := range cmKeys {
+			setBlockHashes(cm, cmKey, owner, "")
+		}
+
+		setMetadata(cm, owner, nil, nil)
+	}
+
+	return orphans, nil
+}
+
+// documentResourceLists returns the resources lists of all
+// CustomResourceStateMetrics documents nested in the document as the paths
+// of the removed instances are not known.
+func documentResourceLists(doc *yaml.Node) []*resourceList {
+	seqs := []*yaml.Node{}
+
+	var walk func(node *yaml.Node)
+
+	walk = func(node *yaml.Node) {
+		if spec := documentValue(node, "spec", yaml.MappingNode, false); spec != nil && spec.Kind == yaml.MappingNode {
+			if seq := documentValue(spec, "resources", yaml.SequenceNode, false); seq != nil &&
+				seq.Kind == yaml.SequenceNode {
+				seqs = append(seqs, seq)
+			}
+		}
+
+		for i := 1; i < len(node.Content); i += 2 {
+			if node.Content[i].Kind == yaml.MappingNode {
+				walk(node.Content[i])
+			}
+		}
+	}
+
+	walk(doc.Content[0])
+
+	// The owners must be identified before the legacy markers are removed
+	owners := make([][]string, len(seqs))
+	for i, seq := range seqs {
+		owners[i] = resourceOwners(seq.Content)
+	}
+
+	stripComments(doc, isLegacyMarker)
+
+	lists := make([]*resourceList, len(seqs))
+
+	for i, seq := range seqs {
+		markResources(seq, owners[i])
+		lists[i] = &resourceList{doc: doc, seq: seq, owners: owners[i]}
+	}
+
+	return lists
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRemoveOrphans(t *testing.T) {
+	g := NewWithT(t)
+
+	fooYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Foo\n    version: v1\n"
+	barYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Bar\n    version: v1\n"
+
+	tests := map[string]string{
+		"root":   "",
+		"nested": "customResourceState.config",
+	}
+
+	for name, path := range tests {
+		content, _, err := mergeResources("", path, "foo@ns", fooYaml)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+		content, _, err = mergeResources(content, path, "bar@ns", barYaml)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+		cm := &corev1.ConfigMap{Data: map[string]string{"config.yaml": content}}
+
+		setBlockHashes(cm, "config.yaml", "foo@ns", fooYaml)
+		setBlockHashes(cm, "config.yaml", "bar@ns", barYaml)
+		setMetadata(cm, "bar@ns", map[string]string{"team": "bar"}, nil)
+
+		// Nothing to remove while both instances exist
+		orphans, err := removeOrphans(cm.DeepCopy(), map[string]struct{}{"foo@ns": {}, "bar@ns": {}})
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(orphans).To(BeEmpty(), "Test [%s]:", name)
+
+		orphans, err = removeOrphans(cm, map[string]struct{}{"foo@ns": {}})
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(orphans).To(Equal([]string{"bar@ns"}), "Test [%s]:", name)
+
+		expected, _, err := mergeResources("", path, "foo@ns", fooYaml)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(cm.Data["config.yaml"]).To(Equal(expected), "Test [%s]:", name)
+
+		g.Expect(getBlockHashes(cm)).NotTo(HaveKey(blockHashKey("config.yaml", "bar@ns")), "Test [%s]:", name)
+		g.Expect(getBlockHashes(cm)).To(HaveKey(blockHashKey("config.yaml", "foo@ns")), "Test [%s]:", name)
+		g.Expect(blockUnchanged(cm, "config.yaml", "foo@ns", fooYaml)).To(BeTrue(), "Test [%s]:", name)
+		g.Expect(getMetadata(cm)).To(BeEmpty(), "Test [%s]:", name)
+	}
+}
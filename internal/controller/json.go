@@ -0,0 +1,345 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+// Name of the ConfigMap annotation holding the comments (e.g. the resource
+// markers) of the content of the keys stored as JSON keyed by the key and by
+// the path of the commented node. JSON can't hold the comments.
+const JSONKeysAnnotation = "ksm.jtyr.io/json-keys"
+
+// Extension of the key whose content is stored as JSON by default.
+const jsonKeyExtension = ".json"
+
+// nodeComments are the comments of a node and of the key of the map holding
+// it. The document node is recorded as the key of the root node.
+type nodeComments struct {
+	KeyHead string `json:"keyHead,omitempty"`
+	KeyLine string `json:"keyLine,omitempty"`
+	KeyFoot string `json:"keyFoot,omitempty"`
+	Head    string `json:"head,omitempty"`
+	Line    string `json:"line,omitempty"`
+	Foot    string `json:"foot,omitempty"`
+}
+
+// jsonFormat returns true if the content of the instance key should be stored
+// as JSON. The format is derived from the extension of the key unless it's
+// specified explicitly.
+func jsonFormat(instance *ksmv1.CustomResourceStateMetrics, cmKey string) bool {
+	if specTargetKind(instance) == ksmv1.TargetKindConfigMap && instance.Spec.ConfigMap.Format != "" {
+		return instance.Spec.ConfigMap.Format == ksmv1.ConfigFormatJSON
+	}
+
+	return strings.HasSuffix(cmKey, jsonKeyExtension)
+}
+
+// getJSONKeys returns the comments of the content of the keys stored as JSON
+// recorded on the ConfigMap keyed by the key.
+func getJSONKeys(cm *corev1.ConfigMap) map[string]map[string]nodeComments {
+	keys := make(map[string]map[string]nodeComments)
+
+	if value, ok := cm.Annotations[JSONKeysAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &keys); err != nil {
+			// Ignore corrupted annotation, it gets rewritten on the next update
+			return make(map[string]map[string]nodeComments)
+		}
+	}
+
+	return keys
+}
+
+// setJSONKeys records the comments of the content of the keys stored as JSON.
+func setJSONKeys(cm *corev1.ConfigMap, keys map[string]map[string]nodeComments) {
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+
+	if len(keys) == 0 {
+		delete(cm.Annotations, JSONKeysAnnotation)
+
+		return
+	}
+
+	// Marshaling of a map of strings can't fail
+	value, _ := json.Marshal(keys)
+
+	cm.Annotations[JSONKeysAnnotation] = string(value)
+}
+
+// setJSONKey records whether the content of the key (and of its staging key)
+// is stored as JSON. It returns whether the record changed.
+func setJSONKey(cm *corev1.ConfigMap, cmKey string, enabled bool) bool {
+	keys := getJSONKeys(cm)
+
+	if _, ok := keys[cmKey]; ok == enabled {
+		return false
+	}
+
+	if enabled {
+		keys[cmKey] = map[string]nodeComments{}
+	} else {
+		delete(keys, cmKey)
+		delete(keys, cmKey+nextKeySuffix)
+	}
+
+	setJSONKeys(cm, keys)
+
+	return true
+}
+
+// isJSONKey returns true if the content of the key (or of its staging key) is
+// stored as JSON.
+func isJSONKey(jsonKeys map[string]map[string]nodeComments, key string) bool {
+	if _, ok := jsonKeys[key]; ok {
+		return true
+	}
+
+	_, ok := jsonKeys[strings.TrimSuffix(key, nextKeySuffix)]
+
+	return ok
+}
+
+// encodeJSONData converts the content of the keys stored as JSON into JSON and
+// records their comments on the ConfigMap. The content which can't be
+// converted is kept as it is.
+func encodeJSONData(cm *corev1.ConfigMap, content map[string]string) map[string]string {
+	jsonKeys := getJSONKeys(cm)
+	if len(jsonKeys) == 0 {
+		return content
+	}
+
+	converted := make(map[string]string, len(content))
+
+	for key, value := range content {
+		converted[key] = value
+
+		if !isJSONKey(jsonKeys, key) {
+			continue
+		}
+
+		data, comments, err := yamlToJSON(value)
+		if err != nil {
+			log.Error(err, "Failed to convert the content into JSON", "key", key)
+
+			continue
+		}
+
+		converted[key] = data
+		jsonKeys[key] = comments
+	}
+
+	setJSONKeys(cm, jsonKeys)
+
+	return converted
+}
+
+// decodeJSONData converts the content of the keys stored as JSON back into
+// YAML with the recorded comments so the content can be processed the same
+// way as the content stored as YAML.
+func decodeJSONData(cm *corev1.ConfigMap) error {
+	jsonKeys := getJSONKeys(cm)
+	if len(jsonKeys) == 0 {
+		return nil
+	}
+
+	for key, value := range cm.Data {
+		if !isJSONKey(jsonKeys, key) {
+			continue
+		}
+
+		content, err := jsonToYAML(value, jsonKeys[key])
+		if err != nil {
+			return fmt.Errorf("failed to convert the JSON key %s: %w", key, err)
+		}
+
+		cm.Data[key] = content
+	}
+
+	return nil
+}
+
+// yamlToJSON returns the YAML content converted into the indented JSON and
+// the comments of its nodes keyed by their paths.
+func yamlToJSON(content string) (string, map[string]nodeComments, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(content), doc); err != nil {
+		return "", nil, fmt.Errorf("failed to parse the content: %w", err)
+	}
+
+	comments := make(map[string]nodeComments)
+
+	if len(doc.Content) == 0 {
+		return "", comments, nil
+	}
+
+	collectComments(doc, doc.Content[0], "", comments)
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, doc.Content[0]); err != nil {
+		return "", nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", strings.Repeat(" ", documentIndent)); err != nil {
+		return "", nil, err
+	}
+
+	out.WriteString("\n")
+
+	return out.String(), comments, nil
+}
+
+// jsonToYAML returns the JSON content converted into YAML with the comments
+// restored at the recorded paths.
+func jsonToYAML(content string, comments map[string]nodeComments) (string, error) {
+	// JSON is a subset of YAML
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(content), doc); err != nil {
+		return "", fmt.Errorf("failed to parse the content: %w", err)
+	}
+
+	if len(doc.Content) == 0 {
+		return content, nil
+	}
+
+	restoreComments(doc, doc.Content[0], "", comments)
+
+	return encodeDocument(doc)
+}
+
+// collectComments records the comments of the node, of its key and of all its
+// children.
+func collectComments(key, node *yaml.Node, path string, comments map[string]nodeComments) {
+	c := nodeComments{Head: node.HeadComment, Line: node.LineComment, Foot: node.FootComment}
+
+	if key != nil {
+		c.KeyHead, c.KeyLine, c.KeyFoot = key.HeadComment, key.LineComment, key.FootComment
+	}
+
+	if c != (nodeComments{}) {
+		comments[path] = c
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			collectComments(node.Content[i], node.Content[i+1], childPath(path, node.Content[i].Value), comments)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			collectComments(nil, item, childPath(path, strconv.Itoa(i)), comments)
+		}
+	}
+}
+
+// restoreComments restores the recorded comments of the node, of its key and
+// of all its children. The nested nodes are switched into the block style.
+func restoreComments(key, node *yaml.Node, path string, comments map[string]nodeComments) {
+	node.Style = 0
+
+	if c, ok := comments[path]; ok {
+		node.HeadComment, node.LineComment, node.FootComment = c.Head, c.Line, c.Foot
+
+		if key != nil {
+			key.HeadComment, key.LineComment, key.FootComment = c.KeyHead, c.KeyLine, c.KeyFoot
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			node.Content[i].Style = 0
+			restoreComments(node.Content[i], node.Content[i+1], childPath(path, node.Content[i].Value), comments)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			restoreComments(nil, item, childPath(path, strconv.Itoa(i)), comments)
+		}
+	}
+}
+
+// childPath returns the JSON pointer of the child of the node.
+func childPath(path, name string) string {
+	return path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// writeJSON writes the node encoded as JSON keeping the order of the map keys.
+func writeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteString("{")
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+
+			// Marshaling of a string can't fail
+			name, _ := json.Marshal(node.Content[i].Value)
+			buf.Write(name)
+			buf.WriteString(":")
+
+			if err := writeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteString("}")
+	case yaml.SequenceNode:
+		buf.WriteString("[")
+
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteString("]")
+	case yaml.AliasNode:
+		return writeJSON(buf, node.Alias)
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return fmt.Errorf("failed to decode the value at line %d: %w", node.Line, err)
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode the value at line %d: %w", node.Line, err)
+		}
+
+		buf.Write(data)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestJSONFormat(t *testing.T) {
+	g := NewWithT(t)
+
+	instance := &ksmv1.CustomResourceStateMetrics{}
+
+	g.Expect(jsonFormat(instance, "config.yaml")).To(BeFalse(), "Test [yaml-key]:")
+	g.Expect(jsonFormat(instance, "config.json")).To(BeTrue(), "Test [json-key]:")
+
+	instance.Spec.ConfigMap.Format = ksmv1.ConfigFormatJSON
+
+	g.Expect(jsonFormat(instance, "config.yaml")).To(BeTrue(), "Test [explicit-json]:")
+
+	instance.Spec.ConfigMap.Format = ksmv1.ConfigFormatYAML
+
+	g.Expect(jsonFormat(instance, "config.json")).To(BeFalse(), "Test [explicit-yaml]:")
+}
+
+func TestJSONConversion(t *testing.T) {
+	g := NewWithT(t)
+
+	resources := "- groupVersionKind:\n    group: myteam.io\n    kind: Foo\n    version: v1\n" +
+		"  metrics:\n    - name: active\n      help: \"true\"\n      each:\n        type: Gauge\n"
+
+	content, _, err := mergeResources(dataHeader, "", "foo@bar", resources)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	data, comments, err := yamlToJSON(content)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [encode]:")
+	g.Expect(json.Valid([]byte(data))).To(BeTrue(), "Test [valid]:")
+	g.Expect(data).To(HavePrefix("{\n  \"kind\": \"CustomResourceStateMetrics\""), "Test [order]:")
+	g.Expect(data).To(ContainSubstring("\"help\": \"true\""), "Test [types]:")
+	g.Expect(data).NotTo(ContainSubstring("#"), "Test [comments]:")
+	g.Expect(comments).NotTo(BeEmpty(), "Test [comments]:")
+
+	restored, err := jsonToYAML(data, comments)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [decode]:")
+	g.Expect(restored).To(Equal(content), "Test [round-trip]:")
+
+	// The resources keep their owners
+	list, err := loadResources(restored, "", false)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(list.owners).To(Equal([]string{"foo@bar"}), "Test [owners]:")
+}
+
+func TestJSONKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	content, _, err := mergeResources(
+		dataHeader, "", "foo@bar", "- groupVersionKind:\n    group: myteam.io\n    kind: Foo\n    version: v1\n")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Data: map[string]string{
+			"config.json":                 content,
+			"config.json" + nextKeySuffix: content,
+		},
+	}
+
+	setBlockHashes(cm, "config.json", "foo@bar", content)
+
+	g.Expect(setJSONKey(cm, "config.json", false)).To(BeFalse(), "Test [not-json]:")
+	g.Expect(setJSONKey(cm, "config.json", true)).To(BeTrue(), "Test [json]:")
+	g.Expect(setJSONKey(cm, "config.json", true)).To(BeFalse(), "Test [unchanged]:")
+
+	ac := configMapApplyConfiguration(cm)
+
+	g.Expect(json.Valid([]byte(ac.Data["config.json"]))).To(BeTrue(), "Test [data]:")
+	g.Expect(json.Valid([]byte(ac.Data["config.json"+nextKeySuffix]))).To(BeTrue(), "Test [staged]:")
+	g.Expect(ac.Annotations).To(HaveKey(JSONKeysAnnotation), "Test [annotations]:")
+
+	// The content read from the API server gets converted back into YAML
+	read := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Annotations: ac.Annotations},
+		Data:       ac.Data,
+	}
+
+	g.Expect(loadData(read)).To(Succeed(), "Test [load]:")
+	g.Expect(read.Data).To(Equal(cm.Data), "Test [load]:")
+
+	g.Expect(setJSONKey(cm, "config.json", false)).To(BeTrue(), "Test [yaml]:")
+	g.Expect(cm.Annotations).NotTo(HaveKey(JSONKeysAnnotation), "Test [yaml]:")
+	g.Expect(configMapApplyConfiguration(cm).Data).To(HaveKeyWithValue("config.json", content), "Test [yaml]:")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// keyedMutex serializes the read-modify-write of the same target ConfigMap so
+// the concurrent reconciles (and the Janitor and the Rebuilder) don't lose
+// each other's writes. The different ConfigMaps are written in parallel.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[types.NamespacedName]*keyedLock
+}
+
+// keyedLock is the lock of a single key counting its holders and waiters so
+// it can be dropped once unused.
+type keyedLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// newKeyedMutex creates a new keyedMutex without any locks.
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{
+		locks: make(map[types.NamespacedName]*keyedLock),
+	}
+}
+
+// lock locks the key and returns the function unlocking it.
+func (m *keyedMutex) lock(key types.NamespacedName) func() {
+	m.mu.Lock()
+
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		m.locks[key] = l
+	}
+
+	l.refs++
+	m.mu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		l.refs--
+		if l.refs == 0 {
+			delete(m.locks, key)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	g := NewWithT(t)
+
+	m := newKeyedMutex()
+	foo := types.NamespacedName{Name: "foo", Namespace: "default"}
+	bar := types.NamespacedName{Name: "bar", Namespace: "default"}
+
+	// Different keys don't block each other
+	unlockFoo := m.lock(foo)
+	unlockBar := m.lock(bar)
+	g.Expect(m.locks).To(HaveLen(2), "Test [different-keys]:")
+
+	unlockBar()
+	unlockFoo()
+	g.Expect(m.locks).To(BeEmpty(), "Test [released]:")
+
+	// The same key is held by a single holder at a time
+	counter := 0
+	wg := sync.WaitGroup{}
+
+	for range 100 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer m.lock(foo)()
+
+			value := counter
+			counter = value + 1
+		}()
+	}
+
+	wg.Wait()
+
+	g.Expect(counter).To(Equal(100), "Test [same-key]:")
+	g.Expect(m.locks).To(BeEmpty(), "Test [same-key]:")
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	rbacv1ac "k8s.io/client-go/applyconfigurations/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+// Name of the ClusterRole and of the ClusterRoleBinding granting
+// kube-state-metrics the access to the custom resources of the instances.
+const KSMClusterRoleName = "crsm-operator-kube-state-metrics"
+
+// KSMGroupPolicy restricts the API groups kube-state-metrics may be granted
+// the access to so the tenants can't expose arbitrary resources (e.g. the
+// Secrets) through the instances.
+type KSMGroupPolicy struct {
+	patterns []string
+}
+
+// NewKSMGroupPolicy parses the comma-separated list of the allowed API groups
+// which can contain the shell patterns (e.g. myteam.io,*.example.com). Empty
+// list allows no group. The core group is never allowed.
+func NewKSMGroupPolicy(allowed string) (*KSMGroupPolicy, error) {
+	policy := &KSMGroupPolicy{}
+
+	for _, pattern := range strings.Split(allowed, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid API group pattern %q: %w", pattern, err)
+		}
+
+		policy.patterns = append(policy.patterns, pattern)
+	}
+
+	return policy, nil
+}
+
+// Allowed returns true if kube-state-metrics may be granted the access to the
+// resources of the API group.
+func (p *KSMGroupPolicy) Allowed(group string) bool {
+	if p == nil || group == "" {
+		return false
+	}
+
+	for _, pattern := range p.patterns {
+		if matched, _ := path.Match(pattern, group); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ksmPolicyRules returns the rules granting the access to the custom
+// resources of the API groups allowed by the policy. The plural of the
+// resource is taken from the resource definition, resolved with the REST
+// mapper or derived from the kind.
+func ksmPolicyRules(
+	mapper meta.RESTMapper, kinds []ksmv1.CustomResourceStateMetricsKind, policy *KSMGroupPolicy) []rbacv1.PolicyRule {
+	groups := make(map[string]map[string]struct{})
+
+	for _, kind := range kinds {
+		if !policy.Allowed(kind.Group) {
+			log.V(1).Info(
+				"Skipping the API group not allowed for kube-state-metrics",
+				"group", kind.Group,
+				"kind", kind.Kind)
+
+			continue
+		}
+
+		plural := kind.ResourcePlural
+
+		if plural == "" && mapper != nil {
+			mapping, err := mapper.RESTMapping(schema.GroupKind{Group: kind.Group, Kind: kind.Kind}, kind.Version)
+			if err == nil {
+				plural = mapping.Resource.Resource
+			}
+		}
+
+		if plural == "" {
+			plural = strings.ToLower(kind.Kind) + "s"
+		}
+
+		if groups[kind.Group] == nil {
+			groups[kind.Group] = make(map[string]struct{})
+		}
+
+		groups[kind.Group][plural] = struct{}{}
+	}
+
+	rules := []rbacv1.PolicyRule{}
+
+	for _, group := range slices.Sorted(maps.Keys(groups)) {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{group},
+			Resources: slices.Sorted(maps.Keys(groups[group])),
+			Verbs:     []string{"list", "watch"},
+		})
+	}
+
+	return rules
+}
+
+// resourceKinds returns the sorted unique kinds of the resources.
+func resourceKinds(resources []ksm.Resource) []ksmv1.CustomResourceStateMetricsKind {
+	kinds := []ksmv1.CustomResourceStateMetricsKind{}
+
+	for _, resource := range resources {
+		kind := ksmv1.CustomResourceStateMetricsKind{
+			Group:          resource.GroupVersionKind.Group,
+			Version:        resource.GroupVersionKind.Version,
+			Kind:           resource.GroupVersionKind.Kind,
+			ResourcePlural: resource.ResourcePlural,
+		}
+
+		if !slices.Contains(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	if len(kinds) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(kinds, func(a, b ksmv1.CustomResourceStateMetricsKind) int {
+		return strings.Compare(
+			a.Group+"/"+a.Version+"/"+a.Kind+"/"+a.ResourcePlural,
+			b.Group+"/"+b.Version+"/"+b.Kind+"/"+b.ResourcePlural)
+	})
+
+	return kinds
+}
+
+// reconcileKSMRBAC grants the kube-state-metrics ServiceAccount the access to
+// the custom resources of all the instances handled by the operator. The
+// kinds are taken from the status of the instances (the current instance
+// overrides its cached copy) so no resources are rendered again and the RBAC
+// is applied only once the rules change.
+func (r *CustomResourceStateMetricsReconciler) reconcileKSMRBAC(
+	ctx context.Context, current *ksmv1.CustomResourceStateMetrics) error {
+	if r.KSMServiceAccount.Name == "" {
+		return nil
+	}
+
+	list := &ksmv1.CustomResourceStateMetricsList{}
+	if err := r.List(ctx, list); err != nil {
+		return fmt.Errorf("failed to list the CustomResourceStateMetrics instances: %w", err)
+	}
+
+	// Consider only the instances handled by the operator which aren't deleted
+	selector := r.selectorPredicate()
+	kinds := []ksmv1.CustomResourceStateMetricsKind{}
+
+	for i := range list.Items {
+		instance := &list.Items[i]
+		if current != nil && instance.UID == current.UID {
+			instance = current
+		}
+
+		if instance.DeletionTimestamp.IsZero() && selector.Generic(event.GenericEvent{Object: instance}) {
+			kinds = append(kinds, instance.Status.Kinds...)
+		}
+	}
+
+	rules := ksmPolicyRules(r.RESTMapper(), kinds, r.KSMGroupPolicy)
+
+	// Skip the apply if the rules didn't change since the last apply
+	hash := utils.Hash(fmt.Sprintf("%s%v", r.KSMServiceAccount, rules))
+
+	r.ksmRBACMu.Lock()
+	defer r.ksmRBACMu.Unlock()
+
+	if hash == r.ksmRBACHash {
+		return nil
+	}
+
+	role := rbacv1ac.ClusterRole(KSMClusterRoleName)
+
+	for _, rule := range rules {
+		role.WithRules(rbacv1ac.PolicyRule().
+			WithAPIGroups(rule.APIGroups...).
+			WithResources(rule.Resources...).
+			WithVerbs(rule.Verbs...))
+	}
+
+	if err := r.Apply(ctx, role, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to apply the ClusterRole of kube-state-metrics: %w", err)
+	}
+
+	binding := rbacv1ac.ClusterRoleBinding(KSMClusterRoleName).
+		WithRoleRef(rbacv1ac.RoleRef().
+			WithAPIGroup(rbacv1.GroupName).
+			WithKind("ClusterRole").
+			WithName(KSMClusterRoleName)).
+		WithSubjects(rbacv1ac.Subject().
+			WithKind(rbacv1.ServiceAccountKind).
+			WithName(r.KSMServiceAccount.Name).
+			WithNamespace(r.KSMServiceAccount.Namespace))
+
+	if err := r.Apply(ctx, binding, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to apply the ClusterRoleBinding of kube-state-metrics: %w", err)
+	}
+
+	r.ksmRBACHash = hash
+
+	return nil
+}
+
+// syncKSMRBAC updates the RBAC of kube-state-metrics after the set of the
+// custom resources might have changed. Failures are only logged as the RBAC
+// is updated again with the next reconciliation of any instance.
+func (r *CustomResourceStateMetricsReconciler) syncKSMRBAC(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics) {
+	if err := r.reconcileKSMRBAC(ctx, instance); err != nil {
+		log.Error(err, "Failed to update the RBAC of kube-state-metrics")
+	}
+}
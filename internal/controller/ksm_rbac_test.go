@@ -0,0 +1,100 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+func TestKSMPolicyRules(t *testing.T) {
+	g := NewWithT(t)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.AddSpecific(
+		schema.GroupVersionKind{Group: "autoscaling.k8s.io", Version: "v1", Kind: "VerticalPodAutoscaler"},
+		schema.GroupVersionResource{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers"},
+		schema.GroupVersionResource{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscaler"},
+		meta.RESTScopeNamespace)
+
+	kind := func(group, name, plural string) ksmv1.CustomResourceStateMetricsKind {
+		return ksmv1.CustomResourceStateMetricsKind{Group: group, Version: "v1", Kind: name, ResourcePlural: plural}
+	}
+
+	kinds := []ksmv1.CustomResourceStateMetricsKind{
+		kind("myteam.io", "Foo", ""),
+		kind("autoscaling.k8s.io", "VerticalPodAutoscaler", ""),
+		kind("myteam.io", "Foo", ""),
+		kind("myteam.io", "Policy", "policies"),
+		kind("", "Secret", ""),
+		kind("other.io", "Bar", ""),
+	}
+
+	policy, err := NewKSMGroupPolicy("myteam.io, *.k8s.io,")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(ksmPolicyRules(mapper, kinds, policy)).To(Equal([]rbacv1.PolicyRule{
+		{
+			APIGroups: []string{"autoscaling.k8s.io"},
+			Resources: []string{"verticalpodautoscalers"},
+			Verbs:     []string{"list", "watch"},
+		},
+		{
+			APIGroups: []string{"myteam.io"},
+			Resources: []string{"foos", "policies"},
+			Verbs:     []string{"list", "watch"},
+		},
+	}), "Test [merged]:")
+
+	g.Expect(ksmPolicyRules(mapper, nil, policy)).To(BeEmpty(), "Test [no-resources]:")
+	g.Expect(ksmPolicyRules(mapper, kinds, nil)).To(BeEmpty(), "Test [no-policy]:")
+
+	// The core group is never allowed
+	policy, err = NewKSMGroupPolicy("*")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(policy.Allowed("")).To(BeFalse(), "Test [core-group]:")
+	g.Expect(policy.Allowed("other.io")).To(BeTrue(), "Test [wildcard]:")
+
+	_, err = NewKSMGroupPolicy("[")
+	g.Expect(err).To(HaveOccurred(), "Test [invalid-pattern]:")
+}
+
+func TestResourceKinds(t *testing.T) {
+	g := NewWithT(t)
+
+	resource := func(group, kind string) ksm.Resource {
+		return ksm.Resource{GroupVersionKind: ksm.GroupVersionKind{Group: group, Version: "v1", Kind: kind}}
+	}
+
+	g.Expect(resourceKinds([]ksm.Resource{
+		resource("myteam.io", "Foo"),
+		resource("autoscaling.k8s.io", "VerticalPodAutoscaler"),
+		resource("myteam.io", "Foo"),
+	})).To(Equal([]ksmv1.CustomResourceStateMetricsKind{
+		{Group: "autoscaling.k8s.io", Version: "v1", Kind: "VerticalPodAutoscaler"},
+		{Group: "myteam.io", Version: "v1", Kind: "Foo"},
+	}), "Test [unique]:")
+
+	g.Expect(resourceKinds(nil)).To(BeNil(), "Test [no-resources]:")
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Default format of the comment identifying the resources of an instance.
+const DefaultMarkerFormat = "# CustomResourceStateMetrics %s"
+
+// Name of the ConfigMap annotation holding the format of the markers the
+// content of the keys was written with keyed by the key. The keys written
+// with the default format are not recorded.
+const MarkerFormatsAnnotation = "ksm.jtyr.io/marker-formats"
+
+// documentLayout is the header of the newly created documents and the format
+// of the markers of the resources.
+type documentLayout struct {
+	header       string
+	markerFormat string
+
+	// Formats of the markers written before the format was changed (also
+	// before the restart as recorded on the ConfigMaps). They are recognized
+	// so the markers get converted with the next write.
+	previousFormats []string
+}
+
+// layout is the current layout of the documents shared by all writers.
+var layout = struct {
+	sync.RWMutex
+	documentLayout
+}{
+	documentLayout: documentLayout{header: dataHeader, markerFormat: DefaultMarkerFormat},
+}
+
+// setDocumentLayout validates and sets the header of the newly created
+// documents and the format of the markers. The defaults are used for the
+// empty values.
+func setDocumentLayout(header, markerFormat string) error {
+	if header == "" {
+		header = dataHeader
+	}
+
+	if markerFormat == "" {
+		markerFormat = DefaultMarkerFormat
+	}
+
+	if err := validateLayout(header, markerFormat); err != nil {
+		return err
+	}
+
+	layout.Lock()
+	defer layout.Unlock()
+
+	previous := layout.previousFormats
+	if !slices.Contains(previous, layout.markerFormat) {
+		previous = append(slices.Clone(previous), layout.markerFormat)
+	}
+
+	layout.header = header
+	layout.markerFormat = markerFormat
+	layout.previousFormats = slices.DeleteFunc(slices.Clone(previous), func(format string) bool {
+		return format == markerFormat
+	})
+
+	return nil
+}
+
+// validateLayout returns an error if the header isn't a YAML map or if the
+// marker format isn't a single line comment with a single %s placeholder of
+// the owner. The empty values are valid.
+func validateLayout(header, markerFormat string) error {
+	if header != "" {
+		if _, err := parseDocument(header); err != nil {
+			return fmt.Errorf("invalid document header: %w", err)
+		}
+	}
+
+	if markerFormat != "" {
+		if !strings.HasPrefix(markerFormat, "#") || strings.Contains(markerFormat, "\n") {
+			return errors.New("invalid marker format: must be a single line comment")
+		}
+
+		if strings.Count(markerFormat, "%") != 1 || !strings.Contains(markerFormat, "%s") {
+			return errors.New("invalid marker format: must contain a single %s placeholder of the owner")
+		}
+
+		if strings.Trim(strings.Replace(markerFormat, "%s", "", 1), "# ") == "" {
+			return errors.New("invalid marker format: must contain a text identifying the markers")
+		}
+
+		if isLegacyMarker(fmt.Sprintf(markerFormat, "name@namespace")) {
+			return errors.New("invalid marker format: conflicts with the BEGIN/END markers")
+		}
+	}
+
+	return nil
+}
+
+// documentHeader returns the header of the newly created documents.
+func documentHeader() string {
+	layout.RLock()
+	defer layout.RUnlock()
+
+	return layout.header
+}
+
+// formatMarker returns the marker of the resources of the instance.
+func formatMarker(instanceNamespacedName string) string {
+	layout.RLock()
+	defer layout.RUnlock()
+
+	return fmt.Sprintf(layout.markerFormat, instanceNamespacedName)
+}
+
+// markerOwner returns the owner of the resource identified by the comment
+// line. The markers in the previous formats and in the default format are
+// recognized too.
+func markerOwner(line string) (string, bool) {
+	layout.RLock()
+	defer layout.RUnlock()
+
+	line = strings.TrimSpace(line)
+
+	formats := append([]string{layout.markerFormat}, layout.previousFormats...)
+	if !slices.Contains(formats, DefaultMarkerFormat) {
+		formats = append(formats, DefaultMarkerFormat)
+	}
+
+	for _, format := range formats {
+		prefix, suffix, _ := strings.Cut(format, "%s")
+
+		if owner, ok := strings.CutPrefix(line, prefix); ok && strings.HasSuffix(owner, suffix) {
+			return strings.TrimSuffix(owner, suffix), true
+		}
+	}
+
+	return "", false
+}
+
+// getMarkerFormats returns the formats of the markers recorded on the
+// ConfigMap keyed by the key.
+func getMarkerFormats(cm *corev1.ConfigMap) map[string]string {
+	formats := make(map[string]string)
+
+	if value, ok := cm.Annotations[MarkerFormatsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &formats); err != nil {
+			// Ignore corrupted annotation, it gets rewritten on the next update
+			return make(map[string]string)
+		}
+	}
+
+	return formats
+}
+
+// setMarkerFormat records the current format of the markers as the format
+// the content of the key was written with.
+func setMarkerFormat(cm *corev1.ConfigMap, cmKey string) {
+	formats := getMarkerFormats(cm)
+
+	layout.RLock()
+	format := layout.markerFormat
+	layout.RUnlock()
+
+	if format == DefaultMarkerFormat {
+		delete(formats, cmKey)
+	} else {
+		formats[cmKey] = format
+	}
+
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+
+	if len(formats) == 0 {
+		delete(cm.Annotations, MarkerFormatsAnnotation)
+
+		return
+	}
+
+	// Marshaling of a map of strings can't fail
+	value, _ := json.Marshal(formats)
+
+	cm.Annotations[MarkerFormatsAnnotation] = string(value)
+}
+
+// recognizeMarkerFormats makes the formats of the markers recorded on the
+// ConfigMap recognized so the markers written in a previous format before
+// the restart of the operator get converted with the next write too.
+func recognizeMarkerFormats(cm *corev1.ConfigMap) {
+	formats := getMarkerFormats(cm)
+	if len(formats) == 0 {
+		return
+	}
+
+	layout.Lock()
+	defer layout.Unlock()
+
+	for _, format := range formats {
+		if format == layout.markerFormat || slices.Contains(layout.previousFormats, format) {
+			continue
+		}
+
+		// Ignore the formats the markers couldn't be written with
+		if validateLayout("", format) != nil {
+			continue
+		}
+
+		layout.previousFormats = append(slices.Clone(layout.previousFormats), format)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestValidateLayout(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name         string
+		header       string
+		markerFormat string
+		valid        bool
+	}{
+		{name: "defaults", valid: true},
+		{name: "custom", header: "# Generated\n" + dataHeader, markerFormat: "# Managed by %s (operator)", valid: true},
+		{name: "header-not-map", header: "- foo\n"},
+		{name: "header-invalid", header: "kind: [\n"},
+		{name: "format-not-comment", markerFormat: "Managed by %s"},
+		{name: "format-multiline", markerFormat: "# Managed\n# by %s"},
+		{name: "format-no-placeholder", markerFormat: "# Managed"},
+		{name: "format-more-verbs", markerFormat: "# Managed by %s %d"},
+		{name: "format-no-text", markerFormat: "# %s"},
+		{name: "format-legacy", markerFormat: "# BEGIN CustomResourceStateMetrics %s"},
+	}
+
+	for _, test := range tests {
+		err := validateLayout(test.header, test.markerFormat)
+
+		if test.valid {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		} else {
+			g.Expect(err).To(HaveOccurred(), "Test [%s]:", test.name)
+		}
+	}
+}
+
+func TestSetDocumentLayout(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Cleanup(func() { _ = setDocumentLayout("", "") })
+
+	content, _, err := mergeResources(dataHeader, "", "foo@ns", "    - groupVersionKind:\n        kind: Foo\n")
+	g.Expect(err).NotTo(HaveOccurred(), "Test [default]:")
+	g.Expect(content).To(ContainSubstring("# CustomResourceStateMetrics foo@ns"), "Test [default]:")
+
+	g.Expect(setDocumentLayout("# Generated\n"+dataHeader, "# Managed by %s (operator)")).To(
+		Succeed(), "Test [custom]:")
+	g.Expect(documentHeader()).To(HavePrefix("# Generated\n"), "Test [custom]:")
+
+	// The markers in the previous format are converted
+	content, _, err = mergeResources(content, "", "bar@ns", "    - groupVersionKind:\n        kind: Bar\n")
+	g.Expect(err).NotTo(HaveOccurred(), "Test [converted]:")
+	g.Expect(content).To(ContainSubstring("# Managed by foo@ns (operator)"), "Test [converted]:")
+	g.Expect(content).To(ContainSubstring("# Managed by bar@ns (operator)"), "Test [converted]:")
+	g.Expect(content).NotTo(ContainSubstring("# CustomResourceStateMetrics"), "Test [converted]:")
+
+	list, err := loadResources(content, "", false)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [owners]:")
+	g.Expect(list.owners).To(Equal([]string{"bar@ns", "foo@ns"}), "Test [owners]:")
+
+	g.Expect(setDocumentLayout("", "# Managed")).NotTo(Succeed(), "Test [invalid]:")
+	g.Expect(formatMarker("foo@ns")).To(Equal("# Managed by foo@ns (operator)"), "Test [invalid]:")
+}
+
+func TestMarkerFormats(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Cleanup(func() { _ = setDocumentLayout("", "") })
+
+	g.Expect(setDocumentLayout("", "# Managed by %s (operator)")).To(Succeed())
+
+	cm := &corev1.ConfigMap{Data: map[string]string{}}
+	cm.Data["config.yaml"], _, _ = mergeResources(dataHeader, "", "foo@ns", "    - groupVersionKind:\n        kind: Foo\n")
+	setBlockHashes(cm, "config.yaml", "foo@ns", "    - groupVersionKind:\n        kind: Foo\n")
+	g.Expect(getMarkerFormats(cm)).To(HaveKeyWithValue("config.yaml", "# Managed by %s (operator)"),
+		"Test [recorded]:")
+
+	// The custom format is forgotten with the restart of the operator
+	g.Expect(setDocumentLayout("", "# Generated for %s")).To(Succeed())
+	layout.Lock()
+	layout.previousFormats = nil
+	layout.Unlock()
+
+	_, found := markerOwner("# Managed by foo@ns (operator)")
+	g.Expect(found).To(BeFalse(), "Test [restarted]:")
+
+	// The default format is always recognized
+	owner, found := markerOwner("# CustomResourceStateMetrics foo@ns")
+	g.Expect(found).To(BeTrue(), "Test [default]:")
+	g.Expect(owner).To(Equal("foo@ns"), "Test [default]:")
+
+	// The format recorded on the ConfigMap is recognized once it's read
+	g.Expect(loadData(cm)).To(Succeed(), "Test [recognized]:")
+
+	owner, found = markerOwner("# Managed by foo@ns (operator)")
+	g.Expect(found).To(BeTrue(), "Test [recognized]:")
+	g.Expect(owner).To(Equal("foo@ns"), "Test [recognized]:")
+
+	// The invalid format isn't recognized
+	cm.Annotations[MarkerFormatsAnnotation] = `{"config.yaml":"%s"}`
+	recognizeMarkerFormats(cm)
+
+	layout.RLock()
+	g.Expect(layout.previousFormats).NotTo(ContainElement("%s"), "Test [invalid]:")
+	layout.RUnlock()
+
+	// The default format isn't recorded
+	g.Expect(setDocumentLayout("", "")).To(Succeed())
+	setBlockHashes(cm, "config.yaml", "foo@ns", "")
+	g.Expect(cm.Annotations).NotTo(HaveKey(MarkerFormatsAnnotation), "Test [default]:")
+}
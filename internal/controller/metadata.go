@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Name of the ConfigMap annotation holding the labels and annotations
+// requested by the instances.
+const MetadataAnnotation = "ksm.jtyr.io/metadata"
+
+// instanceMetadata holds the labels and annotations requested by the instance.
+type instanceMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// getMetadata returns the labels and annotations recorded on the ConfigMap
+// keyed by the instance.
+func getMetadata(cm *corev1.ConfigMap) map[string]instanceMetadata {
+	metadata := make(map[string]instanceMetadata)
+
+	if value, ok := cm.Annotations[MetadataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &metadata); err != nil {
+			// Ignore corrupted annotation, it gets rewritten on the next update
+			return make(map[string]instanceMetadata)
+		}
+	}
+
+	return metadata
+}
+
+// setMetadata records the labels and annotations requested by the instance
+// (removes them if there are none). It returns whether the record changed.
+func setMetadata(cm *corev1.ConfigMap, instanceNamespacedName string, labels, annotations map[string]string) bool {
+	metadata := getMetadata(cm)
+	current, found := metadata[instanceNamespacedName]
+
+	if len(labels) == 0 && len(annotations) == 0 {
+		if !found {
+			return false
+		}
+
+		delete(metadata, instanceNamespacedName)
+	} else {
+		requested := instanceMetadata{Labels: labels, Annotations: annotations}
+
+		if found && maps.Equal(current.Labels, labels) && maps.Equal(current.Annotations, annotations) {
+			return false
+		}
+
+		metadata[instanceNamespacedName] = requested
+	}
+
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+
+	if len(metadata) == 0 {
+		delete(cm.Annotations, MetadataAnnotation)
+
+		return true
+	}
+
+	// Marshaling of a map of strings can't fail
+	value, _ := json.Marshal(metadata)
+
+	cm.Annotations[MetadataAnnotation] = string(value)
+
+	return true
+}
+
+// managedMetadata returns the labels and annotations requested by all the
+// instances writing into the ConfigMap. The instances are merged in the
+// sorted order so the values of the later instances take precedence.
+func managedMetadata(cm *corev1.ConfigMap) (map[string]string, map[string]string) {
+	metadata := getMetadata(cm)
+	labels := make(map[string]string)
+	annotations := make(map[string]string)
+
+	for _, instance := range slices.Sorted(maps.Keys(metadata)) {
+		maps.Copy(labels, metadata[instance].Labels)
+		maps.Copy(annotations, metadata[instance].Annotations)
+	}
+
+	return labels, annotations
+}
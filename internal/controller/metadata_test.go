@@ -0,0 +1,74 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cm",
+			Namespace: "ksm",
+			Annotations: map[string]string{
+				"helm.sh/resource-policy": "keep",
+			},
+		},
+	}
+
+	g.Expect(setMetadata(cm, "foo@bar", nil, nil)).To(BeFalse(), "Test [nothing-requested]:")
+	g.Expect(cm.Annotations).NotTo(HaveKey(MetadataAnnotation), "Test [nothing-requested]:")
+
+	g.Expect(setMetadata(cm, "foo@bar",
+		map[string]string{"team": "foo"},
+		map[string]string{"reloader.stakater.com/match": "true"})).To(BeTrue(), "Test [foo-requested]:")
+	g.Expect(setMetadata(cm, "foo@bar",
+		map[string]string{"team": "foo"},
+		map[string]string{"reloader.stakater.com/match": "true"})).To(BeFalse(), "Test [foo-unchanged]:")
+	g.Expect(setMetadata(cm, "qux@bar",
+		map[string]string{"team": "qux", "tier": "metrics"}, map[string]string{})).To(BeTrue(), "Test [qux-requested]:")
+
+	labels, annotations := managedMetadata(cm)
+	g.Expect(labels).To(Equal(map[string]string{"team": "qux", "tier": "metrics"}), "Test [merged]:")
+	g.Expect(annotations).To(Equal(map[string]string{"reloader.stakater.com/match": "true"}), "Test [merged]:")
+
+	// The labels and annotations are applied together with the operator annotations
+	ac := configMapApplyConfiguration(cm)
+	g.Expect(ac.Labels).To(Equal(labels), "Test [applied]:")
+	g.Expect(ac.Annotations).To(HaveKeyWithValue("reloader.stakater.com/match", "true"), "Test [applied]:")
+	g.Expect(ac.Annotations).To(HaveKey(MetadataAnnotation), "Test [applied]:")
+	g.Expect(ac.Annotations).NotTo(HaveKey("helm.sh/resource-policy"), "Test [applied]:")
+
+	g.Expect(setMetadata(cm, "qux@bar", nil, nil)).To(BeTrue(), "Test [qux-forgotten]:")
+	g.Expect(setMetadata(cm, "foo@bar", nil, nil)).To(BeTrue(), "Test [foo-forgotten]:")
+	g.Expect(cm.Annotations).NotTo(HaveKey(MetadataAnnotation), "Test [forgotten]:")
+
+	labels, annotations = managedMetadata(cm)
+	g.Expect(labels).To(BeEmpty(), "Test [forgotten]:")
+	g.Expect(annotations).To(BeEmpty(), "Test [forgotten]:")
+
+	// Corrupted annotation is ignored
+	cm.Annotations[MetadataAnnotation] = "{"
+	g.Expect(getMetadata(cm)).To(BeEmpty(), "Test [corrupted]:")
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Reason for events recorded when the metric names collide with the metrics
+// of the other instances.
+const reasonMetricCollision = "MetricCollision"
+
+// checkMetricNamePrefix returns an error if any of the rendered metric names
+// doesn't start with the prefix required in the Namespace of the instance by
+// the OperatorConfig. Unlike the admission webhook, it covers the raw and the
+// sourced resources too.
+func (r *CustomResourceStateMetricsReconciler) checkMetricNamePrefix(
+	instance *ksmv1.CustomResourceStateMetrics, names []string) error {
+	prefix, ok := r.runtimeConfig().MetricNamePrefixes[instance.Namespace]
+	if !ok {
+		return nil
+	}
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			return withReason(resultMetricNameNotAllowed, fmt.Errorf(
+				"the metric %s doesn't start with %s required in the Namespace %s", name, prefix, instance.Namespace))
+		}
+	}
+
+	return nil
+}
+
+// checkMetricCollisions returns an error (or only records a warning event) if
+// any of the rendered metric names is already generated by an older instance
+// writing into the same target as they would produce duplicate series. Unlike
+// the admission webhook, it covers the raw and the sourced resources too. Only
+// the older instances are considered so the instance written first keeps
+// being written.
+func (r *CustomResourceStateMetricsReconciler) checkMetricCollisions(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics) error {
+	if len(instance.Status.MetricNames) == 0 {
+		return nil
+	}
+
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+	if err := r.List(ctx, instances); err != nil {
+		return fmt.Errorf("failed to list the CustomResourceStateMetrics instances: %w", err)
+	}
+
+	for _, other := range instances.Items {
+		if other.UID == instance.UID || !other.DeletionTimestamp.IsZero() || !olderInstance(&other, instance) ||
+			!sameTarget(instance.Status.ConfigMap, other.Status.ConfigMap) {
+			continue
+		}
+
+		for _, name := range other.Status.MetricNames {
+			if !slices.Contains(instance.Status.MetricNames, name) {
+				continue
+			}
+
+			err := fmt.Errorf("the metric %s is already generated by %s writing into the same target",
+				name, utils.NamespacedName(other.Name, other.Namespace))
+
+			if !r.WarnMetricCollisions {
+				return withReason(resultMetricCollision, err)
+			}
+
+			// Record the event
+			r.Recorder.Event(instance, corev1.EventTypeWarning, reasonMetricCollision, err.Error()+".")
+		}
+	}
+
+	return nil
+}
+
+// olderInstance returns true if the instance a was created before the
+// instance b. The instances created at the same time are ordered by their
+// Namespace and name.
+func olderInstance(a, b *ksmv1.CustomResourceStateMetrics) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return utils.NamespacedName(a.Name, a.Namespace) < utils.NamespacedName(b.Name, b.Namespace)
+}
+
+// sameTarget returns true if both targets are the same document of the same
+// key of the same ConfigMap (or Secret) in at least one Namespace.
+func sameTarget(a, b *ksmv1.CustomResourceStateMetricsTarget) bool {
+	if a == nil || b == nil || a.Name != b.Name || a.Key != b.Key || a.Path != b.Path ||
+		cmp.Or(a.Kind, ksmv1.TargetKindConfigMap) != cmp.Or(b.Kind, ksmv1.TargetKindConfigMap) {
+		return false
+	}
+
+	namespaces := func(t *ksmv1.CustomResourceStateMetricsTarget) []string {
+		if len(t.Namespaces) > 0 {
+			return t.Namespaces
+		}
+
+		return []string{t.Namespace}
+	}
+
+	for _, ns := range namespaces(a) {
+		if slices.Contains(namespaces(b), ns) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestCheckMetricNamePrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &CustomResourceStateMetricsReconciler{}
+	r.config.Store(&RuntimeConfig{MetricNamePrefixes: map[string]string{"team-a": "team_a_"}})
+
+	newInstance := func(namespace string) *ksmv1.CustomResourceStateMetrics {
+		return &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: namespace}}
+	}
+
+	g.Expect(r.checkMetricNamePrefix(newInstance("team-a"), []string{"team_a_foo"})).To(Succeed(), "Test [prefixed]:")
+	g.Expect(r.checkMetricNamePrefix(newInstance("team-b"), []string{"foo"})).To(Succeed(), "Test [no-policy]:")
+
+	// The sourced resources are rendered before the check so they can't bypass it
+	err := r.checkMetricNamePrefix(newInstance("team-a"), []string{"team_a_foo", "team_b_foo"})
+	g.Expect(err).To(MatchError(ContainSubstring("team_b_foo")), "Test [not-prefixed]:")
+	g.Expect(resultReason(err)).To(Equal(resultMetricNameNotAllowed), "Test [not-prefixed]:")
+}
+
+func TestCheckMetricCollisions(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	created := time.Now()
+	target := &ksmv1.CustomResourceStateMetricsTarget{Name: "ksm", Namespace: "monitoring", Key: "config.yaml"}
+
+	newInstance := func(name string, age time.Duration, names ...string) *ksmv1.CustomResourceStateMetrics {
+		return &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "bar",
+				UID:               types.UID(name),
+				CreationTimestamp: metav1.NewTime(created.Add(-age)),
+			},
+			Status: ksmv1.CustomResourceStateMetricsStatus{ConfigMap: target, MetricNames: names},
+		}
+	}
+
+	older := newInstance("older", time.Hour, "foo_info")
+	newer := newInstance("newer", 0, "foo_info")
+	other := newInstance("other", time.Hour, "bar_info")
+	other.Status.ConfigMap = &ksmv1.CustomResourceStateMetricsTarget{Name: "other", Namespace: "monitoring"}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &CustomResourceStateMetricsReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(older, newer, other).Build(),
+		Recorder: recorder,
+	}
+
+	// The sourced resources are rendered before the check so they can't bypass it
+	err := r.checkMetricCollisions(context.Background(), newer)
+	g.Expect(err).To(MatchError(ContainSubstring("foo_info")), "Test [collision]:")
+	g.Expect(resultReason(err)).To(Equal(resultMetricCollision), "Test [collision]:")
+
+	// The older instance keeps being written
+	g.Expect(r.checkMetricCollisions(context.Background(), older)).To(Succeed(), "Test [older]:")
+
+	// The older instance writes into another target
+	g.Expect(r.checkMetricCollisions(context.Background(), newInstance("newest", 0, "bar_info"))).To(Succeed(),
+		"Test [other-target]:")
+
+	// Only warned about (colliding with both the older and the newer instance)
+	r.WarnMetricCollisions = true
+	g.Expect(r.checkMetricCollisions(context.Background(), newInstance("newest", 0, "foo_info"))).To(Succeed(),
+		"Test [warning]:")
+	g.Expect(recorder.Events).To(HaveLen(2), "Test [warning]:")
+}
+
+func TestSameTarget(t *testing.T) {
+	g := NewWithT(t)
+
+	target := func(namespace string, namespaces ...string) *ksmv1.CustomResourceStateMetricsTarget {
+		return &ksmv1.CustomResourceStateMetricsTarget{
+			Name:       "ksm",
+			Namespace:  namespace,
+			Key:        "config.yaml",
+			Namespaces: namespaces,
+		}
+	}
+
+	secret := target("monitoring")
+	secret.Kind = ksmv1.TargetKindSecret
+
+	g.Expect(sameTarget(target("monitoring"), target("monitoring"))).To(BeTrue(), "Test [same]:")
+	g.Expect(sameTarget(target("a", "a", "b"), target("b"))).To(BeTrue(), "Test [replicated]:")
+	g.Expect(sameTarget(target("monitoring"), target("other"))).To(BeFalse(), "Test [other-namespace]:")
+	g.Expect(sameTarget(target("monitoring"), secret)).To(BeFalse(), "Test [other-kind]:")
+	g.Expect(sameTarget(target("monitoring"), nil)).To(BeFalse(), "Test [unresolved]:")
+}
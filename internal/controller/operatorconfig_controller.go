@@ -0,0 +1,282 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+// Name of the OperatorConfig used by the operator.
+const OperatorConfigName = "cluster"
+
+// Reasons for the status conditions of the OperatorConfig.
+const reasonConfigApplied = "Applied"
+const reasonInvalidConfig = "InvalidConfig"
+
+// RuntimeConfig holds the configuration of the operator which can be changed
+// at runtime by the OperatorConfig.
+type RuntimeConfig struct {
+	Selector          labels.Selector
+	NamespaceSelector labels.Selector
+	DefaultKey        string
+	ResyncPeriod      time.Duration
+	RestartMounting   bool
+	DocumentHeader    string
+	MarkerFormat      string
+
+	// Prefixes the metric names must start with keyed by the Namespace
+	MetricNamePrefixes map[string]string
+}
+
+// newRuntimeConfig returns the defaults overridden by the spec of the
+// OperatorConfig.
+func newRuntimeConfig(defaults RuntimeConfig, spec ksmv1.OperatorConfigSpec) (*RuntimeConfig, error) {
+	config := defaults
+
+	if spec.Selector != "" {
+		selector, err := labels.Parse(spec.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the selector: %w", err)
+		}
+
+		config.Selector = selector
+	}
+
+	if spec.NamespaceSelector != "" {
+		selector, err := labels.Parse(spec.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the Namespace selector: %w", err)
+		}
+
+		config.NamespaceSelector = selector
+	}
+
+	if spec.DefaultKey != "" {
+		config.DefaultKey = spec.DefaultKey
+	}
+
+	if spec.ResyncPeriod != nil {
+		if spec.ResyncPeriod.Duration < 0 {
+			return nil, errors.New("the resync period must not be negative")
+		}
+
+		config.ResyncPeriod = spec.ResyncPeriod.Duration
+	}
+
+	if spec.RestartKSM != nil {
+		config.RestartMounting = *spec.RestartKSM
+	}
+
+	if err := validateLayout(spec.DocumentHeader, spec.MarkerFormat); err != nil {
+		return nil, err
+	}
+
+	if spec.DocumentHeader != "" {
+		config.DocumentHeader = spec.DocumentHeader
+	}
+
+	if spec.MarkerFormat != "" {
+		config.MarkerFormat = spec.MarkerFormat
+	}
+
+	if len(spec.MetricNamePrefixes) > 0 {
+		config.MetricNamePrefixes = make(map[string]string, len(spec.MetricNamePrefixes))
+
+		for namespace, prefix := range spec.MetricNamePrefixes {
+			config.MetricNamePrefixes[namespace] = string(prefix)
+		}
+	}
+
+	return &config, nil
+}
+
+// defaultConfig returns the configuration given by the command line flags.
+func (r *CustomResourceStateMetricsReconciler) defaultConfig() RuntimeConfig {
+	return RuntimeConfig{
+		Selector:          r.Selector,
+		NamespaceSelector: r.NamespaceSelector,
+		DefaultKey:        DefaultKey,
+		ResyncPeriod:      r.ResyncPeriod,
+		RestartMounting:   r.RestartMounting,
+		DocumentHeader:    r.DocumentHeader,
+		MarkerFormat:      r.MarkerFormat,
+	}
+}
+
+// runtimeConfig returns the current configuration of the operator.
+func (r *CustomResourceStateMetricsReconciler) runtimeConfig() RuntimeConfig {
+	if config := r.config.Load(); config != nil {
+		return *config
+	}
+
+	return r.defaultConfig()
+}
+
+// loadOperatorConfig applies the OperatorConfig (or the flags once the
+// OperatorConfig is deleted) and sets the layout of the documents. The
+// invalid configuration is ignored. It returns whether the configuration was
+// applied.
+func (r *CustomResourceStateMetricsReconciler) loadOperatorConfig(ctx context.Context) (bool, error) {
+	operatorConfig := &ksmv1.OperatorConfig{}
+
+	if err := r.Get(ctx, client.ObjectKey{Name: OperatorConfigName}, operatorConfig); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return false, fmt.Errorf("failed to get OperatorConfig %s: %w", OperatorConfigName, err)
+		}
+
+		// Fall back to the flags once the OperatorConfig is deleted
+		r.config.Store(nil)
+	} else {
+		config, err := newRuntimeConfig(r.defaultConfig(), operatorConfig.Spec)
+		if err != nil {
+			log.Error(err, "Ignoring invalid OperatorConfig", "operatorConfig", OperatorConfigName)
+
+			r.configLoaded.Store(true)
+
+			return false, nil
+		}
+
+		r.config.Store(config)
+	}
+
+	config := r.runtimeConfig()
+	if err := setDocumentLayout(config.DocumentHeader, config.MarkerFormat); err != nil {
+		return false, fmt.Errorf("failed to set the layout of the documents: %w", err)
+	}
+
+	r.configLoaded.Store(true)
+
+	return true, nil
+}
+
+// operatorConfigToInstances applies the changed OperatorConfig and maps it to
+// all the selected instances so they are reconciled with the new
+// configuration. The configuration is applied here (instead of in the
+// OperatorConfig reconciler) so it's in effect before the instances are
+// reconciled. The invalid configuration is ignored.
+func (r *CustomResourceStateMetricsReconciler) operatorConfigToInstances(
+	ctx context.Context, obj client.Object) []reconcile.Request {
+	if obj.GetName() != OperatorConfigName {
+		return nil
+	}
+
+	if applied, err := r.loadOperatorConfig(ctx); err != nil {
+		log.Error(err, "Failed to apply OperatorConfig", "operatorConfig", obj.GetName())
+
+		return nil
+	} else if !applied {
+		return nil
+	}
+
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+
+	if err := r.List(ctx, instances); err != nil {
+		log.Error(err, "Failed to list instances", "operatorConfig", obj.GetName())
+
+		return nil
+	}
+
+	selected := r.selectorPredicate()
+	requests := []reconcile.Request{}
+
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+
+		if selected.Generic(event.GenericEvent{Object: instance}) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)})
+		}
+	}
+
+	return requests
+}
+
+// OperatorConfigReconciler reports whether the OperatorConfig is valid. The
+// configuration itself is applied by the CustomResourceStateMetrics
+// controller watching the OperatorConfig.
+type OperatorConfigReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=ksm.jtyr.io,resources=operatorconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ksm.jtyr.io,resources=operatorconfigs/status,verbs=get;update;patch
+
+// Reconcile validates the OperatorConfig and records the result in its status.
+func (r *OperatorConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Only the single OperatorConfig is used
+	if req.Name != OperatorConfigName {
+		return ctrl.Result{}, nil
+	}
+
+	operatorConfig := &ksmv1.OperatorConfig{}
+
+	if err := r.Get(ctx, req.NamespacedName, operatorConfig); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: operatorConfig.Generation,
+		Reason:             reasonConfigApplied,
+		Message:            "The configuration is applied.",
+	}
+
+	if _, err := newRuntimeConfig(RuntimeConfig{}, operatorConfig.Spec); err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reasonInvalidConfig
+		condition.Message = fmt.Sprintf("The configuration is ignored: %v", err)
+	}
+
+	if operatorConfig.Status.ObservedGeneration == operatorConfig.Generation &&
+		meta.IsStatusConditionPresentAndEqual(operatorConfig.Status.Conditions, condition.Type, condition.Status) {
+		return ctrl.Result{}, nil
+	}
+
+	operatorConfig.Status.ObservedGeneration = operatorConfig.Generation
+	meta.SetStatusCondition(&operatorConfig.Status.Conditions, condition)
+
+	if err := r.Status().Update(ctx, operatorConfig); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status of OperatorConfig: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OperatorConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ksmv1.OperatorConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("operatorconfig").
+		Complete(r)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestNewRuntimeConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	defaults := RuntimeConfig{
+		Selector:          labels.Everything(),
+		NamespaceSelector: labels.Everything(),
+		DefaultKey:        DefaultKey,
+		ResyncPeriod:      10 * time.Minute,
+	}
+	restart := true
+
+	// Unset fields keep the defaults
+	config, err := newRuntimeConfig(defaults, ksmv1.OperatorConfigSpec{})
+	g.Expect(err).NotTo(HaveOccurred(), "Test [defaults]:")
+	g.Expect(*config).To(Equal(defaults), "Test [defaults]:")
+
+	config, err = newRuntimeConfig(defaults, ksmv1.OperatorConfigSpec{
+		Selector:     "team=foo",
+		DefaultKey:   "custom.yaml",
+		ResyncPeriod: &metav1.Duration{Duration: time.Hour},
+		RestartKSM:   &restart,
+		MarkerFormat: "# Managed by %s",
+
+		MetricNamePrefixes: map[string]ksmv1.MetricNamePrefix{"team-a": "team_a_"},
+	})
+	g.Expect(err).NotTo(HaveOccurred(), "Test [overridden]:")
+	g.Expect(config.Selector.String()).To(Equal("team=foo"), "Test [overridden]:")
+	g.Expect(config.NamespaceSelector).To(Equal(labels.Everything()), "Test [overridden]:")
+	g.Expect(config.DefaultKey).To(Equal("custom.yaml"), "Test [overridden]:")
+	g.Expect(config.ResyncPeriod).To(Equal(time.Hour), "Test [overridden]:")
+	g.Expect(config.RestartMounting).To(BeTrue(), "Test [overridden]:")
+	g.Expect(config.MarkerFormat).To(Equal("# Managed by %s"), "Test [overridden]:")
+	g.Expect(config.DocumentHeader).To(BeEmpty(), "Test [overridden]:")
+	g.Expect(config.MetricNamePrefixes).To(Equal(map[string]string{"team-a": "team_a_"}), "Test [overridden]:")
+
+	tests := map[string]ksmv1.OperatorConfigSpec{
+		"invalid_selector":           {Selector: "team in (foo"},
+		"invalid_namespace_selector": {NamespaceSelector: "!"},
+		"negative_resync_period":     {ResyncPeriod: &metav1.Duration{Duration: -time.Minute}},
+		"invalid_marker_format":      {MarkerFormat: "# Managed"},
+		"invalid_document_header":    {DocumentHeader: "- resources"},
+	}
+
+	for name, spec := range tests {
+		_, err := newRuntimeConfig(defaults, spec)
+		g.Expect(err).To(HaveOccurred(), "Test [%s]:", name)
+	}
+}
+
+func TestRuntimeConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &CustomResourceStateMetricsReconciler{ResyncPeriod: time.Minute}
+
+	// The flags are used until the OperatorConfig is applied
+	g.Expect(r.runtimeConfig().ResyncPeriod).To(Equal(time.Minute), "Test [flags]:")
+	g.Expect(r.runtimeConfig().DefaultKey).To(Equal(DefaultKey), "Test [flags]:")
+
+	r.config.Store(&RuntimeConfig{ResyncPeriod: time.Hour})
+	g.Expect(r.runtimeConfig().ResyncPeriod).To(Equal(time.Hour), "Test [applied]:")
+
+	r.config.Store(nil)
+	g.Expect(r.runtimeConfig().ResyncPeriod).To(Equal(time.Minute), "Test [deleted]:")
+}
+
+func TestReconcileLoadsOperatorConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Cleanup(func() { _ = setDocumentLayout("", "") })
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	ctx := context.Background()
+	instance := newTestInstance("layout", "layout-config", "Foo")
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&ksmv1.CustomResourceStateMetrics{}).
+		WithObjects(instance, &ksmv1.OperatorConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: OperatorConfigName},
+			Spec:       ksmv1.OperatorConfigSpec{MarkerFormat: "# Managed by %s (operator)"},
+		}).
+		Build()
+	r := &CustomResourceStateMetricsReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(100)}
+
+	// The first reconcile writes with the layout of the OperatorConfig even
+	// before the OperatorConfig watch applies it
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(r.configLoaded.Load()).To(BeTrue())
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(c.Get(ctx, types.NamespacedName{Name: "layout-config", Namespace: "default"}, cm)).To(Succeed())
+	g.Expect(cm.Data[DefaultKey]).To(ContainSubstring("# Managed by layout@default (operator)"))
+	g.Expect(getMarkerFormats(cm)).To(HaveKeyWithValue(DefaultKey, "# Managed by %s (operator)"))
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// isInstanceReference returns true if the owner reference points to an
+// instance.
+func isInstanceReference(ref metav1.OwnerReference) bool {
+	return ref.APIVersion == ksmv1.GroupVersion.String() && ref.Kind == "CustomResourceStateMetrics"
+}
+
+// instanceReferences returns the owner references of the ConfigMap pointing to
+// the instances.
+func instanceReferences(cm *corev1.ConfigMap) []metav1.OwnerReference {
+	refs := []metav1.OwnerReference{}
+
+	for _, ref := range cm.OwnerReferences {
+		if isInstanceReference(ref) {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// blockOwners returns the sorted instances whose blocks are recorded in any key
+// of the ConfigMap.
+func blockOwners(cm *corev1.ConfigMap) []string {
+	owners := []string{}
+
+	for key := range getBlockHashes(cm) {
+		if _, owner, found := strings.Cut(key, "/"); found && !slices.Contains(owners, owner) {
+			owners = append(owners, owner)
+		}
+	}
+
+	slices.Sort(owners)
+
+	return owners
+}
+
+// ownable returns whether the instance may own the ConfigMap. The owner
+// reference can't cross the Namespaces and the retained resources must not
+// be garbage collected with the instance.
+func ownable(instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap) bool {
+	return instance.Spec.ConfigMap.Owned &&
+		targetKind(instance) != ksmv1.TargetKindSecret &&
+		instance.Spec.DeletionPolicy != ksmv1.DeletionPolicyRetain &&
+		cm.Namespace == instance.Namespace
+}
+
+// setOwnerReference makes the instance the owner of the ConfigMap created for
+// it if requested so the ConfigMap gets garbage collected with the instance
+// (persisted with the next write of the ConfigMap). The owner references of
+// the instances are removed once the ConfigMap holds the blocks of the other
+// instances too or once the owner is not requested anymore.
+func (r *CustomResourceStateMetricsReconciler) setOwnerReference(
+	instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap, created bool) error {
+	owners := blockOwners(cm)
+	refs := make([]metav1.OwnerReference, 0, len(cm.OwnerReferences))
+
+	for _, ref := range cm.OwnerReferences {
+		sole := slices.Equal(owners, []string{utils.NamespacedName(ref.Name, cm.Namespace)})
+
+		if isInstanceReference(ref) && (!sole || ref.UID == instance.UID && !ownable(instance, cm)) {
+			log.V(1).Info(
+				"Removing the owner reference of the ConfigMap",
+				"instance", utils.NamespacedName(ref.Name, cm.Namespace),
+				"configMap", utils.NamespacedName(cm.Name, cm.Namespace))
+
+			continue
+		}
+
+		refs = append(refs, ref)
+	}
+
+	cm.OwnerReferences = refs
+
+	if !created || !ownable(instance, cm) ||
+		!slices.Equal(owners, []string{utils.NamespacedName(instance.Name, instance.Namespace)}) {
+		return nil
+	}
+
+	if err := controllerutil.SetOwnerReference(instance, cm, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set the owner reference of the ConfigMap: %w", err)
+	}
+
+	return nil
+}
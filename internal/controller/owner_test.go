@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestSetOwnerReference(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	r := &CustomResourceStateMetricsReconciler{Scheme: scheme}
+
+	instance := &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "foo-uid"},
+		Spec: ksmv1.CustomResourceStateMetricsSpec{
+			ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{Owned: true},
+		},
+	}
+
+	newConfigMap := func(namespace string, owners ...string) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ksm", Namespace: namespace},
+			Data:       map[string]string{"config.yaml": "content\n"},
+		}
+
+		for _, owner := range owners {
+			setBlockHashes(cm, "config.yaml", owner, "block\n")
+		}
+
+		return cm
+	}
+
+	// The only writer owns the created ConfigMap
+	cm := newConfigMap("bar", "foo@bar")
+	g.Expect(r.setOwnerReference(instance, cm, true)).To(Succeed(), "Test [created]:")
+	g.Expect(instanceReferences(cm)).To(HaveLen(1), "Test [created]:")
+	g.Expect(cm.OwnerReferences[0].UID).To(BeEquivalentTo("foo-uid"), "Test [created]:")
+	g.Expect(configMapApplyConfiguration(cm).OwnerReferences).To(HaveLen(1), "Test [applied]:")
+
+	// The reference is kept while the instance is the only writer
+	g.Expect(r.setOwnerReference(instance, cm, false)).To(Succeed(), "Test [kept]:")
+	g.Expect(instanceReferences(cm)).To(HaveLen(1), "Test [kept]:")
+
+	// The reference is removed once another instance writes into the ConfigMap
+	setBlockHashes(cm, "config.yaml", "baz@bar", "block\n")
+	g.Expect(r.setOwnerReference(instance, cm, false)).To(Succeed(), "Test [shared]:")
+	g.Expect(instanceReferences(cm)).To(BeEmpty(), "Test [shared]:")
+	g.Expect(configMapApplyConfiguration(cm).OwnerReferences).To(BeEmpty(), "Test [shared]:")
+
+	// The reference can't cross the Namespaces
+	cm = newConfigMap("monitoring", "foo@bar")
+	g.Expect(r.setOwnerReference(instance, cm, true)).To(Succeed(), "Test [other-namespace]:")
+	g.Expect(instanceReferences(cm)).To(BeEmpty(), "Test [other-namespace]:")
+
+	// The reference is removed once it's not requested anymore
+	cm = newConfigMap("bar", "foo@bar")
+	g.Expect(r.setOwnerReference(instance, cm, true)).To(Succeed())
+
+	instance.Spec.ConfigMap.Owned = false
+	g.Expect(r.setOwnerReference(instance, cm, false)).To(Succeed(), "Test [disabled]:")
+	g.Expect(instanceReferences(cm)).To(BeEmpty(), "Test [disabled]:")
+}
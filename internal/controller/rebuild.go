@@ -0,0 +1,287 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Rebuilder rebuilds the keys of the managed ConfigMaps from the current
+// instances once the operator starts. The resources of the deleted instances
+// and the unmarked resources are dropped (the taken over resources are kept)
+// and the resources of the instances are rendered again instead of being
+// patched.
+type Rebuilder struct {
+	Reconciler *CustomResourceStateMetricsReconciler
+}
+
+// rebuiltBlock holds the rendered resources of the instance.
+type rebuiltBlock struct {
+	instance *ksmv1.CustomResourceStateMetrics
+	owner    string
+	path     string
+	data     string
+}
+
+// configMapKey identifies the key of the ConfigMap.
+type configMapKey struct {
+	cm  types.NamespacedName
+	key string
+}
+
+// NeedLeaderElection makes the rebuild run only on the leader.
+func (b *Rebuilder) NeedLeaderElection() bool {
+	return true
+}
+
+// Start rebuilds the managed ConfigMaps once.
+func (b *Rebuilder) Start(ctx context.Context) error {
+	if err := b.rebuild(ctx); err != nil {
+		log.Error(err, "Failed to rebuild the ConfigMaps")
+	}
+
+	return nil
+}
+
+// rebuild rebuilds all managed ConfigMaps.
+func (b *Rebuilder) rebuild(ctx context.Context) error {
+	r := b.Reconciler
+
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+
+	if err := r.List(ctx, instances); err != nil {
+		return fmt.Errorf("failed to list CustomResourceStateMetrics: %w", err)
+	}
+
+	existing := make(map[string]struct{}, len(instances.Items))
+
+	// The taken over resources have no instance
+	existing[ManualOwner] = struct{}{}
+	blocks := make(map[configMapKey][]rebuiltBlock)
+	selected := r.selectorPredicate()
+
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+		instanceNamespacedName := utils.NamespacedName(instance.Name, instance.Namespace)
+
+		existing[instanceNamespacedName] = struct{}{}
+
+		// The resources of the other instances are kept as they are
+		if !rebuildable(instance) || !selected.Generic(event.GenericEvent{Object: instance}) {
+			continue
+		}
+
+		data, err := r.renderInstance(ctx, instance)
+		if err != nil {
+			log.Error(err, "Unable to render resources for the rebuild", "instance", instanceNamespacedName)
+
+			continue
+		}
+
+		target := instance.Status.ConfigMap
+
+		namespaces := target.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{target.Namespace}
+		}
+
+		for _, namespace := range namespaces {
+			// The targets not allowed by the operator policy are not written anymore
+			if !r.TargetPolicy.Allowed(target.Name, namespace) {
+				continue
+			}
+
+			key := configMapKey{cm: types.NamespacedName{Name: target.Name, Namespace: namespace}, key: target.Key}
+			blocks[key] = append(blocks[key], rebuiltBlock{
+				instance: instance,
+				owner:    instanceNamespacedName,
+				path:     target.Path,
+				data:     data,
+			})
+		}
+	}
+
+	cms := &corev1.ConfigMapList{}
+
+	if err := r.List(ctx, cms); err != nil {
+		return fmt.Errorf("failed to list ConfigMaps: %w", err)
+	}
+
+	for i := range cms.Items {
+		// Only the ConfigMaps written by the operator are managed
+		if _, ok := cms.Items[i].Annotations[BlockHashesAnnotation]; !ok {
+			continue
+		}
+
+		if err := b.rebuildConfigMap(ctx, cms.Items[i].DeepCopy(), blocks, existing); err != nil {
+			log.Error(
+				err,
+				"Failed to rebuild the ConfigMap",
+				"configMap", utils.NamespacedName(cms.Items[i].Name, cms.Items[i].Namespace))
+		}
+	}
+
+	return nil
+}
+
+// rebuildConfigMap rebuilds the managed keys of the ConfigMap.
+func (b *Rebuilder) rebuildConfigMap(
+	ctx context.Context, cm *corev1.ConfigMap, blocks map[configMapKey][]rebuiltBlock,
+	existing map[string]struct{}) error {
+	cmNamespacedName := types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}
+
+	// Prevent overwriting the changes of the concurrent reconciles
+	defer targetLocks.lock(cmNamespacedName)()
+
+	if err := loadData(cm); err != nil {
+		return err
+	}
+
+	changedKeys := []string{}
+
+	for _, key := range managedKeys(cm) {
+		// The staging keys are promoted by the approval only
+		if _, ok := getBlockHashes(cm)[key]; !ok {
+			continue
+		}
+
+		rebuilt := blocks[configMapKey{cm: cmNamespacedName, key: key}]
+
+		data, dropped, err := rebuildContent(cm.Data[key], rebuilt, existing)
+		if err != nil {
+			return fmt.Errorf("failed to rebuild the key %s: %w", key, err)
+		}
+
+		if data == cm.Data[key] {
+			continue
+		}
+
+		cm.Data[key] = data
+
+		for _, block := range rebuilt {
+			setBlockHashes(cm, key, block.owner, block.data)
+		}
+
+		for _, owner := range dropped {
+			setBlockHashes(cm, key, owner, "")
+			setMetadata(cm, owner, nil, nil)
+		}
+
+		changedKeys = append(changedKeys, key)
+	}
+
+	if len(changedKeys) == 0 {
+		return nil
+	}
+
+	log.Info(
+		"Rebuilding ConfigMap",
+		"configMap", utils.NamespacedName(cm.Name, cm.Namespace),
+		"keys", changedKeys,
+		"dryRun", b.Reconciler.DryRun)
+
+	if b.Reconciler.DryRun {
+		return nil
+	}
+
+	if err := applyConfigMap(ctx, b.Reconciler.Client, cm); err != nil {
+		return fmt.Errorf("failed to update the ConfigMap: %w", err)
+	}
+
+	// Restart kube-state-metrics to load the new content
+	for _, key := range changedKeys {
+		for _, block := range blocks[configMapKey{cm: cmNamespacedName, key: key}] {
+			b.Reconciler.rollout(ctx, block.instance, cm, key)
+		}
+	}
+
+	return nil
+}
+
+// rebuildContent rebuilds the content of the ConfigMap key from the rendered
+// blocks. Only the resources of the existing instances which are not rebuilt
+// are kept. It returns the new content and the sorted owners of the dropped
+// resources which no longer exist.
+func rebuildContent(content string, blocks []rebuiltBlock, existing map[string]struct{}) (string, []string, error) {
+	doc, err := parseDocument(content)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rebuilt := make(map[string]struct{}, len(blocks))
+	for _, block := range blocks {
+		rebuilt[block.owner] = struct{}{}
+	}
+
+	dropped := []string{}
+
+	for _, list := range documentResourceLists(doc) {
+		for _, owner := range slices.Clone(list.owners) {
+			_, exists := existing[owner]
+			_, isRebuilt := rebuilt[owner]
+
+			if owner != "" && exists && !isRebuilt {
+				continue
+			}
+
+			if owner != "" && !exists && !slices.Contains(dropped, owner) {
+				dropped = append(dropped, owner)
+			}
+
+			list.remove(owner)
+		}
+	}
+
+	data, err := encodeDocument(doc)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, block := range blocks {
+		if data, _, err = mergeResources(data, block.path, block.owner, block.data); err != nil {
+			return "", nil, err
+		}
+	}
+
+	slices.Sort(dropped)
+
+	return data, dropped, nil
+}
+
+// rebuildable returns whether the resources of the instance can be rendered
+// again without waiting for an approval, a change window or a resync.
+func rebuildable(instance *ksmv1.CustomResourceStateMetrics) bool {
+	target := instance.Status.ConfigMap
+
+	return instance.DeletionTimestamp.IsZero() &&
+		!instance.Spec.Suspend &&
+		!isPaused(instance) &&
+		instance.Spec.Schedule == nil &&
+		!instance.Spec.ConfigMap.Staged &&
+		!instance.Spec.ConfigMap.Immutable &&
+		instance.Spec.ResyncPolicy != ksmv1.ResyncPolicyNever &&
+		target != nil && target.Kind != ksmv1.TargetKindSecret
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestRebuildContent(t *testing.T) {
+	g := NewWithT(t)
+
+	fooYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Foo\n    version: v1\n"
+	barYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Bar\n    version: v1\n"
+	bazYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Baz\n    version: v1\n"
+	tamperedYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Tampered\n    version: v1\n"
+
+	// Unmarked resource followed by the blocks of foo (tampered), bar
+	// (not rebuilt) and baz (deleted)
+	content := "kind: CustomResourceStateMetrics\nspec:\n  resources:\n" +
+		"    - groupVersionKind:\n        group: myteam.io\n        kind: Manual\n        version: v1\n"
+
+	var err error
+
+	for _, block := range []struct{ owner, data string }{
+		{"foo@ns", tamperedYaml},
+		{"bar@ns", barYaml},
+		{"baz@ns", bazYaml},
+	} {
+		content, _, err = mergeResources(content, "", block.owner, block.data)
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	existing := map[string]struct{}{"foo@ns": {}, "bar@ns": {}}
+	blocks := []rebuiltBlock{{owner: "foo@ns", data: fooYaml}}
+
+	data, dropped, err := rebuildContent(content, blocks, existing)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dropped).To(Equal([]string{"baz@ns"}))
+
+	expected, _, err := mergeResources("", "", "bar@ns", barYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+	expected, _, err = mergeResources(expected, "", "foo@ns", fooYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(data).To(Equal(expected))
+
+	// The rebuild of the clean content doesn't change it
+	again, dropped, err := rebuildContent(data, blocks, existing)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dropped).To(BeEmpty())
+	g.Expect(again).To(Equal(data))
+}
+
+func TestRebuildable(t *testing.T) {
+	g := NewWithT(t)
+
+	target := &ksmv1.CustomResourceStateMetricsTarget{Name: "cm", Namespace: "ns", Key: "config.yaml"}
+
+	tests := map[string]struct {
+		instance *ksmv1.CustomResourceStateMetrics
+		expected bool
+	}{
+		"written": {
+			instance: &ksmv1.CustomResourceStateMetrics{
+				Status: ksmv1.CustomResourceStateMetricsStatus{ConfigMap: target},
+			},
+			expected: true,
+		},
+		"not_written": {
+			instance: &ksmv1.CustomResourceStateMetrics{},
+		},
+		"suspended": {
+			instance: &ksmv1.CustomResourceStateMetrics{
+				Spec:   ksmv1.CustomResourceStateMetricsSpec{Suspend: true},
+				Status: ksmv1.CustomResourceStateMetricsStatus{ConfigMap: target},
+			},
+		},
+		"paused": {
+			instance: &ksmv1.CustomResourceStateMetrics{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{PausedAnnotation: "true"}},
+				Status:     ksmv1.CustomResourceStateMetricsStatus{ConfigMap: target},
+			},
+		},
+		"staged": {
+			instance: &ksmv1.CustomResourceStateMetrics{
+				Spec: ksmv1.CustomResourceStateMetricsSpec{
+					ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{Staged: true},
+				},
+				Status: ksmv1.CustomResourceStateMetricsStatus{ConfigMap: target},
+			},
+		},
+		"secret": {
+			instance: &ksmv1.CustomResourceStateMetrics{
+				Status: ksmv1.CustomResourceStateMetricsStatus{
+					ConfigMap: &ksmv1.CustomResourceStateMetricsTarget{Kind: ksmv1.TargetKindSecret, Name: "secret"},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		g.Expect(rebuildable(test.instance)).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
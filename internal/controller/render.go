@@ -0,0 +1,169 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// RenderedKey identifies the key of the ConfigMap (or of the Secret) the
+// resources of the instances are written into.
+type RenderedKey struct {
+	Kind      ksmv1.TargetKind
+	Name      string
+	Namespace string
+	Key       string
+}
+
+// Render assembles the content of the keys the instances would be written
+// into from scratch the same way the operator writes it. The instances are
+// merged in the order of their namespaced names. The content of the JSON keys
+// is rendered as JSON.
+func (r *CustomResourceStateMetricsReconciler) Render(
+	ctx context.Context, instances []ksmv1.CustomResourceStateMetrics) (map[RenderedKey]string, error) {
+	contents, jsonKeys, err := r.render(ctx, instances, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range jsonKeys {
+		if contents[key], _, err = yamlToJSON(contents[key]); err != nil {
+			return nil, fmt.Errorf("failed to convert the content of %s into JSON: %w", key.Key, err)
+		}
+	}
+
+	return contents, nil
+}
+
+// RenderLive assembles the content of the keys the instances would be written
+// into the same way as Render but the resources are merged into the current
+// content of the keys so the resources of the other instances are kept. The
+// content of the JSON keys is kept as YAML with the markers the same way as
+// the LiveContent returns it.
+func (r *CustomResourceStateMetricsReconciler) RenderLive(
+	ctx context.Context, instances []ksmv1.CustomResourceStateMetrics) (map[RenderedKey]string, error) {
+	contents, _, err := r.render(ctx, instances, true)
+
+	return contents, err
+}
+
+// LiveContent returns the current content of the key. The content of the
+// JSON keys is converted into YAML. It returns false if the ConfigMap (or the
+// Secret) doesn't exist.
+func (r *CustomResourceStateMetricsReconciler) LiveContent(ctx context.Context, key RenderedKey) (string, bool, error) {
+	cm := &corev1.ConfigMap{}
+
+	if err := r.getConfigMap(
+		ctx, types.NamespacedName{Name: key.Name, Namespace: key.Namespace}, key.Kind, cm); apierrors.IsNotFound(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	return cm.Data[key.Key], true, nil
+}
+
+// render assembles the content of the keys the instances would be written
+// into starting either from scratch or from the current content of the keys.
+// It returns the YAML content of the keys and the keys which should be
+// stored as JSON.
+func (r *CustomResourceStateMetricsReconciler) render(
+	ctx context.Context, instances []ksmv1.CustomResourceStateMetrics,
+	live bool) (map[RenderedKey]string, map[RenderedKey]struct{}, error) {
+	sorted := make([]*ksmv1.CustomResourceStateMetrics, 0, len(instances))
+
+	for i := range instances {
+		instance := instances[i].DeepCopy()
+
+		// The rendered resources are cached by the UID the manifests read from files don't have
+		if instance.UID == "" {
+			instance.UID = types.UID(utils.NamespacedName(instance.Name, instance.Namespace))
+		}
+
+		sorted = append(sorted, instance)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return utils.NamespacedName(sorted[i].Name, sorted[i].Namespace) <
+			utils.NamespacedName(sorted[j].Name, sorted[j].Namespace)
+	})
+
+	contents := make(map[RenderedKey]string)
+	jsonKeys := make(map[RenderedKey]struct{})
+
+	for _, instance := range sorted {
+		instanceNamespacedName := utils.NamespacedName(instance.Name, instance.Namespace)
+
+		dataYaml, err := r.renderInstance(ctx, instance)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to render %s: %w", instanceNamespacedName, err)
+		}
+
+		cmName, cmNamespace, cmKey, err := r.configMapTarget(ctx, instance)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve the target of %s: %w", instanceNamespacedName, err)
+		}
+
+		namespaces := []string{cmNamespace}
+		if replicated(instance) {
+			if namespaces, err = r.selectedNamespaces(ctx, instance); err != nil {
+				return nil, nil, fmt.Errorf("failed to resolve the target of %s: %w", instanceNamespacedName, err)
+			}
+		}
+
+		for _, namespace := range namespaces {
+			key := RenderedKey{Kind: specTargetKind(instance), Name: cmName, Namespace: namespace, Key: cmKey}
+
+			if jsonFormat(instance, cmKey) {
+				jsonKeys[key] = struct{}{}
+			}
+
+			content, ok := contents[key]
+			if !ok {
+				exists := false
+
+				if live {
+					if content, exists, err = r.LiveContent(ctx, key); err != nil {
+						return nil, nil, fmt.Errorf("failed to get the current content of %s: %w",
+							utils.NamespacedName(key.Name, key.Namespace), err)
+					}
+				}
+
+				// The nested document is created from scratch
+				if !exists && instance.Spec.ConfigMap.Path == "" {
+					content = documentHeader()
+				}
+			}
+
+			if contents[key], _, err = mergeResources(
+				content, instance.Spec.ConfigMap.Path, instanceNamespacedName, dataYaml); err != nil {
+				return nil, nil, fmt.Errorf("failed to merge the resources of %s: %w", instanceNamespacedName, err)
+			}
+		}
+	}
+
+	return contents, jsonKeys, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// renderCache caches the rendered resources of the instances so they don't
+// have to be rendered again if the instance generation didn't change.
+type renderCache struct {
+	mu      sync.Mutex
+	entries map[types.UID]renderCacheEntry
+}
+
+// renderCacheEntry holds the rendered resources of a specific generation.
+type renderCacheEntry struct {
+	generation int64
+	data       string
+}
+
+// newRenderCache creates a new empty renderCache.
+func newRenderCache() *renderCache {
+	return &renderCache{
+		entries: make(map[types.UID]renderCacheEntry),
+	}
+}
+
+// get returns the rendered resources if they were cached for the generation.
+func (c *renderCache) get(uid types.UID, generation int64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[uid]
+	if !ok || entry.generation != generation {
+		return "", false
+	}
+
+	return entry.data, true
+}
+
+// set caches the rendered resources for the generation.
+func (c *renderCache) set(uid types.UID, generation int64, data string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[uid] = renderCacheEntry{
+		generation: generation,
+		data:       data,
+	}
+}
+
+// delete removes the cached rendered resources of the instance.
+func (c *renderCache) delete(uid types.UID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, uid)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRenderCache(t *testing.T) {
+	g := NewWithT(t)
+
+	c := newRenderCache()
+
+	_, found := c.get("foo", 1)
+	g.Expect(found).To(BeFalse(), "Test [empty]:")
+
+	c.set("foo", 1, "data-1")
+
+	data, found := c.get("foo", 1)
+	g.Expect(found).To(BeTrue(), "Test [same-generation]:")
+	g.Expect(data).To(Equal("data-1"), "Test [same-generation]:")
+
+	_, found = c.get("foo", 2)
+	g.Expect(found).To(BeFalse(), "Test [new-generation]:")
+
+	c.delete("foo")
+
+	_, found = c.get("foo", 1)
+	g.Expect(found).To(BeFalse(), "Test [deleted]:")
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestRender(t *testing.T) {
+	g := NewWithT(t)
+
+	fooYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Foo\n    version: v1\n"
+	barYaml := "- groupVersionKind:\n    group: myteam.io\n    kind: Bar\n    version: v1\n"
+
+	newInstance := func(name, cmName, data string) ksmv1.CustomResourceStateMetrics {
+		return ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				ConfigMap:    ksmv1.CustomResourceStateMetricsConfigMap{Name: cmName, Namespace: "monitoring"},
+				ResourcesRaw: data,
+			},
+		}
+	}
+
+	r := &CustomResourceStateMetricsReconciler{}
+
+	contents, err := r.Render(context.Background(), []ksmv1.CustomResourceStateMetrics{
+		newInstance("foo", "ksm", fooYaml),
+		newInstance("baz", "other", fooYaml),
+		newInstance("bar", "ksm", barYaml),
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(contents).To(HaveLen(2), "Test [targets]:")
+
+	// The instances are merged in the order of their names
+	expected, _, err := mergeResources(dataHeader, "", "bar@ns", barYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+	expected, _, err = mergeResources(expected, "", "foo@ns", fooYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	key := RenderedKey{Kind: ksmv1.TargetKindConfigMap, Name: "ksm", Namespace: "monitoring", Key: DefaultKey}
+	g.Expect(contents).To(HaveKeyWithValue(key, expected), "Test [merged]:")
+
+	expected, _, err = mergeResources(dataHeader, "", "baz@ns", fooYaml)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	key = RenderedKey{Kind: ksmv1.TargetKindConfigMap, Name: "other", Namespace: "monitoring", Key: DefaultKey}
+	g.Expect(contents).To(HaveKeyWithValue(key, expected), "Test [single]:")
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// replicated returns whether the resources of the instance are written into
+// the ConfigMaps in all the Namespaces matching the Namespace selector.
+func replicated(instance *ksmv1.CustomResourceStateMetrics) bool {
+	return targetSecret(instance) == nil && instance.Spec.ConfigMap.NamespaceSelector != nil
+}
+
+// selectedNamespaces returns the sorted names of the Namespaces matching the
+// Namespace selector of the ConfigMap. Namespaces being deleted are skipped.
+func (r *CustomResourceStateMetricsReconciler) selectedNamespaces(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics) ([]string, error) {
+	selector, err := metav1.LabelSelectorAsSelector(instance.Spec.ConfigMap.NamespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the Namespace selector of the ConfigMap: %w", err)
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list Namespaces: %w", err)
+	}
+
+	names := []string{}
+
+	for i := range namespaces.Items {
+		if !namespaces.Items[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		names = append(names, namespaces.Items[i].Name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// namespaceToInstances maps the Namespace to the selected instances which
+// replicate the ConfigMap into it or which should start doing so. The
+// instances in the Namespace are mapped as well so the ones missed before are
+// reconciled once the Namespace starts matching the Namespace selector of the
+// operator.
+func (r *CustomResourceStateMetricsReconciler) namespaceToInstances(
+	ctx context.Context, obj client.Object) []reconcile.Request {
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+
+	if err := r.List(ctx, instances); err != nil {
+		log.Error(err, "Failed to list instances", "namespace", obj.GetName())
+
+		return nil
+	}
+
+	selected := r.selectorPredicate()
+	requests := []reconcile.Request{}
+
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+
+		if instance.Namespace != obj.GetName() && !replicatesInto(instance, obj) {
+			continue
+		}
+
+		if !selected.Generic(event.GenericEvent{Object: instance}) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)})
+	}
+
+	return requests
+}
+
+// replicatesInto returns whether the instance replicates the ConfigMap into
+// the Namespace or whether the Namespace matches its Namespace selector.
+func replicatesInto(instance *ksmv1.CustomResourceStateMetrics, namespace client.Object) bool {
+	if !replicated(instance) {
+		return false
+	}
+
+	if target := instance.Status.ConfigMap; target != nil && slices.Contains(target.Namespaces, namespace.GetName()) {
+		return true
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(instance.Spec.ConfigMap.NamespaceSelector)
+	if err != nil {
+		log.Error(
+			err,
+			"Failed to parse the Namespace selector of the ConfigMap",
+			"instance", utils.NamespacedName(instance.Name, instance.Namespace))
+
+		return false
+	}
+
+	return selector.Matches(labels.Set(namespace.GetLabels()))
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/remote"
+	"github.com/jtyr/crsm-operator/internal/utils"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+// Interval in which the remote source is fetched again if not specified.
+const DefaultPollInterval = 10 * time.Minute
+
+// loadResources returns the resources of the instance (parsed from the raw
+// string if specified) followed by the resources read from the referenced
+// ConfigMap and Secret keys and from the remote source with the defaults
+// applied. The missing optional keys are skipped.
+func (r *CustomResourceStateMetricsReconciler) loadResources(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics) ([]ksm.Resource, error) {
+	resources := slices.Clone(instance.Spec.Resources)
+
+	if instance.Spec.ResourcesRaw != "" {
+		raw, err := ksm.ParseResources(instance.Spec.ResourcesRaw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resourcesRaw: %w", err)
+		}
+
+		resources = raw
+	}
+
+	for i, source := range instance.Spec.ResourcesFrom {
+		content, found, err := r.sourceContent(ctx, instance.Namespace, source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read resourcesFrom #%d: %w", i, err)
+		}
+
+		if !found {
+			continue
+		}
+
+		sourced, err := ksm.ParseResources(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resourcesFrom #%d: %w", i, err)
+		}
+
+		resources = append(resources, sourced...)
+	}
+
+	if instance.Spec.Source != nil {
+		sourced, err := r.remoteResources(ctx, instance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the remote source: %w", err)
+		}
+
+		resources = append(resources, sourced...)
+	} else {
+		r.forgetRemoteSource(instance)
+	}
+
+	if defaults := instance.Spec.Defaults; defaults != nil {
+		resources = ksm.ApplyDefaults(resources, defaults.MetricNamePrefix, defaults.CommonLabels)
+	}
+
+	return resources, nil
+}
+
+// remoteResources fetches the resources from the remote source of the
+// instance and checks them against the pinned digest.
+func (r *CustomResourceStateMetricsReconciler) remoteResources(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics) ([]ksm.Resource, error) {
+	source := instance.Spec.Source
+
+	if r.Fetcher == nil {
+		return nil, errors.New("the remote sources are not enabled")
+	}
+
+	interval := pollInterval(source)
+
+	var content []byte
+	var err error
+
+	if source.OCI != "" {
+		content, err = r.Fetcher.FetchOCI(ctx, string(instance.UID), source.OCI, interval)
+	} else {
+		content, err = r.Fetcher.FetchURL(ctx, string(instance.UID), source.URL, interval)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Digest != "" {
+		if err := remote.VerifyDigest(content, source.Digest); err != nil {
+			return nil, err
+		}
+	}
+
+	return ksm.ParseResources(string(content))
+}
+
+// forgetRemoteSource drops the document fetched for the instance once the
+// remote source is removed or the instance is deleted.
+func (r *CustomResourceStateMetricsReconciler) forgetRemoteSource(instance *ksmv1.CustomResourceStateMetrics) {
+	if r.Fetcher != nil {
+		r.Fetcher.Forget(string(instance.UID))
+	}
+}
+
+// pollInterval returns the interval in which the remote source is fetched
+// again. The interval is clamped to the minimum so the source can't be
+// fetched in a loop.
+func pollInterval(source *ksmv1.CustomResourceStateMetricsSource) time.Duration {
+	if source.PollInterval != nil && source.PollInterval.Duration > 0 {
+		return max(source.PollInterval.Duration, ksmv1.MinPollInterval)
+	}
+
+	return DefaultPollInterval
+}
+
+// sourceContent returns the content of the referenced ConfigMap or Secret key
+// and whether it was found. It's an error if the key is missing and it's not
+// optional.
+func (r *CustomResourceStateMetricsReconciler) sourceContent(
+	ctx context.Context, namespace string, source ksmv1.CustomResourceStateMetricsResourcesSource) (string, bool, error) {
+	switch {
+	case source.ConfigMapKeyRef != nil:
+		ref := source.ConfigMapKeyRef
+		optional := ref.Optional != nil && *ref.Optional
+
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, cm); err != nil {
+			if client.IgnoreNotFound(err) == nil && optional {
+				return "", false, nil
+			}
+
+			return "", false, fmt.Errorf("failed to get the ConfigMap %s: %w", ref.Name, err)
+		}
+
+		content, ok := cm.Data[ref.Key]
+		if !ok && !optional {
+			return "", false, fmt.Errorf("the ConfigMap %s has no key %s", ref.Name, ref.Key)
+		}
+
+		return content, ok, nil
+	case source.SecretKeyRef != nil:
+		ref := source.SecretKeyRef
+		optional := ref.Optional != nil && *ref.Optional
+
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+			if client.IgnoreNotFound(err) == nil && optional {
+				return "", false, nil
+			}
+
+			return "", false, fmt.Errorf("failed to get the Secret %s: %w", ref.Name, err)
+		}
+
+		content, ok := secret.Data[ref.Key]
+		if !ok && !optional {
+			return "", false, fmt.Errorf("the Secret %s has no key %s", ref.Name, ref.Key)
+		}
+
+		return string(content), ok, nil
+	}
+
+	return "", false, nil
+}
+
+// sourcesTo returns the function mapping the ConfigMap or the Secret to the
+// selected instances reading the resources from it.
+func (r *CustomResourceStateMetricsReconciler) sourcesTo(kind ksmv1.TargetKind) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		instances := &ksmv1.CustomResourceStateMetricsList{}
+
+		if err := r.List(ctx, instances, client.InNamespace(obj.GetNamespace())); err != nil {
+			log.Error(err, "Failed to list instances", "source", utils.NamespacedName(obj.GetName(), obj.GetNamespace()))
+
+			return nil
+		}
+
+		selected := r.selectorPredicate()
+		requests := []reconcile.Request{}
+
+		for i := range instances.Items {
+			instance := &instances.Items[i]
+
+			if !readsResourcesFrom(instance, kind, obj.GetName()) {
+				continue
+			}
+
+			if !selected.Generic(event.GenericEvent{Object: instance}) {
+				continue
+			}
+
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)})
+		}
+
+		return requests
+	}
+}
+
+// readsResourcesFrom returns whether the instance reads the resources from
+// the ConfigMap or the Secret.
+func readsResourcesFrom(instance *ksmv1.CustomResourceStateMetrics, kind ksmv1.TargetKind, name string) bool {
+	for _, source := range instance.Spec.ResourcesFrom {
+		if kind == ksmv1.TargetKindConfigMap && source.ConfigMapKeyRef != nil && source.ConfigMapKeyRef.Name == name {
+			return true
+		}
+
+		if kind == ksmv1.TargetKindSecret && source.SecretKeyRef != nil && source.SecretKeyRef.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
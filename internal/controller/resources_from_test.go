@@ -0,0 +1,72 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestReadsResourcesFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	instance := &ksmv1.CustomResourceStateMetrics{
+		Spec: ksmv1.CustomResourceStateMetricsSpec{
+			ResourcesFrom: []ksmv1.CustomResourceStateMetricsResourcesSource{
+				{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "foo"},
+					Key:                  "resources.yaml",
+				}},
+				{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "bar"},
+					Key:                  "resources.yaml",
+				}},
+			},
+		},
+	}
+
+	g.Expect(readsResourcesFrom(instance, ksmv1.TargetKindConfigMap, "foo")).To(BeTrue(), "Test [configmap]:")
+	g.Expect(readsResourcesFrom(instance, ksmv1.TargetKindSecret, "bar")).To(BeTrue(), "Test [secret]:")
+	g.Expect(readsResourcesFrom(instance, ksmv1.TargetKindSecret, "foo")).To(BeFalse(), "Test [other-kind]:")
+	g.Expect(readsResourcesFrom(instance, ksmv1.TargetKindConfigMap, "qux")).To(BeFalse(), "Test [other-name]:")
+}
+
+func TestPollInterval(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		interval *metav1.Duration
+		expected time.Duration
+	}{
+		"default":  {interval: nil, expected: DefaultPollInterval},
+		"zero":     {interval: &metav1.Duration{}, expected: DefaultPollInterval},
+		"clamped":  {interval: &metav1.Duration{Duration: time.Nanosecond}, expected: ksmv1.MinPollInterval},
+		"explicit": {interval: &metav1.Duration{Duration: time.Hour}, expected: time.Hour},
+	}
+
+	for name, test := range tests {
+		source := &ksmv1.CustomResourceStateMetricsSource{PollInterval: test.interval}
+
+		g.Expect(pollInterval(source)).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+)
+
+// Reasons of the last reconcile result.
+const resultSynced = "Synced"
+const resultInvalidResources = "InvalidResources"
+const resultInvalidSchedule = "InvalidSchedule"
+const resultTargetError = "TargetError"
+const resultWriteError = "WriteError"
+const resultFieldConflict = "FieldConflict"
+const resultTooLarge = "TooLarge"
+const resultInsufficientPermissions = "InsufficientPermissions"
+const resultTargetNotAllowed = "TargetNotAllowed"
+const resultMetricNameNotAllowed = "MetricNameNotAllowed"
+const resultMetricCollision = "MetricCollision"
+const resultError = "Error"
+
+// reasonError is an error carrying the reason of the reconcile result.
+type reasonError struct {
+	reason string
+	err    error
+}
+
+// Error returns the message of the wrapped error.
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *reasonError) Unwrap() error {
+	return e.err
+}
+
+// withReason wraps the error with the reason of the reconcile result.
+func withReason(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &reasonError{
+		reason: reason,
+		err:    err,
+	}
+}
+
+// resultReason returns the reason of the reconcile result for the error.
+func resultReason(err error) string {
+	var reasonErr *reasonError
+
+	if errors.As(err, &reasonErr) {
+		return reasonErr.reason
+	}
+
+	return resultError
+}
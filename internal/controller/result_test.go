@@ -0,0 +1,58 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestResultReason(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "plain",
+			err:      errors.New("foo"),
+			expected: resultError,
+		},
+		{
+			name:     "reason",
+			err:      withReason(resultWriteError, errors.New("foo")),
+			expected: resultWriteError,
+		},
+		{
+			name:     "wrapped",
+			err:      fmt.Errorf("bar: %w", withReason(resultInvalidResources, errors.New("foo"))),
+			expected: resultInvalidResources,
+		},
+	}
+
+	for _, test := range tests {
+		g.Expect(resultReason(test.err)).To(Equal(test.expected), "Test [%s]:", test.name)
+	}
+
+	g.Expect(withReason(resultWriteError, nil)).To(BeNil(), "Test [nil]:")
+	g.Expect(withReason(resultWriteError, errors.New("foo")).Error()).To(Equal("foo"), "Test [message]:")
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"slices"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Delay of the first retry of the transient failure. It doubles with every
+// consecutive failure up to the maximum.
+const retryBaseDelay = 5 * time.Second
+const retryMaxDelay = 5 * time.Minute
+
+// Counts the consecutive transient failures per instance.
+var retryFailures = newFailureCounter()
+
+// isTransient returns true if the failure is expected to go away without a
+// change of the spec (e.g. the target Namespace doesn't exist yet, the
+// ConfigMap was modified concurrently or the API server is throttling).
+func isTransient(err error) bool {
+	return apierrors.IsNotFound(err) ||
+		(apierrors.IsConflict(err) && !isFieldConflict(err)) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+}
+
+// isPermanent returns true if the failure can't be fixed without a change of
+// the spec so retrying it is pointless.
+func isPermanent(err error) bool {
+	return slices.Contains(stalledResults, resultReason(err))
+}
+
+// retryDelay returns the exponential backoff for the number of the
+// consecutive failures.
+func retryDelay(failures int) time.Duration {
+	delay := retryBaseDelay
+
+	for i := 1; i < failures && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, retryMaxDelay)
+}
+
+// retryResult returns the result of the reconciliation for its error. The
+// transient failures are retried with the exponential backoff, the permanent
+// failures are not retried until the instance changes and the other failures
+// are left to the rate limiter of the controller.
+func retryResult(
+	instance *ksmv1.CustomResourceStateMetrics, result ctrl.Result, err error) (ctrl.Result, error) {
+	if err == nil {
+		retryFailures.reset(instance.UID)
+
+		return result, nil
+	}
+
+	// The deleted instance must be retried so its finalizer is removed eventually
+	if isPermanent(err) && instance.DeletionTimestamp.IsZero() {
+		retryFailures.reset(instance.UID)
+
+		return ctrl.Result{}, reconcile.TerminalError(err)
+	}
+
+	if !isTransient(err) {
+		return result, err
+	}
+
+	delay := retryDelay(retryFailures.inc(instance.UID))
+
+	log.Info(
+		"Retrying transient failure",
+		"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+		"requeueAfter", delay,
+		"error", err.Error())
+
+	return ctrl.Result{RequeueAfter: delay}, nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestRetryDelay(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(retryDelay(1)).To(Equal(retryBaseDelay), "Test [first]:")
+	g.Expect(retryDelay(2)).To(Equal(2*retryBaseDelay), "Test [second]:")
+	g.Expect(retryDelay(3)).To(Equal(4*retryBaseDelay), "Test [third]:")
+	g.Expect(retryDelay(100)).To(Equal(retryMaxDelay), "Test [capped]:")
+}
+
+func TestRetryResult(t *testing.T) {
+	g := NewWithT(t)
+
+	instance := &ksmv1.CustomResourceStateMetrics{ObjectMeta: metav1.ObjectMeta{UID: "retry-result"}}
+	missingNamespace := fmt.Errorf("failed to update the ConfigMap: %w", withReason(resultWriteError,
+		apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "foo")))
+
+	// The transient failures are retried with the growing delay
+	result, err := retryResult(instance, ctrl.Result{}, missingNamespace)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [transient]:")
+	g.Expect(result.RequeueAfter).To(Equal(retryBaseDelay), "Test [transient]:")
+
+	result, err = retryResult(instance, ctrl.Result{}, apierrors.NewTooManyRequests("throttled", 1))
+	g.Expect(err).NotTo(HaveOccurred(), "Test [throttled]:")
+	g.Expect(result.RequeueAfter).To(Equal(2*retryBaseDelay), "Test [throttled]:")
+
+	// The success resets the backoff
+	result, err = retryResult(instance, ctrl.Result{RequeueAfter: time.Hour}, nil)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [success]:")
+	g.Expect(result.RequeueAfter).To(Equal(time.Hour), "Test [success]:")
+
+	result, _ = retryResult(instance, ctrl.Result{}, apierrors.NewConflict(
+		schema.GroupResource{Resource: "configmaps"}, "foo", errors.New("foo")))
+	g.Expect(result.RequeueAfter).To(Equal(retryBaseDelay), "Test [reset]:")
+
+	// The permanent failures are not retried
+	tooLarge := withReason(resultTooLarge, errors.New("too large"))
+
+	_, err = retryResult(instance, ctrl.Result{}, tooLarge)
+	g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeTrue(), "Test [permanent]:")
+	g.Expect(errors.Is(err, tooLarge)).To(BeTrue(), "Test [permanent]:")
+
+	// The deleted instance is retried until its finalizer is removed
+	deleted := instance.DeepCopy()
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	_, err = retryResult(deleted, ctrl.Result{}, tooLarge)
+	g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeFalse(), "Test [deleted]:")
+
+	// The other failures are left to the rate limiter
+	_, err = retryResult(instance, ctrl.Result{}, errors.New("unknown"))
+	g.Expect(err).To(MatchError("unknown"), "Test [other]:")
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Name of the annotation pinning the resources to the revision recorded in the
+// status instead of the rendered resources (e.g. when a change of the spec
+// breaks kube-state-metrics). The resources are rendered again once the
+// annotation is removed.
+const RollbackToAnnotation = "ksm.jtyr.io/rollback-to"
+
+// Number of the revisions kept in the status if not specified.
+const defaultRevisionHistoryLimit = 3
+
+// Maximal total size of the resources of the revisions kept in the status so
+// the instance doesn't exceed the size limit of the etcd objects.
+const maxRevisionsSize = 256 * 1024
+
+// Reason for events recorded when the resources are rolled back.
+const reasonRolledBack = "RolledBack"
+
+// revisionHistoryLimit returns the number of the revisions kept in the status.
+func revisionHistoryLimit(instance *ksmv1.CustomResourceStateMetrics) int {
+	if instance.Spec.RevisionHistoryLimit == nil {
+		return defaultRevisionHistoryLimit
+	}
+
+	return int(*instance.Spec.RevisionHistoryLimit)
+}
+
+// recordRevision records the written resources as the latest revision in the
+// status (persisted with the next status update). The resources identical to
+// an older revision move that revision to the end so its number stays valid
+// for the rollback. Only the last revisions up to the limit (and up to the
+// total size of their resources) are kept.
+func recordRevision(instance *ksmv1.CustomResourceStateMetrics, block string, now metav1.Time) {
+	revisions := instance.Status.Revisions
+	hash := utils.Hash(block)
+
+	index := slices.IndexFunc(revisions, func(revision ksmv1.CustomResourceStateMetricsRevision) bool {
+		return revision.Hash == hash
+	})
+
+	switch {
+	case index >= 0 && index == len(revisions)-1:
+		// The latest revision was written again
+	case index >= 0:
+		revision := revisions[index]
+		revisions = append(slices.Delete(revisions, index, index+1), revision)
+	default:
+		number := int64(1)
+		for _, revision := range revisions {
+			number = max(number, revision.Revision+1)
+		}
+
+		revisions = append(revisions, ksmv1.CustomResourceStateMetricsRevision{
+			Revision:  number,
+			Hash:      hash,
+			Time:      now,
+			Resources: block,
+		})
+	}
+
+	if limit := revisionHistoryLimit(instance); len(revisions) > limit {
+		revisions = revisions[len(revisions)-limit:]
+	}
+
+	// Drop the oldest revisions exceeding the total size
+	size := 0
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if size += len(revisions[i].Resources); size > maxRevisionsSize {
+			revisions = revisions[i+1:]
+
+			break
+		}
+	}
+
+	if len(revisions) == 0 {
+		revisions = nil
+	}
+
+	instance.Status.Revisions = revisions
+}
+
+// rollbackResources returns the resources of the revision requested by the
+// rollback annotation. It returns false if no rollback is requested.
+func rollbackResources(instance *ksmv1.CustomResourceStateMetrics) (string, bool, error) {
+	value := instance.Annotations[RollbackToAnnotation]
+	if value == "" {
+		return "", false, nil
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid value of the %s annotation %q: %w", RollbackToAnnotation, value, err)
+	}
+
+	for _, revision := range instance.Status.Revisions {
+		if revision.Revision == number {
+			return revision.Resources, true, nil
+		}
+	}
+
+	return "", false, fmt.Errorf(
+		"revision %d requested by the %s annotation is not recorded", number, RollbackToAnnotation)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestRecordRevision(t *testing.T) {
+	g := NewWithT(t)
+
+	instance := &ksmv1.CustomResourceStateMetrics{}
+	now := metav1.Now()
+
+	numbers := func() []int64 {
+		result := []int64{}
+
+		for _, revision := range instance.Status.Revisions {
+			result = append(result, revision.Revision)
+		}
+
+		return result
+	}
+
+	recordRevision(instance, "a", now)
+	recordRevision(instance, "b", now)
+	recordRevision(instance, "b", now)
+
+	g.Expect(numbers()).To(Equal([]int64{1, 2}), "Test [same]:")
+
+	recordRevision(instance, "a", now)
+
+	g.Expect(numbers()).To(Equal([]int64{2, 1}), "Test [older]:")
+	g.Expect(instance.Status.Revisions[1].Resources).To(Equal("a"), "Test [older]:")
+
+	recordRevision(instance, "c", now)
+	recordRevision(instance, "d", now)
+
+	g.Expect(numbers()).To(Equal([]int64{1, 3, 4}), "Test [limit]:")
+
+	large := strings.Repeat("x", maxRevisionsSize/2)
+	recordRevision(instance, large+"e", now)
+	recordRevision(instance, large+"f", now)
+
+	g.Expect(numbers()).To(Equal([]int64{6}), "Test [size]:")
+
+	limit := int32(0)
+	instance.Spec.RevisionHistoryLimit = &limit
+	recordRevision(instance, "e", now)
+
+	g.Expect(instance.Status.Revisions).To(BeNil(), "Test [disabled]:")
+}
+
+func TestRollbackResources(t *testing.T) {
+	g := NewWithT(t)
+
+	instance := &ksmv1.CustomResourceStateMetrics{
+		Status: ksmv1.CustomResourceStateMetricsStatus{
+			Revisions: []ksmv1.CustomResourceStateMetricsRevision{
+				{Revision: 1, Resources: "a"},
+				{Revision: 2, Resources: "b"},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		annotation string
+		expected   string
+		rolledBack bool
+		fails      bool
+	}{
+		"none":      {annotation: "", expected: "", rolledBack: false},
+		"recorded":  {annotation: "1", expected: "a", rolledBack: true},
+		"missing":   {annotation: "3", fails: true},
+		"malformed": {annotation: "latest", fails: true},
+	}
+
+	for name, test := range tests {
+		instance.Annotations = map[string]string{RollbackToAnnotation: test.annotation}
+
+		resources, rolledBack, err := rollbackResources(instance)
+
+		if test.fails {
+			g.Expect(err).To(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(resources).To(Equal(test.expected), "Test [%s]:", name)
+		g.Expect(rolledBack).To(Equal(test.rolledBack), "Test [%s]:", name)
+	}
+}
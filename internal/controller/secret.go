@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+// targetKind returns the kind of the object the resources of the instance are
+// written into, preferring the object they were written into before.
+func targetKind(instance *ksmv1.CustomResourceStateMetrics) ksmv1.TargetKind {
+	if target := instance.Status.ConfigMap; target != nil && target.Name != "" {
+		if target.Kind == ksmv1.TargetKindSecret {
+			return ksmv1.TargetKindSecret
+		}
+
+		return ksmv1.TargetKindConfigMap
+	}
+
+	return specTargetKind(instance)
+}
+
+// targetSecret returns the Secret the resources of the instance should be
+// written into according to its specification or nil if there is none.
+func targetSecret(instance *ksmv1.CustomResourceStateMetrics) *ksmv1.CustomResourceStateMetricsSecret {
+	if instance.Spec.Target == nil {
+		return nil
+	}
+
+	return instance.Spec.Target.Secret
+}
+
+// specTargetKind returns the kind of the object the resources of the instance
+// should be written into according to its specification.
+func specTargetKind(instance *ksmv1.CustomResourceStateMetrics) ksmv1.TargetKind {
+	if targetSecret(instance) != nil {
+		return ksmv1.TargetKindSecret
+	}
+
+	return ksmv1.TargetKindConfigMap
+}
+
+// configMapFromSecret converts the Secret into a ConfigMap so its content can
+// be processed the same way as the content of the ConfigMap.
+func configMapFromSecret(secret *corev1.Secret) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            secret.Name,
+			Namespace:       secret.Namespace,
+			ResourceVersion: secret.ResourceVersion,
+			Annotations:     secret.Annotations,
+		},
+		Data: make(map[string]string, len(secret.Data)),
+	}
+
+	for key, value := range secret.Data {
+		cm.Data[key] = string(value)
+	}
+
+	return cm
+}
+
+// secretApplyConfiguration returns the apply configuration of the Secret
+// holding only the fields of the ConfigMap managed by the operator. The data
+// field is applied instead of the stringData field as the keys of the
+// stringData field are not removed from the data field once they are omitted
+// from the apply.
+func secretApplyConfiguration(cm *corev1.ConfigMap) *corev1ac.SecretApplyConfiguration {
+	content := make(map[string]string)
+
+	for _, key := range managedKeys(cm) {
+		content[key] = cm.Data[key]
+	}
+
+	data := make(map[string][]byte)
+
+	for key, value := range encodeJSONData(cm, content) {
+		data[key] = []byte(value)
+	}
+
+	labels, annotations := appliedMetadata(cm)
+
+	ac := corev1ac.Secret(cm.Name, cm.Namespace).
+		WithLabels(labels).
+		WithAnnotations(annotations).
+		WithData(data)
+
+	// Fail if the Secret was modified since it was read
+	if cm.ResourceVersion != "" {
+		ac.WithResourceVersion(cm.ResourceVersion)
+	}
+
+	return ac
+}
+
+// applySecret applies the managed fields of the ConfigMap into the Secret of
+// the same name with Server-Side Apply.
+func applySecret(ctx context.Context, c client.Client, cm *corev1.ConfigMap, opts ...client.ApplyOption) error {
+	opts = append([]client.ApplyOption{client.FieldOwner(FieldManager)}, opts...)
+
+	return c.Apply(ctx, secretApplyConfiguration(cm), opts...)
+}
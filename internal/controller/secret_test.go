@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestSecretApplyConfiguration(t *testing.T) {
+	g := NewWithT(t)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "foo",
+			Namespace:       "bar",
+			ResourceVersion: "42",
+		},
+		Data: map[string][]byte{
+			"config.yaml": []byte("block\n"),
+			"other.yaml":  []byte("other\n"),
+		},
+	}
+
+	cm := configMapFromSecret(secret)
+
+	g.Expect(cm.Data).To(Equal(map[string]string{"config.yaml": "block\n", "other.yaml": "other\n"}), "Test [data]:")
+
+	setBlockHashes(cm, "config.yaml", "foo@bar", "block\n")
+
+	ac := secretApplyConfiguration(cm)
+
+	g.Expect(*ac.Name).To(Equal("foo"), "Test [name]:")
+	g.Expect(*ac.Namespace).To(Equal("bar"), "Test [namespace]:")
+	g.Expect(*ac.ResourceVersion).To(Equal("42"), "Test [resourceVersion]:")
+	g.Expect(ac.Data).To(Equal(map[string][]byte{"config.yaml": []byte("block\n")}), "Test [managed-data]:")
+	g.Expect(ac.StringData).To(BeEmpty(), "Test [string-data]:")
+	g.Expect(ac.Annotations).To(HaveKey(BlockHashesAnnotation), "Test [annotations]:")
+}
+
+func TestTargetKind(t *testing.T) {
+	g := NewWithT(t)
+
+	secret := &ksmv1.CustomResourceStateMetricsSecret{Name: "foo"}
+
+	tests := map[string]struct {
+		secret   *ksmv1.CustomResourceStateMetricsSecret
+		target   *ksmv1.CustomResourceStateMetricsTarget
+		expected ksmv1.TargetKind
+	}{
+		"configmap": {
+			expected: ksmv1.TargetKindConfigMap,
+		},
+		"secret": {
+			secret:   secret,
+			expected: ksmv1.TargetKindSecret,
+		},
+		"written-secret": {
+			target:   &ksmv1.CustomResourceStateMetricsTarget{Kind: ksmv1.TargetKindSecret, Name: "foo"},
+			expected: ksmv1.TargetKindSecret,
+		},
+		"written-configmap": {
+			secret:   secret,
+			target:   &ksmv1.CustomResourceStateMetricsTarget{Name: "foo"},
+			expected: ksmv1.TargetKindConfigMap,
+		},
+	}
+
+	for name, test := range tests {
+		instance := &ksmv1.CustomResourceStateMetrics{
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				Target: &ksmv1.CustomResourceStateMetricsSpecTarget{Secret: test.secret},
+			},
+			Status: ksmv1.CustomResourceStateMetricsStatus{ConfigMap: test.target},
+		}
+
+		g.Expect(targetKind(instance)).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Maximum size of the data of the ConfigMap (or of the Secret) accepted by
+// the API server.
+const MaxConfigMapSize = 1024 * 1024
+
+// errConfigMapTooLarge is returned if the write was refused because the
+// ConfigMap would exceed the size limit.
+var errConfigMapTooLarge = errors.New("the ConfigMap would exceed the size limit")
+
+// projectedSize returns the size of the data of the ConfigMap once its
+// managed fields are applied.
+func projectedSize(cm *corev1.ConfigMap) int {
+	data, binaryData := splitData(cm, cm.Data)
+	size := 0
+
+	for key, value := range data {
+		size += len(key) + len(value)
+	}
+
+	for key, value := range binaryData {
+		size += len(key) + len(value)
+	}
+
+	// Binary data not managed by the operator
+	for key, value := range cm.BinaryData {
+		if _, ok := binaryData[key]; !ok {
+			size += len(key) + len(value)
+		}
+	}
+
+	return size
+}
+
+// checkSize refuses the write of the ConfigMap which would exceed the size
+// limit and records the projected size. The Degraded status condition of the
+// instance is updated accordingly (persisted with the next status update).
+func (r *CustomResourceStateMetricsReconciler) checkSize(
+	instance *ksmv1.CustomResourceStateMetrics, cm *corev1.ConfigMap) error {
+	size := projectedSize(cm)
+
+	if r.MetricsRecorder != nil {
+		r.MetricsRecorder.SetConfigMapSize(cm.Name, cm.Namespace, size)
+	}
+
+	if size > MaxConfigMapSize {
+		message := fmt.Sprintf("The projected size of the ConfigMap %s (%d bytes) exceeds the limit of %d bytes.",
+			utils.NamespacedName(cm.Name, cm.Namespace), size, MaxConfigMapSize)
+
+		log.Info(
+			"Refusing to write the ConfigMap exceeding the size limit",
+			"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+			"configMap", utils.NamespacedName(cm.Name, cm.Namespace),
+			"size", size)
+
+		// Record the event
+		r.Recorder.Event(instance, corev1.EventTypeWarning, reasonTooLarge, message)
+
+		// Update the status condition
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeDegraded,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonTooLarge,
+			Message:            message,
+		})
+
+		return withReason(resultTooLarge, fmt.Errorf("%w: projected size of %d bytes is over %d bytes",
+			errConfigMapTooLarge, size, MaxConfigMapSize))
+	}
+
+	// Clear the condition set by the previously refused write
+	if condition := meta.FindStatusCondition(instance.Status.Conditions, conditionTypeDegraded); condition != nil &&
+		condition.Status == metav1.ConditionTrue && condition.Reason == reasonTooLarge {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               conditionTypeDegraded,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             reasonWithinLimit,
+			Message:            "The projected size of the ConfigMap is within the limit.",
+		})
+	}
+
+	return nil
+}
+
+// keySizes returns the size of each managed key of the ConfigMap as stored
+// (compressed if requested).
+func keySizes(cm *corev1.ConfigMap) map[string]int {
+	content := make(map[string]string)
+
+	for _, key := range managedKeys(cm) {
+		content[key] = cm.Data[key]
+	}
+
+	data, binaryData := splitData(cm, content)
+	compressedKeys := getCompressedKeys(cm)
+	sizes := make(map[string]int, len(content))
+
+	for key := range content {
+		if binaryKey, ok := binaryDataKey(compressedKeys, key); ok {
+			sizes[key] = len(binaryData[binaryKey])
+		} else {
+			sizes[key] = len(data[key])
+		}
+	}
+
+	return sizes
+}
+
+// blockCount returns the number of the instance blocks recorded in the
+// ConfigMap.
+func blockCount(cm *corev1.ConfigMap) int {
+	count := 0
+
+	for key := range getBlockHashes(cm) {
+		if strings.Contains(key, "/") {
+			count++
+		}
+	}
+
+	return count
+}
+
+// recordConfigMapMetrics records the size of each managed key and the number
+// of the instance blocks of the written ConfigMap.
+func (r *CustomResourceStateMetricsReconciler) recordConfigMapMetrics(cm *corev1.ConfigMap) {
+	if r.MetricsRecorder == nil {
+		return
+	}
+
+	for key, size := range keySizes(cm) {
+		r.MetricsRecorder.SetConfigMapKeySize(cm.Name, cm.Namespace, key, size)
+	}
+
+	r.MetricsRecorder.SetConfigMapBlocks(cm.Name, cm.Namespace, blockCount(cm))
+}
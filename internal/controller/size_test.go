@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+)
+
+func TestProjectedSize(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{
+		Data: map[string]string{
+			"config.yaml": "block\n",
+			"other.yaml":  "other\n",
+		},
+		BinaryData: map[string][]byte{
+			"other.bin": []byte("bin"),
+		},
+	}
+
+	g.Expect(projectedSize(cm)).To(Equal(11+6+10+6+9+3), "Test [plain]:")
+
+	// The compressed content is counted instead of the plain content
+	setBlockHashes(cm, "config.yaml", "foo@bar", "block\n")
+	setCompressedKey(cm, "config.yaml", ".gz")
+
+	g.Expect(projectedSize(cm)).To(Equal(14+len(compress("block\n"))+10+6+9+3), "Test [compressed]:")
+}
+
+func TestCheckSize(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &CustomResourceStateMetricsReconciler{Recorder: record.NewFakeRecorder(10)}
+	instance := &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ksm", Namespace: "monitoring"},
+		Data: map[string]string{
+			"config.yaml": strings.Repeat("x", MaxConfigMapSize),
+		},
+	}
+
+	err := r.checkSize(instance, cm)
+	g.Expect(errors.Is(err, errConfigMapTooLarge)).To(BeTrue(), "Test [too-large]:")
+	g.Expect(resultReason(err)).To(Equal(resultTooLarge), "Test [too-large]:")
+	g.Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeDegraded)).To(BeTrue(),
+		"Test [too-large]:")
+
+	cm.Data["config.yaml"] = "block\n"
+
+	g.Expect(r.checkSize(instance, cm)).To(Succeed(), "Test [within-limit]:")
+	g.Expect(meta.IsStatusConditionFalse(instance.Status.Conditions, conditionTypeDegraded)).To(BeTrue(),
+		"Test [within-limit]:")
+}
+
+func TestKeySizes(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{
+		Data: map[string]string{
+			"config.yaml": "block\n",
+			"other.yaml":  "other\n",
+		},
+	}
+
+	// Only the managed keys are measured
+	setBlockHashes(cm, "config.yaml", "foo@bar", "block\n")
+
+	g.Expect(keySizes(cm)).To(Equal(map[string]int{"config.yaml": 6}), "Test [plain]:")
+
+	setCompressedKey(cm, "config.yaml", ".gz")
+
+	g.Expect(keySizes(cm)).To(Equal(map[string]int{"config.yaml": len(compress("block\n"))}), "Test [compressed]:")
+}
+
+func TestBlockCount(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{Data: map[string]string{"config.yaml": "", "other.yaml": ""}}
+
+	g.Expect(blockCount(cm)).To(Equal(0), "Test [empty]:")
+
+	setBlockHashes(cm, "config.yaml", "foo@ns", "foo\n")
+	setBlockHashes(cm, "config.yaml", "bar@ns", "bar\n")
+	setBlockHashes(cm, "other.yaml", "foo@ns", "foo\n")
+
+	g.Expect(blockCount(cm)).To(Equal(3), "Test [blocks]:")
+
+	setBlockHashes(cm, "config.yaml", "bar@ns", "")
+
+	g.Expect(blockCount(cm)).To(Equal(2), "Test [removed]:")
+}
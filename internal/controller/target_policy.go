@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// TargetPolicy restricts the ConfigMaps (and the Secrets) the instances may
+// write into so the tenants can't target arbitrary objects (e.g. in the
+// kube-system Namespace).
+type TargetPolicy struct {
+	patterns []string
+}
+
+// NewTargetPolicy parses the comma-separated list of the allowed targets in
+// the form namespace/name where both parts can contain the shell patterns
+// (e.g. monitoring/*,team-*/ksm-config). Empty list allows all targets.
+func NewTargetPolicy(allowed string) (*TargetPolicy, error) {
+	policy := &TargetPolicy{}
+
+	for _, pattern := range strings.Split(allowed, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		if strings.Count(pattern, "/") != 1 {
+			return nil, fmt.Errorf("target pattern %q must be in the form namespace/name", pattern)
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid target pattern %q: %w", pattern, err)
+		}
+
+		policy.patterns = append(policy.patterns, pattern)
+	}
+
+	return policy, nil
+}
+
+// Allowed returns true if the instances may write into the target.
+func (p *TargetPolicy) Allowed(name, namespace string) bool {
+	if p == nil || len(p.patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range p.patterns {
+		if matched, _ := path.Match(pattern, namespace+"/"+name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkSecretTargets returns an error if any of the Secret targets outside of
+// the Namespace of the instance is not allowed explicitly by the policy. The
+// Secrets in the other Namespaces are denied if no policy is set so the
+// tenants can't write arbitrary Secrets.
+func (p *TargetPolicy) checkSecretTargets(instanceNamespace, name string, namespaces []string) error {
+	for _, namespace := range namespaces {
+		if namespace != instanceNamespace && (p == nil || len(p.patterns) == 0 || !p.Allowed(name, namespace)) {
+			return withReason(resultTargetNotAllowed, fmt.Errorf(
+				"the Secret %s outside of the Namespace %s is not allowed by the operator policy",
+				utils.NamespacedName(name, namespace), instanceNamespace))
+		}
+	}
+
+	return nil
+}
+
+// checkTargets returns an error if any of the targets is not allowed by the
+// policy.
+func (p *TargetPolicy) checkTargets(name string, namespaces []string) error {
+	for _, namespace := range namespaces {
+		if !p.Allowed(name, namespace) {
+			return withReason(resultTargetNotAllowed, fmt.Errorf(
+				"the target %s is not allowed by the operator policy", utils.NamespacedName(name, namespace)))
+		}
+	}
+
+	return nil
+}
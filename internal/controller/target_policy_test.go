@@ -0,0 +1,75 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTargetPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		allowed   string
+		name      string
+		namespace string
+		expected  bool
+	}{
+		"no_policy":         {name: "ksm", namespace: "kube-system", expected: true},
+		"exact_match":       {allowed: "monitoring/ksm", name: "ksm", namespace: "monitoring", expected: true},
+		"name_pattern":      {allowed: "monitoring/*", name: "ksm", namespace: "monitoring", expected: true},
+		"namespace_pattern": {allowed: "kube-system/ksm, team-*/ksm", name: "ksm", namespace: "team-a", expected: true},
+		"not_allowed":       {allowed: "monitoring/*", name: "ksm", namespace: "kube-system"},
+	}
+
+	for name, test := range tests {
+		policy, err := NewTargetPolicy(test.allowed)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(policy.Allowed(test.name, test.namespace)).To(Equal(test.expected), "Test [%s]:", name)
+	}
+
+	// Nil policy allows all targets
+	var policy *TargetPolicy
+	g.Expect(policy.checkTargets("ksm", []string{"kube-system"})).To(Succeed(), "Test [nil_policy]:")
+
+	// Invalid patterns
+	for _, allowed := range []string{"ksm", "monitoring/ksm/key", "monitoring/[ksm"} {
+		_, err := NewTargetPolicy(allowed)
+		g.Expect(err).To(HaveOccurred(), "Test [invalid %s]:", allowed)
+	}
+
+	// Any of the replicated targets not allowed
+	policy, _ = NewTargetPolicy("team-a/ksm")
+	err := policy.checkTargets("ksm", []string{"team-a", "team-b"})
+	g.Expect(resultReason(err)).To(Equal(resultTargetNotAllowed), "Test [replicated]:")
+
+	// The Secrets outside of the instance Namespace must be allowed explicitly
+	policy = nil
+	g.Expect(policy.checkSecretTargets("team-a", "ksm", []string{"team-a"})).To(Succeed(), "Test [secret_same]:")
+
+	err = policy.checkSecretTargets("team-a", "ksm", []string{"kube-system"})
+	g.Expect(resultReason(err)).To(Equal(resultTargetNotAllowed), "Test [secret_no_policy]:")
+
+	policy, _ = NewTargetPolicy("monitoring/ksm")
+	g.Expect(policy.checkSecretTargets("team-a", "ksm", []string{"monitoring"})).To(Succeed(),
+		"Test [secret_allowed]:")
+
+	err = policy.checkSecretTargets("team-a", "ksm", []string{"kube-system"})
+	g.Expect(resultReason(err)).To(Equal(resultTargetNotAllowed), "Test [secret_not_allowed]:")
+}
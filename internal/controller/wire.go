@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/discovery"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// reloadDeployment returns the kube-state-metrics Deployment referenced by the
+// instance. The Deployment must be in the Namespace of the ConfigMap (the
+// default) or of the instance so the tenants can't restart the Deployments in
+// the other Namespaces.
+func reloadDeployment(
+	instance *ksmv1.CustomResourceStateMetrics, cmNamespace string) (types.NamespacedName, error) {
+	ref := instance.Spec.Reload.DeploymentRef
+	deployment := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+
+	if deployment.Namespace == "" {
+		deployment.Namespace = cmNamespace
+	}
+
+	if deployment.Namespace != cmNamespace && deployment.Namespace != instance.Namespace {
+		return types.NamespacedName{}, withReason(resultTargetNotAllowed, fmt.Errorf(
+			"the Deployment %s must be in the Namespace of the ConfigMap or of the instance",
+			utils.NamespacedName(deployment.Name, deployment.Namespace)))
+	}
+
+	return deployment, nil
+}
+
+// wireDeployment ensures the kube-state-metrics Deployment referenced by the
+// instance reads the key of the ConfigMap if it's requested.
+func (r *CustomResourceStateMetricsReconciler) wireDeployment(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, cmName, cmNamespace, cmKey string) error {
+	reload := instance.Spec.Reload
+	if reload == nil || !reload.ManageConfigFile || targetKind(instance) == ksmv1.TargetKindSecret {
+		return nil
+	}
+
+	deploymentName, err := reloadDeployment(instance, cmNamespace)
+	if err != nil {
+		return err
+	}
+
+	deployment := &appsv1.Deployment{}
+
+	if err := r.Get(ctx, deploymentName, deployment); err != nil {
+		return fmt.Errorf("failed to get the referenced Deployment: %w", err)
+	}
+
+	patch := client.MergeFrom(deployment.DeepCopy())
+
+	if !discovery.WireConfigMap(deployment, cmName, cmKey) {
+		return nil
+	}
+
+	log.Info(
+		"Wiring the ConfigMap into the Deployment",
+		"instance", utils.NamespacedName(instance.Name, instance.Namespace),
+		"deployment", utils.NamespacedName(deployment.Name, deployment.Namespace),
+		"configMap", utils.NamespacedName(cmName, cmNamespace),
+		"key", cmKey,
+		"dryRun", r.DryRun)
+
+	if r.DryRun {
+		return nil
+	}
+
+	if err := r.Patch(ctx, deployment, patch); err != nil {
+		return fmt.Errorf("failed to patch the referenced Deployment: %w", err)
+	}
+
+	return nil
+}
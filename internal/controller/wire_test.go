@@ -0,0 +1,127 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/rollout"
+)
+
+func TestReloadDeployment(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		namespace string
+		expected  string
+		fails     bool
+	}{
+		"default":             {namespace: "", expected: "monitoring"},
+		"configmap_namespace": {namespace: "monitoring", expected: "monitoring"},
+		"instance_namespace":  {namespace: "bar", expected: "bar"},
+		"other_namespace":     {namespace: "kube-system", fails: true},
+	}
+
+	for name, test := range tests {
+		instance := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				Reload: &ksmv1.CustomResourceStateMetricsReload{
+					DeploymentRef: ksmv1.CustomResourceStateMetricsDeploymentRef{Name: "ksm", Namespace: test.namespace},
+				},
+			},
+		}
+
+		deployment, err := reloadDeployment(instance, "monitoring")
+
+		if test.fails {
+			g.Expect(resultReason(err)).To(Equal(resultTargetNotAllowed), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(deployment).To(Equal(types.NamespacedName{Name: "ksm", Namespace: test.expected}), "Test [%s]:", name)
+	}
+}
+
+func TestRolloutReferencedDeployment(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	newDeployment := func(namespace string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "ksm", Namespace: namespace}}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(newDeployment("monitoring"), newDeployment("kube-system")).
+		Build()
+	r := &CustomResourceStateMetricsReconciler{
+		Client:    c,
+		Recorder:  record.NewFakeRecorder(10),
+		Restarter: rollout.NewRestarter(c, time.Minute),
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ksm", Namespace: "monitoring"},
+		Data:       map[string]string{"config.yaml": "content\n"},
+	}
+
+	checksum := func(namespace string) string {
+		deployment := &appsv1.Deployment{}
+		g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(newDeployment(namespace)), deployment)).
+			To(Succeed())
+
+		return deployment.Spec.Template.Annotations[rollout.ChecksumAnnotation]
+	}
+
+	newInstance := func(namespace string) *ksmv1.CustomResourceStateMetrics {
+		return &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				Reload: &ksmv1.CustomResourceStateMetricsReload{
+					DeploymentRef: ksmv1.CustomResourceStateMetricsDeploymentRef{Name: "ksm", Namespace: namespace},
+				},
+			},
+		}
+	}
+
+	// The Deployment in the Namespace of the ConfigMap is restarted
+	r.rollout(context.Background(), newInstance(""), cm, "config.yaml")
+	g.Expect(checksum("monitoring")).NotTo(BeEmpty(), "Test [configmap-namespace]:")
+
+	// The Deployment in another Namespace is not restarted
+	r.rollout(context.Background(), newInstance("kube-system"), cm, "config.yaml")
+	g.Expect(checksum("kube-system")).To(BeEmpty(), "Test [other-namespace]:")
+	g.Expect(r.Recorder.(*record.FakeRecorder).Events).To(HaveLen(1), "Test [other-namespace]:")
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Default interval in which the flush of the buffered writes is attempted.
+const DefaultFlushInterval = 10 * time.Second
+
+// errWriteBuffered is returned if the write of the ConfigMap was buffered
+// because the API server is unreachable.
+var errWriteBuffered = errors.New("the ConfigMap write was buffered until the API server is reachable")
+
+// errWriteBatched is returned if the write of the ConfigMap was batched with
+// the writes of the other instances targeting the same ConfigMap.
+var errWriteBatched = errors.New("the ConfigMap write was batched with the writes of other instances")
+
+// WriteBuffer keeps the desired state of the target ConfigMaps in memory while
+// the API server is unreachable and flushes it once the connectivity returns.
+// Writes older than MaxAge are dropped and left to the next reconciliation.
+// If BatchWindow is set, the writes are delayed by the window so the changes
+// of all the instances targeting the same ConfigMap (e.g. applied at once by
+// a GitOps sync) are merged into the buffered content and written only once.
+type WriteBuffer struct {
+	Client      client.Client
+	MaxAge      time.Duration
+	Interval    time.Duration
+	BatchWindow time.Duration
+
+	mu       sync.Mutex
+	entries  map[types.NamespacedName]bufferedWrite
+	awaiting map[types.UID]awaitedWrite
+}
+
+// bufferedWrite holds the desired state of a ConfigMap.
+type bufferedWrite struct {
+	cm      *corev1.ConfigMap
+	created time.Time
+	batched bool
+}
+
+// awaitedWrite holds the addition of an instance whose effects (the rollout
+// of kube-state-metrics and the status update) wait for the flush of its
+// buffered write.
+type awaitedWrite struct {
+	key      types.NamespacedName
+	block    string
+	change   string
+	replaced bool
+}
+
+// NewWriteBuffer creates a new empty WriteBuffer.
+func NewWriteBuffer(c client.Client, maxAge, interval time.Duration) *WriteBuffer {
+	return &WriteBuffer{
+		Client:   c,
+		MaxAge:   maxAge,
+		Interval: interval,
+		entries:  make(map[types.NamespacedName]bufferedWrite),
+		awaiting: make(map[types.UID]awaitedWrite),
+	}
+}
+
+// NeedLeaderElection makes the buffer flush only on the leader.
+func (b *WriteBuffer) NeedLeaderElection() bool {
+	return true
+}
+
+// Start runs the flush loop until the context is canceled.
+func (b *WriteBuffer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			b.flush(ctx)
+		}
+	}
+}
+
+// get returns a copy of the buffered ConfigMap unless it's too old.
+func (b *WriteBuffer) get(key types.NamespacedName) (*corev1.ConfigMap, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.entries[key]
+	if !ok || !entry.batched && time.Since(entry.created) > b.MaxAge {
+		return nil, false
+	}
+
+	return entry.cm.DeepCopy(), true
+}
+
+// add buffers the desired state of the ConfigMap.
+func (b *WriteBuffer) add(cm *corev1.ConfigMap) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[client.ObjectKeyFromObject(cm)] = bufferedWrite{
+		cm:      cm.DeepCopy(),
+		created: time.Now(),
+	}
+}
+
+// batch buffers the desired state of the ConfigMap until the batch window
+// counted from the first batched change elapses.
+func (b *WriteBuffer) batch(cm *corev1.ConfigMap) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := client.ObjectKeyFromObject(cm)
+	created := time.Now()
+
+	if entry, ok := b.entries[key]; ok && entry.batched {
+		created = entry.created
+	}
+
+	b.entries[key] = bufferedWrite{
+		cm:      cm.DeepCopy(),
+		created: created,
+		batched: true,
+	}
+}
+
+// batching returns true if the writes are batched.
+func (b *WriteBuffer) batching() bool {
+	return b.BatchWindow > 0
+}
+
+// remove drops the buffered ConfigMap.
+func (b *WriteBuffer) remove(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.entries, key)
+}
+
+// queued returns whether a write of the ConfigMap is still buffered and
+// whether it's batched.
+func (b *WriteBuffer) queued(key types.NamespacedName) (bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.entries[key]
+
+	return ok, entry.batched
+}
+
+// await records the addition of the instance waiting for the flush of its
+// buffered write.
+func (b *WriteBuffer) await(uid types.UID, write awaitedWrite) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.awaiting[uid] = write
+}
+
+// awaited returns the addition of the instance waiting for the flush of its
+// buffered write.
+func (b *WriteBuffer) awaited(uid types.UID) (awaitedWrite, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	write, ok := b.awaiting[uid]
+
+	return write, ok
+}
+
+// forget drops the addition of the instance waiting for the flush.
+func (b *WriteBuffer) forget(uid types.UID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.awaiting, uid)
+}
+
+// pending returns the buffered writes ordered from the oldest.
+func (b *WriteBuffer) pending() []bufferedWrite {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	writes := make([]bufferedWrite, 0, len(b.entries))
+	for _, entry := range b.entries {
+		writes = append(writes, entry)
+	}
+
+	sort.Slice(writes, func(i, j int) bool {
+		return writes[i].created.Before(writes[j].created)
+	})
+
+	return writes
+}
+
+// done drops the buffered write unless it was replaced in the meantime.
+func (b *WriteBuffer) done(write bufferedWrite) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := client.ObjectKeyFromObject(write.cm)
+
+	if entry, ok := b.entries[key]; ok && entry.created.Equal(write.created) && entry.cm == write.cm {
+		delete(b.entries, key)
+	}
+}
+
+// flush writes the buffered ConfigMaps one by one and stops at the first
+// write failing because the API server is still unreachable.
+func (b *WriteBuffer) flush(ctx context.Context) {
+	for _, write := range b.pending() {
+		cmNamespacedName := utils.NamespacedName(write.cm.Name, write.cm.Namespace)
+
+		// Wait for the other changes of the batch
+		if write.batched && time.Since(write.created) < b.BatchWindow {
+			continue
+		}
+
+		// Batched writes don't expire, they are only delayed by the window
+		if !write.batched && time.Since(write.created) > b.MaxAge {
+			log.Info("Dropping stale buffered write", "configMap", cmNamespacedName)
+
+			b.done(write)
+
+			continue
+		}
+
+		err := applyConfigMap(ctx, b.Client, write.cm.DeepCopy())
+		if isUnavailable(err) {
+			log.V(1).Info("API server is still unreachable", "configMap", cmNamespacedName)
+
+			return
+		}
+
+		if err != nil {
+			// The next reconciliation computes the desired state again
+			log.Error(err, "Failed to flush buffered write", "configMap", cmNamespacedName)
+		} else {
+			log.Info("Flushed buffered write", "configMap", cmNamespacedName)
+		}
+
+		b.done(write)
+	}
+}
+
+// isUnavailable returns true if the error indicates that the API server is
+// unreachable.
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
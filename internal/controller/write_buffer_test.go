@@ -0,0 +1,301 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/rollout"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+func TestWriteBuffer(t *testing.T) {
+	g := NewWithT(t)
+
+	b := NewWriteBuffer(nil, time.Minute, DefaultFlushInterval)
+	key := types.NamespacedName{Name: "foo", Namespace: "bar"}
+
+	_, found := b.get(key)
+	g.Expect(found).To(BeFalse(), "Test [empty]:")
+
+	b.add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Data:       map[string]string{"config.yaml": "data-1"},
+	})
+
+	cm, found := b.get(key)
+	g.Expect(found).To(BeTrue(), "Test [buffered]:")
+	g.Expect(cm.Data["config.yaml"]).To(Equal("data-1"), "Test [buffered]:")
+
+	// Older write must not drop the newer one
+	old := b.pending()[0]
+	b.add(cm)
+	b.done(old)
+
+	_, found = b.get(key)
+	g.Expect(found).To(BeTrue(), "Test [replaced]:")
+
+	b.remove(key)
+
+	_, found = b.get(key)
+	g.Expect(found).To(BeFalse(), "Test [removed]:")
+
+	b.MaxAge = 0
+	b.add(cm)
+
+	_, found = b.get(key)
+	g.Expect(found).To(BeFalse(), "Test [stale]:")
+}
+
+func TestWriteBufferBatch(t *testing.T) {
+	g := NewWithT(t)
+
+	b := NewWriteBuffer(nil, 0, DefaultFlushInterval)
+	b.BatchWindow = time.Minute
+	key := types.NamespacedName{Name: "foo", Namespace: "bar"}
+
+	b.batch(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Data:       map[string]string{"config.yaml": "block-1"},
+	})
+
+	// Batched writes don't expire with the buffering disabled
+	cm, found := b.get(key)
+	g.Expect(found).To(BeTrue(), "Test [batched]:")
+
+	first := b.pending()[0]
+
+	// The next change of the batch is merged into the buffered content
+	cm.Data["config.yaml"] += "block-2"
+	b.batch(cm)
+
+	cm, _ = b.get(key)
+	g.Expect(cm.Data["config.yaml"]).To(Equal("block-1block-2"), "Test [merged]:")
+	g.Expect(b.pending()[0].created).To(Equal(first.created), "Test [merged]:")
+
+	// The write is not flushed (there is no client) until the window elapses
+	b.flush(context.Background())
+	g.Expect(b.pending()).To(HaveLen(1), "Test [window]:")
+
+	// Older write must not drop the merged one
+	b.done(first)
+	g.Expect(b.pending()).To(HaveLen(1), "Test [replaced]:")
+
+	g.Expect(isBuffered(fmt.Errorf("failed to update ConfigMap: %w", errWriteBatched))).To(BeTrue(),
+		"Test [is_buffered]:")
+}
+
+func TestWriteBufferFlushBatch(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	// The buffering is disabled so only the batching delays the write
+	b := NewWriteBuffer(c, 0, DefaultFlushInterval)
+	b.BatchWindow = time.Millisecond
+	key := types.NamespacedName{Name: "foo", Namespace: "bar"}
+
+	batched := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Data:       map[string]string{"config.yaml": "block-1"},
+	}
+	setBlockHashes(batched, "config.yaml", "foo@bar", "block-1")
+	b.batch(batched)
+
+	time.Sleep(10 * time.Millisecond)
+
+	b.flush(context.Background())
+	g.Expect(b.pending()).To(BeEmpty(), "Test [flushed]:")
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(c.Get(context.Background(), key, cm)).To(Succeed(), "Test [written]:")
+	g.Expect(cm.Data["config.yaml"]).To(Equal("block-1"), "Test [written]:")
+}
+
+func TestReconcileBatchedWrite(t *testing.T) {
+	g := NewWithT(t)
+
+	c := newTestFlushClient(g).Build()
+
+	// The buffering is disabled so only the batching delays the write
+	b := NewWriteBuffer(c, 0, DefaultFlushInterval)
+	b.BatchWindow = time.Millisecond
+
+	testFlushedWrite(g, "batched", c, b, func() {
+		time.Sleep(10 * time.Millisecond)
+	})
+}
+
+func TestReconcileBufferedWrite(t *testing.T) {
+	g := NewWithT(t)
+
+	// The API server is unreachable until the flush
+	unavailable := true
+
+	c := newTestFlushClient(g).WithInterceptorFuncs(interceptor.Funcs{
+		Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration,
+			opts ...client.ApplyOption) error {
+			if unavailable {
+				return apierrors.NewServiceUnavailable("foo")
+			}
+
+			return c.Apply(ctx, obj, opts...)
+		},
+	}).Build()
+
+	b := NewWriteBuffer(c, time.Minute, DefaultFlushInterval)
+
+	testFlushedWrite(g, "buffered", c, b, func() {
+		unavailable = false
+	})
+}
+
+// newTestFlushClient returns the builder of the fake client with the
+// kube-state-metrics Deployment ksm.
+func newTestFlushClient(g *WithT) *fake.ClientBuilder {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	return fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&ksmv1.CustomResourceStateMetrics{}).
+		WithObjects(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "ksm", Namespace: "default"}})
+}
+
+// testFlushedWrite checks that the addition buffered by the first reconcile
+// restarts kube-state-metrics and updates the status of the instance once the
+// write is flushed.
+func testFlushedWrite(g *WithT, name string, c client.Client, b *WriteBuffer, beforeFlush func()) {
+	ctx := context.Background()
+	r := &CustomResourceStateMetricsReconciler{
+		Client:      c,
+		Scheme:      c.Scheme(),
+		Recorder:    record.NewFakeRecorder(100),
+		Restarter:   rollout.NewRestarter(c, time.Minute),
+		WriteBuffer: b,
+	}
+
+	instance := newTestInstance(name, "ksm", "Foo")
+	instance.UID = types.UID(name)
+	instance.Spec.Reload = &ksmv1.CustomResourceStateMetricsReload{
+		DeploymentRef: ksmv1.CustomResourceStateMetricsDeploymentRef{Name: "ksm"},
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(instance)}
+
+	g.Expect(c.Create(ctx, instance)).To(Succeed(), "Test [%s]:", name)
+
+	checksum := func() string {
+		deployment := &appsv1.Deployment{}
+		g.Expect(c.Get(ctx, types.NamespacedName{Name: "ksm", Namespace: "default"}, deployment)).To(Succeed())
+
+		return deployment.Spec.Template.Annotations[rollout.ChecksumAnnotation]
+	}
+
+	// The write is buffered so nothing is restarted yet
+	result, err := r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+	g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Test [%s]:", name)
+	g.Expect(checksum()).To(BeEmpty(), "Test [%s]:", name)
+
+	// The requeue before the flush keeps waiting for the write
+	result, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+	g.Expect(result.RequeueAfter).To(BeNumerically(">", 0), "Test [%s]:", name)
+	g.Expect(checksum()).To(BeEmpty(), "Test [%s]:", name)
+
+	beforeFlush()
+	b.flush(ctx)
+	g.Expect(b.pending()).To(BeEmpty(), "Test [%s]:", name)
+
+	// The requeue after the flush finishes the addition
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(c.Get(ctx, types.NamespacedName{Name: "ksm", Namespace: "default"}, cm)).To(Succeed(),
+		"Test [%s]:", name)
+	g.Expect(checksum()).To(Equal(utils.Hash(cm.Data[r.runtimeConfig().DefaultKey])), "Test [%s]:", name)
+
+	g.Expect(c.Get(ctx, req.NamespacedName, instance)).To(Succeed(), "Test [%s]:", name)
+	g.Expect(instance.Status.BlockHash).NotTo(BeEmpty(), "Test [%s]:", name)
+	g.Expect(instance.Status.LastSyncTime).NotTo(BeNil(), "Test [%s]:", name)
+	g.Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, conditionTypeReady)).To(BeTrue(),
+		"Test [%s]:", name)
+
+	_, awaiting := b.awaited(instance.UID)
+	g.Expect(awaiting).To(BeFalse(), "Test [%s]:", name)
+}
+
+func TestIsUnavailable(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "nil",
+			err:      nil,
+			expected: false,
+		},
+		{
+			name:     "service-unavailable",
+			err:      apierrors.NewServiceUnavailable("foo"),
+			expected: true,
+		},
+		{
+			name:     "connection-refused",
+			err:      fmt.Errorf("failed to update ConfigMap: %w", syscall.ECONNREFUSED),
+			expected: true,
+		},
+		{
+			name:     "conflict",
+			err:      apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "foo", errors.New("foo")),
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		g.Expect(isUnavailable(test.err)).To(Equal(test.expected), "Test [%s]:", test.name)
+	}
+}
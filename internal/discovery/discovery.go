@@ -0,0 +1,248 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Argument of kube-state-metrics pointing to the custom resource state config file.
+const configFileArg = "--custom-resource-state-config-file"
+
+// ErrNotFound is returned when no kube-state-metrics Deployment with a
+// ConfigMap mounted as the custom resource state config file was found.
+var ErrNotFound = errors.New("no kube-state-metrics Deployment with custom resource state ConfigMap found")
+
+// Labels used by the common Helm charts and manifests to identify the
+// kube-state-metrics Deployment.
+var deploymentLabels = []client.MatchingLabels{
+	{"app.kubernetes.io/name": "kube-state-metrics"},
+	{"app": "kube-state-metrics"},
+	{"k8s-app": "kube-state-metrics"},
+}
+
+// Target describes the ConfigMap used by kube-state-metrics as the custom
+// resource state config.
+type Target struct {
+	// Name of the ConfigMap.
+	Name string
+
+	// Namespace of the ConfigMap.
+	Namespace string
+
+	// Key of the ConfigMap containing the config.
+	Key string
+
+	// Name of the kube-state-metrics Deployment mounting the ConfigMap.
+	Deployment string
+}
+
+// FindTarget finds the kube-state-metrics Deployment by its labels and
+// resolves the ConfigMap it mounts as the custom resource state config. If
+// the namespace is empty, all Namespaces are searched.
+func FindTarget(ctx context.Context, c client.Reader, namespace string) (*Target, error) {
+	for _, selector := range deploymentLabels {
+		deployments := &appsv1.DeploymentList{}
+
+		if err := c.List(ctx, deployments, selector, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("failed to list Deployments: %w", err)
+		}
+
+		// Make the result deterministic if there are multiple Deployments
+		sort.Slice(deployments.Items, func(i, j int) bool {
+			a, b := deployments.Items[i], deployments.Items[j]
+
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+
+			return a.Name < b.Name
+		})
+
+		for i := range deployments.Items {
+			if target := TargetFromDeployment(&deployments.Items[i]); target != nil {
+				return target, nil
+			}
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// TargetFromDeployment resolves the ConfigMap mounted as the custom resource
+// state config file in the kube-state-metrics Deployment. It returns nil if
+// the config file argument is missing or it doesn't point into a ConfigMap.
+func TargetFromDeployment(deployment *appsv1.Deployment) *Target {
+	podSpec := deployment.Spec.Template.Spec
+
+	for _, container := range podSpec.Containers {
+		configFile := ConfigFilePath(container)
+
+		if configFile == "" {
+			continue
+		}
+
+		for _, mount := range container.VolumeMounts {
+			volume := findVolume(podSpec.Volumes, mount.Name)
+
+			if volume == nil || volume.ConfigMap == nil {
+				continue
+			}
+
+			var filePath string
+
+			if mount.SubPath != "" {
+				if path.Clean(mount.MountPath) != path.Clean(configFile) {
+					continue
+				}
+
+				filePath = mount.SubPath
+			} else {
+				mountPath := strings.TrimSuffix(mount.MountPath, "/") + "/"
+
+				if !strings.HasPrefix(configFile, mountPath) {
+					continue
+				}
+
+				filePath = strings.TrimPrefix(configFile, mountPath)
+			}
+
+			return &Target{
+				Name:       volume.ConfigMap.Name,
+				Namespace:  deployment.Namespace,
+				Key:        keyFromPath(volume.ConfigMap.Items, filePath),
+				Deployment: deployment.Name,
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeploymentsMountingConfigMap returns all Deployments in the Namespace which
+// mount the ConfigMap as a volume.
+func DeploymentsMountingConfigMap(
+	ctx context.Context, c client.Reader, namespace, name string) ([]appsv1.Deployment, error) {
+	return deploymentsMounting(ctx, c, namespace, name, MountsConfigMap)
+}
+
+// DeploymentsMountingSecret returns all Deployments in the Namespace which
+// mount the Secret as a volume.
+func DeploymentsMountingSecret(
+	ctx context.Context, c client.Reader, namespace, name string) ([]appsv1.Deployment, error) {
+	return deploymentsMounting(ctx, c, namespace, name, MountsSecret)
+}
+
+// deploymentsMounting returns all Deployments in the Namespace which mount the
+// named object according to the mounts function.
+func deploymentsMounting(
+	ctx context.Context, c client.Reader, namespace, name string,
+	mounts func(*appsv1.Deployment, string) bool) ([]appsv1.Deployment, error) {
+	deployments := &appsv1.DeploymentList{}
+
+	if err := c.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list Deployments: %w", err)
+	}
+
+	result := []appsv1.Deployment{}
+
+	for _, deployment := range deployments.Items {
+		if mounts(&deployment, name) {
+			result = append(result, deployment)
+		}
+	}
+
+	return result, nil
+}
+
+// MountsConfigMap checks whether the Deployment mounts the ConfigMap as a
+// volume (directly or as part of a projected volume).
+func MountsConfigMap(deployment *appsv1.Deployment, name string) bool {
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.ConfigMap != nil && volume.ConfigMap.Name == name {
+			return true
+		}
+
+		if volume.Projected == nil {
+			continue
+		}
+
+		for _, source := range volume.Projected.Sources {
+			if source.ConfigMap != nil && source.ConfigMap.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// MountsSecret checks whether the Deployment mounts the Secret as a volume
+// (directly or as part of a projected volume).
+func MountsSecret(deployment *appsv1.Deployment, name string) bool {
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == name {
+			return true
+		}
+
+		if volume.Projected == nil {
+			continue
+		}
+
+		for _, source := range volume.Projected.Sources {
+			if source.Secret != nil && source.Secret.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ConfigFilePath returns the path of the custom resource state config file
+// passed to the container or an empty string if it's not set.
+func ConfigFilePath(container corev1.Container) string {
+	args := append(append([]string{}, container.Command...), container.Args...)
+
+	for i, arg := range args {
+		if value, ok := strings.CutPrefix(arg, configFileArg+"="); ok {
+			return value
+		}
+
+		if arg == configFileArg && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+
+	return ""
+}
+
+// findVolume finds the Volume by its name.
+func findVolume(volumes []corev1.Volume, name string) *corev1.Volume {
+	for i := range volumes {
+		if volumes[i].Name == name {
+			return &volumes[i]
+		}
+	}
+
+	return nil
+}
+
+// keyFromPath translates the file path within the ConfigMap volume into the
+// ConfigMap key.
+func keyFromPath(items []corev1.KeyToPath, filePath string) string {
+	for _, item := range items {
+		if path.Clean(item.Path) == path.Clean(filePath) {
+			return item.Key
+		}
+	}
+
+	return filePath
+}
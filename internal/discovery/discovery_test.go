@@ -0,0 +1,221 @@
+package discovery
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/gomega"
+)
+
+func newDeployment(args []string, mount corev1.VolumeMount, items []corev1.KeyToPath) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-state-metrics",
+			Namespace: "monitoring",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:         "kube-state-metrics",
+							Args:         args,
+							VolumeMounts: []corev1.VolumeMount{mount},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "customresourcestate-config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: "ksm-customresourcestate-config",
+									},
+									Items: items,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestTargetFromDeployment(t *testing.T) {
+	g := NewWithT(t)
+
+	mount := corev1.VolumeMount{
+		Name:      "customresourcestate-config",
+		MountPath: "/etc/customresourcestate",
+	}
+
+	tests := map[string]struct {
+		deployment *appsv1.Deployment
+		expected   *Target
+	}{
+		"helm-chart": {
+			deployment: newDeployment(
+				[]string{"--port=8080", "--custom-resource-state-config-file=/etc/customresourcestate/config.yaml"},
+				mount, nil),
+			expected: &Target{
+				Name:       "ksm-customresourcestate-config",
+				Namespace:  "monitoring",
+				Key:        "config.yaml",
+				Deployment: "kube-state-metrics",
+			},
+		},
+		"separate-arg-with-items": {
+			deployment: newDeployment(
+				[]string{"--custom-resource-state-config-file", "/etc/customresourcestate/crsm.yaml"},
+				mount, []corev1.KeyToPath{{Key: "config.yaml", Path: "crsm.yaml"}}),
+			expected: &Target{
+				Name:       "ksm-customresourcestate-config",
+				Namespace:  "monitoring",
+				Key:        "config.yaml",
+				Deployment: "kube-state-metrics",
+			},
+		},
+		"sub-path": {
+			deployment: newDeployment(
+				[]string{"--custom-resource-state-config-file=/config.yaml"},
+				corev1.VolumeMount{
+					Name:      "customresourcestate-config",
+					MountPath: "/config.yaml",
+					SubPath:   "crsm.yaml",
+				}, nil),
+			expected: &Target{
+				Name:       "ksm-customresourcestate-config",
+				Namespace:  "monitoring",
+				Key:        "crsm.yaml",
+				Deployment: "kube-state-metrics",
+			},
+		},
+		"no-arg": {
+			deployment: newDeployment([]string{"--port=8080"}, mount, nil),
+			expected:   nil,
+		},
+		"different-mount": {
+			deployment: newDeployment(
+				[]string{"--custom-resource-state-config-file=/etc/other/config.yaml"},
+				mount, nil),
+			expected: nil,
+		},
+	}
+
+	for name, test := range tests {
+		result := TargetFromDeployment(test.deployment)
+
+		g.Expect(result).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestMountsConfigMap(t *testing.T) {
+	g := NewWithT(t)
+
+	deployment := newDeployment(nil, corev1.VolumeMount{}, nil)
+	deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "projected",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ConfigMap: &corev1.ConfigMapProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "projected-config"},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	g.Expect(MountsConfigMap(deployment, "ksm-customresourcestate-config")).To(BeTrue())
+	g.Expect(MountsConfigMap(deployment, "projected-config")).To(BeTrue())
+	g.Expect(MountsConfigMap(deployment, "other")).To(BeFalse())
+}
+
+func TestMountsSecret(t *testing.T) {
+	g := NewWithT(t)
+
+	deployment := newDeployment(nil, corev1.VolumeMount{}, nil)
+	deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes,
+		corev1.Volume{
+			Name: "secret",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: "ksm-customresourcestate-secret"},
+			},
+		},
+		corev1.Volume{
+			Name: "projected",
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources: []corev1.VolumeProjection{
+						{
+							Secret: &corev1.SecretProjection{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "projected-secret"},
+							},
+						},
+					},
+				},
+			},
+		})
+
+	g.Expect(MountsSecret(deployment, "ksm-customresourcestate-secret")).To(BeTrue())
+	g.Expect(MountsSecret(deployment, "projected-secret")).To(BeTrue())
+	g.Expect(MountsSecret(deployment, "ksm-customresourcestate-config")).To(BeFalse())
+}
+
+func TestWireConfigMap(t *testing.T) {
+	g := NewWithT(t)
+
+	mount := corev1.VolumeMount{
+		Name:      "customresourcestate-config",
+		MountPath: "/etc/customresourcestate",
+	}
+
+	tests := map[string]struct {
+		deployment *appsv1.Deployment
+		name       string
+		changed    bool
+	}{
+		"already-wired": {
+			deployment: newDeployment(
+				[]string{"--custom-resource-state-config-file=/etc/customresourcestate/config.yaml"},
+				mount, nil),
+			name:    "ksm-customresourcestate-config",
+			changed: false,
+		},
+		"different-configmap": {
+			deployment: newDeployment(
+				[]string{"--custom-resource-state-config-file", "/etc/customresourcestate/config.yaml", "--port=8080"},
+				mount, nil),
+			name:    "crsm-config",
+			changed: true,
+		},
+		"no-arg": {
+			deployment: newDeployment([]string{"--port=8080"}, mount, nil),
+			name:       "crsm-config",
+			changed:    true,
+		},
+	}
+
+	for name, test := range tests {
+		g.Expect(WireConfigMap(test.deployment, test.name, "config.yaml")).To(Equal(test.changed), "Test [%s]:", name)
+
+		// The Deployment reads the key of the ConfigMap
+		target := TargetFromDeployment(test.deployment)
+		g.Expect(target).NotTo(BeNil(), "Test [%s]:", name)
+		g.Expect(target.Name).To(Equal(test.name), "Test [%s]:", name)
+		g.Expect(target.Key).To(Equal("config.yaml"), "Test [%s]:", name)
+
+		// The previous value of the argument is dropped
+		args := test.deployment.Spec.Template.Spec.Containers[0].Args
+		g.Expect(args).NotTo(ContainElement("/etc/customresourcestate/config.yaml"), "Test [%s]:", name)
+
+		// Wiring is idempotent
+		g.Expect(WireConfigMap(test.deployment, test.name, "config.yaml")).To(BeFalse(), "Test [%s]:", name)
+	}
+}
@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Name of the volume the operator adds into the kube-state-metrics Deployment.
+const WiredVolumeName = "crsm-operator-config"
+
+// Path the volume added by the operator is mounted at.
+const WiredMountPath = "/etc/crsm-operator"
+
+// Name of the kube-state-metrics container used if no container has the
+// config file argument.
+const containerName = "kube-state-metrics"
+
+// WireConfigMap ensures the kube-state-metrics Deployment has the volume, the
+// volume mount and the custom resource state config file argument pointing
+// at the key of the ConfigMap. It returns whether the Deployment changed.
+func WireConfigMap(deployment *appsv1.Deployment, name, key string) bool {
+	// Nothing to do if the Deployment already reads the key
+	if target := TargetFromDeployment(deployment); target != nil && target.Name == name && target.Key == key {
+		return false
+	}
+
+	podSpec := &deployment.Spec.Template.Spec
+	container := wiredContainer(podSpec)
+
+	if container == nil {
+		return false
+	}
+
+	// Volume
+	volume := corev1.Volume{
+		Name: WiredVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			},
+		},
+	}
+
+	if existing := findVolume(podSpec.Volumes, WiredVolumeName); existing != nil {
+		*existing = volume
+	} else {
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+	}
+
+	// Volume mount
+	mount := corev1.VolumeMount{
+		Name:      WiredVolumeName,
+		MountPath: WiredMountPath,
+		ReadOnly:  true,
+	}
+
+	if i := slices.IndexFunc(container.VolumeMounts, func(m corev1.VolumeMount) bool {
+		return m.Name == WiredVolumeName
+	}); i >= 0 {
+		container.VolumeMounts[i] = mount
+	} else {
+		container.VolumeMounts = append(container.VolumeMounts, mount)
+	}
+
+	// Argument (the previous value is dropped so it doesn't override the new one)
+	args := []string{}
+
+	for i := 0; i < len(container.Args); i++ {
+		if strings.HasPrefix(container.Args[i], configFileArg+"=") {
+			continue
+		}
+
+		if container.Args[i] == configFileArg {
+			i++
+
+			continue
+		}
+
+		args = append(args, container.Args[i])
+	}
+
+	container.Args = append(args, configFileArg+"="+path.Join(WiredMountPath, key))
+
+	return true
+}
+
+// wiredContainer returns the container the config file is passed to. It's
+// the container which already has the config file argument, the container
+// named after kube-state-metrics or the only container of the Pod.
+func wiredContainer(podSpec *corev1.PodSpec) *corev1.Container {
+	for i := range podSpec.Containers {
+		if ConfigFilePath(podSpec.Containers[i]) != "" {
+			return &podSpec.Containers[i]
+		}
+	}
+
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == containerName {
+			return &podSpec.Containers[i]
+		}
+	}
+
+	if len(podSpec.Containers) == 1 {
+		return &podSpec.Containers[0]
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// SamplingRecorder is an EventRecorder recording all Warning events but only
+// every Nth Normal event to reduce the pressure on the API server.
+type SamplingRecorder struct {
+	recorder record.EventRecorder
+	rate     uint64
+	counter  atomic.Uint64
+}
+
+// NewSamplingRecorder wraps the recorder so it records only every Nth Normal
+// event. Rate 0 disables the Normal events, rate 1 records all of them.
+func NewSamplingRecorder(recorder record.EventRecorder, rate uint) record.EventRecorder {
+	if rate == 1 {
+		return recorder
+	}
+
+	return &SamplingRecorder{
+		recorder: recorder,
+		rate:     uint64(rate),
+	}
+}
+
+// sample returns true if the event of the type should be recorded.
+func (r *SamplingRecorder) sample(eventtype string) bool {
+	if eventtype != corev1.EventTypeNormal {
+		return true
+	}
+
+	if r.rate == 0 {
+		return false
+	}
+
+	return (r.counter.Add(1)-1)%r.rate == 0
+}
+
+// Event records the event if it's sampled.
+func (r *SamplingRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.sample(eventtype) {
+		r.recorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf records the event if it's sampled.
+func (r *SamplingRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...any) {
+	if r.sample(eventtype) {
+		r.recorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	}
+}
+
+// AnnotatedEventf records the event if it's sampled.
+func (r *SamplingRecorder) AnnotatedEventf(
+	object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...any) {
+	if r.sample(eventtype) {
+		r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	}
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSamplingRecorder(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     uint
+		normal   int
+		warning  int
+		expected int
+	}{
+		{
+			name:     "all",
+			rate:     1,
+			normal:   4,
+			warning:  1,
+			expected: 5,
+		},
+		{
+			name:     "disabled",
+			rate:     0,
+			normal:   4,
+			warning:  1,
+			expected: 1,
+		},
+		{
+			name:     "every-second",
+			rate:     2,
+			normal:   4,
+			warning:  1,
+			expected: 3,
+		},
+	}
+
+	for _, test := range tests {
+		fake := record.NewFakeRecorder(10) //nolint:mnd
+		recorder := NewSamplingRecorder(fake, test.rate)
+
+		for range test.normal {
+			recorder.Event(nil, corev1.EventTypeNormal, "Foo", "foo")
+		}
+
+		for range test.warning {
+			recorder.Eventf(nil, corev1.EventTypeWarning, "Foo", "foo %s", "bar")
+		}
+
+		if len(fake.Events) != test.expected {
+			t.Errorf("Test [%s]: expected %d events, got %d", test.name, test.expected, len(fake.Events))
+		}
+	}
+}
+
+func TestThrottlingRecorder(t *testing.T) {
+	foo := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "ns", UID: "foo"}}
+	bar := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "ns"}}
+
+	tests := []struct {
+		name     string
+		window   time.Duration
+		limit    int
+		expected int
+	}{
+		{
+			name:     "disabled",
+			expected: 7,
+		},
+		{
+			name:     "deduplication",
+			window:   time.Minute,
+			expected: 5,
+		},
+		{
+			name:     "rate-limit",
+			limit:    2,
+			expected: 5,
+		},
+		{
+			name:     "both",
+			window:   time.Minute,
+			limit:    1,
+			expected: 3,
+		},
+	}
+
+	for _, test := range tests {
+		fake := record.NewFakeRecorder(20) //nolint:mnd
+		recorder := NewThrottlingRecorder(fake, test.window, test.limit)
+		now := time.Now()
+
+		if throttling, ok := recorder.(*ThrottlingRecorder); ok {
+			throttling.now = func() time.Time { return now }
+		}
+
+		// Identical events of foo
+		for range 3 {
+			recorder.Event(foo, corev1.EventTypeNormal, "Foo", "foo")
+		}
+
+		// Different events of foo and bar
+		recorder.Eventf(foo, corev1.EventTypeWarning, "Foo", "foo %s", "bar")
+		recorder.Event(bar, corev1.EventTypeNormal, "Foo", "foo")
+		recorder.Event(bar, corev1.EventTypeNormal, "Bar", "bar")
+
+		// The deduplication window and the rate limit expire
+		now = now.Add(time.Hour)
+		recorder.Event(foo, corev1.EventTypeNormal, "Foo", "foo")
+
+		if len(fake.Events) != test.expected {
+			t.Errorf("Test [%s]: expected %d events, got %d", test.name, test.expected, len(fake.Events))
+		}
+	}
+}
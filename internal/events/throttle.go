@@ -0,0 +1,158 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Interval the rate limit of the events of an object applies to.
+const rateLimitInterval = time.Minute
+
+// ThrottlingRecorder is an EventRecorder dropping the events identical to the
+// event recorded for the same object within the deduplication window and the
+// events exceeding the rate limit of the object.
+type ThrottlingRecorder struct {
+	recorder record.EventRecorder
+	window   time.Duration
+	limit    int
+	now      func() time.Time
+
+	mu        sync.Mutex
+	recorded  map[string]time.Time
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+// bucket counts the events of the object recorded since the start.
+type bucket struct {
+	start time.Time
+	count int
+}
+
+// NewThrottlingRecorder wraps the recorder so it records the identical events
+// of the same object only once within the window and at most limit events of
+// the same object per minute. Zero window disables the deduplication, zero
+// limit disables the rate limit.
+func NewThrottlingRecorder(recorder record.EventRecorder, window time.Duration, limit int) record.EventRecorder {
+	if window <= 0 && limit <= 0 {
+		return recorder
+	}
+
+	return &ThrottlingRecorder{
+		recorder: recorder,
+		window:   window,
+		limit:    limit,
+		now:      time.Now,
+		recorded: make(map[string]time.Time),
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// allow returns true if the event should be recorded.
+func (r *ThrottlingRecorder) allow(object runtime.Object, eventtype, reason, message string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	key := objectKey(object)
+	eventKey := key + "\x00" + eventtype + "\x00" + reason + "\x00" + message
+
+	r.prune(now)
+
+	if r.window > 0 {
+		if last, ok := r.recorded[eventKey]; ok && now.Sub(last) < r.window {
+			return false
+		}
+	}
+
+	if r.limit > 0 {
+		b, ok := r.buckets[key]
+		if !ok || now.Sub(b.start) >= rateLimitInterval {
+			b = &bucket{start: now}
+			r.buckets[key] = b
+		}
+
+		if b.count >= r.limit {
+			return false
+		}
+
+		b.count++
+	}
+
+	if r.window > 0 {
+		r.recorded[eventKey] = now
+	}
+
+	return true
+}
+
+// prune forgets the expired records so the memory doesn't grow with the
+// deleted objects.
+func (r *ThrottlingRecorder) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < max(r.window, rateLimitInterval) {
+		return
+	}
+
+	r.lastPrune = now
+
+	for key, last := range r.recorded {
+		if now.Sub(last) >= r.window {
+			delete(r.recorded, key)
+		}
+	}
+
+	for key, b := range r.buckets {
+		if now.Sub(b.start) >= rateLimitInterval {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+// objectKey returns the key identifying the object of the event.
+func objectKey(object runtime.Object) string {
+	if object == nil {
+		return ""
+	}
+
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return ""
+	}
+
+	if uid := accessor.GetUID(); uid != "" {
+		return string(uid)
+	}
+
+	return accessor.GetNamespace() + "/" + accessor.GetName()
+}
+
+// Event records the event if it's not throttled.
+func (r *ThrottlingRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.allow(object, eventtype, reason, message) {
+		r.recorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf records the event if it's not throttled.
+func (r *ThrottlingRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...any) {
+	message := fmt.Sprintf(messageFmt, args...)
+
+	if r.allow(object, eventtype, reason, message) {
+		r.recorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// AnnotatedEventf records the event if it's not throttled.
+func (r *ThrottlingRecorder) AnnotatedEventf(
+	object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...any) {
+	message := fmt.Sprintf(messageFmt, args...)
+
+	if r.allow(object, eventtype, reason, message) {
+		r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
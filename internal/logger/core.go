@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// Period of the sampling of the repeated messages.
+const samplingTick = time.Second
+
+// namedCore filters the messages by the level of the logger which logged them.
+type namedCore struct {
+	zapcore.Core
+
+	level  zapcore.Level
+	levels map[string]zapcore.Level
+}
+
+func (c *namedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &namedCore{Core: c.Core.With(fields), level: c.level, levels: c.levels}
+}
+
+func (c *namedCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.loggerLevel(ent.LoggerName) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// loggerLevel returns the level of the logger. The level of the parent logger
+// applies to its children (e.g. [crsm].foo) unless they have their own.
+func (c *namedCore) loggerLevel(name string) zapcore.Level {
+	for {
+		if level, ok := c.levels[name]; ok {
+			return level
+		}
+
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return c.level
+		}
+
+		name = name[:i]
+	}
+}
+
+// sampledCore samples the repeated messages below the warning level.
+type sampledCore struct {
+	zapcore.Core
+
+	sampled zapcore.Core
+}
+
+func (c *sampledCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sampledCore{Core: c.Core.With(fields), sampled: c.sampled.With(fields)}
+}
+
+func (c *sampledCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < zapcore.WarnLevel {
+		return c.sampled.Check(ent, ce)
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// SetNamedLevels sets the levels of the named loggers from the comma-separated
+// list of the logger names and their levels (e.g. [crsm]=2,janitor=debug). The
+// brackets around the names are optional. The other loggers keep the level of
+// the options.
+func SetNamedLevels(opts *zap.Options, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	level := zapcore.InfoLevel
+	if l, ok := opts.Level.(zapcore.Level); ok {
+		level = l
+	}
+
+	levels := make(map[string]zapcore.Level)
+	minLevel := level
+
+	for _, item := range strings.Split(value, ",") {
+		name, lvl, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found || name == "" {
+			return fmt.Errorf("invalid logger level %q (must be name=level)", item)
+		}
+
+		if !strings.HasPrefix(name, "[") {
+			name = fmt.Sprintf("[%s]", name)
+		}
+
+		l, err := ParseLevel(lvl)
+		if err != nil {
+			return fmt.Errorf("invalid level of the logger %s: %w", name, err)
+		}
+
+		levels[name] = l
+		minLevel = min(minLevel, l)
+	}
+
+	// The core must let through the messages of the most verbose logger
+	opts.Level = minLevel
+	opts.ZapOpts = append(opts.ZapOpts, uberzap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &namedCore{Core: core, level: level, levels: levels}
+	}))
+
+	return nil
+}
+
+// SetSampling samples the repeated messages below the warning level. Only the
+// first messages with the same text logged within a second are written and
+// then only every thereafter-th of them. The messages more verbose than debug
+// aren't sampled.
+func SetSampling(opts *zap.Options, first, thereafter int) {
+	if first <= 0 {
+		return
+	}
+
+	opts.ZapOpts = append(opts.ZapOpts, uberzap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &sampledCore{
+			Core:    core,
+			sampled: zapcore.NewSamplerWithOptions(core, samplingTick, first, thereafter),
+		}
+	}))
+}
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNamedCore(t *testing.T) {
+	observed, logs := observer.New(zapcore.Level(-TRACE_VERBOSITY))
+	core := &namedCore{
+		Core:  observed,
+		level: zapcore.InfoLevel,
+		levels: map[string]zapcore.Level{
+			"[crsm]":     zapcore.Level(-TRACE_VERBOSITY),
+			"[crsm].foo": zapcore.InfoLevel,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		level   zapcore.Level
+		written bool
+	}{
+		{name: "[crsm]", level: zapcore.Level(-TRACE_VERBOSITY), written: true},
+		{name: "[crsm].bar", level: zapcore.DebugLevel, written: true},
+		{name: "[crsm].foo", level: zapcore.DebugLevel, written: false},
+		{name: "[janitor]", level: zapcore.DebugLevel, written: false},
+		{name: "[janitor]", level: zapcore.InfoLevel, written: true},
+	}
+
+	for _, test := range tests {
+		before := logs.Len()
+
+		if ce := core.With(nil).Check(
+			zapcore.Entry{LoggerName: test.name, Level: test.level, Message: "msg"}, nil); ce != nil {
+			ce.Write()
+		}
+
+		if written := logs.Len() > before; written != test.written {
+			t.Errorf("Expected written %v for %s at %v, got %v", test.written, test.name, test.level, written)
+		}
+	}
+}
+
+func TestSampledCore(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := &sampledCore{
+		Core:    observed,
+		sampled: zapcore.NewSamplerWithOptions(observed, samplingTick, 2, 0),
+	}
+
+	for _, level := range []zapcore.Level{zapcore.InfoLevel, zapcore.ErrorLevel} {
+		for range 5 {
+			if ce := core.Check(zapcore.Entry{Level: level, Message: "msg", Time: time.Now()}, nil); ce != nil {
+				ce.Write()
+			}
+		}
+	}
+
+	if count := logs.FilterLevelExact(zapcore.InfoLevel).Len(); count != 2 {
+		t.Errorf("Expected 2 sampled info messages, got %d", count)
+	}
+
+	if count := logs.FilterLevelExact(zapcore.ErrorLevel).Len(); count != 5 {
+		t.Errorf("Expected 5 error messages, got %d", count)
+	}
+}
@@ -2,14 +2,21 @@ package logger
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
 const DEBUG_VERBOSITY = 1
 const TRACE_VERBOSITY = 2
 
+// Formats of the log messages.
+const FormatConsole = "console"
+const FormatJSON = "json"
+
 type Logger struct {
 	Name string
 	Log  logr.Logger
@@ -25,17 +32,66 @@ func New(name string) Logger {
 }
 
 func (l *Logger) Info(msg string, keysAndValues ...any) {
-	l.Log.Info(msg, keysAndValues)
+	l.Log.Info(msg, keysAndValues...)
 }
 
 func (l *Logger) Error(err error, msg string, keysAndValues ...any) {
-	l.Log.Error(err, msg, keysAndValues)
+	l.Log.Error(err, msg, keysAndValues...)
 }
 
 func (l *Logger) Debug(msg string, keysAndValues ...any) {
-	l.Log.V(DEBUG_VERBOSITY).Info(msg, keysAndValues)
+	l.Log.V(DEBUG_VERBOSITY).Info(msg, keysAndValues...)
 }
 
 func (l *Logger) Trace(msg string, keysAndValues ...any) {
-	l.Log.V(TRACE_VERBOSITY).Info(msg, keysAndValues)
+	l.Log.V(TRACE_VERBOSITY).Info(msg, keysAndValues...)
+}
+
+// Options returns the options of the logger writing the messages in the format
+// (console or json) up to the level (error, info, debug, trace or the
+// verbosity number).
+func Options(format, level string) (zap.Options, error) {
+	opts := zap.Options{}
+
+	switch format {
+	case FormatConsole:
+		opts.Development = true
+	case FormatJSON:
+		opts.Development = false
+	default:
+		return opts, fmt.Errorf("unknown log format %q (must be %s or %s)", format, FormatConsole, FormatJSON)
+	}
+
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Level = lvl
+
+	return opts, nil
+}
+
+// ParseLevel returns the zap level of the level name or of the verbosity
+// number.
+func ParseLevel(level string) (zapcore.Level, error) {
+	switch level {
+	case "error":
+		return zapcore.ErrorLevel, nil
+	case "info":
+		return zapcore.InfoLevel, nil
+	case "debug":
+		return zapcore.Level(-DEBUG_VERBOSITY), nil
+	case "trace":
+		return zapcore.Level(-TRACE_VERBOSITY), nil
+	}
+
+	// The level must fit into the int8 of the zap level
+	verbosity, err := strconv.ParseUint(level, 10, 7)
+	if err != nil {
+		return zapcore.InfoLevel, fmt.Errorf(
+			"unknown log level %q (must be error, info, debug, trace or the verbosity number)", level)
+	}
+
+	return zapcore.Level(-int(verbosity)), nil //nolint:gosec
 }
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]zapcore.Level{
+		"error": zapcore.ErrorLevel,
+		"info":  zapcore.InfoLevel,
+		"debug": zapcore.Level(-DEBUG_VERBOSITY),
+		"trace": zapcore.Level(-TRACE_VERBOSITY),
+		"0":     zapcore.InfoLevel,
+		"3":     zapcore.Level(-3),
+	}
+
+	for level, expected := range tests {
+		result, err := ParseLevel(level)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", level, err)
+		}
+
+		if result != expected {
+			t.Errorf("Expected %v for %q, got %v", expected, level, result)
+		}
+	}
+
+	for _, level := range []string{"", "verbose", "-1"} {
+		if _, err := ParseLevel(level); err == nil {
+			t.Errorf("Expected error for %q", level)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	opts, err := Options(FormatJSON, "debug")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if opts.Development {
+		t.Errorf("Expected production options for the JSON format")
+	}
+
+	if opts.Level != zapcore.Level(-DEBUG_VERBOSITY) {
+		t.Errorf("Expected the debug level, got %v", opts.Level)
+	}
+
+	if opts, _ := Options(FormatConsole, "info"); !opts.Development {
+		t.Errorf("Expected development options for the console format")
+	}
+
+	if _, err := Options("xml", "info"); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
@@ -1,20 +1,63 @@
 package metrics
 
 import (
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 type MetricsRecorder interface {
-	// IncCRSMTotal increments the total number of CRSM resources available on the cluster.
-	IncCRSMTotal()
+	// IncCRSMTotal increments the number of CRSM resources available in the Namespace.
+	IncCRSMTotal(namespace string)
+
+	// DecCRSMTotal decrements the number of CRSM resources available in the Namespace.
+	DecCRSMTotal(namespace string)
+
+	// SetMetricsMissing sets the number of metrics of the CRSM resource missing in the kube-state-metrics output.
+	SetMetricsMissing(name, namespace string, count int)
+
+	// DeleteMetricsMissing removes the missing metrics record of the CRSM resource.
+	DeleteMetricsMissing(name, namespace string)
+
+	// SetGVKUsage sets the number of CRSM resources defining metrics for the group and kind.
+	SetGVKUsage(group, kind string, count int)
+
+	// SetLastReconcileResult sets the reason of the last reconcile result of the CRSM resource.
+	SetLastReconcileResult(name, namespace, reason string)
+
+	// DeleteLastReconcileResult removes the last reconcile result records of the CRSM resource.
+	DeleteLastReconcileResult(name, namespace string)
+
+	// SetConfigMapSize sets the projected size of the data of the ConfigMap written by the operator.
+	SetConfigMapSize(name, namespace string, size int)
 
-	// DecCRSMTotal decrements the total number of CRSM resources available on the cluster.
-	DecCRSMTotal()
+	// SetConfigMapKeySize sets the size of the key of the ConfigMap written by the operator.
+	SetConfigMapKeySize(name, namespace, key string, size int)
+
+	// SetConfigMapBlocks sets the number of the instance blocks in the ConfigMap written by the operator.
+	SetConfigMapBlocks(name, namespace string, count int)
+
+	// SetInstanceReady sets whether the CRSM resource is ready.
+	SetInstanceReady(name, namespace string, ready bool)
+
+	// DeleteInstanceReady removes the readiness record of the CRSM resource.
+	DeleteInstanceReady(name, namespace string)
 }
 
 type PrometheusMetricsRecorder struct {
-	crsmTotal *prometheus.GaugeVec
+	crsmTotal       *prometheus.GaugeVec
+	metricsMissing  *prometheus.GaugeVec
+	gvkUsage        *prometheus.GaugeVec
+	lastResult      *prometheus.GaugeVec
+	configMapSize   *prometheus.GaugeVec
+	configMapBytes  *prometheus.GaugeVec
+	configMapBlocks *prometheus.GaugeVec
+	instanceReady   *prometheus.GaugeVec
+
+	// Reasons reported so far so they can be zeroed for each CRSM resource
+	reasonsMu sync.Mutex
+	reasons   map[string]struct{}
 }
 
 // NewPrometheusMetricsRecorder creates a new PrometheusMetricsRecorder and registers metrics.
@@ -28,26 +71,159 @@ func newPrometheusMetricsRecorderWithRegistry(registry prometheus.Registerer) *P
 		crsmTotal: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "crsm_total",
-				Help: "Total number of CRSM resources available on the cluster.",
+				Help: "Number of CRSM resources available in the Namespace.",
+			},
+			[]string{"namespace"},
+		),
+		metricsMissing: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "crsm_metrics_missing",
+				Help: "Number of metrics defined by the CRSM resource missing in the kube-state-metrics output.",
+			},
+			[]string{"name", "namespace"},
+		),
+		gvkUsage: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "crsm_gvk_usage",
+				Help: "Number of CRSM resources defining metrics for the group and kind.",
+			},
+			[]string{"group", "kind"},
+		),
+		lastResult: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "crsm_last_reconcile_result",
+				Help: "Reason of the last reconcile result of the CRSM resource (1 for the current reason, 0 otherwise).",
+			},
+			[]string{"name", "namespace", "reason"},
+		),
+		configMapSize: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "crsm_configmap_size_bytes",
+				Help: "Projected size of the data of the ConfigMap written by the operator in bytes.",
+			},
+			[]string{"name", "namespace"},
+		),
+		configMapBytes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "crsm_configmap_bytes",
+				Help: "Size of the key of the ConfigMap written by the operator in bytes.",
+			},
+			[]string{"name", "namespace", "key"},
+		),
+		configMapBlocks: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "crsm_configmap_blocks",
+				Help: "Number of the instance blocks in the ConfigMap written by the operator.",
+			},
+			[]string{"name", "namespace"},
+		),
+		instanceReady: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "crsm_instance_ready",
+				Help: "Whether the CRSM resource is ready (1 if ready, 0 otherwise).",
 			},
-			[]string{},
+			[]string{"name", "namespace"},
 		),
+		reasons: make(map[string]struct{}),
 	}
 
 	// Register metrics with the provided registry
 	registry.MustRegister(
 		recorder.crsmTotal,
+		recorder.metricsMissing,
+		recorder.gvkUsage,
+		recorder.lastResult,
+		recorder.configMapSize,
+		recorder.configMapBytes,
+		recorder.configMapBlocks,
+		recorder.instanceReady,
 	)
 
 	return recorder
 }
 
-// IncCRSMTotal increments the total number of CRSM resources available on the cluster.
-func (r *PrometheusMetricsRecorder) IncCRSMTotal() {
-	r.crsmTotal.WithLabelValues().Inc()
+// IncCRSMTotal increments the number of CRSM resources available in the Namespace.
+func (r *PrometheusMetricsRecorder) IncCRSMTotal(namespace string) {
+	r.crsmTotal.WithLabelValues(namespace).Inc()
+}
+
+// DecCRSMTotal decrements the number of CRSM resources available in the Namespace.
+func (r *PrometheusMetricsRecorder) DecCRSMTotal(namespace string) {
+	r.crsmTotal.WithLabelValues(namespace).Dec()
+}
+
+// SetMetricsMissing sets the number of metrics of the CRSM resource missing in the kube-state-metrics output.
+func (r *PrometheusMetricsRecorder) SetMetricsMissing(name, namespace string, count int) {
+	r.metricsMissing.WithLabelValues(name, namespace).Set(float64(count))
+}
+
+// DeleteMetricsMissing removes the missing metrics record of the CRSM resource.
+func (r *PrometheusMetricsRecorder) DeleteMetricsMissing(name, namespace string) {
+	r.metricsMissing.DeleteLabelValues(name, namespace)
+}
+
+// SetGVKUsage sets the number of CRSM resources defining metrics for the group and kind.
+func (r *PrometheusMetricsRecorder) SetGVKUsage(group, kind string, count int) {
+	if count == 0 {
+		r.gvkUsage.DeleteLabelValues(group, kind)
+
+		return
+	}
+
+	r.gvkUsage.WithLabelValues(group, kind).Set(float64(count))
+}
+
+// SetLastReconcileResult sets the reason of the last reconcile result of the CRSM resource.
+func (r *PrometheusMetricsRecorder) SetLastReconcileResult(name, namespace, reason string) {
+	r.reasonsMu.Lock()
+	defer r.reasonsMu.Unlock()
+
+	r.reasons[reason] = struct{}{}
+
+	for known := range r.reasons {
+		value := 0.0
+		if known == reason {
+			value = 1.0
+		}
+
+		r.lastResult.WithLabelValues(name, namespace, known).Set(value)
+	}
+}
+
+// DeleteLastReconcileResult removes the last reconcile result records of the CRSM resource.
+func (r *PrometheusMetricsRecorder) DeleteLastReconcileResult(name, namespace string) {
+	r.lastResult.DeletePartialMatch(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace,
+	})
+}
+
+// SetConfigMapSize sets the projected size of the data of the ConfigMap written by the operator.
+func (r *PrometheusMetricsRecorder) SetConfigMapSize(name, namespace string, size int) {
+	r.configMapSize.WithLabelValues(name, namespace).Set(float64(size))
+}
+
+// SetConfigMapKeySize sets the size of the key of the ConfigMap written by the operator.
+func (r *PrometheusMetricsRecorder) SetConfigMapKeySize(name, namespace, key string, size int) {
+	r.configMapBytes.WithLabelValues(name, namespace, key).Set(float64(size))
+}
+
+// SetConfigMapBlocks sets the number of the instance blocks in the ConfigMap written by the operator.
+func (r *PrometheusMetricsRecorder) SetConfigMapBlocks(name, namespace string, count int) {
+	r.configMapBlocks.WithLabelValues(name, namespace).Set(float64(count))
+}
+
+// SetInstanceReady sets whether the CRSM resource is ready.
+func (r *PrometheusMetricsRecorder) SetInstanceReady(name, namespace string, ready bool) {
+	value := 0.0
+	if ready {
+		value = 1.0
+	}
+
+	r.instanceReady.WithLabelValues(name, namespace).Set(value)
 }
 
-// DecCRSMTotal decrements the total number of CRSM resources available on the cluster.
-func (r *PrometheusMetricsRecorder) DecCRSMTotal() {
-	r.crsmTotal.WithLabelValues().Dec()
+// DeleteInstanceReady removes the readiness record of the CRSM resource.
+func (r *PrometheusMetricsRecorder) DeleteInstanceReady(name, namespace string) {
+	r.instanceReady.DeleteLabelValues(name, namespace)
 }
@@ -18,13 +18,144 @@ func TestCRSMTotal(t *testing.T) {
 	recorder := newPrometheusMetricsRecorderWithRegistry(registry)
 
 	// Test incrementation and decrementation of the gauge value
-	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues())).To(Equal(0.0), "Test crsmTotal initial:")
-	recorder.IncCRSMTotal()
-	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues())).To(Equal(1.0), "Test crsmTotal increment 1:")
-	recorder.IncCRSMTotal()
-	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues())).To(Equal(2.0), "Test crsmTotal increment 2:")
-	recorder.DecCRSMTotal()
-	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues())).To(Equal(1.0), "Test crsmTotal decrement 1:")
-	recorder.DecCRSMTotal()
-	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues())).To(Equal(0.0), "Test crsmTotal decrement 2:")
+	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues("foo"))).To(Equal(0.0), "Test crsmTotal initial:")
+	recorder.IncCRSMTotal("foo")
+	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues("foo"))).To(Equal(1.0), "Test crsmTotal increment 1:")
+	recorder.IncCRSMTotal("foo")
+	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues("foo"))).To(Equal(2.0), "Test crsmTotal increment 2:")
+	recorder.DecCRSMTotal("foo")
+	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues("foo"))).To(Equal(1.0), "Test crsmTotal decrement 1:")
+	recorder.DecCRSMTotal("foo")
+	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues("foo"))).To(Equal(0.0), "Test crsmTotal decrement 2:")
+
+	// Test the separate gauge values of the Namespaces
+	recorder.IncCRSMTotal("foo")
+	recorder.IncCRSMTotal("bar")
+	g.Expect(testutil.ToFloat64(recorder.crsmTotal.WithLabelValues("bar"))).To(Equal(1.0), "Test crsmTotal namespace:")
+	g.Expect(testutil.CollectAndCount(recorder.crsmTotal)).To(Equal(2), "Test crsmTotal count:")
+}
+
+func TestMetricsMissing(t *testing.T) {
+	// Initiate Gomega
+	g := NewWithT(t)
+
+	// Create a custom registry
+	registry := prometheus.NewRegistry()
+	recorder := newPrometheusMetricsRecorderWithRegistry(registry)
+
+	// Test setting and deleting of the gauge value
+	recorder.SetMetricsMissing("foo", "bar", 2)
+	g.Expect(testutil.ToFloat64(recorder.metricsMissing.WithLabelValues("foo", "bar"))).To(Equal(2.0),
+		"Test metricsMissing set:")
+	g.Expect(testutil.CollectAndCount(recorder.metricsMissing)).To(Equal(1), "Test metricsMissing count:")
+	recorder.DeleteMetricsMissing("foo", "bar")
+	g.Expect(testutil.CollectAndCount(recorder.metricsMissing)).To(Equal(0), "Test metricsMissing delete:")
+}
+
+func TestGVKUsage(t *testing.T) {
+	// Initiate Gomega
+	g := NewWithT(t)
+
+	// Create a custom registry
+	registry := prometheus.NewRegistry()
+	recorder := newPrometheusMetricsRecorderWithRegistry(registry)
+
+	// Test setting and removal of the gauge value
+	recorder.SetGVKUsage("myteam.io", "Foo", 3)
+	g.Expect(testutil.ToFloat64(recorder.gvkUsage.WithLabelValues("myteam.io", "Foo"))).To(Equal(3.0),
+		"Test gvkUsage set:")
+	recorder.SetGVKUsage("myteam.io", "Foo", 0)
+	g.Expect(testutil.CollectAndCount(recorder.gvkUsage)).To(Equal(0), "Test gvkUsage zero:")
+}
+
+func TestLastReconcileResult(t *testing.T) {
+	// Initiate Gomega
+	g := NewWithT(t)
+
+	// Create a custom registry
+	registry := prometheus.NewRegistry()
+	recorder := newPrometheusMetricsRecorderWithRegistry(registry)
+
+	// Test switching of the reason and removal of the gauge values
+	recorder.SetLastReconcileResult("foo", "bar", "Synced")
+	g.Expect(testutil.ToFloat64(recorder.lastResult.WithLabelValues("foo", "bar", "Synced"))).To(Equal(1.0),
+		"Test lastResult set:")
+	recorder.SetLastReconcileResult("foo", "bar", "WriteError")
+	g.Expect(testutil.ToFloat64(recorder.lastResult.WithLabelValues("foo", "bar", "Synced"))).To(Equal(0.0),
+		"Test lastResult previous reason:")
+	g.Expect(testutil.ToFloat64(recorder.lastResult.WithLabelValues("foo", "bar", "WriteError"))).To(Equal(1.0),
+		"Test lastResult current reason:")
+	recorder.SetLastReconcileResult("baz", "bar", "Synced")
+	g.Expect(testutil.CollectAndCount(recorder.lastResult)).To(Equal(4), "Test lastResult count:")
+	recorder.DeleteLastReconcileResult("foo", "bar")
+	g.Expect(testutil.CollectAndCount(recorder.lastResult)).To(Equal(2), "Test lastResult delete:")
+}
+
+func TestConfigMapSize(t *testing.T) {
+	// Initiate Gomega
+	g := NewWithT(t)
+
+	// Create a custom registry
+	registry := prometheus.NewRegistry()
+	recorder := newPrometheusMetricsRecorderWithRegistry(registry)
+
+	// Test setting of the gauge value
+	recorder.SetConfigMapSize("ksm", "monitoring", 1024)
+	g.Expect(testutil.ToFloat64(recorder.configMapSize.WithLabelValues("ksm", "monitoring"))).To(Equal(1024.0),
+		"Test configMapSize set:")
+	recorder.SetConfigMapSize("ksm", "monitoring", 2048)
+	g.Expect(testutil.ToFloat64(recorder.configMapSize.WithLabelValues("ksm", "monitoring"))).To(Equal(2048.0),
+		"Test configMapSize update:")
+}
+
+func TestConfigMapKeySize(t *testing.T) {
+	// Initiate Gomega
+	g := NewWithT(t)
+
+	// Create a custom registry
+	registry := prometheus.NewRegistry()
+	recorder := newPrometheusMetricsRecorderWithRegistry(registry)
+
+	// Test setting of the gauge values per key
+	recorder.SetConfigMapKeySize("ksm", "monitoring", "config.yaml", 1024)
+	recorder.SetConfigMapKeySize("ksm", "monitoring", "other.yaml", 512)
+	g.Expect(testutil.ToFloat64(recorder.configMapBytes.WithLabelValues("ksm", "monitoring", "config.yaml"))).To(
+		Equal(1024.0), "Test configMapBytes set:")
+	g.Expect(testutil.CollectAndCount(recorder.configMapBytes)).To(Equal(2), "Test configMapBytes count:")
+}
+
+func TestConfigMapBlocks(t *testing.T) {
+	// Initiate Gomega
+	g := NewWithT(t)
+
+	// Create a custom registry
+	registry := prometheus.NewRegistry()
+	recorder := newPrometheusMetricsRecorderWithRegistry(registry)
+
+	// Test setting of the gauge value
+	recorder.SetConfigMapBlocks("ksm", "monitoring", 3)
+	g.Expect(testutil.ToFloat64(recorder.configMapBlocks.WithLabelValues("ksm", "monitoring"))).To(Equal(3.0),
+		"Test configMapBlocks set:")
+	recorder.SetConfigMapBlocks("ksm", "monitoring", 2)
+	g.Expect(testutil.ToFloat64(recorder.configMapBlocks.WithLabelValues("ksm", "monitoring"))).To(Equal(2.0),
+		"Test configMapBlocks update:")
+}
+
+func TestInstanceReady(t *testing.T) {
+	// Initiate Gomega
+	g := NewWithT(t)
+
+	// Create a custom registry
+	registry := prometheus.NewRegistry()
+	recorder := newPrometheusMetricsRecorderWithRegistry(registry)
+
+	// Test setting and deleting of the gauge value
+	recorder.SetInstanceReady("foo", "bar", true)
+	g.Expect(testutil.ToFloat64(recorder.instanceReady.WithLabelValues("foo", "bar"))).To(Equal(1.0),
+		"Test instanceReady ready:")
+	recorder.SetInstanceReady("foo", "bar", false)
+	g.Expect(testutil.ToFloat64(recorder.instanceReady.WithLabelValues("foo", "bar"))).To(Equal(0.0),
+		"Test instanceReady not ready:")
+	recorder.DeleteInstanceReady("foo", "bar")
+	g.Expect(testutil.CollectAndCount(recorder.instanceReady)).To(Equal(0), "Test instanceReady delete:")
 }
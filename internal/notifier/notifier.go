@@ -0,0 +1,94 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Types of the notification events.
+const (
+	EventAdded   = "Added"
+	EventUpdated = "Updated"
+	EventRemoved = "Removed"
+	EventFailed  = "Failed"
+)
+
+// Notifier sends notifications about the sync events.
+type Notifier interface {
+	// Notify sends the event to the notification sink.
+	Notify(ctx context.Context, event Event) error
+}
+
+// Event is the payload of the notification.
+type Event struct {
+	// Type of the event.
+	Type string `json:"type"`
+
+	// Name of the CustomResourceStateMetrics instance.
+	Name string `json:"name"`
+
+	// Namespace of the CustomResourceStateMetrics instance.
+	Namespace string `json:"namespace"`
+
+	// Name of the target ConfigMap.
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// Namespace of the target ConfigMap.
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
+
+	// Key of the target ConfigMap.
+	ConfigMapKey string `json:"configMapKey,omitempty"`
+
+	// Human readable description of the event.
+	Message string `json:"message"`
+
+	// Time of the event.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// HTTPNotifier sends the events as JSON payload via HTTP POST.
+type HTTPNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPNotifier creates a new HTTPNotifier with the request timeout.
+func NewHTTPNotifier(url string, timeout time.Duration) *HTTPNotifier {
+	return &HTTPNotifier{
+		URL: url,
+		Client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// Notify sends the event to the URL.
+func (n *HTTPNotifier) Notify(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to send notification: unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
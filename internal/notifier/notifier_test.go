@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestHTTPNotifier(t *testing.T) {
+	g := NewWithT(t)
+
+	received := make(chan Event, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		received <- event
+	}))
+	defer server.Close()
+
+	n := NewHTTPNotifier(server.URL, time.Second)
+	event := Event{
+		Type:      EventAdded,
+		Name:      "foo",
+		Namespace: "bar",
+		Message:   "Added.",
+		Timestamp: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	g.Expect(n.Notify(context.Background(), event)).To(Succeed())
+	g.Expect(<-received).To(Equal(event))
+
+	failing := NewHTTPNotifier(server.URL+"/missing", time.Second)
+	server.Config.Handler = http.NotFoundHandler()
+
+	g.Expect(failing.Notify(context.Background(), event)).NotTo(Succeed())
+}
@@ -0,0 +1,411 @@
+package preflight
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/discovery"
+	"github.com/jtyr/crsm-operator/internal/utils"
+)
+
+// Status of a check.
+type Status string
+
+const (
+	StatusPass Status = "PASS"
+	StatusFail Status = "FAIL"
+	StatusSkip Status = "SKIP"
+)
+
+// Time before the certificate expiration when the check fails.
+const certExpirationThreshold = 7 * 24 * time.Hour
+
+// GroupVersionKind of the CustomResourceDefinition.
+var crdGVK = schema.GroupVersionKind{
+	Group:   "apiextensions.k8s.io",
+	Version: "v1",
+	Kind:    "CustomResourceDefinition",
+}
+
+// CustomResourceDefinitions required by the operator.
+var requiredCRDs = []string{
+	"customresourcestatemetrics.ksm.jtyr.io",
+	"crsmreports.ksm.jtyr.io",
+}
+
+// Permission required by the operator.
+type permission struct {
+	group       string
+	resource    string
+	subresource string
+	verbs       []string
+}
+
+// Permissions required by the operator (see config/rbac/role.yaml).
+var requiredPermissions = []permission{
+	{resource: "configmaps", verbs: []string{"get", "list", "watch", "create", "update", "patch", "delete"}},
+	{resource: "events", verbs: []string{"create", "patch"}},
+	{resource: "namespaces", verbs: []string{"get", "list", "watch"}},
+	{resource: "secrets", verbs: []string{"get", "list", "watch", "create", "patch"}},
+	{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verbs: []string{"get", "list", "watch"}},
+	{group: "apps", resource: "deployments", verbs: []string{"get", "list", "watch", "patch"}},
+	{group: "authorization.k8s.io", resource: "selfsubjectaccessreviews", verbs: []string{"create"}},
+	{group: "rbac.authorization.k8s.io", resource: "clusterroles", verbs: []string{
+		"get", "create", "patch", "escalate", "bind"}},
+	{group: "rbac.authorization.k8s.io", resource: "clusterrolebindings", verbs: []string{
+		"get", "create", "patch", "escalate", "bind"}},
+	{group: ksmv1.GroupVersion.Group, resource: "customresourcestatemetrics", verbs: []string{
+		"get", "list", "watch", "update", "patch"}},
+	{group: ksmv1.GroupVersion.Group, resource: "customresourcestatemetrics", subresource: "status", verbs: []string{
+		"get", "update", "patch"}},
+	{group: ksmv1.GroupVersion.Group, resource: "customresourcestatemetrics", subresource: "finalizers", verbs: []string{
+		"update"}},
+	{group: ksmv1.GroupVersion.Group, resource: "crsmreports", verbs: []string{
+		"get", "list", "watch", "create", "update"}},
+	{group: ksmv1.GroupVersion.Group, resource: "crsmreports", subresource: "status", verbs: []string{
+		"get", "update", "patch"}},
+}
+
+// Result of a single check.
+type Result struct {
+	Name    string
+	Status  Status
+	Message string
+}
+
+// Options of the preflight check.
+type Options struct {
+	// ServiceAccount of the operator whose permissions are checked. If
+	// empty, the permissions of the caller are checked.
+	ServiceAccount types.NamespacedName
+
+	// Path to the webhook certificate and key. The check is skipped if
+	// the path is empty.
+	WebhookCertPath string
+	WebhookCertName string
+	WebhookCertKey  string
+
+	// Namespace where kube-state-metrics is discovered. All Namespaces
+	// are searched if empty.
+	KSMNamespace string
+}
+
+// Run runs all checks and returns their results.
+func Run(ctx context.Context, c client.Client, opts Options) []Result {
+	results := []Result{}
+
+	results = append(results, checkCRDs(ctx, c)...)
+	results = append(results, checkPermissions(ctx, c, opts.ServiceAccount)...)
+	results = append(results, checkWebhookCerts(opts, time.Now()))
+	results = append(results, checkKSM(ctx, c, opts.KSMNamespace))
+	results = append(results, checkTargets(ctx, c)...)
+
+	return results
+}
+
+// Print prints the report of the results.
+func Print(w io.Writer, results []Result) {
+	for _, result := range results {
+		_, _ = fmt.Fprintf(w, "[%s] %s: %s\n", result.Status, result.Name, result.Message)
+	}
+}
+
+// Failed returns true if any of the checks failed.
+func Failed(results []Result) bool {
+	return slices.ContainsFunc(results, func(result Result) bool {
+		return result.Status == StatusFail
+	})
+}
+
+// checkCRDs checks that the CustomResourceDefinitions are installed,
+// established and serve the API version of the operator.
+func checkCRDs(ctx context.Context, c client.Client) []Result {
+	results := []Result{}
+
+	for _, name := range requiredCRDs {
+		result := Result{Name: "CRD " + name}
+
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(crdGVK)
+
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+			result.Status, result.Message = StatusFail, fmt.Sprintf("failed to get: %s", err)
+		} else if !crdEstablished(crd) {
+			result.Status, result.Message = StatusFail, "not established"
+		} else if !crdServed(crd, ksmv1.GroupVersion.Version) {
+			result.Status, result.Message = StatusFail, fmt.Sprintf("version %s not served", ksmv1.GroupVersion.Version)
+		} else {
+			result.Status, result.Message = StatusPass, "installed and served"
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// crdEstablished returns true if the CustomResourceDefinition has the
+// Established condition.
+func crdEstablished(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+
+	for _, condition := range conditions {
+		fields, ok := condition.(map[string]any)
+
+		if ok && fields["type"] == "Established" && fields["status"] == "True" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// crdServed returns true if the CustomResourceDefinition serves the version.
+func crdServed(crd *unstructured.Unstructured, version string) bool {
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+	for _, v := range versions {
+		fields, ok := v.(map[string]any)
+
+		if ok && fields["name"] == version && fields["served"] == true {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkPermissions checks that the operator has all required permissions.
+func checkPermissions(ctx context.Context, c client.Client, serviceAccount types.NamespacedName) []Result {
+	results := []Result{}
+
+	for _, p := range requiredPermissions {
+		resource := p.resource
+		if p.subresource != "" {
+			resource += "/" + p.subresource
+		}
+
+		if p.group != "" {
+			resource += "." + p.group
+		}
+
+		result := Result{Name: "RBAC " + resource, Status: StatusPass, Message: "allowed"}
+		denied := []string{}
+
+		for _, verb := range p.verbs {
+			attributes := &authorizationv1.ResourceAttributes{
+				Group:       p.group,
+				Resource:    p.resource,
+				Subresource: p.subresource,
+				Verb:        verb,
+			}
+
+			allowed, err := accessAllowed(ctx, c, serviceAccount, attributes)
+			if err != nil {
+				result.Status, result.Message = StatusFail, fmt.Sprintf("failed to review access: %s", err)
+
+				break
+			}
+
+			if !allowed {
+				denied = append(denied, verb)
+			}
+		}
+
+		if result.Status == StatusPass && len(denied) > 0 {
+			result.Status, result.Message = StatusFail, fmt.Sprintf("denied verbs %v", denied)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// accessAllowed reviews the access of the ServiceAccount or of the caller if
+// the ServiceAccount is empty.
+func accessAllowed(
+	ctx context.Context, c client.Client, serviceAccount types.NamespacedName,
+	attributes *authorizationv1.ResourceAttributes) (bool, error) {
+	if serviceAccount.Name == "" {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: attributes,
+			},
+		}
+
+		if err := c.Create(ctx, review); err != nil {
+			return false, err
+		}
+
+		return review.Status.Allowed, nil
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: attributes,
+			User:               fmt.Sprintf("system:serviceaccount:%s:%s", serviceAccount.Namespace, serviceAccount.Name),
+			Groups: []string{
+				"system:serviceaccounts",
+				"system:serviceaccounts:" + serviceAccount.Namespace,
+				"system:authenticated",
+			},
+		},
+	}
+
+	if err := c.Create(ctx, review); err != nil {
+		return false, err
+	}
+
+	return review.Status.Allowed, nil
+}
+
+// checkWebhookCerts checks that the webhook certificate can be loaded and
+// doesn't expire soon.
+func checkWebhookCerts(opts Options, now time.Time) Result {
+	result := Result{Name: "Webhook certificate"}
+
+	if opts.WebhookCertPath == "" {
+		result.Status, result.Message = StatusSkip, "no certificate path specified"
+
+		return result
+	}
+
+	if err := validateCert(
+		filepath.Join(opts.WebhookCertPath, opts.WebhookCertName),
+		filepath.Join(opts.WebhookCertPath, opts.WebhookCertKey),
+		now); err != nil {
+		result.Status, result.Message = StatusFail, err.Error()
+
+		return result
+	}
+
+	result.Status, result.Message = StatusPass, "valid"
+
+	return result
+}
+
+// validateCert validates the certificate and key pair.
+func validateCert(certFile, keyFile string, now time.Time) error {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the certificate: %w", err)
+	}
+
+	if len(pair.Certificate) == 0 {
+		return errors.New("no certificate found")
+	}
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse the certificate: %w", err)
+	}
+
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("the certificate is not valid before %s", cert.NotBefore.Format(time.RFC3339))
+	}
+
+	if now.Add(certExpirationThreshold).After(cert.NotAfter) {
+		return fmt.Errorf("the certificate expires at %s", cert.NotAfter.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// checkKSM checks that the kube-state-metrics Deployment can be discovered.
+func checkKSM(ctx context.Context, c client.Client, namespace string) Result {
+	result := Result{Name: "kube-state-metrics"}
+
+	target, err := discovery.FindTarget(ctx, c, namespace)
+	if err != nil {
+		result.Status, result.Message = StatusFail, err.Error()
+
+		return result
+	}
+
+	result.Status = StatusPass
+	result.Message = fmt.Sprintf(
+		"Deployment %s uses ConfigMap %s (key %s)",
+		utils.NamespacedName(target.Deployment, target.Namespace),
+		utils.NamespacedName(target.Name, target.Namespace),
+		target.Key)
+
+	return result
+}
+
+// checkTargets checks that the ConfigMaps the existing instances write into
+// are writable by a server-side dry-run update.
+func checkTargets(ctx context.Context, c client.Client) []Result {
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+
+	if err := c.List(ctx, instances); err != nil {
+		return []Result{{
+			Name:    "Target ConfigMaps",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("failed to list CustomResourceStateMetrics: %s", err),
+		}}
+	}
+
+	checked := make(map[types.NamespacedName]struct{})
+	results := []Result{}
+
+	for _, instance := range instances.Items {
+		target := instance.Status.ConfigMap
+		if target == nil || target.Name == "" {
+			continue
+		}
+
+		// Check every replicated ConfigMap
+		namespaces := target.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{target.Namespace}
+		}
+
+		for _, namespace := range namespaces {
+			key := types.NamespacedName{Name: target.Name, Namespace: namespace}
+			if _, ok := checked[key]; ok {
+				continue
+			}
+
+			checked[key] = struct{}{}
+
+			result := Result{Name: "ConfigMap " + utils.NamespacedName(key.Name, key.Namespace)}
+			cm := &corev1.ConfigMap{}
+
+			if err := c.Get(ctx, key, cm); err != nil {
+				result.Status, result.Message = StatusFail, fmt.Sprintf("failed to get: %s", err)
+			} else if err := c.Update(ctx, cm, client.DryRunAll); err != nil {
+				result.Status, result.Message = StatusFail, fmt.Sprintf("not writable: %s", err)
+			} else {
+				result.Status, result.Message = StatusPass, "writable"
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	if len(results) == 0 {
+		results = append(results, Result{
+			Name:    "Target ConfigMaps",
+			Status:  StatusSkip,
+			Message: "no CustomResourceStateMetrics with a resolved ConfigMap found",
+		})
+	}
+
+	return results
+}
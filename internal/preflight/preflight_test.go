@@ -0,0 +1,118 @@
+package preflight
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/gomega"
+)
+
+// writeCert writes a self-signed certificate valid until the time into the directory.
+func writeCert(t *testing.T, dir string, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "webhook"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	if err := os.WriteFile(filepath.Join(dir, "tls.crt"), certPem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "tls.key"), keyPem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckWebhookCerts(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+
+	validDir := t.TempDir()
+	writeCert(t, validDir, now.Add(90*24*time.Hour))
+
+	expiringDir := t.TempDir()
+	writeCert(t, expiringDir, now.Add(24*time.Hour))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected Status
+	}{
+		{name: "no-path", path: "", expected: StatusSkip},
+		{name: "missing", path: t.TempDir(), expected: StatusFail},
+		{name: "valid", path: validDir, expected: StatusPass},
+		{name: "expiring", path: expiringDir, expected: StatusFail},
+	}
+
+	for _, test := range tests {
+		result := checkWebhookCerts(Options{
+			WebhookCertPath: test.path,
+			WebhookCertName: "tls.crt",
+			WebhookCertKey:  "tls.key",
+		}, now)
+
+		g.Expect(result.Status).To(Equal(test.expected), "Test [%s]: %s", test.name, result.Message)
+	}
+}
+
+func TestCRDStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	crd := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"versions": []any{
+				map[string]any{"name": "v1", "served": true},
+				map[string]any{"name": "v2", "served": false},
+			},
+		},
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "Established", "status": "True"},
+			},
+		},
+	}}
+
+	g.Expect(crdEstablished(crd)).To(BeTrue(), "Test [established]:")
+	g.Expect(crdServed(crd, "v1")).To(BeTrue(), "Test [served]:")
+	g.Expect(crdServed(crd, "v2")).To(BeFalse(), "Test [not-served]:")
+	g.Expect(crdServed(crd, "v3")).To(BeFalse(), "Test [missing]:")
+}
+
+func TestFailed(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(Failed([]Result{{Status: StatusPass}, {Status: StatusSkip}})).To(BeFalse(), "Test [passed]:")
+	g.Expect(Failed([]Result{{Status: StatusPass}, {Status: StatusFail}})).To(BeTrue(), "Test [failed]:")
+}
@@ -0,0 +1,447 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timeout of the requests used if none is specified.
+const DefaultTimeout = 30 * time.Second
+
+// Maximum size of the fetched document (and of the OCI manifest).
+const maxDocumentSize = 4 * 1024 * 1024
+
+// Media types of the OCI image manifest accepted from the registry.
+const manifestMediaTypes = "application/vnd.oci.image.manifest.v1+json," +
+	"application/vnd.docker.distribution.manifest.v2+json"
+
+// ErrDigestMismatch is returned if the fetched content doesn't match the
+// pinned digest.
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+// ErrNotAllowed is returned if the location is not allowed to be fetched.
+var ErrNotAllowed = errors.New("not allowed")
+
+// Fetcher fetches the remote documents via HTTP or from OCI registries. The
+// fetched documents are cached per owner (e.g. the instance) so they are
+// fetched again only once the poll interval passes.
+type Fetcher struct {
+	Client *http.Client
+
+	// Prefixes of the URLs and of the OCI references allowed to be fetched
+	// (e.g. https://example.com/metrics/ or ghcr.io/myteam/). The scheme, the
+	// host and the registry must match exactly, only the paths are prefixes.
+	// Nothing can be fetched if empty.
+	Allowed []string
+
+	mu      sync.Mutex
+	fetched map[string]entry
+}
+
+// entry holds the fetched document.
+type entry struct {
+	key     string
+	content []byte
+	time    time.Time
+}
+
+// NewFetcher creates a new Fetcher with the request timeout and the allowed
+// prefixes. The redirects are followed only within the same host or to the
+// allowed URLs.
+func NewFetcher(timeout time.Duration, allowed []string) *Fetcher {
+	f := &Fetcher{
+		Allowed: allowed,
+		fetched: make(map[string]entry),
+	}
+
+	f.Client = &http.Client{
+		Timeout:       timeout,
+		CheckRedirect: f.checkRedirect,
+	}
+
+	return f
+}
+
+// allowedURL returns true if the URL is allowed by any of the allowed URL
+// prefixes. The scheme and the host (including the port) must match exactly
+// and the cleaned path must be within the path of the prefix so e.g.
+// https://example.com allows neither https://example.com.evil.io nor
+// https://example.com@evil.io.
+func (f *Fetcher) allowedURL(location string) bool {
+	u, err := url.Parse(location)
+	if err != nil || u.User != nil || u.Host == "" {
+		return false
+	}
+
+	for _, prefix := range f.Allowed {
+		if !strings.Contains(prefix, "://") {
+			continue
+		}
+
+		p, err := url.Parse(prefix)
+		if err != nil || p.User != nil || p.Host == "" {
+			continue
+		}
+
+		if u.Scheme == p.Scheme && strings.EqualFold(u.Host, p.Host) && withinPath(u.Path, p.Path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedReference returns true if the OCI reference is allowed by any of the
+// allowed OCI reference prefixes. The registry must match exactly and the
+// repository must be within the repository of the prefix so e.g.
+// ghcr.io/myteam doesn't allow ghcr.io/myteam-evil.
+func (f *Fetcher) allowedReference(ref string) bool {
+	r, err := parseReference(ref)
+	if err != nil {
+		return false
+	}
+
+	for _, prefix := range f.Allowed {
+		if prefix == "" || strings.Contains(prefix, "://") {
+			continue
+		}
+
+		registry, repository, _ := strings.Cut(prefix, "/")
+
+		if strings.EqualFold(r.registry, registry) && withinPath(r.repository, repository) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withinPath returns true if the cleaned path equals to the cleaned prefix or
+// if it's nested in it.
+func withinPath(location, prefix string) bool {
+	location = path.Clean("/" + location)
+	prefix = strings.TrimSuffix(path.Clean("/"+prefix), "/")
+
+	return location == prefix || strings.HasPrefix(location, prefix+"/")
+}
+
+// checkRedirect stops the redirects to the other hosts which are not allowed.
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	if req.URL.Host != via[0].URL.Host && !f.allowedURL(req.URL.String()) {
+		return fmt.Errorf("redirect to %s is %w", req.URL.Host, ErrNotAllowed)
+	}
+
+	return nil
+}
+
+// FetchURL returns the document served on the URL.
+func (f *Fetcher) FetchURL(ctx context.Context, owner, location string, interval time.Duration) ([]byte, error) {
+	if !f.allowedURL(location) {
+		return nil, fmt.Errorf("the URL %s is %w", location, ErrNotAllowed)
+	}
+
+	return f.cached(owner, "url:"+location, interval, func() ([]byte, error) {
+		content, _, err := f.get(ctx, location, "", "")
+
+		return content, err
+	})
+}
+
+// FetchOCI returns the content of the first layer of the OCI artifact.
+func (f *Fetcher) FetchOCI(ctx context.Context, owner, reference string, interval time.Duration) ([]byte, error) {
+	if !f.allowedReference(reference) {
+		return nil, fmt.Errorf("the OCI reference %s is %w", reference, ErrNotAllowed)
+	}
+
+	return f.cached(owner, "oci:"+reference, interval, func() ([]byte, error) {
+		return f.pull(ctx, reference)
+	})
+}
+
+// Forget drops the document cached for the owner.
+func (f *Fetcher) Forget(owner string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.fetched, owner)
+}
+
+// cached returns the document cached for the owner if it was fetched from the
+// same location within the interval or fetches it otherwise.
+func (f *Fetcher) cached(
+	owner, key string, interval time.Duration, fetch func() ([]byte, error)) ([]byte, error) {
+	f.mu.Lock()
+	e, ok := f.fetched[owner]
+	f.mu.Unlock()
+
+	if ok && e.key == key && time.Since(e.time) < interval {
+		return e.content, nil
+	}
+
+	content, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.fetched[owner] = entry{key: key, content: content, time: time.Now()}
+	f.mu.Unlock()
+
+	return content, nil
+}
+
+// VerifyDigest checks that the content matches the digest (sha256:<hex>).
+func VerifyDigest(content []byte, digest string) error {
+	if actual := Digest(content); actual != digest {
+		return fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, digest, actual)
+	}
+
+	return nil
+}
+
+// Digest returns the SHA-256 digest (sha256:<hex>) of the content.
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// reference is the parsed OCI artifact reference.
+type reference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseReference parses the OCI artifact reference in the
+// "registry/repository[:tag][@digest]" format.
+func parseReference(ref string) (reference, error) {
+	registry, rest, found := strings.Cut(ref, "/")
+	if !found || registry == "" || rest == "" {
+		return reference{}, fmt.Errorf("invalid OCI reference %q: missing registry or repository", ref)
+	}
+
+	r := reference{registry: registry, tag: "latest"}
+
+	if repository, digest, found := strings.Cut(rest, "@"); found {
+		rest = repository
+		r.digest = digest
+	}
+
+	// The tag follows the last colon of the last path segment
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		r.tag = rest[i+1:]
+		rest = rest[:i]
+	}
+
+	r.repository = rest
+
+	if r.repository == "" || r.tag == "" {
+		return reference{}, fmt.Errorf("invalid OCI reference %q", ref)
+	}
+
+	return r, nil
+}
+
+// manifest is the part of the OCI image manifest used to find the layer.
+type manifest struct {
+	Layers []struct {
+		Digest string `json:"digest"`
+	} `json:"layers"`
+}
+
+// pull returns the content of the first layer of the OCI artifact.
+func (f *Fetcher) pull(ctx context.Context, ref string) ([]byte, error) {
+	r, err := parseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	base := "https://" + r.registry + "/v2/" + r.repository
+
+	version := r.tag
+	if r.digest != "" {
+		version = r.digest
+	}
+
+	content, token, err := f.get(ctx, base+"/manifests/"+version, manifestMediaTypes, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the manifest: %w", err)
+	}
+
+	if r.digest != "" {
+		if err := VerifyDigest(content, r.digest); err != nil {
+			return nil, fmt.Errorf("failed to verify the manifest: %w", err)
+		}
+	}
+
+	m := manifest{}
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode the manifest: %w", err)
+	}
+
+	if len(m.Layers) == 0 {
+		return nil, fmt.Errorf("the artifact %s has no layers", ref)
+	}
+
+	layer := m.Layers[0].Digest
+
+	content, _, err = f.get(ctx, base+"/blobs/"+layer, "", token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the layer: %w", err)
+	}
+
+	if err := VerifyDigest(content, layer); err != nil {
+		return nil, fmt.Errorf("failed to verify the layer: %w", err)
+	}
+
+	return content, nil
+}
+
+// get sends the GET request and returns the response body together with the
+// bearer token used. If the registry requests the authentication, the
+// anonymous token is obtained from the same host (or from an allowed URL) and
+// the request is sent again.
+func (f *Fetcher) get(ctx context.Context, location, accept, token string) ([]byte, string, error) {
+	resp, err := f.send(ctx, location, accept, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+
+		if token, err = f.anonymousToken(ctx, resp.Request.URL, challenge); err != nil {
+			return nil, "", err
+		}
+
+		if resp, err = f.send(ctx, location, accept, token); err != nil {
+			return nil, "", err
+		}
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, location)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the response from %s: %w", location, err)
+	}
+
+	if len(content) > maxDocumentSize {
+		return nil, "", fmt.Errorf("the response from %s exceeds %d bytes", location, maxDocumentSize)
+	}
+
+	return content, token, nil
+}
+
+// send sends the GET request.
+func (f *Fetcher) send(ctx context.Context, location, accept, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	return resp, nil
+}
+
+// anonymousToken obtains the anonymous bearer token from the token service
+// described by the authentication challenge. The token service must be served
+// via HTTPS from the host of the request or from an allowed URL.
+func (f *Fetcher) anonymousToken(ctx context.Context, origin *url.URL, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	values := parseChallenge(params)
+
+	realm, err := url.Parse(values["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid authentication realm %q", values["realm"])
+	}
+
+	if realm.Scheme != "https" || realm.Host != origin.Host && !f.allowedURL(realm.String()) {
+		return "", fmt.Errorf("the authentication realm %s is %w", realm.Host, ErrNotAllowed)
+	}
+
+	query := realm.Query()
+
+	for _, name := range []string{"service", "scope"} {
+		if value, ok := values[name]; ok {
+			query.Set(name, value)
+		}
+	}
+
+	realm.RawQuery = query.Encode()
+
+	content, _, err := f.get(ctx, realm.String(), "", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to get the token: %w", err)
+	}
+
+	response := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+
+	if err := json.Unmarshal(content, &response); err != nil {
+		return "", fmt.Errorf("failed to decode the token: %w", err)
+	}
+
+	if response.Token != "" {
+		return response.Token, nil
+	}
+
+	return response.AccessToken, nil
+}
+
+// parseChallenge parses the comma-separated key="value" parameters of the
+// authentication challenge.
+func parseChallenge(params string) map[string]string {
+	values := make(map[string]string)
+
+	for _, param := range strings.Split(params, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found {
+			continue
+		}
+
+		values[key] = strings.Trim(value, `"`)
+	}
+
+	return values
+}
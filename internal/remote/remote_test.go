@@ -0,0 +1,255 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const document = `- groupVersionKind:
+    group: myteam.io
+    version: v1
+    kind: Foo
+`
+
+func TestFetchURL(t *testing.T) {
+	g := NewWithT(t)
+
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.URL.Path != "/resources.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(document))
+	}))
+	defer server.Close()
+
+	f := NewFetcher(DefaultTimeout, []string{server.URL + "/"})
+
+	content, err := f.FetchURL(context.Background(), "foo", server.URL+"/resources.yaml", time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal(document))
+
+	// Served from the cache within the interval
+	_, err = f.FetchURL(context.Background(), "foo", server.URL+"/resources.yaml", time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requests).To(Equal(1))
+
+	// Fetched again once the interval passes
+	_, err = f.FetchURL(context.Background(), "foo", server.URL+"/resources.yaml", 0)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requests).To(Equal(2))
+
+	// Fetched again once forgotten
+	f.Forget("foo")
+	_, err = f.FetchURL(context.Background(), "foo", server.URL+"/resources.yaml", time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requests).To(Equal(3))
+	g.Expect(f.fetched).To(HaveLen(1))
+
+	_, err = f.FetchURL(context.Background(), "foo", server.URL+"/missing.yaml", time.Hour)
+	g.Expect(err).To(MatchError(ContainSubstring("unexpected status code 404")))
+
+	// Nothing is requested from the locations which aren't allowed
+	_, err = f.FetchURL(context.Background(), "foo", "http://169.254.169.254/latest/meta-data/", 0)
+	g.Expect(err).To(MatchError(ErrNotAllowed))
+	g.Expect(requests).To(Equal(4))
+}
+
+func TestFetchOCI(t *testing.T) {
+	g := NewWithT(t)
+
+	layerDigest := Digest([]byte(document))
+	manifestContent, _ := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"layers": []map[string]any{
+			{"mediaType": "application/yaml", "digest": layerDigest, "size": len(document)},
+		},
+	})
+
+	var server *httptest.Server
+
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			g.Expect(r.URL.Query().Get("scope")).To(Equal("repository:metrics/foo:pull"))
+
+			_, _ = w.Write([]byte(`{"token": "anonymous"}`))
+
+			return
+		}
+
+		// Require the anonymous token
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate",
+				`Bearer realm="`+server.URL+`/token",service="registry",scope="repository:metrics/foo:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/metrics/foo/manifests/1.0.0", "/v2/metrics/foo/manifests/" + Digest(manifestContent):
+			_, _ = w.Write(manifestContent)
+		case "/v2/metrics/foo/blobs/" + layerDigest:
+			_, _ = w.Write([]byte(document))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "https://")
+	f := &Fetcher{Client: server.Client(), Allowed: []string{registry + "/metrics/"}, fetched: make(map[string]entry)}
+
+	content, err := f.FetchOCI(context.Background(), "foo", registry+"/metrics/foo:1.0.0", time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal(document))
+
+	content, err = f.FetchOCI(context.Background(), "foo", registry+"/metrics/foo@"+Digest(manifestContent), time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal(document))
+
+	_, err = f.FetchOCI(context.Background(), "foo", registry+"/metrics/foo:2.0.0", time.Hour)
+	g.Expect(err).To(MatchError(ContainSubstring("unexpected status code 404")))
+
+	_, err = f.FetchOCI(context.Background(), "foo", registry+"/other/foo:1.0.0", time.Hour)
+	g.Expect(err).To(MatchError(ErrNotAllowed))
+}
+
+func TestFetchOCIRealm(t *testing.T) {
+	g := NewWithT(t)
+
+	tokenRequests := 0
+
+	tokenServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		tokenRequests++
+
+		_, _ = w.Write([]byte(`{"token": "anonymous"}`))
+	}))
+	defer tokenServer.Close()
+
+	// The registry points the realm to another host
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+tokenServer.URL+`/token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "https://")
+	f := &Fetcher{Client: server.Client(), Allowed: []string{registry}, fetched: make(map[string]entry)}
+
+	_, err := f.FetchOCI(context.Background(), "foo", registry+"/metrics/foo:1.0.0", time.Hour)
+	g.Expect(err).To(MatchError(ErrNotAllowed))
+	g.Expect(tokenRequests).To(Equal(0))
+}
+
+func TestAllowed(t *testing.T) {
+	g := NewWithT(t)
+
+	f := &Fetcher{Allowed: []string{"https://example.com", "https://example.org/metrics/", "ghcr.io/myteam/"}}
+
+	urls := map[string]bool{
+		"https://example.com":                          true,
+		"https://example.com/metrics.yaml":             true,
+		"https://EXAMPLE.com/metrics.yaml":             true,
+		"https://example.com:8443/metrics.yaml":        false,
+		"https://example.com.evil.io/":                 false,
+		"https://example.com@evil.io/x":                false,
+		"https://example.com:x@evil.io/":               false,
+		"https://user@example.com/metrics.yaml":        false,
+		"http://example.com/metrics.yaml":              false,
+		"https://example.org/metrics/foo.yaml":         true,
+		"https://example.org/metrics":                  true,
+		"https://example.org/metrics-evil/foo.yaml":    false,
+		"https://example.org/metrics/../secret.yaml":   false,
+		"https://example.org/metrics/%2e%2e/secret":    false,
+		"https://example.org/other.yaml":               false,
+		"ghcr.io/myteam/metrics:1.0.0":                 false,
+		"https://ghcr.io/myteam/metrics/manifests/1.0": false,
+	}
+
+	for location, expected := range urls {
+		g.Expect(f.allowedURL(location)).To(Equal(expected), "Test [%s]:", location)
+	}
+
+	references := map[string]bool{
+		"ghcr.io/myteam/metrics:1.0.0":         true,
+		"ghcr.io/myteam/metrics@sha256:abc":    true,
+		"ghcr.io/myteam-evil/metrics:1.0.0":    false,
+		"ghcr.io/other/metrics:1.0.0":          false,
+		"ghcr.io@evil.io/myteam/metrics:1.0.0": false,
+		"ghcr.io:443/myteam/metrics:1.0.0":     false,
+		"ghcr.io/myteam/../other/metrics":      false,
+		"https://example.com/metrics.yaml":     false,
+	}
+
+	for ref, expected := range references {
+		g.Expect(f.allowedReference(ref)).To(Equal(expected), "Test [%s]:", ref)
+	}
+
+	g.Expect((&Fetcher{}).allowedURL("https://example.com")).To(BeFalse(), "Test [empty]:")
+}
+
+func TestVerifyDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(VerifyDigest([]byte(document), Digest([]byte(document)))).To(Succeed())
+
+	err := VerifyDigest([]byte(document), Digest([]byte("foo")))
+	g.Expect(errors.Is(err, ErrDigestMismatch)).To(BeTrue())
+}
+
+func TestParseReference(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		ref      string
+		expected reference
+		err      bool
+	}{
+		"tag": {
+			ref:      "registry.example.com/metrics/foo:1.0.0",
+			expected: reference{registry: "registry.example.com", repository: "metrics/foo", tag: "1.0.0"},
+		},
+		"default_tag": {
+			ref:      "registry.example.com:5000/foo",
+			expected: reference{registry: "registry.example.com:5000", repository: "foo", tag: "latest"},
+		},
+		"digest": {
+			ref: "registry.example.com/foo@sha256:abc",
+			expected: reference{
+				registry: "registry.example.com", repository: "foo", tag: "latest", digest: "sha256:abc",
+			},
+		},
+		"no_repository": {
+			ref: "registry.example.com",
+			err: true,
+		},
+	}
+
+	for name, test := range tests {
+		r, err := parseReference(test.ref)
+
+		if test.err {
+			g.Expect(err).To(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(r).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
@@ -0,0 +1,117 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Name of the Pod template annotation holding the checksum of the config.
+const ChecksumAnnotation = "ksm.jtyr.io/config-checksum"
+
+// Logger definition with a prefix.
+var log = ctrl.Log.WithName("[rollout]")
+
+// Restarter restarts Deployments by setting the checksum of the config on
+// their Pod template. Restarts can be either done immediately or batched so
+// multiple changes within the window result in a single restart.
+type Restarter struct {
+	Client client.Client
+	Window time.Duration
+
+	mu      sync.Mutex
+	pending map[types.NamespacedName]string
+}
+
+// NewRestarter creates a new Restarter.
+func NewRestarter(c client.Client, window time.Duration) *Restarter {
+	return &Restarter{
+		Client:  c,
+		Window:  window,
+		pending: make(map[types.NamespacedName]string),
+	}
+}
+
+// NeedLeaderElection makes the restarter flush only on the leader.
+func (r *Restarter) NeedLeaderElection() bool {
+	return true
+}
+
+// Start flushes the batched restarts in the window interval until the
+// context is canceled.
+func (r *Restarter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			r.flush(ctx)
+		}
+	}
+}
+
+// Schedule batches the restart of the Deployment. Only the latest checksum
+// is applied if the restart is scheduled multiple times within the window.
+func (r *Restarter) Schedule(deployment types.NamespacedName, checksum string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending[deployment] = checksum
+}
+
+// Restart restarts the Deployment immediately unless the Pod template
+// already has the checksum.
+func (r *Restarter) Restart(ctx context.Context, deployment types.NamespacedName, checksum string) error {
+	// Drop the batched restart as it's superseded
+	r.mu.Lock()
+	delete(r.pending, deployment)
+	r.mu.Unlock()
+
+	d := &appsv1.Deployment{}
+	if err := r.Client.Get(ctx, deployment, d); err != nil {
+		return fmt.Errorf("failed to get Deployment: %w", err)
+	}
+
+	if d.Spec.Template.Annotations[ChecksumAnnotation] == checksum {
+		return nil
+	}
+
+	patch := client.MergeFrom(d.DeepCopy())
+
+	if d.Spec.Template.Annotations == nil {
+		d.Spec.Template.Annotations = make(map[string]string)
+	}
+
+	d.Spec.Template.Annotations[ChecksumAnnotation] = checksum
+
+	if err := r.Client.Patch(ctx, d, patch); err != nil {
+		return fmt.Errorf("failed to patch Deployment: %w", err)
+	}
+
+	log.Info("Restarted Deployment", "deployment", deployment.String(), "checksum", checksum)
+
+	return nil
+}
+
+// flush restarts all Deployments with batched restarts.
+func (r *Restarter) flush(ctx context.Context) {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[types.NamespacedName]string)
+	r.mu.Unlock()
+
+	for deployment, checksum := range pending {
+		if err := r.Restart(ctx, deployment, checksum); err != nil {
+			log.Error(err, "Failed to restart Deployment", "deployment", deployment.String())
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package rollout
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSchedule(t *testing.T) {
+	// Initiate Gomega
+	g := NewWithT(t)
+
+	r := NewRestarter(nil, time.Minute)
+	deployment := types.NamespacedName{Name: "kube-state-metrics", Namespace: "monitoring"}
+
+	// Test coalescing of multiple restarts of the same Deployment
+	r.Schedule(deployment, "foo")
+	r.Schedule(deployment, "bar")
+	g.Expect(r.pending).To(HaveLen(1), "Test pending count:")
+	g.Expect(r.pending[deployment]).To(Equal("bar"), "Test pending checksum:")
+}
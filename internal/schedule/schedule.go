@@ -0,0 +1,164 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Maximum time searched for the next window start.
+const maxLookahead = 7 * 24 * time.Hour
+
+// Bounds of the individual cron fields.
+var fieldBounds = [5][2]int{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week
+}
+
+// Schedule is a parsed cron expression (minute hour day-of-month month
+// day-of-week) supporting lists, ranges, steps and wildcards.
+type Schedule struct {
+	fields [5]map[int]struct{}
+
+	// Whether the day fields were restricted (standard cron semantics apply
+	// OR if both are restricted)
+	domRestricted bool
+	dowRestricted bool
+}
+
+// Window is a time window starting at the times of the schedule.
+type Window struct {
+	Schedule *Schedule
+	Duration time.Duration
+	Location *time.Location
+}
+
+// Parse parses the cron expression.
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fieldBounds) {
+		return nil, fmt.Errorf("expected %d fields in the cron expression %q, got %d", len(fieldBounds), expr, len(parts))
+	}
+
+	s := &Schedule{
+		domRestricted: parts[2] != "*",
+		dowRestricted: parts[4] != "*",
+	}
+
+	for i, part := range parts {
+		values, err := parseField(part, fieldBounds[i][0], fieldBounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the field %q of the cron expression %q: %w", part, expr, err)
+		}
+
+		s.fields[i] = values
+	}
+
+	return s, nil
+}
+
+// parseField parses a single cron field.
+func parseField(field string, minValue, maxValue int) (map[int]struct{}, error) {
+	values := make(map[int]struct{})
+
+	for _, item := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+
+		step := 1
+
+		if hasStep {
+			var err error
+
+			if step, err = strconv.Atoi(stepStr); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step %q", stepStr)
+			}
+		}
+
+		start, end := minValue, maxValue
+
+		if rng != "*" {
+			startStr, endStr, isRange := strings.Cut(rng, "-")
+
+			var err error
+
+			if start, err = strconv.Atoi(startStr); err != nil {
+				return nil, fmt.Errorf("invalid value %q", startStr)
+			}
+
+			end = start
+
+			if isRange {
+				if end, err = strconv.Atoi(endStr); err != nil {
+					return nil, fmt.Errorf("invalid value %q", endStr)
+				}
+			} else if hasStep {
+				end = maxValue
+			}
+		}
+
+		if start < minValue || end > maxValue || start > end {
+			return nil, fmt.Errorf("value %q out of range %d-%d", rng, minValue, maxValue)
+		}
+
+		for v := start; v <= end; v += step {
+			values[v] = struct{}{}
+		}
+	}
+
+	return values, nil
+}
+
+// Matches returns true if the schedule fires at the minute of the time.
+func (s *Schedule) Matches(t time.Time) bool {
+	if !s.has(0, t.Minute()) || !s.has(1, t.Hour()) || !s.has(3, int(t.Month())) {
+		return false
+	}
+
+	dom := s.has(2, t.Day())
+	dow := s.has(4, int(t.Weekday()))
+
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// has returns true if the field contains the value.
+func (s *Schedule) has(field, value int) bool {
+	_, ok := s.fields[field][value]
+
+	return ok
+}
+
+// Open returns true if the time falls into the window.
+func (w *Window) Open(now time.Time) bool {
+	now = now.In(w.Location).Truncate(time.Minute)
+
+	for t := now; !t.Before(now.Add(-w.Duration)); t = t.Add(-time.Minute) {
+		// The window end is exclusive
+		if w.Schedule.Matches(t) && now.Before(t.Add(w.Duration)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Next returns the start of the next window. It returns false if there is
+// none within the next 7 days.
+func (w *Window) Next(now time.Time) (time.Time, bool) {
+	now = now.In(w.Location).Truncate(time.Minute)
+
+	for t := now.Add(time.Minute); t.Before(now.Add(maxLookahead)); t = t.Add(time.Minute) {
+		if w.Schedule.Matches(t) {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,71 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParse(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name string
+		expr string
+		err  bool
+	}{
+		{name: "wildcards", expr: "* * * * *"},
+		{name: "business-hours", expr: "0 9 * * 1-5"},
+		{name: "lists-and-steps", expr: "0,30 */2 1-15/7 * 0"},
+		{name: "too-few-fields", expr: "* * * *", err: true},
+		{name: "out-of-range", expr: "60 * * * *", err: true},
+		{name: "invalid-step", expr: "*/0 * * * *", err: true},
+		{name: "invalid-value", expr: "foo * * * *", err: true},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.expr)
+
+		if test.err {
+			g.Expect(err).To(HaveOccurred(), "Test [%s]:", test.name)
+		} else {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", test.name)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	g := NewWithT(t)
+
+	// Working days from 9:00 to 17:00
+	s, err := Parse("0 9 * * 1-5")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	w := &Window{
+		Schedule: s,
+		Duration: 8 * time.Hour, //nolint:mnd
+		Location: time.UTC,
+	}
+
+	// 2025-01-06 is a Monday
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected bool
+	}{
+		{name: "before", now: time.Date(2025, 1, 6, 8, 59, 0, 0, time.UTC), expected: false},
+		{name: "start", now: time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC), expected: true},
+		{name: "inside", now: time.Date(2025, 1, 6, 13, 30, 0, 0, time.UTC), expected: true},
+		{name: "end", now: time.Date(2025, 1, 6, 17, 0, 0, 0, time.UTC), expected: false},
+		{name: "weekend", now: time.Date(2025, 1, 5, 13, 30, 0, 0, time.UTC), expected: false},
+	}
+
+	for _, test := range tests {
+		g.Expect(w.Open(test.now)).To(Equal(test.expected), "Test [%s]:", test.name)
+	}
+
+	next, found := w.Next(time.Date(2025, 1, 4, 12, 0, 0, 0, time.UTC))
+	g.Expect(found).To(BeTrue(), "Test [next]:")
+	g.Expect(next).To(Equal(time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)), "Test [next]:")
+}
@@ -0,0 +1,171 @@
+// Package schema validates the manifests against the OpenAPI schemas of the
+// CustomResourceDefinitions the same way the API server does.
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+)
+
+// Extension keeping the fields not defined by the schema.
+const preserveUnknownFields = "x-kubernetes-preserve-unknown-fields"
+
+// Size of the buffer used to detect whether the files are YAML or JSON.
+const decoderBufferSize = 4096
+
+// customResourceDefinition holds the parts of the CustomResourceDefinition
+// the schemas are read from.
+type customResourceDefinition struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind string `json:"kind"`
+		} `json:"names"`
+		Versions []struct {
+			Name   string `json:"name"`
+			Schema struct {
+				OpenAPIV3Schema *spec.Schema `json:"openAPIV3Schema"`
+			} `json:"schema"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+// Validator validates the objects against the schemas of the
+// CustomResourceDefinitions.
+type Validator struct {
+	schemas map[schema.GroupVersionKind]*spec.Schema
+}
+
+// New loads the schemas of all versions of the CustomResourceDefinitions
+// found in the YAML files of the filesystem.
+func New(crds fs.FS) (*Validator, error) {
+	v := &Validator{
+		schemas: make(map[schema.GroupVersionKind]*spec.Schema),
+	}
+
+	err := fs.WalkDir(crds, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Ext(name) != ".yaml" {
+			return err
+		}
+
+		content, err := fs.ReadFile(crds, name)
+		if err != nil {
+			return err
+		}
+
+		decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), decoderBufferSize)
+
+		for {
+			crd := customResourceDefinition{}
+
+			if err := decoder.Decode(&crd); errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("failed to decode %s: %w", name, err)
+			}
+
+			if crd.Kind != "CustomResourceDefinition" {
+				continue
+			}
+
+			for _, version := range crd.Spec.Versions {
+				if version.Schema.OpenAPIV3Schema == nil {
+					continue
+				}
+
+				gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+				v.schemas[gvk] = version.Schema.OpenAPIV3Schema
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Validate returns the schema violations of the object including the fields
+// not defined by the schema. It returns false if there is no schema for the
+// kind of the object.
+func (v *Validator) Validate(obj map[string]interface{}) ([]error, bool) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return []error{err}, true
+	}
+
+	s, ok := v.schemas[gv.WithKind(kind)]
+	if !ok {
+		return nil, false
+	}
+
+	errs := validate.NewSchemaValidator(s, nil, "", strfmt.Default).Validate(obj).Errors
+	errs = append(errs, unknownFields("", obj, s)...)
+
+	return errs, true
+}
+
+// unknownFields returns the errors for the fields of the value not defined by
+// the schema. The objects without any properties defined (e.g. the metadata)
+// are not checked.
+func unknownFields(fieldPath string, value interface{}, s *spec.Schema) []error {
+	if s == nil {
+		return nil
+	}
+
+	if preserve, _ := s.Extensions.GetBool(preserveUnknownFields); preserve {
+		return nil
+	}
+
+	errs := []error{}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		names := make([]string, 0, len(typed))
+		for name := range typed {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			childPath := name
+			if fieldPath != "" {
+				childPath = fieldPath + "." + name
+			}
+
+			if property, ok := s.Properties[name]; ok {
+				errs = append(errs, unknownFields(childPath, typed[name], &property)...)
+			} else if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+				errs = append(errs, unknownFields(childPath, typed[name], s.AdditionalProperties.Schema)...)
+			} else if len(s.Properties) > 0 {
+				errs = append(errs, fmt.Errorf("%s: unknown field", childPath))
+			}
+		}
+	case []interface{}:
+		if s.Items == nil || s.Items.Schema == nil {
+			return nil
+		}
+
+		for i, item := range typed {
+			errs = append(errs, unknownFields(fmt.Sprintf("%s[%d]", fieldPath, i), item, s.Items.Schema)...)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+)
+
+const fooCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: foos.example.com
+spec:
+  group: example.com
+  names:
+    kind: Foo
+  versions:
+    - name: v1
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            apiVersion:
+              type: string
+            kind:
+              type: string
+            metadata:
+              type: object
+            spec:
+              type: object
+              required:
+                - size
+              properties:
+                size:
+                  type: integer
+                  minimum: 1
+                policy:
+                  type: string
+                  enum:
+                    - Delete
+                    - Retain
+                labels:
+                  type: object
+                  additionalProperties:
+                    type: string
+                raw:
+                  type: object
+                  x-kubernetes-preserve-unknown-fields: true
+`
+
+func TestValidate(t *testing.T) {
+	g := NewWithT(t)
+
+	v, err := New(fstest.MapFS{"bases/foo.yaml": {Data: []byte(fooCRD)}})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	newFoo := func(spec map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Foo",
+			"metadata":   map[string]interface{}{"name": "foo", "namespace": "default"},
+			"spec":       spec,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		obj     map[string]interface{}
+		known   bool
+		invalid bool
+	}{
+		{
+			name: "valid",
+			obj: newFoo(map[string]interface{}{
+				"size":   float64(3),
+				"policy": "Retain",
+				"labels": map[string]interface{}{"team": "foo"},
+				"raw":    map[string]interface{}{"anything": true},
+			}),
+			known: true,
+		},
+		{
+			name:    "missing-required",
+			obj:     newFoo(map[string]interface{}{}),
+			known:   true,
+			invalid: true,
+		},
+		{
+			name:    "out-of-range",
+			obj:     newFoo(map[string]interface{}{"size": float64(0)}),
+			known:   true,
+			invalid: true,
+		},
+		{
+			name:    "invalid-enum",
+			obj:     newFoo(map[string]interface{}{"size": float64(1), "policy": "Keep"}),
+			known:   true,
+			invalid: true,
+		},
+		{
+			name:    "unknown-field",
+			obj:     newFoo(map[string]interface{}{"size": float64(1), "sise": float64(1)}),
+			known:   true,
+			invalid: true,
+		},
+		{
+			name: "unknown-kind",
+			obj:  map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"},
+		},
+	}
+
+	for _, test := range tests {
+		errs, known := v.Validate(test.obj)
+
+		g.Expect(known).To(Equal(test.known), "Test [%s]:", test.name)
+
+		if test.invalid {
+			g.Expect(errs).NotTo(BeEmpty(), "Test [%s]:", test.name)
+		} else {
+			g.Expect(errs).To(BeEmpty(), "Test [%s]:", test.name)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/verifier"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+// Names used for the synthetic resources.
+const (
+	group        = "selftest.ksm.jtyr.io"
+	version      = "v1"
+	kind         = "SelfTest"
+	plural       = "selftests"
+	name         = "crsm-selftest"
+	metricPrefix = "crsm_selftest"
+	metricName   = "value"
+)
+
+// Interval in which the conditions are polled.
+const pollInterval = 2 * time.Second
+
+// Logger definition with a prefix.
+var log = ctrl.Log.WithName("[selftest]")
+
+// GroupVersionKind of the CustomResourceDefinition.
+var crdGVK = schema.GroupVersionKind{
+	Group:   "apiextensions.k8s.io",
+	Version: "v1",
+	Kind:    "CustomResourceDefinition",
+}
+
+// Options of the self-test.
+type Options struct {
+	// Namespace where the synthetic resources are created.
+	Namespace string
+
+	// ConfigMap the CustomResourceStateMetrics instance writes into. If
+	// empty, the operator resolves the ConfigMap itself.
+	ConfigMap ksmv1.CustomResourceStateMetricsConfigMap
+
+	// URL of the kube-state-metrics metrics endpoint.
+	URL string
+
+	// How long to wait for the metric to appear.
+	Timeout time.Duration
+}
+
+// Run creates a synthetic CustomResourceDefinition, a custom resource and a
+// CustomResourceStateMetrics instance, waits for the metric to appear in the
+// kube-state-metrics output and cleans up afterwards.
+func Run(ctx context.Context, c client.Client, opts Options) (err error) {
+	crd := newCRD()
+	cr := newCR(opts.Namespace)
+	instance := newInstance(opts.Namespace, opts.ConfigMap)
+
+	// Clean up everything regardless of the result
+	defer func() {
+		err = errors.Join(err, cleanup(ctx, c, instance, cr, crd, opts.Timeout))
+	}()
+
+	log.Info("Creating CustomResourceDefinition", "name", crd.GetName())
+
+	if err := c.Create(ctx, crd); err != nil {
+		return fmt.Errorf("failed to create CustomResourceDefinition: %w", err)
+	}
+
+	if err := waitForEstablished(ctx, c, crd, opts.Timeout); err != nil {
+		return err
+	}
+
+	log.Info("Creating custom resource", "name", name, "namespace", opts.Namespace)
+
+	if err := c.Create(ctx, cr); err != nil {
+		return fmt.Errorf("failed to create custom resource: %w", err)
+	}
+
+	log.Info("Creating CustomResourceStateMetrics", "name", name, "namespace", opts.Namespace)
+
+	if err := c.Create(ctx, instance); err != nil {
+		return fmt.Errorf("failed to create CustomResourceStateMetrics: %w", err)
+	}
+
+	fullName := metricPrefix + "_" + metricName
+
+	log.Info("Waiting for the metric to appear in kube-state-metrics", "metric", fullName, "url", opts.URL)
+
+	if err := wait.PollUntilContextTimeout(ctx, pollInterval, opts.Timeout, true,
+		func(ctx context.Context) (bool, error) {
+			series, err := verifier.ScrapeSeries(ctx, nil, opts.URL)
+			if err != nil {
+				log.V(1).Info("Failed to scrape kube-state-metrics", "error", err.Error())
+
+				return false, nil
+			}
+
+			_, found := series[fullName]
+
+			return found, nil
+		}); err != nil {
+		return fmt.Errorf(
+			"metric %s didn't appear in kube-state-metrics (check its RBAC for the %s group): %w",
+			fullName, group, err)
+	}
+
+	log.Info("Self-test passed", "metric", fullName)
+
+	return nil
+}
+
+// newCRD creates the synthetic CustomResourceDefinition.
+func newCRD() *unstructured.Unstructured {
+	crd := &unstructured.Unstructured{
+		Object: map[string]any{
+			"spec": map[string]any{
+				"group": group,
+				"names": map[string]any{
+					"kind":     kind,
+					"listKind": kind + "List",
+					"plural":   plural,
+					"singular": "selftest",
+				},
+				"scope": "Namespaced",
+				"versions": []any{
+					map[string]any{
+						"name":    version,
+						"served":  true,
+						"storage": true,
+						"schema": map[string]any{
+							"openAPIV3Schema": map[string]any{
+								"type":                                 "object",
+								"x-kubernetes-preserve-unknown-fields": true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	crd.SetGroupVersionKind(crdGVK)
+	crd.SetName(plural + "." + group)
+
+	return crd
+}
+
+// newCR creates the synthetic custom resource.
+func newCR(namespace string) *unstructured.Unstructured {
+	cr := &unstructured.Unstructured{
+		Object: map[string]any{
+			"spec": map[string]any{
+				"value": int64(42), //nolint:mnd
+			},
+		},
+	}
+
+	cr.SetGroupVersionKind(schema.GroupVersionKind{Group: group, Version: version, Kind: kind})
+	cr.SetName(name)
+	cr.SetNamespace(namespace)
+
+	return cr
+}
+
+// newInstance creates the CustomResourceStateMetrics instance generating a
+// metric for the synthetic custom resource.
+func newInstance(
+	namespace string, configMap ksmv1.CustomResourceStateMetricsConfigMap) *ksmv1.CustomResourceStateMetrics {
+	prefix := metricPrefix
+
+	return &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: ksmv1.CustomResourceStateMetricsSpec{
+			ConfigMap: configMap,
+			Resources: []ksm.Resource{{
+				GroupVersionKind: ksm.GroupVersionKind{Group: group, Version: version, Kind: kind},
+				MetricNamePrefix: &prefix,
+				Metrics: []ksm.Metric{{
+					Name: metricName,
+					Help: "Value of the CRSM Operator self-test resource.",
+					Each: ksm.Each{
+						Type:  ksm.MetricTypeGauge,
+						Gauge: &ksm.Gauge{Path: []string{"spec", "value"}},
+					},
+				}},
+			}},
+		},
+	}
+}
+
+// waitForEstablished waits until the CustomResourceDefinition is established.
+func waitForEstablished(
+	ctx context.Context, c client.Client, crd *unstructured.Unstructured, timeout time.Duration) error {
+	if err := wait.PollUntilContextTimeout(ctx, pollInterval, timeout, true,
+		func(ctx context.Context) (bool, error) {
+			current := &unstructured.Unstructured{}
+			current.SetGroupVersionKind(crdGVK)
+
+			if err := c.Get(ctx, client.ObjectKeyFromObject(crd), current); err != nil {
+				return false, client.IgnoreNotFound(err)
+			}
+
+			conditions, _, _ := unstructured.NestedSlice(current.Object, "status", "conditions")
+
+			for _, condition := range conditions {
+				fields, ok := condition.(map[string]any)
+
+				if ok && fields["type"] == "Established" && fields["status"] == "True" {
+					return true, nil
+				}
+			}
+
+			return false, nil
+		}); err != nil {
+		return fmt.Errorf("CustomResourceDefinition wasn't established: %w", err)
+	}
+
+	return nil
+}
+
+// cleanup deletes the synthetic resources and waits for the
+// CustomResourceStateMetrics instance to be gone so its block is removed
+// from the ConfigMap.
+func cleanup(ctx context.Context, c client.Client, instance, cr, crd client.Object, timeout time.Duration) error {
+	var errs []error
+
+	log.Info("Cleaning up")
+
+	for _, obj := range []client.Object{instance, cr, crd} {
+		if err := c.Delete(ctx, obj); client.IgnoreNotFound(err) != nil && !meta.IsNoMatchError(err) {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", obj.GetName(), err))
+		}
+	}
+
+	if err := wait.PollUntilContextTimeout(ctx, pollInterval, timeout, true,
+		func(ctx context.Context) (bool, error) {
+			err := c.Get(ctx, client.ObjectKeyFromObject(instance), &ksmv1.CustomResourceStateMetrics{})
+
+			return apierrors.IsNotFound(err), nil
+		}); err != nil {
+		errs = append(errs, fmt.Errorf("CustomResourceStateMetrics wasn't deleted: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
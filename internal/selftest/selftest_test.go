@@ -0,0 +1,105 @@
+package selftest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+func TestNewInstance(t *testing.T) {
+	g := NewWithT(t)
+
+	instance := newInstance("default", ksmv1.CustomResourceStateMetricsConfigMap{Name: "ksm"})
+	cr := newCR("default")
+
+	g.Expect(instance.Spec.ConfigMap.Name).To(Equal("ksm"))
+	g.Expect(ksm.MetricNames(instance.Spec.Resources)).To(Equal([]string{metricPrefix + "_" + metricName}))
+
+	gvk := instance.Spec.Resources[0].GroupVersionKind
+	g.Expect(schema.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}).
+		To(Equal(cr.GroupVersionKind()))
+	g.Expect(newCRD().GetName()).To(Equal(plural + "." + cr.GroupVersionKind().Group))
+}
+
+func TestRun(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		metrics string
+		fails   bool
+	}{
+		"found":   {metrics: metricPrefix + "_" + metricName + `{name="crsm-selftest"} 42` + "\n"},
+		"missing": {metrics: "kube_customresource_other 1\n", fails: true},
+	}
+
+	for name, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(test.metrics))
+		}))
+
+		c := newFakeClient(g)
+
+		err := Run(context.Background(), c, Options{Namespace: "default", URL: server.URL, Timeout: 100 * time.Millisecond})
+
+		server.Close()
+
+		if test.fails {
+			g.Expect(err).To(MatchError(ContainSubstring("didn't appear")), "Test [%s]:", name)
+		} else {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		}
+
+		// Cleaned up regardless of the result
+		instance := newInstance("default", ksmv1.CustomResourceStateMetricsConfigMap{})
+
+		for _, obj := range []client.Object{instance, newCR("default"), newCRD()} {
+			err := c.Get(context.Background(), client.ObjectKeyFromObject(obj), obj)
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Test [%s]: %s", name, obj.GetName())
+		}
+	}
+}
+
+// newFakeClient returns the client knowing the synthetic resources which marks
+// the created CustomResourceDefinition as established.
+func newFakeClient(g *WithT) client.Client {
+	scheme := runtime.NewScheme()
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(crdGVK, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: group, Version: version, Kind: kind}, meta.RESTScopeNamespace)
+	mapper.Add(ksmv1.GroupVersion.WithKind("CustomResourceStateMetrics"), meta.RESTScopeNamespace)
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(mapper).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if crd, ok := obj.(*unstructured.Unstructured); ok && crd.GroupVersionKind() == crdGVK {
+					conditions := []any{map[string]any{"type": "Established", "status": "True"}}
+
+					if err := unstructured.SetNestedSlice(crd.Object, conditions, "status", "conditions"); err != nil {
+						return err
+					}
+				}
+
+				return c.Create(ctx, obj, opts...)
+			},
+		}).
+		Build()
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Matches the variables (e.g. ${metadata.name}) including the escaped ones
+// (e.g. $${metadata.name}).
+var variableRegexp = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+// Matches the label lookup variable (e.g. metadata.labels['team']).
+var labelRegexp = regexp.MustCompile(`^metadata\.labels\['([^']+)'\]$`)
+
+// Interpolate replaces the variables in the content by the values resolved
+// against the object. The supported variables are ${metadata.name},
+// ${metadata.namespace} and ${metadata.labels['<key>']}. The escaped variable
+// ($${...}) is replaced by its literal value (${...}). It's an error if the
+// variable is unknown or if the label doesn't exist.
+func Interpolate(content string, obj metav1.Object) (string, error) {
+	var errs []string
+
+	result := variableRegexp.ReplaceAllStringFunc(content, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+
+		value, err := resolveVariable(variableRegexp.FindStringSubmatch(match)[1], obj)
+		if err != nil {
+			errs = append(errs, err.Error())
+
+			return match
+		}
+
+		return value
+	})
+
+	if len(errs) > 0 {
+		return "", fmt.Errorf("failed to interpolate the variables: %s", strings.Join(errs, ", "))
+	}
+
+	return result, nil
+}
+
+// resolveVariable returns the value of the variable of the object.
+func resolveVariable(variable string, obj metav1.Object) (string, error) {
+	variable = strings.TrimSpace(variable)
+
+	switch variable {
+	case "metadata.name":
+		return obj.GetName(), nil
+	case "metadata.namespace":
+		return obj.GetNamespace(), nil
+	}
+
+	if m := labelRegexp.FindStringSubmatch(variable); m != nil {
+		value, ok := obj.GetLabels()[m[1]]
+		if !ok {
+			return "", fmt.Errorf("label %q doesn't exist", m[1])
+		}
+
+		return value, nil
+	}
+
+	return "", fmt.Errorf("unknown variable %q", variable)
+}
@@ -51,6 +51,34 @@ func LabelsChangedPredicate() predicate.Funcs {
 	}
 }
 
+// AnnotationsChangedPredicate defines custom predicate to reconcile only if
+// any of the specified resource annotations changed.
+func AnnotationsChangedPredicate(names ...string) predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldAnnotations := e.ObjectOld.GetAnnotations()
+			newAnnotations := e.ObjectNew.GetAnnotations()
+
+			for _, name := range names {
+				if oldAnnotations[name] != newAnnotations[name] {
+					return true
+				}
+			}
+
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
 // FinalizersChangedPredicate defines custom predicate to reconcile only if resource finalizers changed.
 func FinalizersChangedPredicate() predicate.Funcs {
 	return predicate.Funcs{
@@ -74,7 +102,7 @@ func FinalizersChangedPredicate() predicate.Funcs {
 
 // NamespaceLabelSelectorPredicate defines custom predicate to reconcile only
 // resources within Namespaces with matching labels.
-func NamespaceLabelSelectorPredicate(client client.Client, selector labels.Selector) predicate.Funcs {
+func NamespaceLabelSelectorPredicate(client client.Reader, selector labels.Selector) predicate.Funcs {
 	return predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
 			return namespaceMatches(client, selector, e.Object.GetNamespace())
@@ -91,8 +119,15 @@ func NamespaceLabelSelectorPredicate(client client.Client, selector labels.Selec
 	}
 }
 
-// namespaceMatches checks if the Namespace selector matches the Namespace labels.
-func namespaceMatches(client client.Client, selector labels.Selector, namespace string) bool {
+// namespaceMatches checks if the Namespace selector matches the Namespace
+// labels. The Namespace is read from the client which should be backed by the
+// cache so no request is sent to the API server for each event.
+func namespaceMatches(client client.Reader, selector labels.Selector, namespace string) bool {
+	// No need to look up the Namespace if the selector matches everything
+	if selector.Empty() {
+		return true
+	}
+
 	var ns corev1.Namespace
 
 	err := client.Get(context.Background(), types.NamespacedName{Name: namespace, Namespace: ""}, &ns)
@@ -103,3 +138,68 @@ func namespaceMatches(client client.Client, selector labels.Selector, namespace
 
 	return selector.Matches(labels.Set(ns.GetLabels()))
 }
+
+// ConfigMapDataChangedPredicate defines custom predicate to reconcile only if
+// the ConfigMap data or binary data changed.
+func ConfigMapDataChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldConfigMap, okOld := e.ObjectOld.(*corev1.ConfigMap)
+			newConfigMap, okNew := e.ObjectNew.(*corev1.ConfigMap)
+
+			if !okOld || !okNew {
+				return false
+			}
+
+			return !reflect.DeepEqual(oldConfigMap.Data, newConfigMap.Data) ||
+				!reflect.DeepEqual(oldConfigMap.BinaryData, newConfigMap.BinaryData)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// CreatedPredicate defines custom predicate to reconcile only if the resource
+// was created.
+func CreatedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// DeletedPredicate defines custom predicate to reconcile only if the resource
+// was deleted.
+func DeletedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
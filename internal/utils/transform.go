@@ -0,0 +1,40 @@
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// TransformStripManagedFields returns a cache transform function dropping the
+// managedFields from the cached objects. The field is never used by the
+// operator and it's safe to drop it also from the objects that get updated as
+// the API server keeps the existing managedFields if none are sent.
+func TransformStripManagedFields() toolscache.TransformFunc {
+	return func(obj any) (any, error) {
+		if accessor, err := meta.Accessor(obj); err == nil {
+			accessor.SetManagedFields(nil)
+		}
+
+		return obj, nil
+	}
+}
+
+// TransformStripMetadata returns a cache transform function dropping the
+// managedFields and the last-applied annotation from the cached objects. It
+// must be used only for objects the operator never updates as the annotation
+// would be removed by the update.
+func TransformStripMetadata() toolscache.TransformFunc {
+	return func(obj any) (any, error) {
+		if accessor, err := meta.Accessor(obj); err == nil {
+			accessor.SetManagedFields(nil)
+
+			if annotations := accessor.GetAnnotations(); annotations != nil {
+				delete(annotations, corev1.LastAppliedConfigAnnotation)
+				accessor.SetAnnotations(annotations)
+			}
+		}
+
+		return obj, nil
+	}
+}
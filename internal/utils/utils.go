@@ -1,9 +1,21 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 )
 
+// Number of characters of the hash used to identify content.
+const hashLength = 12
+
 func NamespacedName(name, namespace string) string {
 	return fmt.Sprintf("%s@%s", name, namespace)
 }
+
+// Hash returns a short SHA256 based hash of the data.
+func Hash(data string) string {
+	sum := sha256.Sum256([]byte(data))
+
+	return hex.EncodeToString(sum[:])[:hashLength]
+}
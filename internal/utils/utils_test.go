@@ -2,6 +2,11 @@ package utils
 
 import (
 	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 func TestNamespacedName(t *testing.T) {
@@ -12,3 +17,122 @@ func TestNamespacedName(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, result)
 	}
 }
+
+func TestHash(t *testing.T) {
+	result := Hash("foo")
+	expected := "2c26b46b68ff"
+
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	if Hash("foo") == Hash("bar") {
+		t.Errorf("Expected different hashes for different data")
+	}
+}
+
+func TestTransformStripMetadata(t *testing.T) {
+	obj := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+			Annotations: map[string]string{
+				corev1.LastAppliedConfigAnnotation: "{}",
+				"foo":                              "bar",
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+	}
+
+	result, err := TransformStripMetadata()(obj)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ns := result.(*corev1.Namespace)
+
+	if ns.ManagedFields != nil {
+		t.Errorf("Expected no managedFields, got %v", ns.ManagedFields)
+	}
+
+	if _, ok := ns.Annotations[corev1.LastAppliedConfigAnnotation]; ok {
+		t.Errorf("Expected no last-applied annotation")
+	}
+
+	if ns.Annotations["foo"] != "bar" {
+		t.Errorf("Expected other annotations to be kept, got %v", ns.Annotations)
+	}
+
+	// Tombstones and other non-objects are passed through
+	if result, _ := TransformStripManagedFields()("foo"); result != "foo" {
+		t.Errorf("Expected %q, got %v", "foo", result)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	obj := &metav1.ObjectMeta{
+		Name:      "foo",
+		Namespace: "bar",
+		Labels:    map[string]string{"app.kubernetes.io/part-of": "baz"},
+	}
+
+	tests := map[string]struct {
+		content  string
+		expected string
+		err      bool
+	}{
+		"name_and_namespace": {
+			content:  "metricNamePrefix: ${metadata.namespace}_${ metadata.name }",
+			expected: "metricNamePrefix: bar_foo",
+		},
+		"label": {
+			content:  "team: ${metadata.labels['app.kubernetes.io/part-of']}",
+			expected: "team: baz",
+		},
+		"escaped": {
+			content:  "path: $${metadata.name}",
+			expected: "path: ${metadata.name}",
+		},
+		"no_variables": {
+			content:  "name: $foo",
+			expected: "name: $foo",
+		},
+		"missing_label": {
+			content: "${metadata.labels['team']}",
+			err:     true,
+		},
+		"unknown_variable": {
+			content: "${spec.foo}",
+			err:     true,
+		},
+	}
+
+	for name, test := range tests {
+		result, err := Interpolate(test.content, obj)
+
+		if test.err {
+			if err == nil {
+				t.Errorf("Test [%s]: expected error, got %q", name, result)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Test [%s]: unexpected error: %v", name, err)
+		}
+
+		if result != test.expected {
+			t.Errorf("Test [%s]: expected %q, got %q", name, test.expected, result)
+		}
+	}
+}
+
+func TestNamespaceLabelSelectorPredicateEmpty(t *testing.T) {
+	// The Namespace must not be looked up (there is no client) if the selector matches everything
+	predicate := NamespaceLabelSelectorPredicate(nil, labels.Everything())
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"}}
+
+	if !predicate.Generic(event.GenericEvent{Object: pod}) {
+		t.Errorf("Expected the empty selector to match")
+	}
+}
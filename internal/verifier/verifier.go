@@ -0,0 +1,206 @@
+package verifier
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/metrics"
+	"github.com/jtyr/crsm-operator/internal/utils"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+// ConditionTypeMetricsAvailable is the type of the status condition
+// indicating whether the metrics are present in the kube-state-metrics output.
+const ConditionTypeMetricsAvailable = "MetricsAvailable"
+
+// Reasons for status conditions.
+const reasonMetricsFound = "MetricsFound"
+const reasonMetricsMissing = "MetricsMissing"
+
+// Maximum size of a single line of the scraped metrics.
+const maxLineSize = 1024 * 1024
+
+// Logger definition with a prefix.
+var log = ctrl.Log.WithName("[verifier]")
+
+// Verifier periodically scrapes the kube-state-metrics endpoint and checks
+// that the metrics defined by the CustomResourceStateMetrics instances are
+// present in its output.
+type Verifier struct {
+	Client          client.Client
+	MetricsRecorder metrics.MetricsRecorder
+	URL             string
+	Interval        time.Duration
+	HTTPClient      *http.Client
+}
+
+// NeedLeaderElection makes the verifier run only on the leader.
+func (v *Verifier) NeedLeaderElection() bool {
+	return true
+}
+
+// Start runs the verification loop until the context is canceled.
+func (v *Verifier) Start(ctx context.Context) error {
+	log.Info("Starting verifier", "url", v.URL, "interval", v.Interval)
+
+	ticker := time.NewTicker(v.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := v.verify(ctx); err != nil {
+				log.Error(err, "Failed to verify metrics")
+			}
+		}
+	}
+}
+
+// verify checks the metrics of all the CustomResourceStateMetrics instances.
+func (v *Verifier) verify(ctx context.Context) error {
+	series, err := ScrapeSeries(ctx, v.HTTPClient, v.URL)
+	if err != nil {
+		return err
+	}
+
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+
+	if err := v.Client.List(ctx, instances); err != nil {
+		return fmt.Errorf("failed to list CustomResourceStateMetrics: %w", err)
+	}
+
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+
+		// Verify only instances which were written into the ConfigMap
+		if !meta.IsStatusConditionTrue(instance.Status.Conditions, "Ready") {
+			continue
+		}
+
+		if err := v.verifyInstance(ctx, instance, series); err != nil {
+			log.Error(
+				err,
+				"Failed to verify metrics",
+				"instance", utils.NamespacedName(instance.Name, instance.Namespace))
+		}
+	}
+
+	return nil
+}
+
+// verifyInstance checks the metrics of the instance and updates its status.
+func (v *Verifier) verifyInstance(
+	ctx context.Context, instance *ksmv1.CustomResourceStateMetrics, series map[string]struct{}) error {
+	resources := instance.Spec.Resources
+
+	if defaults := instance.Spec.Defaults; defaults != nil {
+		resources = ksm.ApplyDefaults(resources, defaults.MetricNamePrefix, defaults.CommonLabels)
+	}
+
+	names := ksm.MetricNames(resources)
+
+	missing := []string{}
+
+	for _, name := range names {
+		if _, ok := series[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if v.MetricsRecorder != nil {
+		v.MetricsRecorder.SetMetricsMissing(instance.Name, instance.Namespace, len(missing))
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionTypeMetricsAvailable,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.Generation,
+		Reason:             reasonMetricsFound,
+		Message:            "All metrics are present in the kube-state-metrics output.",
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reasonMetricsMissing
+		condition.Message = fmt.Sprintf(
+			"Metrics missing in the kube-state-metrics output (check the paths): %s.",
+			strings.Join(missing, ", "))
+	}
+
+	patch := client.MergeFrom(instance.DeepCopy())
+
+	if !meta.SetStatusCondition(&instance.Status.Conditions, condition) {
+		return nil
+	}
+
+	if err := v.Client.Status().Patch(ctx, instance, patch); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	return nil
+}
+
+// ScrapeSeries reads the metric names from the kube-state-metrics endpoint.
+func ScrapeSeries(ctx context.Context, httpClient *http.Client, url string) (map[string]struct{}, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrape kube-state-metrics: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to scrape kube-state-metrics: unexpected status %s", resp.Status)
+	}
+
+	return seriesNames(resp.Body)
+}
+
+// seriesNames parses the metric names from the Prometheus text format.
+func seriesNames(r io.Reader) (map[string]struct{}, error) {
+	names := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if end := strings.IndexAny(line, "{ "); end > 0 {
+			names[line[:end]] = struct{}{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,28 @@
+package verifier
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSeriesNames(t *testing.T) {
+	g := NewWithT(t)
+
+	input := strings.Join([]string{
+		"# HELP kube_customresource_uptime Foo uptime",
+		"# TYPE kube_customresource_uptime gauge",
+		`kube_customresource_uptime{customresource_group="myteam.io",name="foo"} 43.21`,
+		`kube_customresource_uptime{customresource_group="myteam.io",name="bar"} 12.34`,
+		"",
+		"foo_ready 1",
+	}, "\n")
+
+	result, err := seriesNames(strings.NewReader(input))
+
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(HaveLen(2))
+	g.Expect(result).To(HaveKey("kube_customresource_uptime"))
+	g.Expect(result).To(HaveKey("foo_ready"))
+}
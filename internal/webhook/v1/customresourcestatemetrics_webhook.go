@@ -0,0 +1,659 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/internal/utils"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+// Logger definition with a prefix.
+var log = ctrl.Log.WithName("[webhook]")
+
+// Prefix of the annotations reserved for the operator.
+const reservedPrefix = "ksm.jtyr.io/"
+
+// SetupCustomResourceStateMetricsWebhookWithManager registers the webhooks for
+// CustomResourceStateMetrics in the manager. The defaults and the name of the
+// OperatorConfig must be the same as the ones used by the controller. The
+// colliding metric names are only warned about if warnCollisions is set.
+func SetupCustomResourceStateMetricsWebhookWithManager(
+	mgr ctrl.Manager, defaultConfigMap types.NamespacedName, defaultKey, operatorConfigName string,
+	warnCollisions bool) error {
+	return ctrl.NewWebhookManagedBy(mgr, &ksmv1.CustomResourceStateMetrics{}).
+		WithDefaulter(&CustomResourceStateMetricsCustomDefaulter{
+			DefaultConfigMap: defaultConfigMap,
+			DefaultKey:       defaultKey,
+		}).
+		WithValidator(&CustomResourceStateMetricsCustomValidator{
+			Client:             mgr.GetClient(),
+			OperatorConfigName: operatorConfigName,
+			WarnCollisions:     warnCollisions,
+		}).
+		Complete()
+}
+
+//nolint:lll
+// +kubebuilder:webhook:path=/mutate-ksm-jtyr-io-v1-customresourcestatemetrics,mutating=true,failurePolicy=fail,sideEffects=None,groups=ksm.jtyr.io,resources=customresourcestatemetrics,verbs=create;update,versions=v1,name=mcustomresourcestatemetrics-v1.kb.io,admissionReviewVersions=v1
+
+// CustomResourceStateMetricsCustomDefaulter fills the ConfigMap details the
+// controller would otherwise resolve implicitly.
+type CustomResourceStateMetricsCustomDefaulter struct {
+	DefaultConfigMap types.NamespacedName
+	DefaultKey       string
+}
+
+// Default sets the defaults of the instance upon creation and update.
+func (d *CustomResourceStateMetricsCustomDefaulter) Default(
+	_ context.Context, obj *ksmv1.CustomResourceStateMetrics) error {
+	// Leave the instance being deleted untouched
+	if !obj.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	// The Secret takes precedence over the ConfigMap
+	if secret := targetSecret(obj); secret != nil {
+		if secret.Key == "" {
+			secret.Key = d.DefaultKey
+		}
+
+		if secret.Namespace == "" {
+			secret.Namespace = obj.Namespace
+		}
+
+		return nil
+	}
+
+	cm := &obj.Spec.ConfigMap
+
+	if cm.Key == "" {
+		cm.Key = d.DefaultKey
+	}
+
+	// The discovered ConfigMap is resolved by the controller only
+	if cm.Discover {
+		return nil
+	}
+
+	// Use the operator default if no ConfigMap was specified
+	if cm.Name == "" && d.DefaultConfigMap.Name != "" {
+		cm.Name = d.DefaultConfigMap.Name
+
+		if cm.Namespace == "" {
+			cm.Namespace = d.DefaultConfigMap.Namespace
+		}
+	}
+
+	// Without the name, the ConfigMap is discovered by the controller
+	if cm.Name == "" {
+		return nil
+	}
+
+	// The Namespace is ignored if the ConfigMap is replicated
+	if cm.Namespace == "" && cm.NamespaceSelector == nil {
+		cm.Namespace = obj.Namespace
+	}
+
+	log.V(1).Info(
+		"Defaulted ConfigMap",
+		"instance", utils.NamespacedName(obj.Name, obj.Namespace),
+		"configMap", utils.NamespacedName(cm.Name, cm.Namespace),
+		"key", cm.Key)
+
+	return nil
+}
+
+//nolint:lll
+// +kubebuilder:webhook:path=/validate-ksm-jtyr-io-v1-customresourcestatemetrics,mutating=false,failurePolicy=fail,sideEffects=None,groups=ksm.jtyr.io,resources=customresourcestatemetrics,verbs=create;update,versions=v1,name=vcustomresourcestatemetrics-v1.kb.io,admissionReviewVersions=v1
+
+// CustomResourceStateMetricsCustomValidator rejects the instances whose
+// resources can't be loaded by kube-state-metrics, whose ConfigMap settings
+// can't be applied, whose metric names don't use the prefix required in their
+// Namespace or whose metric names collide with the metric names of the other
+// instances writing into the same target.
+type CustomResourceStateMetricsCustomValidator struct {
+	// Client used to read the OperatorConfig and to list the other instances.
+	// The metric names are not checked if not set.
+	Client client.Reader
+
+	// Name of the OperatorConfig holding the metric name prefixes required in
+	// the Namespaces.
+	OperatorConfigName string
+
+	// Whether the colliding metric names are only warned about instead of
+	// rejected.
+	WarnCollisions bool
+}
+
+// ValidateCreate validates the instance upon creation.
+func (v *CustomResourceStateMetricsCustomValidator) ValidateCreate(
+	ctx context.Context, obj *ksmv1.CustomResourceStateMetrics) (admission.Warnings, error) {
+	return v.validateMetrics(ctx, obj)
+}
+
+// ValidateUpdate validates the instance upon update.
+func (v *CustomResourceStateMetricsCustomValidator) ValidateUpdate(
+	ctx context.Context, _, newObj *ksmv1.CustomResourceStateMetrics) (admission.Warnings, error) {
+	// Let the instance with broken resources be deleted
+	if !newObj.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return v.validateMetrics(ctx, newObj)
+}
+
+// ValidateDelete validates the instance upon deletion.
+func (v *CustomResourceStateMetricsCustomValidator) ValidateDelete(
+	_ context.Context, _ *ksmv1.CustomResourceStateMetrics) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the resources the same way kube-state-metrics does and the
+// settings of the ConfigMap.
+func (v *CustomResourceStateMetricsCustomValidator) validate(obj *ksmv1.CustomResourceStateMetrics) error {
+	errs := validateNamespaceSelector(obj)
+	errs = append(errs, validateMetadata(obj)...)
+	errs = append(errs, validateReload(obj)...)
+	errs = append(errs, validateResourcesFrom(obj)...)
+	errs = append(errs, validateSource(obj)...)
+	errs = append(errs, validateResourcesRaw(obj)...)
+	errs = append(errs, validateInterpolation(obj)...)
+
+	if obj.Spec.ConfigMap.Immutable && targetSecret(obj) != nil {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "configMap", "immutable"),
+			"can't be combined with the Secret"))
+	}
+
+	if obj.Spec.ConfigMap.Compress && targetSecret(obj) != nil {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "configMap", "compress"),
+			"can't be combined with the Secret"))
+	}
+
+	path := field.NewPath("spec", "resources")
+
+	for i := range obj.Spec.Resources {
+		if err := ksm.ValidateResource(obj.Spec.Resources[i]); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), field.OmitValueType{},
+				"kube-state-metrics can't load the resource: "+err.Error()))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	log.V(1).Info(
+		"Rejecting invalid instance",
+		"instance", utils.NamespacedName(obj.Name, obj.Namespace),
+		"errors", errs.ToAggregate().Error())
+
+	return apierrors.NewInvalid(ksmv1.GroupVersion.WithKind("CustomResourceStateMetrics").GroupKind(), obj.Name, errs)
+}
+
+// validateMetrics validates the instance itself and then its metric names
+// against the policy of its Namespace and against the other instances.
+func (v *CustomResourceStateMetricsCustomValidator) validateMetrics(
+	ctx context.Context, obj *ksmv1.CustomResourceStateMetrics) (admission.Warnings, error) {
+	if err := v.validate(obj); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateMetricNamePrefix(ctx, obj); err != nil {
+		return nil, err
+	}
+
+	return v.validateCollisions(ctx, obj)
+}
+
+// validateMetricNamePrefix rejects the metric names of the inline resources
+// which don't start with the prefix required in the Namespace of the instance
+// by the OperatorConfig (e.g. so the tenants can't emit the metrics looking
+// like the metrics of the other tenants). The sourced resources are checked by
+// the controller once rendered.
+func (v *CustomResourceStateMetricsCustomValidator) validateMetricNamePrefix(
+	ctx context.Context, obj *ksmv1.CustomResourceStateMetrics) error {
+	if v.Client == nil || v.OperatorConfigName == "" {
+		return nil
+	}
+
+	operatorConfig := &ksmv1.OperatorConfig{}
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: v.OperatorConfigName}, operatorConfig); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			// The instance is not blocked if the policy can't be read
+			log.Error(err, "Failed to get the OperatorConfig to check the metric name prefix",
+				"instance", utils.NamespacedName(obj.Name, obj.Namespace))
+		}
+
+		return nil
+	}
+
+	prefix, ok := operatorConfig.Spec.MetricNamePrefixes[obj.Namespace]
+	if !ok {
+		return nil
+	}
+
+	var errs field.ErrorList
+
+	for _, name := range ksm.MetricNames(inlineResources(obj)) {
+		if !strings.HasPrefix(name, string(prefix)) {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "resources"), name,
+				fmt.Sprintf("the metric names in the Namespace %s must start with %s", obj.Namespace, prefix)))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	log.V(1).Info(
+		"Rejecting instance with disallowed metric name prefix",
+		"instance", utils.NamespacedName(obj.Name, obj.Namespace),
+		"errors", errs.ToAggregate().Error())
+
+	return apierrors.NewInvalid(ksmv1.GroupVersion.WithKind("CustomResourceStateMetrics").GroupKind(), obj.Name, errs)
+}
+
+// validateCollisions rejects (or warns about) the metric names of the inline
+// resources which are already generated by the other instances writing into
+// the same target as they would produce duplicate series. The instances whose
+// target is not resolved yet are not checked. The sourced resources are
+// checked by the controller once rendered.
+func (v *CustomResourceStateMetricsCustomValidator) validateCollisions(
+	ctx context.Context, obj *ksmv1.CustomResourceStateMetrics) (admission.Warnings, error) {
+	if v.Client == nil {
+		return nil, nil
+	}
+
+	target := instanceTarget(obj)
+	if target == nil {
+		return nil, nil
+	}
+
+	names := make(map[string]struct{})
+
+	for _, name := range ksm.MetricNames(inlineResources(obj)) {
+		names[name] = struct{}{}
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	instances := &ksmv1.CustomResourceStateMetricsList{}
+	if err := v.Client.List(ctx, instances); err != nil {
+		// The instance is not blocked if the other instances can't be listed
+		log.Error(err, "Failed to list the instances to check the metric name collisions",
+			"instance", utils.NamespacedName(obj.Name, obj.Namespace))
+
+		return nil, nil
+	}
+
+	var errs field.ErrorList
+	var warnings admission.Warnings
+
+	for _, other := range instances.Items {
+		if (other.Name == obj.Name && other.Namespace == obj.Namespace) || !other.DeletionTimestamp.IsZero() ||
+			!sameTarget(target, other.Status.ConfigMap) {
+			continue
+		}
+
+		for _, name := range other.Status.MetricNames {
+			if _, ok := names[name]; !ok {
+				continue
+			}
+
+			msg := fmt.Sprintf("metric %s is already generated by %s writing into the same target",
+				name, utils.NamespacedName(other.Name, other.Namespace))
+
+			if v.WarnCollisions {
+				warnings = append(warnings, msg)
+			} else {
+				errs = append(errs, field.Forbidden(field.NewPath("spec", "resources"), msg))
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return warnings, nil
+	}
+
+	log.V(1).Info(
+		"Rejecting instance with colliding metric names",
+		"instance", utils.NamespacedName(obj.Name, obj.Namespace),
+		"errors", errs.ToAggregate().Error())
+
+	return nil, apierrors.NewInvalid(
+		ksmv1.GroupVersion.WithKind("CustomResourceStateMetrics").GroupKind(), obj.Name, errs)
+}
+
+// instanceTarget returns the target the instance writes into. The target
+// specified explicitly is preferred over the one resolved by the controller.
+// It returns nil if the target is not known yet.
+func instanceTarget(obj *ksmv1.CustomResourceStateMetrics) *ksmv1.CustomResourceStateMetricsTarget {
+	namespace := func(ns string) string {
+		if ns == "" {
+			return obj.Namespace
+		}
+
+		return ns
+	}
+
+	if secret := targetSecret(obj); secret != nil {
+		return &ksmv1.CustomResourceStateMetricsTarget{
+			Kind:      ksmv1.TargetKindSecret,
+			Name:      secret.Name,
+			Namespace: namespace(secret.Namespace),
+			Key:       secret.Key,
+			Path:      obj.Spec.ConfigMap.Path,
+		}
+	}
+
+	cm := obj.Spec.ConfigMap
+
+	if cm.Name != "" && !cm.Discover && cm.NamespaceSelector == nil {
+		return &ksmv1.CustomResourceStateMetricsTarget{
+			Kind:      ksmv1.TargetKindConfigMap,
+			Name:      cm.Name,
+			Namespace: namespace(cm.Namespace),
+			Key:       cm.Key,
+			Path:      cm.Path,
+		}
+	}
+
+	return obj.Status.ConfigMap
+}
+
+// sameTarget returns true if both targets are the same document of the same
+// key of the same ConfigMap (or Secret) in at least one Namespace.
+func sameTarget(a, b *ksmv1.CustomResourceStateMetricsTarget) bool {
+	if a == nil || b == nil || a.Name != b.Name || a.Key != b.Key || a.Path != b.Path ||
+		targetKind(a) != targetKind(b) {
+		return false
+	}
+
+	namespaces := func(t *ksmv1.CustomResourceStateMetricsTarget) []string {
+		if len(t.Namespaces) > 0 {
+			return t.Namespaces
+		}
+
+		return []string{t.Namespace}
+	}
+
+	for _, ns := range namespaces(a) {
+		if slices.Contains(namespaces(b), ns) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// targetKind returns the kind of the target. Empty kind means ConfigMap.
+func targetKind(t *ksmv1.CustomResourceStateMetricsTarget) ksmv1.TargetKind {
+	if t.Kind == "" {
+		return ksmv1.TargetKindConfigMap
+	}
+
+	return t.Kind
+}
+
+// inlineResources returns the resources specified in the instance itself
+// (parsed from the raw string if specified) with the defaults applied. The
+// sourced resources are not known at the admission.
+func inlineResources(obj *ksmv1.CustomResourceStateMetrics) []ksm.Resource {
+	resources := obj.Spec.Resources
+
+	if obj.Spec.ResourcesRaw != "" {
+		// The raw resources were validated already
+		resources, _ = ksm.ParseResources(obj.Spec.ResourcesRaw)
+	}
+
+	if defaults := obj.Spec.Defaults; defaults != nil {
+		resources = ksm.ApplyDefaults(resources, defaults.MetricNamePrefix, defaults.CommonLabels)
+	}
+
+	return resources
+}
+
+// validateNamespaceSelector rejects the Namespace selector of the ConfigMap
+// which is invalid or which is combined with the discovery or the Secret.
+func validateNamespaceSelector(obj *ksmv1.CustomResourceStateMetrics) field.ErrorList {
+	var errs field.ErrorList
+
+	selector := obj.Spec.ConfigMap.NamespaceSelector
+	if selector == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec", "configMap", "namespaceSelector")
+
+	if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+		errs = append(errs, field.Invalid(path, field.OmitValueType{}, err.Error()))
+	}
+
+	if obj.Spec.ConfigMap.Discover {
+		errs = append(errs, field.Forbidden(path, "can't be combined with the discovery of the ConfigMap"))
+	}
+
+	if targetSecret(obj) != nil {
+		errs = append(errs, field.Forbidden(path, "can't be combined with the Secret"))
+	}
+
+	return errs
+}
+
+// validateMetadata rejects the labels and annotations of the ConfigMap which
+// are invalid or which use the prefix reserved for the operator.
+func validateMetadata(obj *ksmv1.CustomResourceStateMetrics) field.ErrorList {
+	path := field.NewPath("spec", "configMap")
+
+	errs := metav1validation.ValidateLabels(obj.Spec.ConfigMap.Labels, path.Child("labels"))
+	errs = append(errs, apivalidation.ValidateAnnotations(obj.Spec.ConfigMap.Annotations, path.Child("annotations"))...)
+
+	for key := range obj.Spec.ConfigMap.Annotations {
+		if strings.HasPrefix(key, reservedPrefix) {
+			errs = append(errs, field.Forbidden(path.Child("annotations").Key(key),
+				"the "+reservedPrefix+" prefix is reserved for the operator"))
+		}
+	}
+
+	return errs
+}
+
+// validateResourcesFrom rejects the sources of the resources which don't
+// reference exactly one key.
+func validateResourcesFrom(obj *ksmv1.CustomResourceStateMetrics) field.ErrorList {
+	var errs field.ErrorList
+
+	path := field.NewPath("spec", "resourcesFrom")
+
+	for i, source := range obj.Spec.ResourcesFrom {
+		switch {
+		case source.ConfigMapKeyRef == nil && source.SecretKeyRef == nil:
+			errs = append(errs, field.Required(path.Index(i), "configMapKeyRef or secretKeyRef must be specified"))
+		case source.ConfigMapKeyRef != nil && source.SecretKeyRef != nil:
+			errs = append(errs, field.Forbidden(path.Index(i), "configMapKeyRef and secretKeyRef are mutually exclusive"))
+		case source.ConfigMapKeyRef != nil && source.ConfigMapKeyRef.Name == "":
+			errs = append(errs, field.Required(path.Index(i).Child("configMapKeyRef", "name"), ""))
+		case source.SecretKeyRef != nil && source.SecretKeyRef.Name == "":
+			errs = append(errs, field.Required(path.Index(i).Child("secretKeyRef", "name"), ""))
+		}
+	}
+
+	return errs
+}
+
+// validateSource rejects the remote source which doesn't specify exactly one
+// location or which has a non-positive poll interval.
+func validateSource(obj *ksmv1.CustomResourceStateMetrics) field.ErrorList {
+	var errs field.ErrorList
+
+	source := obj.Spec.Source
+	if source == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec", "source")
+
+	switch {
+	case source.URL == "" && source.OCI == "":
+		errs = append(errs, field.Required(path, "url or oci must be specified"))
+	case source.URL != "" && source.OCI != "":
+		errs = append(errs, field.Forbidden(path, "url and oci are mutually exclusive"))
+	}
+
+	if source.PollInterval != nil && source.PollInterval.Duration < ksmv1.MinPollInterval {
+		errs = append(errs, field.Invalid(path.Child("pollInterval"), source.PollInterval.Duration.String(),
+			fmt.Sprintf("must be at least %s", ksmv1.MinPollInterval)))
+	}
+
+	return errs
+}
+
+// validateResourcesRaw rejects the raw resources which can't be loaded by
+// kube-state-metrics or which are combined with the other resources or with
+// the defaults.
+func validateResourcesRaw(obj *ksmv1.CustomResourceStateMetrics) field.ErrorList {
+	var errs field.ErrorList
+
+	if obj.Spec.ResourcesRaw == "" {
+		return nil
+	}
+
+	path := field.NewPath("spec", "resourcesRaw")
+
+	if len(obj.Spec.Resources) > 0 || len(obj.Spec.ResourcesFrom) > 0 || obj.Spec.Source != nil {
+		errs = append(errs, field.Forbidden(path, "can't be combined with resources, resourcesFrom or source"))
+	}
+
+	if obj.Spec.Defaults != nil {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "defaults"), "can't be combined with resourcesRaw"))
+	}
+
+	if _, err := ksm.ParseResources(obj.Spec.ResourcesRaw); err != nil {
+		errs = append(errs, field.Invalid(path, field.OmitValueType{}, err.Error()))
+	}
+
+	return errs
+}
+
+// validateInterpolation rejects the inline resources using the variables
+// which can't be resolved against the instance.
+func validateInterpolation(obj *ksmv1.CustomResourceStateMetrics) field.ErrorList {
+	var errs field.ErrorList
+
+	if !obj.Spec.Interpolate {
+		return nil
+	}
+
+	path := field.NewPath("spec", "resources")
+
+	for i := range obj.Spec.Resources {
+		content, err := json.Marshal(&obj.Spec.Resources[i])
+		if err != nil {
+			continue
+		}
+
+		if _, err := utils.Interpolate(string(content), obj); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), field.OmitValueType{}, err.Error()))
+		}
+	}
+
+	if _, err := utils.Interpolate(obj.Spec.ResourcesRaw, obj); err != nil {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "resourcesRaw"), field.OmitValueType{}, err.Error()))
+	}
+
+	return errs
+}
+
+// validateReload rejects the referenced Deployment outside of the Namespace of
+// the ConfigMap and of the instance and the management of its config file
+// which is combined with a target the Deployment can't read.
+func validateReload(obj *ksmv1.CustomResourceStateMetrics) field.ErrorList {
+	var errs field.ErrorList
+
+	reload := obj.Spec.Reload
+	if reload == nil {
+		return nil
+	}
+
+	// The Namespace of the default and of the discovered ConfigMap is checked
+	// by the controller
+	targetNamespace := obj.Spec.ConfigMap.Namespace
+	if targetSecret(obj) != nil {
+		targetNamespace = targetSecret(obj).Namespace
+	}
+
+	if namespace := reload.DeploymentRef.Namespace; namespace != "" && targetNamespace != "" &&
+		namespace != targetNamespace && namespace != obj.Namespace {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "reload", "deploymentRef", "namespace"),
+			namespace, "must be the Namespace of the ConfigMap or of the instance"))
+	}
+
+	if !reload.ManageConfigFile {
+		return errs
+	}
+
+	path := field.NewPath("spec", "reload", "manageConfigFile")
+
+	if targetSecret(obj) != nil {
+		errs = append(errs, field.Forbidden(path, "can't be combined with the Secret"))
+	}
+
+	if obj.Spec.ConfigMap.NamespaceSelector != nil {
+		errs = append(errs, field.Forbidden(path, "can't be combined with the Namespace selector of the ConfigMap"))
+	}
+
+	if obj.Spec.ConfigMap.Compress {
+		errs = append(errs, field.Forbidden(path, "can't be combined with the compression of the ConfigMap"))
+	}
+
+	if reload.DeploymentRef.Namespace != "" && obj.Spec.ConfigMap.Namespace != "" &&
+		reload.DeploymentRef.Namespace != obj.Spec.ConfigMap.Namespace {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "reload", "deploymentRef", "namespace"),
+			reload.DeploymentRef.Namespace, "must be the Namespace of the ConfigMap"))
+	}
+
+	return errs
+}
+
+// targetSecret returns the Secret the resources of the instance are written
+// into or nil if there is none.
+func targetSecret(obj *ksmv1.CustomResourceStateMetrics) *ksmv1.CustomResourceStateMetricsSecret {
+	if obj.Spec.Target == nil {
+		return nil
+	}
+
+	return obj.Spec.Target.Secret
+}
@@ -0,0 +1,888 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksmv1 "github.com/jtyr/crsm-operator/api/v1"
+	"github.com/jtyr/crsm-operator/pkg/ksm"
+)
+
+func TestValidate(t *testing.T) {
+	g := NewWithT(t)
+
+	gvk := ksm.GroupVersionKind{Group: "myteam.io", Version: "v1", Kind: "Foo"}
+	valid := ksm.Resource{
+		GroupVersionKind: gvk,
+		Metrics: []ksm.Metric{{
+			Name: "uptime",
+			Each: ksm.Each{Type: ksm.MetricTypeGauge, Gauge: &ksm.Gauge{Path: []string{"status", "uptime"}}},
+		}},
+	}
+	invalid := ksm.Resource{
+		GroupVersionKind: gvk,
+		Metrics:          []ksm.Metric{{Name: "uptime", Each: ksm.Each{Type: "Counter"}}},
+	}
+
+	tests := map[string]struct {
+		resources []ksm.Resource
+		field     string
+	}{
+		"valid": {
+			resources: []ksm.Resource{valid},
+		},
+		"empty": {
+			resources: []ksm.Resource{},
+		},
+		"invalid": {
+			resources: []ksm.Resource{valid, invalid},
+			field:     "spec.resources[1]",
+		},
+	}
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec:       ksmv1.CustomResourceStateMetricsSpec{Resources: test.resources},
+		}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if test.field == "" {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+		g.Expect(err.Error()).To(ContainSubstring(test.field), "Test [%s]:", name)
+		g.Expect(err.Error()).To(ContainSubstring(`each.type "Counter"`), "Test [%s]:", name)
+
+		_, err = v.ValidateUpdate(context.Background(), obj, obj)
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+
+		// Deletion of the instance must not be blocked
+		now := metav1.Now()
+		obj.DeletionTimestamp = &now
+
+		_, err = v.ValidateUpdate(context.Background(), obj, obj)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+	}
+}
+
+func TestValidateCollisions(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	target := ksmv1.CustomResourceStateMetricsConfigMap{Name: "ksm", Namespace: "monitoring", Key: "config.yaml"}
+	prefix := "myteam"
+
+	other := &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-a"},
+		Status: ksmv1.CustomResourceStateMetricsStatus{
+			ConfigMap:   &ksmv1.CustomResourceStateMetricsTarget{Name: "ksm", Namespace: "monitoring", Key: "config.yaml"},
+			MetricNames: []string{"kube_customresource_uptime"},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(other).WithStatusSubresource(other).Build()
+
+	tests := map[string]struct {
+		configMap ksmv1.CustomResourceStateMetricsConfigMap
+		defaults  *ksmv1.CustomResourceStateMetricsDefaults
+		name      string
+		collides  bool
+	}{
+		"collision": {
+			configMap: target,
+			collides:  true,
+		},
+		"other-key": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "ksm", Namespace: "monitoring", Key: "other.yaml"},
+		},
+		"other-prefix": {
+			configMap: target,
+			defaults:  &ksmv1.CustomResourceStateMetricsDefaults{MetricNamePrefix: &prefix},
+		},
+		"same-instance": {
+			configMap: target,
+			name:      "other",
+		},
+		"unresolved-target": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Discover: true},
+		},
+	}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				ConfigMap: test.configMap,
+				Defaults:  test.defaults,
+				Resources: []ksm.Resource{{
+					GroupVersionKind: ksm.GroupVersionKind{Group: "myteam.io", Version: "v1", Kind: "Foo"},
+					Metrics: []ksm.Metric{{
+						Name: "uptime",
+						Each: ksm.Each{Type: ksm.MetricTypeGauge, Gauge: &ksm.Gauge{Path: []string{"status", "uptime"}}},
+					}},
+				}},
+			},
+		}
+
+		if test.name != "" {
+			obj.Name = test.name
+		}
+
+		v := &CustomResourceStateMetricsCustomValidator{Client: c}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if !test.collides {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+		g.Expect(err.Error()).To(ContainSubstring("kube_customresource_uptime"), "Test [%s]:", name)
+		g.Expect(err.Error()).To(ContainSubstring("other@team-a"), "Test [%s]:", name)
+
+		// The collisions are only reported as the warnings
+		v.WarnCollisions = true
+
+		warnings, err := v.ValidateUpdate(context.Background(), obj, obj)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(warnings).To(HaveLen(1), "Test [%s]:", name)
+	}
+}
+
+func TestValidateMetricNamePrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ksmv1.AddToScheme(scheme)).To(Succeed())
+
+	operatorConfig := &ksmv1.OperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: ksmv1.OperatorConfigSpec{
+			MetricNamePrefixes: map[string]ksmv1.MetricNamePrefix{"team-a": "team_a_"},
+		},
+	}
+
+	teamPrefix := "team_a"
+	otherPrefix := "team_b"
+
+	tests := map[string]struct {
+		namespace string
+		prefix    *string
+		defaults  *ksmv1.CustomResourceStateMetricsDefaults
+		invalid   bool
+	}{
+		"own-prefix": {
+			namespace: "team-a",
+			prefix:    &teamPrefix,
+		},
+		"defaults-prefix": {
+			namespace: "team-a",
+			defaults:  &ksmv1.CustomResourceStateMetricsDefaults{MetricNamePrefix: &teamPrefix},
+		},
+		"other-prefix": {
+			namespace: "team-a",
+			prefix:    &otherPrefix,
+			invalid:   true,
+		},
+		"default-prefix": {
+			namespace: "team-a",
+			invalid:   true,
+		},
+		"no-policy": {
+			namespace: "team-b",
+		},
+	}
+
+	v := &CustomResourceStateMetricsCustomValidator{
+		Client:             fake.NewClientBuilder().WithScheme(scheme).WithObjects(operatorConfig).Build(),
+		OperatorConfigName: "cluster",
+	}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: test.namespace},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				Defaults: test.defaults,
+				Resources: []ksm.Resource{{
+					GroupVersionKind: ksm.GroupVersionKind{Group: "myteam.io", Version: "v1", Kind: "Foo"},
+					MetricNamePrefix: test.prefix,
+					Metrics: []ksm.Metric{{
+						Name: "uptime",
+						Each: ksm.Each{Type: ksm.MetricTypeGauge, Gauge: &ksm.Gauge{Path: []string{"status", "uptime"}}},
+					}},
+				}},
+			},
+		}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if !test.invalid {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+		g.Expect(err.Error()).To(ContainSubstring("must start with team_a_"), "Test [%s]:", name)
+	}
+
+	// No policy applies without the OperatorConfig
+	v.Client = fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	_, err := v.ValidateCreate(context.Background(), &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"},
+	})
+	g.Expect(err).NotTo(HaveOccurred(), "Test [no-operator-config]:")
+}
+
+func TestValidateNamespaceSelector(t *testing.T) {
+	g := NewWithT(t)
+
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"ksm-shard": "true"}}
+
+	tests := map[string]struct {
+		configMap ksmv1.CustomResourceStateMetricsConfigMap
+		secret    *ksmv1.CustomResourceStateMetricsSecret
+		errors    []string
+	}{
+		"no_selector": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm"},
+		},
+		"valid": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", NamespaceSelector: selector},
+		},
+		"invalid": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{
+				Name: "cm",
+				NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key: "ksm-shard", Operator: "Foo",
+				}}},
+			},
+			errors: []string{"not a valid label selector operator"},
+		},
+		"discover": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Discover: true, NamespaceSelector: selector},
+			errors:    []string{"can't be combined with the discovery of the ConfigMap"},
+		},
+		"secret": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{NamespaceSelector: selector},
+			secret:    &ksmv1.CustomResourceStateMetricsSecret{Name: "secret"},
+			errors:    []string{"can't be combined with the Secret"},
+		},
+	}
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec:       ksmv1.CustomResourceStateMetricsSpec{ConfigMap: test.configMap, Target: &ksmv1.CustomResourceStateMetricsSpecTarget{Secret: test.secret}},
+		}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if len(test.errors) == 0 {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+		g.Expect(err.Error()).To(ContainSubstring("spec.configMap.namespaceSelector"), "Test [%s]:", name)
+
+		for _, msg := range test.errors {
+			g.Expect(err.Error()).To(ContainSubstring(msg), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		labels      map[string]string
+		annotations map[string]string
+		errors      []string
+	}{
+		"none": {},
+		"valid": {
+			labels:      map[string]string{"app.kubernetes.io/part-of": "kube-state-metrics"},
+			annotations: map[string]string{"reloader.stakater.com/match": "true"},
+		},
+		"invalid_label": {
+			labels: map[string]string{"foo": "bar baz"},
+			errors: []string{"spec.configMap.labels"},
+		},
+		"invalid_annotation": {
+			annotations: map[string]string{"foo/bar/baz": "true"},
+			errors:      []string{"spec.configMap.annotations"},
+		},
+		"reserved_annotation": {
+			annotations: map[string]string{"ksm.jtyr.io/block-hashes": "{}"},
+			errors:      []string{"spec.configMap.annotations[ksm.jtyr.io/block-hashes]", "reserved"},
+		},
+	}
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{
+					Name:        "cm",
+					Labels:      test.labels,
+					Annotations: test.annotations,
+				},
+			},
+		}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if len(test.errors) == 0 {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+
+		for _, msg := range test.errors {
+			g.Expect(err.Error()).To(ContainSubstring(msg), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestValidateImmutable(t *testing.T) {
+	g := NewWithT(t)
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	obj := &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Spec: ksmv1.CustomResourceStateMetricsSpec{
+			ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", Immutable: true},
+		},
+	}
+
+	_, err := v.ValidateCreate(context.Background(), obj)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [configmap]:")
+
+	obj.Spec.Target = &ksmv1.CustomResourceStateMetricsSpecTarget{
+		Secret: &ksmv1.CustomResourceStateMetricsSecret{Name: "secret"},
+	}
+
+	_, err = v.ValidateCreate(context.Background(), obj)
+	g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [secret]:")
+	g.Expect(err.Error()).To(ContainSubstring("spec.configMap.immutable"), "Test [secret]:")
+}
+
+func TestValidateCompress(t *testing.T) {
+	g := NewWithT(t)
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	obj := &ksmv1.CustomResourceStateMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Spec: ksmv1.CustomResourceStateMetricsSpec{
+			ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", Compress: true},
+		},
+	}
+
+	_, err := v.ValidateCreate(context.Background(), obj)
+	g.Expect(err).NotTo(HaveOccurred(), "Test [configmap]:")
+
+	obj.Spec.Target = &ksmv1.CustomResourceStateMetricsSpecTarget{
+		Secret: &ksmv1.CustomResourceStateMetricsSecret{Name: "secret"},
+	}
+
+	_, err = v.ValidateCreate(context.Background(), obj)
+	g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [secret]:")
+	g.Expect(err.Error()).To(ContainSubstring("spec.configMap.compress"), "Test [secret]:")
+}
+
+func TestValidateResourcesFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	configMapKeyRef := &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "resources"},
+		Key:                  "resources.yaml",
+	}
+	secretKeyRef := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "resources"},
+		Key:                  "resources.yaml",
+	}
+
+	tests := map[string]struct {
+		source ksmv1.CustomResourceStateMetricsResourcesSource
+		errors []string
+	}{
+		"configmap": {
+			source: ksmv1.CustomResourceStateMetricsResourcesSource{ConfigMapKeyRef: configMapKeyRef},
+		},
+		"secret": {
+			source: ksmv1.CustomResourceStateMetricsResourcesSource{SecretKeyRef: secretKeyRef},
+		},
+		"none": {
+			errors: []string{"spec.resourcesFrom[0]", "configMapKeyRef or secretKeyRef must be specified"},
+		},
+		"both": {
+			source: ksmv1.CustomResourceStateMetricsResourcesSource{
+				ConfigMapKeyRef: configMapKeyRef,
+				SecretKeyRef:    secretKeyRef,
+			},
+			errors: []string{"spec.resourcesFrom[0]", "mutually exclusive"},
+		},
+		"no_name": {
+			source: ksmv1.CustomResourceStateMetricsResourcesSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "resources.yaml"},
+			},
+			errors: []string{"spec.resourcesFrom[0].configMapKeyRef.name"},
+		},
+	}
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				ConfigMap:     ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm"},
+				ResourcesFrom: []ksmv1.CustomResourceStateMetricsResourcesSource{test.source},
+			},
+		}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if len(test.errors) == 0 {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+
+		for _, msg := range test.errors {
+			g.Expect(err.Error()).To(ContainSubstring(msg), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestValidateSource(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		source ksmv1.CustomResourceStateMetricsSource
+		errors []string
+	}{
+		"url": {
+			source: ksmv1.CustomResourceStateMetricsSource{URL: "https://example.com/resources.yaml"},
+		},
+		"oci": {
+			source: ksmv1.CustomResourceStateMetricsSource{
+				OCI:          "registry.example.com/metrics/foo:1.0.0",
+				PollInterval: &metav1.Duration{Duration: time.Hour},
+			},
+		},
+		"none": {
+			errors: []string{"spec.source", "url or oci must be specified"},
+		},
+		"both": {
+			source: ksmv1.CustomResourceStateMetricsSource{
+				URL: "https://example.com/resources.yaml",
+				OCI: "registry.example.com/metrics/foo:1.0.0",
+			},
+			errors: []string{"spec.source", "mutually exclusive"},
+		},
+		"zero_interval": {
+			source: ksmv1.CustomResourceStateMetricsSource{
+				URL:          "https://example.com/resources.yaml",
+				PollInterval: &metav1.Duration{},
+			},
+			errors: []string{"spec.source.pollInterval", "must be at least 1m0s"},
+		},
+		"short_interval": {
+			source: ksmv1.CustomResourceStateMetricsSource{
+				URL:          "https://example.com/resources.yaml",
+				PollInterval: &metav1.Duration{Duration: time.Nanosecond},
+			},
+			errors: []string{"spec.source.pollInterval", "must be at least 1m0s"},
+		},
+	}
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm"},
+				Source:    &test.source,
+			},
+		}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if len(test.errors) == 0 {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+
+		for _, msg := range test.errors {
+			g.Expect(err.Error()).To(ContainSubstring(msg), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestValidateResourcesRaw(t *testing.T) {
+	g := NewWithT(t)
+
+	raw := `# Pasted from kube-state-metrics
+- groupVersionKind:
+    group: myteam.io
+    version: v1
+    kind: Foo
+  metrics:
+    - name: uptime
+      each:
+        type: Gauge
+        gauge:
+          path: [status, uptime]
+`
+
+	tests := map[string]struct {
+		spec   ksmv1.CustomResourceStateMetricsSpec
+		errors []string
+	}{
+		"valid": {
+			spec: ksmv1.CustomResourceStateMetricsSpec{ResourcesRaw: raw},
+		},
+		"invalid_yaml": {
+			spec:   ksmv1.CustomResourceStateMetricsSpec{ResourcesRaw: "resources: []\n"},
+			errors: []string{"spec.resourcesRaw", "failed to decode the resources from YAML"},
+		},
+		"invalid_resource": {
+			spec:   ksmv1.CustomResourceStateMetricsSpec{ResourcesRaw: "- groupVersionKind:\n    kind: Foo\n"},
+			errors: []string{"spec.resourcesRaw", "groupVersionKind.version must be specified"},
+		},
+		"combined": {
+			spec: ksmv1.CustomResourceStateMetricsSpec{
+				ResourcesRaw: raw,
+				Source:       &ksmv1.CustomResourceStateMetricsSource{URL: "https://example.com/resources.yaml"},
+			},
+			errors: []string{"spec.resourcesRaw", "can't be combined"},
+		},
+		"defaults": {
+			spec: ksmv1.CustomResourceStateMetricsSpec{
+				ResourcesRaw: raw,
+				Defaults: &ksmv1.CustomResourceStateMetricsDefaults{
+					CommonLabels: map[string]string{"team": "myteam"},
+				},
+			},
+			errors: []string{"spec.defaults", "can't be combined with resourcesRaw"},
+		},
+	}
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	for name, test := range tests {
+		test.spec.ConfigMap = ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm"}
+
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec:       test.spec,
+		}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if len(test.errors) == 0 {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+
+		for _, msg := range test.errors {
+			g.Expect(err.Error()).To(ContainSubstring(msg), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestValidateInterpolation(t *testing.T) {
+	g := NewWithT(t)
+
+	resource := func(prefix string) ksm.Resource {
+		return ksm.Resource{
+			GroupVersionKind: ksm.GroupVersionKind{Group: "myteam.io", Version: "v1", Kind: "Foo"},
+			MetricNamePrefix: &prefix,
+			Metrics: []ksm.Metric{{
+				Name: "uptime",
+				Each: ksm.Each{Type: ksm.MetricTypeGauge, Gauge: &ksm.Gauge{Path: []string{"status", "uptime"}}},
+			}},
+		}
+	}
+
+	tests := map[string]struct {
+		interpolate bool
+		prefix      string
+		errors      []string
+	}{
+		"resolved": {
+			interpolate: true,
+			prefix:      "${metadata.namespace}_${metadata.labels['team']}",
+		},
+		"missing_label": {
+			interpolate: true,
+			prefix:      "${metadata.labels['owner']}",
+			errors:      []string{"spec.resources[0]", `label "owner" doesn't exist`},
+		},
+		"unknown_variable": {
+			interpolate: true,
+			prefix:      "${spec.foo}",
+			errors:      []string{"spec.resources[0]", `unknown variable "spec.foo"`},
+		},
+		"disabled": {
+			prefix: "${spec.foo}",
+		},
+	}
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", Labels: map[string]string{"team": "baz"}},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				ConfigMap:   ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm"},
+				Interpolate: test.interpolate,
+				Resources:   []ksm.Resource{resource(test.prefix)},
+			},
+		}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if len(test.errors) == 0 {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+
+		for _, msg := range test.errors {
+			g.Expect(err.Error()).To(ContainSubstring(msg), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestValidateReload(t *testing.T) {
+	g := NewWithT(t)
+
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"ksm-shard": "true"}}
+
+	tests := map[string]struct {
+		configMap ksmv1.CustomResourceStateMetricsConfigMap
+		secret    *ksmv1.CustomResourceStateMetricsSecret
+		namespace string
+		errors    []string
+	}{
+		"configmap": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", Namespace: "ksm"},
+			namespace: "ksm",
+		},
+		"secret": {
+			secret: &ksmv1.CustomResourceStateMetricsSecret{Name: "secret"},
+			errors: []string{"spec.reload.manageConfigFile", "can't be combined with the Secret"},
+		},
+		"namespace_selector": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", NamespaceSelector: selector},
+			errors:    []string{"spec.reload.manageConfigFile", "can't be combined with the Namespace selector"},
+		},
+		"compress": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", Compress: true},
+			errors:    []string{"spec.reload.manageConfigFile", "can't be combined with the compression"},
+		},
+		"different_namespace": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", Namespace: "ksm"},
+			namespace: "monitoring",
+			errors:    []string{"spec.reload.deploymentRef.namespace", "must be the Namespace of the ConfigMap"},
+		},
+	}
+
+	v := &CustomResourceStateMetricsCustomValidator{}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				ConfigMap: test.configMap,
+				Target:    &ksmv1.CustomResourceStateMetricsSpecTarget{Secret: test.secret},
+				Reload: &ksmv1.CustomResourceStateMetricsReload{
+					DeploymentRef: ksmv1.CustomResourceStateMetricsDeploymentRef{
+						Name:      "kube-state-metrics",
+						Namespace: test.namespace,
+					},
+					ManageConfigFile: true,
+				},
+			},
+		}
+
+		_, err := v.ValidateCreate(context.Background(), obj)
+
+		if len(test.errors) == 0 {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue(), "Test [%s]:", name)
+
+		for _, msg := range test.errors {
+			g.Expect(err.Error()).To(ContainSubstring(msg), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestValidateReloadNamespace(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		configMap string
+		namespace string
+		invalid   bool
+	}{
+		"configmap_namespace": {configMap: "monitoring", namespace: "monitoring"},
+		"instance_namespace":  {configMap: "monitoring", namespace: "bar"},
+		"default_namespace":   {configMap: "monitoring"},
+		"unresolved":          {namespace: "monitoring"},
+		"other_namespace":     {configMap: "monitoring", namespace: "kube-system", invalid: true},
+	}
+
+	for name, test := range tests {
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec: ksmv1.CustomResourceStateMetricsSpec{
+				ConfigMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", Namespace: test.configMap},
+				Reload: &ksmv1.CustomResourceStateMetricsReload{
+					DeploymentRef: ksmv1.CustomResourceStateMetricsDeploymentRef{
+						Name:      "kube-state-metrics",
+						Namespace: test.namespace,
+					},
+				},
+			},
+		}
+
+		errs := validateReload(obj)
+
+		if test.invalid {
+			g.Expect(errs).To(HaveLen(1), "Test [%s]:", name)
+		} else {
+			g.Expect(errs).To(BeEmpty(), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	g := NewWithT(t)
+
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"ksm-shard": "true"}}
+
+	tests := map[string]struct {
+		configMap        ksmv1.CustomResourceStateMetricsConfigMap
+		secret           *ksmv1.CustomResourceStateMetricsSecret
+		defaultConfigMap types.NamespacedName
+		expected         ksmv1.CustomResourceStateMetricsConfigMap
+		expectedSecret   *ksmv1.CustomResourceStateMetricsSecret
+	}{
+		"explicit": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm"},
+			expected:  ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", Namespace: "bar", Key: "config.yaml"},
+		},
+		"explicit_namespace": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", Namespace: "ksm", Key: "crsm.yaml"},
+			expected:  ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", Namespace: "ksm", Key: "crsm.yaml"},
+		},
+		"operator_default": {
+			defaultConfigMap: types.NamespacedName{Name: "default", Namespace: "ksm"},
+			expected:         ksmv1.CustomResourceStateMetricsConfigMap{Name: "default", Namespace: "ksm", Key: "config.yaml"},
+		},
+		"operator_default_without_namespace": {
+			defaultConfigMap: types.NamespacedName{Name: "default"},
+			expected:         ksmv1.CustomResourceStateMetricsConfigMap{Name: "default", Namespace: "bar", Key: "config.yaml"},
+		},
+		"discovered": {
+			configMap:        ksmv1.CustomResourceStateMetricsConfigMap{Discover: true},
+			defaultConfigMap: types.NamespacedName{Name: "default", Namespace: "ksm"},
+			expected:         ksmv1.CustomResourceStateMetricsConfigMap{Discover: true, Key: "config.yaml"},
+		},
+		"no_default": {
+			expected: ksmv1.CustomResourceStateMetricsConfigMap{Key: "config.yaml"},
+		},
+		"replicated": {
+			configMap: ksmv1.CustomResourceStateMetricsConfigMap{Name: "cm", NamespaceSelector: selector},
+			expected: ksmv1.CustomResourceStateMetricsConfigMap{
+				Name: "cm", Key: "config.yaml", NamespaceSelector: selector,
+			},
+		},
+		"secret": {
+			secret:         &ksmv1.CustomResourceStateMetricsSecret{Name: "secret"},
+			expectedSecret: &ksmv1.CustomResourceStateMetricsSecret{Name: "secret", Namespace: "bar", Key: "config.yaml"},
+		},
+	}
+
+	for name, test := range tests {
+		d := &CustomResourceStateMetricsCustomDefaulter{
+			DefaultConfigMap: test.defaultConfigMap,
+			DefaultKey:       "config.yaml",
+		}
+
+		obj := &ksmv1.CustomResourceStateMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec:       ksmv1.CustomResourceStateMetricsSpec{ConfigMap: test.configMap, Target: &ksmv1.CustomResourceStateMetricsSpecTarget{Secret: test.secret}},
+		}
+
+		err := d.Default(context.Background(), obj)
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(obj.Spec.ConfigMap).To(Equal(test.expected), "Test [%s]:", name)
+		g.Expect(targetSecret(obj)).To(Equal(test.expectedSecret), "Test [%s]:", name)
+	}
+}
@@ -0,0 +1,417 @@
+// Package ksm contains copies of the kube-state-metrics custom resource state
+// types which can be embedded in the API types. It's not internal so the API
+// types can be imported by the other modules.
+// +kubebuilder:object:generate=true
+package ksm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMetricNamePrefix is the metric name prefix used by kube-state-metrics
+// if the resource doesn't specify any.
+const DefaultMetricNamePrefix = "kube_customresource"
+
+// Types of the metrics supported by kube-state-metrics.
+const MetricTypeGauge = "Gauge"
+const MetricTypeStateSet = "StateSet"
+const MetricTypeInfo = "Info"
+
+// Resource is a copy of the kube-state-metrics custom resource state Resource
+// with the "omitempty" JSON tag flags so it can be embedded in the API types.
+type Resource struct {
+	// Group, version and kind of the custom resource.
+	GroupVersionKind GroupVersionKind `json:"groupVersionKind"`
+
+	// Prefix of the names of the metrics. Default: kube_customresource.
+	// +optional
+	MetricNamePrefix *string `json:"metricNamePrefix,omitempty"`
+
+	// List of the metrics generated for the custom resource.
+	// +optional
+	Metrics []Metric `json:"metrics,omitempty"`
+
+	// Labels added to all metrics of the custom resource.
+	// +optional
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+
+	// Labels added to all metrics of the custom resource with the values
+	// read from the specified paths.
+	// +optional
+	LabelsFromPath map[string][]string `json:"labelsFromPath,omitempty"`
+
+	// Plural name of the custom resource if it can't be derived from the
+	// kind.
+	// +optional
+	ResourcePlural string `json:"resourcePlural,omitempty"`
+
+	// Verbosity of the logs of the errors encountered while generating the
+	// metrics.
+	// +optional
+	ErrorLogV int32 `json:"errorLogV,omitempty"`
+}
+
+// GroupVersionKind of the custom resource the metrics are generated for.
+type GroupVersionKind struct {
+	// Group of the custom resource. Empty for the core group.
+	// +optional
+	Group string `json:"group"`
+
+	// Version of the custom resource.
+	Version string `json:"version"`
+
+	// Kind of the custom resource.
+	Kind string `json:"kind"`
+}
+
+// Metric is a copy of the kube-state-metrics custom resource state Generator.
+type Metric struct {
+	// Name of the metric.
+	Name string `json:"name"`
+
+	// Help text of the metric.
+	// +optional
+	Help string `json:"help,omitempty"`
+
+	// Definition of how the metric value is generated.
+	Each Each `json:"each"`
+
+	// Labels added to the metric.
+	// +optional
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+
+	// Labels added to the metric with the values read from the specified
+	// paths.
+	// +optional
+	LabelsFromPath map[string][]string `json:"labelsFromPath,omitempty"`
+
+	// Verbosity of the logs of the errors encountered while generating the
+	// metric.
+	// +optional
+	ErrorLogV int32 `json:"errorLogV,omitempty"`
+}
+
+// Each is a copy of the kube-state-metrics custom resource state Metric
+// defining how the metric value is generated.
+type Each struct {
+	// Type of the metric.
+	// +kubebuilder:validation:Enum=Gauge;StateSet;Info
+	Type string `json:"type"`
+
+	// Configuration of the Gauge metric type.
+	// +optional
+	Gauge *Gauge `json:"gauge,omitempty"`
+
+	// Configuration of the StateSet metric type.
+	// +optional
+	StateSet *StateSet `json:"stateSet,omitempty"`
+
+	// Configuration of the Info metric type.
+	// +optional
+	Info *Info `json:"info,omitempty"`
+}
+
+// Gauge is a copy of the kube-state-metrics custom resource state MetricGauge.
+type Gauge struct {
+	// Path to the value in the custom resource.
+	// +optional
+	Path []string `json:"path,omitempty"`
+
+	// Labels added to the metric with the values read from the specified
+	// paths relative to the path.
+	// +optional
+	LabelsFromPath map[string][]string `json:"labelsFromPath,omitempty"`
+
+	// Path to the value relative to the path.
+	// +optional
+	ValueFrom []string `json:"valueFrom,omitempty"`
+
+	// Name of the label holding the key of the map the value was read from.
+	// +optional
+	LabelFromKey string `json:"labelFromKey,omitempty"`
+
+	// Whether the missing value should be reported as zero.
+	// +optional
+	NilIsZero bool `json:"nilIsZero,omitempty"`
+}
+
+// StateSet is a copy of the kube-state-metrics custom resource state
+// MetricStateSet.
+type StateSet struct {
+	// Path to the value in the custom resource.
+	// +optional
+	Path []string `json:"path,omitempty"`
+
+	// Labels added to the metric with the values read from the specified
+	// paths relative to the path.
+	// +optional
+	LabelsFromPath map[string][]string `json:"labelsFromPath,omitempty"`
+
+	// List of the possible states.
+	List []string `json:"list"`
+
+	// Name of the label holding the state.
+	// +optional
+	LabelName string `json:"labelName,omitempty"`
+
+	// Path to the value relative to the path.
+	// +optional
+	ValueFrom []string `json:"valueFrom,omitempty"`
+}
+
+// Info is a copy of the kube-state-metrics custom resource state MetricInfo.
+type Info struct {
+	// Path to the value in the custom resource.
+	// +optional
+	Path []string `json:"path,omitempty"`
+
+	// Labels added to the metric with the values read from the specified
+	// paths relative to the path.
+	// +optional
+	LabelsFromPath map[string][]string `json:"labelsFromPath,omitempty"`
+
+	// Name of the label holding the key of the map the value was read from.
+	// +optional
+	LabelFromKey string `json:"labelFromKey,omitempty"`
+}
+
+// ValidateResource checks that kube-state-metrics is able to load the
+// resource. It does the same checks kube-state-metrics does when it compiles
+// the metrics.
+func ValidateResource(resource Resource) error {
+	if resource.GroupVersionKind.Version == "" {
+		return errors.New("groupVersionKind.version must be specified")
+	}
+
+	if resource.GroupVersionKind.Kind == "" {
+		return errors.New("groupVersionKind.kind must be specified")
+	}
+
+	for i, metric := range resource.Metrics {
+		if err := metric.validate(); err != nil {
+			return fmt.Errorf("metrics #%d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ParseResources parses the YAML list of resources and checks that
+// kube-state-metrics is able to load them. The unknown fields are refused.
+// The lists of multiple YAML documents are flattened into a single list.
+func ParseResources(content string) ([]Resource, error) {
+	raw := []interface{}{}
+
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+
+	for i := 0; ; i++ {
+		var doc interface{}
+
+		if err := decoder.Decode(&doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode the resources from YAML: %w", err)
+		}
+
+		// Skip the empty documents (e.g. after the trailing separator)
+		if doc == nil {
+			continue
+		}
+
+		items, ok := doc.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("failed to decode the resources from YAML: document #%d is not a list", i)
+		}
+
+		raw = append(raw, items...)
+	}
+
+	// Convert the generic structure into the resources via JSON
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the resources to JSON: %w", err)
+	}
+
+	// Refuse the unknown fields (e.g. misspelled) instead of dropping them
+	jsonDecoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	jsonDecoder.DisallowUnknownFields()
+
+	resources := []Resource{}
+	if err := jsonDecoder.Decode(&resources); err != nil {
+		return nil, fmt.Errorf("failed to decode the resources from JSON: %w", err)
+	}
+
+	for i := range resources {
+		if err := ValidateResource(resources[i]); err != nil {
+			return nil, fmt.Errorf("kube-state-metrics can't load the resource #%d: %w", i, err)
+		}
+	}
+
+	return resources, nil
+}
+
+// FlattenDocuments returns the YAML lists of resources of multiple YAML
+// documents merged into a single list keeping their comments. The content of
+// a single document is returned as it is.
+func FlattenDocuments(content string) (string, error) {
+	var list *yaml.Node
+
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	documents := 0
+
+	for {
+		doc := &yaml.Node{}
+
+		if err := decoder.Decode(doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("failed to decode the resources from YAML: %w", err)
+		}
+
+		documents++
+
+		// Skip the empty documents (e.g. after the trailing separator)
+		if len(doc.Content) == 0 || doc.Content[0].Tag == "!!null" {
+			continue
+		}
+
+		if doc.Content[0].Kind != yaml.SequenceNode {
+			return "", fmt.Errorf("failed to decode the resources from YAML: document #%d is not a list", documents-1)
+		}
+
+		if list == nil {
+			list = doc.Content[0]
+		} else {
+			list.Content = append(list.Content, doc.Content[0].Content...)
+		}
+	}
+
+	if documents <= 1 {
+		return content, nil
+	}
+
+	if list == nil {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2) //nolint:mnd
+
+	if err := encoder.Encode(list); err != nil {
+		return "", fmt.Errorf("failed to encode the resources to YAML: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode the resources to YAML: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// validate checks that the metric can be compiled by kube-state-metrics.
+func (m Metric) validate() error {
+	if m.Name == "" {
+		return errors.New("name must be specified")
+	}
+
+	var missing bool
+
+	switch m.Each.Type {
+	case MetricTypeGauge:
+		missing = m.Each.Gauge == nil
+	case MetricTypeStateSet:
+		missing = m.Each.StateSet == nil
+	case MetricTypeInfo:
+		missing = m.Each.Info == nil
+	default:
+		return fmt.Errorf("each.type %q is not one of %s, %s or %s",
+			m.Each.Type, MetricTypeGauge, MetricTypeStateSet, MetricTypeInfo)
+	}
+
+	if missing {
+		return fmt.Errorf("each.%s must be specified for the %s type", lowerFirst(m.Each.Type), m.Each.Type)
+	}
+
+	return nil
+}
+
+// lowerFirst returns the string with the first letter in lower case.
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// MetricNames returns the fully-qualified names of the metrics defined by
+// the resources.
+func MetricNames(resources []Resource) []string {
+	names := []string{}
+
+	for _, resource := range resources {
+		for _, metric := range resource.Metrics {
+			names = append(names, FullName(resource.GetMetricNamePrefix(), metric.Name))
+		}
+	}
+
+	return names
+}
+
+// ApplyDefaults returns the copies of the resources with the metric name
+// prefix set if the resource doesn't specify any and with the common labels
+// added to the common labels of the resource. The labels of the resource take
+// precedence.
+func ApplyDefaults(resources []Resource, metricNamePrefix *string, commonLabels map[string]string) []Resource {
+	result := make([]Resource, 0, len(resources))
+
+	for _, resource := range resources {
+		if resource.MetricNamePrefix == nil && metricNamePrefix != nil {
+			prefix := *metricNamePrefix
+			resource.MetricNamePrefix = &prefix
+		}
+
+		if len(commonLabels) > 0 {
+			labels := maps.Clone(commonLabels)
+			maps.Copy(labels, resource.CommonLabels)
+			resource.CommonLabels = labels
+		}
+
+		result = append(result, resource)
+	}
+
+	return result
+}
+
+// GetMetricNamePrefix returns the metric name prefix of the resource.
+func (r Resource) GetMetricNamePrefix() string {
+	if r.MetricNamePrefix == nil {
+		return DefaultMetricNamePrefix
+	}
+
+	return *r.MetricNamePrefix
+}
+
+// FullName joins the metric name prefix with the metric name the same way
+// kube-state-metrics does.
+func FullName(prefix, name string) string {
+	parts := []string{}
+
+	for _, part := range []string{prefix, name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, "_")
+}
@@ -0,0 +1,264 @@
+package ksm
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseResources(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		content  string
+		expected []Resource
+		err      string
+	}{
+		"valid": {
+			content: `
+- groupVersionKind:
+    group: myteam.io
+    version: v1
+    kind: Foo
+  metrics:
+    - name: uptime
+      each:
+        type: Gauge
+        gauge:
+          path: [status, uptime]
+`,
+			expected: []Resource{{
+				GroupVersionKind: GroupVersionKind{Group: "myteam.io", Version: "v1", Kind: "Foo"},
+				Metrics: []Metric{{
+					Name: "uptime",
+					Each: Each{Type: MetricTypeGauge, Gauge: &Gauge{Path: []string{"status", "uptime"}}},
+				}},
+			}},
+		},
+		"multiple-documents": {
+			content: "- groupVersionKind:\n    version: v1\n    kind: Foo\n" +
+				"---\n" +
+				"---\n- groupVersionKind:\n    version: v1\n    kind: Bar\n---\n",
+			expected: []Resource{
+				{GroupVersionKind: GroupVersionKind{Version: "v1", Kind: "Foo"}},
+				{GroupVersionKind: GroupVersionKind{Version: "v1", Kind: "Bar"}},
+			},
+		},
+		"not-a-list": {
+			content: "resources: []\n",
+			err:     "failed to decode the resources from YAML",
+		},
+		"document-not-a-list": {
+			content: "- groupVersionKind:\n    version: v1\n    kind: Foo\n---\nresources: []\n",
+			err:     "document #1 is not a list",
+		},
+		"unknown-field": {
+			content: "- groupVersionKind:\n    version: v1\n    kind: Foo\n  metric:\n    - name: uptime\n",
+			err:     `unknown field "metric"`,
+		},
+		"unknown-nested-field": {
+			content: "- groupVersionKind:\n    version: v1\n    kind: Foo\n" +
+				"  metrics:\n    - name: uptime\n      each:\n        type: Gauge\n        gauge:\n          paht: [a]\n",
+			err: `unknown field "paht"`,
+		},
+		"invalid-resource": {
+			content: "- groupVersionKind:\n    kind: Foo\n",
+			err:     "groupVersionKind.version must be specified",
+		},
+	}
+
+	for name, test := range tests {
+		resources, err := ParseResources(test.content)
+
+		if test.err != "" {
+			g.Expect(err).To(MatchError(ContainSubstring(test.err)), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(resources).To(Equal(test.expected), "Test [%s]:", name)
+	}
+
+	resources, err := ParseResources("")
+	g.Expect(err).NotTo(HaveOccurred(), "Test [empty]:")
+	g.Expect(resources).To(BeEmpty(), "Test [empty]:")
+}
+
+func TestFlattenDocuments(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		content  string
+		expected string
+		err      string
+	}{
+		"single-document": {
+			content:  "-   name: foo # kept as it is\n",
+			expected: "-   name: foo # kept as it is\n",
+		},
+		"multiple-documents": {
+			content:  "# Foo\n- name: foo\n---\n# Bar\n- name: bar\n---\n",
+			expected: "# Foo\n- name: foo\n# Bar\n- name: bar\n",
+		},
+		"empty-documents": {
+			content:  "---\n---\n",
+			expected: "",
+		},
+		"not-a-list": {
+			content: "- name: foo\n---\nname: bar\n",
+			err:     "document #1 is not a list",
+		},
+	}
+
+	for name, test := range tests {
+		content, err := FlattenDocuments(test.content)
+
+		if test.err != "" {
+			g.Expect(err).To(MatchError(ContainSubstring(test.err)), "Test [%s]:", name)
+
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		g.Expect(content).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestMetricNames(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		resources []string
+		expected  []string
+	}{
+		"default-prefix": {
+			resources: []string{`{"metrics": [{"name": "uptime"}, {"name": "ready"}]}`},
+			expected:  []string{"kube_customresource_uptime", "kube_customresource_ready"},
+		},
+		"custom-prefix": {
+			resources: []string{`{"metricNamePrefix": "foo", "metrics": [{"name": "uptime"}]}`},
+			expected:  []string{"foo_uptime"},
+		},
+		"empty-prefix": {
+			resources: []string{`{"metricNamePrefix": "", "metrics": [{"name": "uptime"}]}`},
+			expected:  []string{"uptime"},
+		},
+		"multiple-resources": {
+			resources: []string{
+				`{"metricNamePrefix": "foo", "metrics": [{"name": "uptime"}]}`,
+				`{"metricNamePrefix": "bar", "metrics": [{"name": "uptime"}]}`,
+			},
+			expected: []string{"foo_uptime", "bar_uptime"},
+		},
+		"no-metrics": {
+			resources: []string{`{"groupVersionKind": {"version": "v1", "kind": "Foo"}}`},
+			expected:  []string{},
+		},
+	}
+
+	for name, test := range tests {
+		resources := []Resource{}
+
+		for _, raw := range test.resources {
+			var resource Resource
+
+			g.Expect(json.Unmarshal([]byte(raw), &resource)).To(Succeed(), "Test [%s]:", name)
+
+			resources = append(resources, resource)
+		}
+
+		g.Expect(MetricNames(resources)).To(Equal(test.expected), "Test [%s]:", name)
+	}
+}
+
+func TestValidateResource(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := map[string]struct {
+		resource string
+		err      string
+	}{
+		"valid": {
+			resource: `{"groupVersionKind": {"group": "myteam.io", "version": "v1", "kind": "Foo"}, "metrics": [` +
+				`{"name": "uptime", "each": {"type": "Gauge", "gauge": {"path": ["status", "uptime"]}}},` +
+				`{"name": "phase", "each": {"type": "StateSet", "stateSet": {"list": ["A", "B"], "labelName": "phase"}}},` +
+				`{"name": "info", "each": {"type": "Info", "info": {"labelsFromPath": {"name": ["metadata", "name"]}}}}]}`,
+		},
+		"core-group": {
+			resource: `{"groupVersionKind": {"version": "v1", "kind": "Pod"}}`,
+		},
+		"missing-kind": {
+			resource: `{"groupVersionKind": {"group": "myteam.io", "version": "v1"}}`,
+			err:      "groupVersionKind.kind must be specified",
+		},
+		"missing-version": {
+			resource: `{"groupVersionKind": {"group": "myteam.io", "kind": "Foo"}}`,
+			err:      "groupVersionKind.version must be specified",
+		},
+		"missing-name": {
+			resource: `{"groupVersionKind": {"version": "v1", "kind": "Foo"}, "metrics": [` +
+				`{"each": {"type": "Gauge", "gauge": {}}}]}`,
+			err: "metrics #0: name must be specified",
+		},
+		"unknown-metric-type": {
+			resource: `{"groupVersionKind": {"version": "v1", "kind": "Foo"}, "metrics": [` +
+				`{"name": "uptime", "each": {"type": "Counter"}}]}`,
+			err: `metrics #0: each.type "Counter" is not one of Gauge, StateSet or Info`,
+		},
+		"missing-metric-config": {
+			resource: `{"groupVersionKind": {"version": "v1", "kind": "Foo"}, "metrics": [` +
+				`{"name": "uptime", "each": {"type": "Gauge", "gauge": {}}},` +
+				`{"name": "phase", "each": {"type": "StateSet", "gauge": {}}}]}`,
+			err: "metrics #1: each.stateSet must be specified for the StateSet type",
+		},
+	}
+
+	for name, test := range tests {
+		var resource Resource
+
+		g.Expect(json.Unmarshal([]byte(test.resource), &resource)).To(Succeed(), "Test [%s]:", name)
+
+		err := ValidateResource(resource)
+
+		if test.err == "" {
+			g.Expect(err).NotTo(HaveOccurred(), "Test [%s]:", name)
+		} else {
+			g.Expect(err).To(MatchError(ContainSubstring(test.err)), "Test [%s]:", name)
+		}
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	g := NewWithT(t)
+
+	defaultPrefix := "myteam"
+	ownPrefix := "other"
+
+	resources := []Resource{
+		{
+			GroupVersionKind: GroupVersionKind{Version: "v1", Kind: "Foo"},
+			CommonLabels:     map[string]string{"team": "foo"},
+		},
+		{
+			GroupVersionKind: GroupVersionKind{Version: "v1", Kind: "Bar"},
+			MetricNamePrefix: &ownPrefix,
+		},
+	}
+
+	result := ApplyDefaults(resources, &defaultPrefix, map[string]string{"team": "myteam", "tier": "backend"})
+
+	g.Expect(result[0].GetMetricNamePrefix()).To(Equal("myteam"))
+	g.Expect(result[0].CommonLabels).To(Equal(map[string]string{"team": "foo", "tier": "backend"}))
+	g.Expect(result[1].GetMetricNamePrefix()).To(Equal("other"))
+	g.Expect(result[1].CommonLabels).To(Equal(map[string]string{"team": "myteam", "tier": "backend"}))
+
+	// The original resources are left untouched
+	g.Expect(resources[0].MetricNamePrefix).To(BeNil())
+	g.Expect(resources[0].CommonLabels).To(Equal(map[string]string{"team": "foo"}))
+	g.Expect(resources[1].CommonLabels).To(BeNil())
+
+	// Nothing to apply
+	g.Expect(ApplyDefaults(resources, nil, nil)).To(Equal(resources))
+}